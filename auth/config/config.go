@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Config the settings of the auth service. Every setting has a default, a YAML key, an env var and a flag,
+// ex: jwt_ttl, JWT_TTL and -jwt-ttl
+type Config struct {
+	Port         int           `yaml:"port" usage:"the port the service listens on"`
+	Mongo        MongoConfig   `yaml:"mongo"`
+	JWTSecretKey string        `yaml:"jwt_secret_key" secret:"true" usage:"the key the tokens are signed with"`
+	JWTTTL       time.Duration `yaml:"jwt_ttl" usage:"the time the tokens expire after, ex: 1h"`
+	// RedisURL the rate limits are shared by the replicas when it is set
+	RedisURL string `yaml:"redis_url" secret:"url" usage:"the Redis server the replicas share the rate limits in, ex: redis://localhost:6379/0"`
+	// TrustedProxies the client IP is the X-Forwarded-For address set by one of these proxies or networks
+	TrustedProxies []string        `yaml:"trusted_proxies" usage:"the proxies the X-Forwarded-For header is trusted from, ex: 10.0.0.0/8,192.168.1.2"`
+	Timeouts       TimeoutsConfig  `yaml:"timeouts"`
+	RateLimit      RateLimitConfig `yaml:"rate_limit"`
+}
+
+type MongoConfig struct {
+	URI            string        `yaml:"uri" secret:"url" usage:"the MongoDB connection string, ex: mongodb://localhost:27017"`
+	Database       string        `yaml:"database" usage:"the MongoDB database of the service"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" usage:"the time the connection to MongoDB can take"`
+}
+
+type TimeoutsConfig struct {
+	Request time.Duration `yaml:"request" usage:"the time a request can take"`
+	// Handler the time the login and the registration can take in the repo and the hasher
+	Handler time.Duration `yaml:"handler" usage:"the time the login and the registration can take"`
+}
+
+// RateLimitConfig the requests have no token so they are limited by client IP
+type RateLimitConfig struct {
+	// MaxClients the replica keeps the buckets of up to this number of clients, for ClientTTL after their last request
+	MaxClients int           `yaml:"max_clients" usage:"the number of clients the replica keeps the rate limits of"`
+	ClientTTL  time.Duration `yaml:"client_ttl" usage:"the time the replica keeps the rate limit of a client after its last request"`
+	Login      TierConfig    `yaml:"login"`
+	Register   TierConfig    `yaml:"register"`
+}
+
+type TierConfig struct {
+	Rate  float64 `yaml:"rate" usage:"the requests per second"`
+	Burst int     `yaml:"burst" usage:"the requests that can be sent at once"`
+	Daily int     `yaml:"daily" usage:"the requests per UTC day, 0 for no daily quota"`
+}
+
+// Default the settings used when neither the config file, the env vars nor the flags set them
+func Default() Config {
+	return Config{
+		Port: 80,
+		Mongo: MongoConfig{
+			Database:       "mx-auth",
+			ConnectTimeout: 10 * time.Second,
+		},
+		JWTTTL: time.Hour,
+		Timeouts: TimeoutsConfig{
+			Request: 5 * time.Second,
+			Handler: time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			MaxClients: 100_000,
+			ClientTTL:  time.Minute,
+			Login:      TierConfig{Rate: 1, Burst: 5, Daily: 500},
+			Register:   TierConfig{Rate: 0.1, Burst: 3, Daily: 20},
+		},
+	}
+}
+
+// Load layers the defaults, the YAML file, the env vars and the flags of the args, ex: os.Args[1:], then validates
+// the config. It returns the args left after the flags, ex: backfill-canonical-usernames, and every invalid setting
+// at once, or flag.ErrHelp when the args ask for the usage
+func Load(args []string, getenv func(string) string) (Config, []string, error) {
+	config := Default()
+	rest, err := load("auth", &config, args, getenv)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, ErrInvalidFlags) {
+		return config, nil, err
+	}
+	err = errors.Join(err, config.Validate())
+	if err != nil {
+		return config, rest, errors.Join(ErrInvalidConfig, err)
+	}
+	return config, rest, nil
+}
+
+// Validate returns every invalid setting at once
+func (config Config) Validate() error {
+	var errs []error
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(config.Port > 0 && config.Port <= 65535, "port (PORT) must be between 1 and 65535, got %d", config.Port)
+	check(config.Mongo.URI != "", "mongo.uri (MONGO_URI) must be set, ex: mongodb://localhost:27017")
+	check(config.Mongo.Database != "", "mongo.database (MONGO_DATABASE) must be set")
+	check(config.Mongo.ConnectTimeout > 0, "mongo.connect_timeout (MONGO_CONNECT_TIMEOUT) must be positive, got %s", config.Mongo.ConnectTimeout)
+	check(config.JWTSecretKey != "", "jwt_secret_key (JWT_SECRET_KEY) must be set")
+	check(config.JWTTTL > 0, "jwt_ttl (JWT_TTL) must be positive, ex: 1h, got %s", config.JWTTTL)
+	if config.RedisURL != "" {
+		redisURL, err := url.Parse(config.RedisURL)
+		check(err == nil && (redisURL.Scheme == "redis" || redisURL.Scheme == "rediss"),
+			"redis_url (REDIS_URL) must be a redis:// or rediss:// URL, ex: redis://localhost:6379/0")
+	}
+	for _, proxy := range config.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil,
+			"trusted_proxies (TRUSTED_PROXIES) must be IP addresses or CIDR ranges, got %q", proxy)
+	}
+
+	check(config.Timeouts.Request > 0, "timeouts.request (TIMEOUTS_REQUEST) must be positive, got %s", config.Timeouts.Request)
+	check(config.Timeouts.Handler > 0, "timeouts.handler (TIMEOUTS_HANDLER) must be positive, got %s", config.Timeouts.Handler)
+
+	check(config.RateLimit.MaxClients > 0, "rate_limit.max_clients (RATE_LIMIT_MAX_CLIENTS) must be positive, got %d", config.RateLimit.MaxClients)
+	check(config.RateLimit.ClientTTL > 0, "rate_limit.client_ttl (RATE_LIMIT_CLIENT_TTL) must be positive, got %s", config.RateLimit.ClientTTL)
+	errs = append(errs, config.RateLimit.Login.validate("rate_limit.login")...)
+	errs = append(errs, config.RateLimit.Register.validate("rate_limit.register")...)
+
+	return errors.Join(errs...)
+}
+
+func (tier TierConfig) validate(path string) []error {
+	var errs []error
+	if tier.Rate <= 0 {
+		errs = append(errs, fmt.Errorf("%s.rate must be positive, got %g", path, tier.Rate))
+	}
+	if tier.Burst < 1 {
+		errs = append(errs, fmt.Errorf("%s.burst must be at least 1, got %d", path, tier.Burst))
+	}
+	if tier.Daily < 0 {
+		errs = append(errs, fmt.Errorf("%s.daily must not be negative, got %d", path, tier.Daily))
+	}
+	return errs
+}
+
+// Redacted the settings by YAML key with the secrets redacted, to be logged
+func (config Config) Redacted() map[string]any {
+	return redacted(&config)
+}
+
+// WriteRedacted writes the settings as YAML with the secrets redacted
+func (config Config) WriteRedacted(w io.Writer) error {
+	return writeRedacted(w, &config)
+}
@@ -4,4 +4,10 @@ const (
 	LogKeyTimeUTC    = "time_utc"
 	LogKeyStatusCode = "status_code"
 	LogKeyErrorCode  = "error_code"
+	LogKeyUsername   = "username"
+	LogKeyQuotaGroup = "quota_group"
+	LogKeyConfig     = "config"
+	LogKeyTenant     = "tenant"
+	LogKeyScopes     = "scopes"
+	LogKeyExpiresAt  = "expires_at"
 )
@@ -4,6 +4,7 @@ import (
 	"auth/consts"
 	"auth/jwt"
 	"auth/models"
+	"auth/repo"
 	"auth/service"
 	"context"
 	"errors"
@@ -23,22 +24,26 @@ type authHandler struct {
 	authenticatorService service.Authenticator
 	registratorService   service.Registrator
 	jwtGenerator         jwt.JWTGenerator
+	// timeout the time a login or a registration can take, ex: 1m
+	timeout time.Duration
 }
 
 func NewAuthHandler(
 	authenticatorService service.Authenticator,
 	registratorService service.Registrator,
 	jwtGenerator jwt.JWTGenerator,
+	timeout time.Duration,
 ) AuthHandler {
 	return &authHandler{
 		authenticatorService: authenticatorService,
 		registratorService:   registratorService,
 		jwtGenerator:         jwtGenerator,
+		timeout:              timeout,
 	}
 }
 
 func (handler *authHandler) Login(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), handler.timeout)
 	defer cancel()
 
 	var input models.LoginInput
@@ -58,7 +63,7 @@ func (handler *authHandler) Login(c *gin.Context) {
 		return
 	}
 
-	scopes, authenticated := handler.authenticatorService.Authenticate(ctx, input.Username, input.Password)
+	user, authenticated := handler.authenticatorService.Authenticate(ctx, input.Username, input.Password)
 	if !authenticated {
 		output.ErrorCode = ErrCodeAuthFailed
 		log.Error().
@@ -71,7 +76,7 @@ func (handler *authHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := handler.jwtGenerator.Generate(input.Username, scopes)
+	token, err := handler.jwtGenerator.Generate(user.Username, user.Tenant, user.Scopes)
 	if err != nil {
 		err = errors.Join(ErrCouldNotGenerateToken, err)
 		output.ErrorCode = ErrCodeCouldNotGenerateToken
@@ -94,7 +99,7 @@ func (handler *authHandler) Login(c *gin.Context) {
 }
 
 func (handler *authHandler) Register(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), handler.timeout)
 	defer cancel()
 
 	var input models.RegisterInput
@@ -113,17 +118,29 @@ func (handler *authHandler) Register(c *gin.Context) {
 		return
 	}
 
-	err = handler.registratorService.Register(ctx, input.Username, input.Password, input.Scopes)
+	err = handler.registratorService.Register(ctx, input.Username, input.Password, input.InviteCode, input.Scopes)
 	if err != nil {
 		err = errors.Join(ErrRegistrationFailed, err)
 		output.ErrorCode = ErrCodeRegistrationFailed
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repo.ErrUsernameTaken):
+			output.ErrorCode = ErrCodeUsernameTaken
+			statusCode = http.StatusConflict
+		case errors.Is(err, repo.ErrInviteNotFound):
+			output.ErrorCode = ErrCodeInviteNotFound
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrScopesNotAllowed):
+			output.ErrorCode = ErrCodeScopesNotAllowed
+			statusCode = http.StatusForbidden
+		}
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 			Int(consts.LogKeyErrorCode, output.ErrorCode).
-			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Int(consts.LogKeyStatusCode, statusCode).
 			Msg("error while trying to register")
-		c.JSON(http.StatusInternalServerError, output)
+		c.JSON(statusCode, output)
 		return
 	}
 	log.Info().
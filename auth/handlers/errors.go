@@ -21,4 +21,7 @@ const (
 	ErrCodeAuthFailed            int = 2
 	ErrCodeCouldNotGenerateToken int = 3
 	ErrCodeRegistrationFailed    int = 4
+	ErrCodeUsernameTaken         int = 5
+	ErrCodeInviteNotFound        int = 6
+	ErrCodeScopesNotAllowed      int = 7
 )
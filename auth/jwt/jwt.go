@@ -11,7 +11,7 @@ const issuer = "auth"
 
 // JWTGenerator public interface
 type JWTGenerator interface {
-	Generate(username string, scopes []string) (string, error)
+	Generate(username string, tenant string, scopes []string) (string, error)
 }
 
 // jwtGenerator private struct that implements the JWTGenerator methods
@@ -27,12 +27,13 @@ func NewJWTGenerator(key []byte, ttl time.Duration) JWTGenerator {
 	}
 }
 
-func (generator *jwtGenerator) Generate(username string, scopes []string) (string, error) {
+func (generator *jwtGenerator) Generate(username string, tenant string, scopes []string) (string, error) {
 	now := time.Now().UTC()
 	expirationTime := now.Add(generator.ttl)
 
 	claims := &models.JWTClaims{
 		Username: username,
+		Tenant:   tenant,
 		Scopes:   scopes,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
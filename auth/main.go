@@ -1,6 +1,7 @@
 package main
 
 import (
+	"auth/config"
 	"auth/consts"
 	"auth/handlers"
 	"auth/hasher"
@@ -10,44 +11,58 @@ import (
 	"auth/service"
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/time/rate"
 )
 
 func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
 
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		err := errors.New("MONGO_URI env var not set")
+	cfg, rest, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
-			Msg("make sure to set the MONGO_URI env var")
+			Msg("make sure the config file, the env vars and the flags are valid, see auth -h")
 		return
 	}
 
-	jwtSecretKey := os.Getenv("JWT_SECRET_KEY")
-	if jwtSecretKey == "" {
-		err := errors.New("JWT_SECRET_KEY env var not set")
-		log.Error().
-			Err(err).
-			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
-			Msg("make sure to set the JWT_SECRET_KEY env var")
+	// auth print-config prints the effective config with the secrets redacted
+	if len(rest) > 0 && rest[0] == "print-config" {
+		err = cfg.WriteRedacted(os.Stdout)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("failed to print the config")
+		}
 		return
 	}
 
+	log.Info().
+		Interface(consts.LogKeyConfig, cfg.Redacted()).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Msg("loaded the config")
+
 	// Set up a connection to MongoDB
-	clientOptions := options.Client().ApplyURI(mongoURI)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	clientOptions := options.Client().ApplyURI(cfg.Mongo.URI)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.ConnectTimeout)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -73,37 +88,80 @@ func main() {
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Msg("connected to MongoDB")
 
-	repo := repo.NewMongoRepo(client)
+	// auth backfill-canonical-usernames sets the canonical usernames the users are unique and looked up by
+	if len(rest) > 0 && rest[0] == "backfill-canonical-usernames" {
+		backfillCanonicalUsernames(client, cfg.Mongo.Database)
+		return
+	}
+
+	// auth create-invite <tenant> [scope...] prints the code a user registers into the tenant with
+	if len(rest) > 0 && rest[0] == "create-invite" {
+		createInvite(client, cfg.Mongo.Database, rest[1:])
+		return
+	}
+
+	repo := repo.NewMongoRepo(client, cfg.Mongo.Database)
 	hasher := hasher.NewHasher()
 
 	authenticatorService := service.NewAuthenticator(repo, hasher)
 	registratorService := service.NewRegistrator(repo, hasher)
-	jwtGenerator := jwt.NewJWTGenerator([]byte(jwtSecretKey), time.Hour)
+	jwtGenerator := jwt.NewJWTGenerator([]byte(cfg.JWTSecretKey), cfg.JWTTTL)
 
-	authHandler := handlers.NewAuthHandler(authenticatorService, registratorService, jwtGenerator)
+	authHandler := handlers.NewAuthHandler(authenticatorService, registratorService, jwtGenerator, cfg.Timeouts.Handler)
 
 	// setup gin engine
 	gin.SetMode(gin.ReleaseMode)
 
 	engine := gin.New()
+	err = engine.SetTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("failed to set the trusted proxies")
+		return
+	}
 
 	engine.Use(gin.Recovery())
-	engine.Use(middleware.TimeoutMiddleware(5 * time.Second))
 
-	// rate limit 5 req/s with burst of 10
-	limiter := middleware.NewClientLimiter(5, 10)
-	engine.Use(middleware.RateLimitMiddleware(limiter))
+	// the requests have no token so they are limited by client IP
+	clientLimiter := middleware.NewClientLimiter(cfg.RateLimit.MaxClients, cfg.RateLimit.ClientTTL)
+	var limiter middleware.RateLimiter = clientLimiter
+	if cfg.RedisURL != "" {
+		redisClient, err := newRedisClient(cfg.RedisURL)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("failed to parse the Redis URL")
+			return
+		}
+		defer redisClient.Close()
+		// the replica limits each client by itself while the Redis server is unreachable, it is retried every 5s
+		redisLimiter := middleware.NewRedisLimiter(redisClient, "ratelimit:auth:")
+		limiter = middleware.NewFallbackLimiter(redisLimiter, clientLimiter, 5*time.Second)
+	}
+
+	loginQuota := middleware.QuotaGroup{
+		Name:  "login",
+		Tiers: []middleware.QuotaTier{quotaTier("standard", cfg.RateLimit.Login)},
+	}
+	registerQuota := middleware.QuotaGroup{
+		Name:  "register",
+		Tiers: []middleware.QuotaTier{quotaTier("standard", cfg.RateLimit.Register)},
+	}
 
-	engine.POST("/login", authHandler.Login)
-	engine.POST("register", authHandler.Register)
+	engine.Use(middleware.TimeoutMiddleware(cfg.Timeouts.Request, nil))
+
+	engine.POST("/login", middleware.QuotaMiddleware(limiter, loginQuota), authHandler.Login)
+	engine.POST("register", middleware.QuotaMiddleware(limiter, registerQuota), authHandler.Register)
 
 	// graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// listen for requests on port 80
 	go func() {
-		port := "80"
+		port := strconv.Itoa(cfg.Port)
 		err := engine.Run(":" + port)
 		if err != nil {
 			log.Error().
@@ -132,3 +190,84 @@ func main() {
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Msg("successfully disconnected from MongoDB")
 }
+
+// quotaTier the tier of the rate limit and the daily quota of a route group
+func quotaTier(name string, tier config.TierConfig) middleware.QuotaTier {
+	return middleware.QuotaTier{
+		Name:  name,
+		Limit: middleware.Limit{Rate: rate.Limit(tier.Rate), Burst: tier.Burst},
+		Daily: tier.Daily,
+	}
+}
+
+// newRedisClient creates the client of the rate limits, the requests do not wait more than 100ms for the server
+// unless the URL sets other timeouts
+func newRedisClient(redisURL string) (*redis.Client, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	if options.DialTimeout == 0 {
+		options.DialTimeout = 100 * time.Millisecond
+	}
+	if options.ReadTimeout == 0 {
+		options.ReadTimeout = 100 * time.Millisecond
+	}
+	if options.WriteTimeout == 0 {
+		options.WriteTimeout = 100 * time.Millisecond
+	}
+	return redis.NewClient(options), nil
+}
+
+func backfillCanonicalUsernames(client *mongo.Client, database string) {
+	defer func() {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer disconnectCancel()
+		_ = client.Disconnect(disconnectCtx)
+	}()
+
+	count, conflicts, err := service.BackfillCanonicalUsernames(context.Background(), repo.NewMongoRepo(client, database))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msgf("failed to backfill the canonical usernames after %d users", count)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Msgf("backfilled the canonical usernames of %d users, %d conflicting users were skipped", count, conflicts)
+}
+
+func createInvite(client *mongo.Client, database string, args []string) {
+	defer func() {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer disconnectCancel()
+		_ = client.Disconnect(disconnectCtx)
+	}()
+
+	if len(args) == 0 || args[0] == "" {
+		log.Error().
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("the tenant of the invite is missing, see auth create-invite <tenant> [scope...]")
+		return
+	}
+
+	code, invite, err := service.CreateInvite(context.Background(), repo.NewMongoRepo(client, database), args[0], args[1:])
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("failed to create the invite")
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Str(consts.LogKeyTenant, invite.Tenant).
+		Strs(consts.LogKeyScopes, invite.Scopes).
+		Time(consts.LogKeyExpiresAt, invite.ExpiresAt).
+		Msg("created the invite")
+	fmt.Println(code)
+}
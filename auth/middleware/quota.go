@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"auth/consts"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// QuotaTier the limits of the users with the scope, the tier without a scope applies to every other user
+type QuotaTier struct {
+	Name  string
+	Scope string
+	Limit Limit
+	// Daily the requests a user can send each UTC day, 0 for no daily quota
+	Daily int
+}
+
+// QuotaGroup the routes sharing the same quotas, ex: /login. The tiers are listed from the most
+// privileged one, the first tier the scopes of the user give applies
+type QuotaGroup struct {
+	Name  string
+	Tiers []QuotaTier
+}
+
+// Tier returns the first tier the scopes give, the last tier when they give none
+func (group QuotaGroup) Tier(scopes []string) QuotaTier {
+	for _, tier := range group.Tiers {
+		if tier.Scope == "" || slices.Contains(scopes, tier.Scope) {
+			return tier
+		}
+	}
+	return group.Tiers[len(group.Tiers)-1]
+}
+
+// Identity returns who the quotas of the request are counted for, the user of the token, ex: user:alice, or the
+// client IP on the routes without a token like /login, ex: ip:10.0.0.1. c.ClientIP is only the address of the client
+// behind the proxies trusted by the engine
+func Identity(c *gin.Context) string {
+	if username := c.GetString("username"); username != "" {
+		return "user:" + username
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// QuotaMiddleware limits the requests of each identity on the routes of the group with the tier of its scopes.
+// The responses have the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and the X-Quota-Limit,
+// X-Quota-Remaining and X-Quota-Reset headers when the tier has a daily quota. The refused ones have Retry-After,
+// every duration is in seconds. The requests are allowed when the limiter fails
+func QuotaMiddleware(limiter RateLimiter, group QuotaGroup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		tier := group.Tier(c.GetStringSlice("scopes"))
+		key := group.Name + ":" + Identity(c)
+
+		rateLimit, err := limiter.Allow(ctx, key, tier.Limit)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyQuotaGroup, group.Name).
+				Msg("error while checking the rate limit")
+		} else {
+			c.Header("RateLimit-Limit", strconv.Itoa(rateLimit.Limit))
+			c.Header("RateLimit-Remaining", strconv.Itoa(rateLimit.Remaining))
+			c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(rateLimit.Reset)))
+			if !rateLimit.Allowed {
+				c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(rateLimit.RetryAfter))))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error": "rate limit exceeded",
+				})
+				return
+			}
+		}
+
+		if tier.Daily == 0 {
+			c.Next()
+			return
+		}
+
+		// the requests refused by the rate limit are not counted
+		now := time.Now().UTC()
+		day, reset := quotaDay(now)
+		count, err := limiter.Count(ctx, key, day, 1)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyQuotaGroup, group.Name).
+				Msg("error while counting the daily quota")
+			c.Next()
+			return
+		}
+
+		c.Header("X-Quota-Limit", strconv.Itoa(tier.Daily))
+		c.Header("X-Quota-Remaining", strconv.Itoa(max(0, tier.Daily-count)))
+		c.Header("X-Quota-Reset", strconv.Itoa(ceilSeconds(reset.Sub(now))))
+		if count > tier.Daily {
+			c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(reset.Sub(now)))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "daily quota exceeded",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// quotaDay returns the start of the UTC day of the time and the start of the next one
+func quotaDay(now time.Time) (time.Time, time.Time) {
+	year, month, day := now.UTC().Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var testQuotaGroup = QuotaGroup{
+	Name: "login",
+	Tiers: []QuotaTier{
+		{Name: "standard", Limit: Limit{Rate: 100, Burst: 100}, Daily: 2},
+	},
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimit, error) {
+	return RateLimit{}, assert.AnError
+}
+
+func (failingLimiter) Count(ctx context.Context, key string, day time.Time, n int) (int, error) {
+	return 0, assert.AnError
+}
+
+func TestQuotaTier(t *testing.T) {
+	group := QuotaGroup{
+		Name: "login",
+		Tiers: []QuotaTier{
+			{Name: "trusted", Scope: "auth:trusted", Limit: Limit{Rate: 10, Burst: 10}},
+			{Name: "standard", Scope: "auth:standard", Limit: Limit{Rate: 1, Burst: 5}},
+		},
+	}
+
+	assert.Equal(t, "trusted", group.Tier([]string{"auth:standard", "auth:trusted"}).Name)
+	assert.Equal(t, "standard", group.Tier([]string{"auth:standard"}).Name)
+	// the requests without a token have no scopes, they get the last tier
+	assert.Equal(t, "standard", group.Tier(nil).Name)
+	assert.Equal(t, "standard", testQuotaGroup.Tier(nil).Name)
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	testCases := []struct {
+		name               string
+		limiter            RateLimiter
+		group              *QuotaGroup
+		clientIPs          []string
+		expectedStatusCode int
+		expectedError      string
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "standard tier",
+			clientIPs:          []string{"10.0.0.1"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "100",
+				"RateLimit-Remaining": "99",
+				"X-Quota-Limit":       "2",
+				"X-Quota-Remaining":   "1",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:               "daily quota exceeded",
+			clientIPs:          []string{"10.0.0.1", "10.0.0.1", "10.0.0.1"},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedError:      "daily quota exceeded",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":   "100",
+				"X-Quota-Limit":     "2",
+				"X-Quota-Remaining": "0",
+			},
+		},
+		{
+			name:               "the clients have their own quotas",
+			clientIPs:          []string{"10.0.0.1", "10.0.0.1", "10.0.0.2"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Remaining": "99",
+				"X-Quota-Remaining":   "1",
+			},
+		},
+		{
+			name: "rate limit exceeded",
+			group: &QuotaGroup{
+				Name:  "register",
+				Tiers: []QuotaTier{{Name: "standard", Limit: Limit{Rate: 1, Burst: 3}}},
+			},
+			clientIPs:          []string{"10.0.0.1", "10.0.0.1", "10.0.0.1", "10.0.0.1"},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedError:      "rate limit exceeded",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "3",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "3",
+				"Retry-After":         "1",
+				"X-Quota-Limit":       "",
+			},
+		},
+		{
+			name:               "the requests are allowed when the limiter fails",
+			limiter:            failingLimiter{},
+			clientIPs:          []string{"10.0.0.1"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+				"X-Quota-Limit":   "",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			limiter := testCase.limiter
+			if limiter == nil {
+				limiter = NewClientLimiter(100, time.Minute)
+			}
+			group := testQuotaGroup
+			if testCase.group != nil {
+				group = *testCase.group
+			}
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.New()
+			router.POST("/test", QuotaMiddleware(limiter, group), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			var rr *httptest.ResponseRecorder
+			for _, clientIP := range testCase.clientIPs {
+				req, _ := http.NewRequest(http.MethodPost, "/test", nil)
+				req.RemoteAddr = clientIP + ":1234"
+				rr = httptest.NewRecorder()
+
+				// Perform the request
+				router.ServeHTTP(rr, req)
+			}
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedError != "" {
+				assert.JSONEq(t, `{"error": "`+testCase.expectedError+`"}`, rr.Body.String())
+			}
+			for header, value := range testCase.expectedHeaders {
+				assert.Equal(t, value, rr.Header().Get(header), header)
+			}
+			// the daily quotas are reset at midnight UTC
+			if rr.Header().Get("X-Quota-Limit") != "" {
+				reset, err := strconv.Atoi(rr.Header().Get("X-Quota-Reset"))
+				assert.NoError(t, err)
+				assert.InDelta(t, 12*time.Hour.Seconds(), reset, 12*time.Hour.Seconds())
+			}
+			if testCase.expectedError == "daily quota exceeded" {
+				assert.Equal(t, rr.Header().Get("X-Quota-Reset"), rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	testCases := []struct {
+		name             string
+		username         string
+		trustedProxies   []string
+		expectedIdentity string
+	}{
+		{
+			name:             "the user of the token",
+			username:         "alice",
+			expectedIdentity: "user:alice",
+		},
+		{
+			name:             "the forwarded address of a trusted proxy",
+			trustedProxies:   []string{"192.168.0.0/16"},
+			expectedIdentity: "ip:203.0.113.7",
+		},
+		{
+			name:             "the forwarded address of an untrusted proxy is ignored",
+			trustedProxies:   nil,
+			expectedIdentity: "ip:192.168.1.1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			var identity string
+			router := gin.New()
+			assert.NoError(t, router.SetTrustedProxies(testCase.trustedProxies))
+			router.POST("/test", func(c *gin.Context) {
+				if testCase.username != "" {
+					c.Set("username", testCase.username)
+				}
+				identity = Identity(c)
+			})
+
+			req, _ := http.NewRequest(http.MethodPost, "/test", nil)
+			req.RemoteAddr = "192.168.1.1:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, testCase.expectedIdentity, identity)
+		})
+	}
+}
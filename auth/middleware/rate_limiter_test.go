@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testLimit = Limit{Rate: 1, Burst: 2}
+
+func allow(t *testing.T, limiter RateLimiter, key string, limit Limit) RateLimit {
+	rateLimit, err := limiter.Allow(context.Background(), key, limit)
+	assert.NoError(t, err)
+	return rateLimit
+}
+
+func count(t *testing.T, limiter RateLimiter, key string, day time.Time, n int) int {
+	count, err := limiter.Count(context.Background(), key, day, n)
+	assert.NoError(t, err)
+	return count
+}
+
+func TestClientLimiterAllow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl := NewClientLimiter(100, time.Minute)
+	cl.now = func() time.Time { return now }
+
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, allow(t, cl, "10.0.0.1", testLimit))
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, allow(t, cl, "10.0.0.1", testLimit))
+	assert.Equal(t, RateLimit{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}, allow(t, cl, "10.0.0.1", testLimit))
+
+	// the other clients have their own bucket
+	assert.True(t, allow(t, cl, "10.0.0.2", testLimit).Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, RateLimit{Allowed: false, Limit: 2, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}, allow(t, cl, "10.0.0.1", testLimit))
+}
+
+func TestClientLimiterEviction(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 1, Burst: 1}
+	cl := NewClientLimiter(limiterShards, time.Minute)
+	cl.now = func() time.Time { return now }
+
+	clients := func() int {
+		count := 0
+		for i := range cl.shards {
+			count += cl.shards[i].lru.Len()
+		}
+		return count
+	}
+
+	// a shard keeps one bucket, the least recently used one is evicted
+	for i := range 1000 {
+		allow(t, cl, fmt.Sprintf("10.0.%d.%d", i/256, i%256), limit)
+	}
+	assert.LessOrEqual(t, clients(), limiterShards)
+
+	// the idle buckets are removed by the requests of the other clients of their shard
+	cl = NewClientLimiter(100_000, time.Minute)
+	cl.now = func() time.Time { return now }
+	for i := range 1000 {
+		allow(t, cl, fmt.Sprintf("10.0.%d.%d", i/256, i%256), limit)
+	}
+	assert.Equal(t, 1000, clients())
+	now = now.Add(2 * time.Minute)
+	for i := range 1000 {
+		allow(t, cl, fmt.Sprintf("10.1.%d.%d", i/256, i%256), limit)
+	}
+	assert.Equal(t, 1000, clients())
+	assert.False(t, allow(t, cl, "10.1.0.0", limit).Allowed)
+}
+
+func TestClientLimiterLimitChange(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl := NewClientLimiter(100, time.Minute)
+	cl.now = func() time.Time { return now }
+
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, allow(t, cl, "login:ip:10.0.0.1", testLimit))
+
+	// the bucket of the key keeps its tokens when its limit changes, ex: a replica with another config
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 10, Remaining: 0, Reset: time.Second}, allow(t, cl, "login:ip:10.0.0.1", Limit{Rate: 10, Burst: 10}))
+	assert.False(t, allow(t, cl, "login:ip:10.0.0.1", Limit{Rate: 10, Burst: 10}).Allowed)
+}
+
+func TestClientLimiterCount(t *testing.T) {
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl := NewClientLimiter(limiterShards, time.Minute)
+
+	assert.Equal(t, 0, count(t, cl, "login:ip:10.0.0.1", day, 0))
+	assert.Equal(t, 1, count(t, cl, "login:ip:10.0.0.1", day, 1))
+	assert.Equal(t, 2, count(t, cl, "login:ip:10.0.0.1", day, 1))
+	assert.Equal(t, 2, count(t, cl, "login:ip:10.0.0.1", day, 0))
+
+	// the counts start again the next day, the requests of the previous day are no longer counted
+	nextDay := day.AddDate(0, 0, 1)
+	assert.Equal(t, 1, count(t, cl, "login:ip:10.0.0.1", nextDay, 1))
+	assert.Equal(t, 0, count(t, cl, "login:ip:10.0.0.1", day, 1))
+
+	// a shard counts one key, a new key evicts the least recently counted one and its count starts again
+	assert.Equal(t, 2, count(t, cl, "login:ip:10.0.0.1", nextDay, 1))
+	var sameShard string
+	for i := 0; sameShard == ""; i++ {
+		if key := fmt.Sprintf("login:ip:10.1.%d.%d", i/256, i%256); cl.shard(key) == cl.shard("login:ip:10.0.0.1") {
+			sameShard = key
+		}
+	}
+	assert.Equal(t, 1, count(t, cl, sameShard, nextDay, 1))
+	assert.Equal(t, 0, count(t, cl, "login:ip:10.0.0.1", nextDay, 0))
+	assert.Equal(t, 1, count(t, cl, "login:ip:10.0.0.1", nextDay, 1))
+
+	// every new key is counted
+	counted := 0
+	for i := range 1000 {
+		counted += count(t, cl, fmt.Sprintf("login:ip:10.1.%d.%d", i/256, i%256), nextDay, 1)
+	}
+	assert.Equal(t, 1000, counted)
+}
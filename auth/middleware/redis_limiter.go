@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"auth/consts"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrRateLimitReply = errors.New("the rate limit script returned an unexpected reply")
+
+// gcraScript the generic cell rate algorithm, the bucket of a client is the theoretical arrival time of its next
+// request (tat), in microseconds of the Redis clock so the replicas agree on the time. A request is allowed when
+// the tat is at most the burst tolerance ahead of now, it then moves the tat one emission interval further.
+// KEYS[1] the key of the client, ARGV[1] the emission interval and ARGV[2] the burst tolerance, in microseconds.
+// It returns whether the request is allowed and the tat after it, relative to now. The tat is formatted as an integer,
+// the default conversion of the Lua numbers only keeps 14 digits
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if tat == nil or tat < now then
+	tat = now
+end
+local nextTat = tat + interval
+if nextTat - tolerance > now then
+	return {0, tat - now}
+end
+redis.call("SET", KEYS[1], string.format("%.0f", nextTat), "PX", math.ceil((nextTat - now) / 1000))
+return {1, nextTat - now}
+`)
+
+// countScript adds ARGV[1] requests to the daily count KEYS[1] and returns it, the count expires at the unix time
+// ARGV[2]. A count that is only read is not created
+var countScript = redis.NewScript(`
+if tonumber(ARGV[1]) == 0 then
+	return tonumber(redis.call("GET", KEYS[1]) or "0")
+end
+local count = redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("EXPIREAT", KEYS[1], ARGV[2])
+return count
+`)
+
+// RedisLimiter a token bucket and a daily request count per key shared by the replicas, they are keys of a Redis
+// server, the buckets expire once they are full again and the counts the day after theirs
+type RedisLimiter struct {
+	client    redis.Scripter
+	keyPrefix string
+}
+
+// NewRedisLimiter the keys of the buckets and the counts start with the key prefix, ex: "ratelimit:auth:"
+func NewRedisLimiter(client redis.Scripter, keyPrefix string) *RedisLimiter {
+	return &RedisLimiter{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (rl *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimit, error) {
+	// interval the time a token takes to come back, tolerance the time the full bucket takes
+	interval := time.Duration(float64(time.Second) / float64(limit.Rate))
+	tolerance := interval * time.Duration(limit.Burst)
+
+	reply, err := gcraScript.Run(ctx, rl.client, []string{rl.keyPrefix + key},
+		interval.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return RateLimit{}, err
+	}
+	if len(reply) != 2 {
+		return RateLimit{}, ErrRateLimitReply
+	}
+
+	// the bucket is full when the tat is now, each interval the tat is ahead of now is a token taken
+	tat := time.Duration(reply[1]) * time.Microsecond
+	rateLimit := RateLimit{
+		Allowed:   reply[0] == 1,
+		Limit:     limit.Burst,
+		Remaining: max(0, int((tolerance-tat)/interval)),
+		Reset:     tat,
+	}
+	if !rateLimit.Allowed {
+		rateLimit.RetryAfter = tat + interval - tolerance
+	}
+	return rateLimit, nil
+}
+
+// Count the counts are kept until the end of the next day so the replicas with a late clock still find them
+func (rl *RedisLimiter) Count(ctx context.Context, key string, day time.Time, n int) (int, error) {
+	countKey := rl.keyPrefix + "daily:" + key + ":" + day.Format(time.DateOnly)
+	count, err := countScript.Run(ctx, rl.client, []string{countKey}, n, day.AddDate(0, 0, 2).Unix()).Int()
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FallbackLimiter uses the primary limiter, ex: a RedisLimiter, and the fallback limiter, ex: a ClientLimiter,
+// when the primary fails. The primary is retried after the retry delay, the requests do not wait for a store
+// that is unreachable meanwhile
+type FallbackLimiter struct {
+	primary    RateLimiter
+	fallback   RateLimiter
+	retryDelay time.Duration
+	mu         sync.Mutex
+	// retryAt the time the primary is used again, zero while it works
+	retryAt time.Time
+	now     func() time.Time
+}
+
+func NewFallbackLimiter(primary RateLimiter, fallback RateLimiter, retryDelay time.Duration) *FallbackLimiter {
+	return &FallbackLimiter{
+		primary:    primary,
+		fallback:   fallback,
+		retryDelay: retryDelay,
+		now:        time.Now,
+	}
+}
+
+func (fl *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimit, error) {
+	var rateLimit RateLimit
+	err := fl.use(ctx, func(limiter RateLimiter) error {
+		var err error
+		rateLimit, err = limiter.Allow(ctx, key, limit)
+		return err
+	})
+	return rateLimit, err
+}
+
+func (fl *FallbackLimiter) Count(ctx context.Context, key string, day time.Time, n int) (int, error) {
+	var count int
+	err := fl.use(ctx, func(limiter RateLimiter) error {
+		var err error
+		count, err = limiter.Count(ctx, key, day, n)
+		return err
+	})
+	return count, err
+}
+
+// use calls fn with the primary limiter, or with the fallback limiter when the primary fails or failed less than
+// the retry delay ago
+func (fl *FallbackLimiter) use(ctx context.Context, fn func(limiter RateLimiter) error) error {
+	now := fl.now()
+	fl.mu.Lock()
+	failing := now.Before(fl.retryAt)
+	fl.mu.Unlock()
+	if failing {
+		return fn(fl.fallback)
+	}
+
+	err := fn(fl.primary)
+	// the error of a canceled request does not tell anything about the primary
+	if err != nil && ctx.Err() != nil {
+		return fn(fl.fallback)
+	}
+
+	fl.mu.Lock()
+	recovered := err == nil && !fl.retryAt.IsZero()
+	failed := err != nil && fl.retryAt.IsZero()
+	if err != nil {
+		fl.retryAt = now.Add(fl.retryDelay)
+	} else {
+		fl.retryAt = time.Time{}
+	}
+	fl.mu.Unlock()
+
+	if failed {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("the rate limiter failed, using the fallback limiter")
+	}
+	if recovered {
+		log.Info().
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("the rate limiter recovered")
+	}
+	if err != nil {
+		return fn(fl.fallback)
+	}
+	return nil
+}
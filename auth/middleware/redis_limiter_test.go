@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{
+		Addr:       server.Addr(),
+		MaxRetries: -1,
+	})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return server, client
+}
+
+func TestRedisLimiterAllow(t *testing.T) {
+	server, client := newRedisClient(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.SetTime(now)
+
+	// the replicas share the buckets
+	replicaA := NewRedisLimiter(client, "ratelimit:auth:")
+	replicaB := NewRedisLimiter(client, "ratelimit:auth:")
+
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, allow(t, replicaA, "10.0.0.1", testLimit))
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, allow(t, replicaB, "10.0.0.1", testLimit))
+	assert.Equal(t, RateLimit{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}, allow(t, replicaA, "10.0.0.1", testLimit))
+
+	// the other clients have their own bucket
+	assert.True(t, allow(t, replicaB, "10.0.0.2", testLimit).Allowed)
+
+	server.SetTime(now.Add(500 * time.Millisecond))
+	assert.Equal(t, RateLimit{Allowed: false, Limit: 2, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}, allow(t, replicaA, "10.0.0.1", testLimit))
+
+	server.SetTime(now.Add(1500 * time.Millisecond))
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 0, Reset: 1500 * time.Millisecond}, allow(t, replicaB, "10.0.0.1", testLimit))
+
+	// the key expires once the bucket is full again
+	assert.Equal(t, time.Second, server.TTL("ratelimit:auth:10.0.0.2"))
+}
+
+func TestRedisLimiterCount(t *testing.T) {
+	server, client := newRedisClient(t)
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.SetTime(day.Add(time.Hour))
+
+	// the replicas share the counts
+	replicaA := NewRedisLimiter(client, "ratelimit:auth:")
+	replicaB := NewRedisLimiter(client, "ratelimit:auth:")
+
+	assert.Equal(t, 0, count(t, replicaA, "login:ip:10.0.0.1", day, 0))
+	assert.False(t, server.Exists("ratelimit:auth:daily:login:ip:10.0.0.1:2025-01-01"))
+	assert.Equal(t, 1, count(t, replicaA, "login:ip:10.0.0.1", day, 1))
+	assert.Equal(t, 2, count(t, replicaB, "login:ip:10.0.0.1", day, 1))
+	assert.Equal(t, 2, count(t, replicaB, "login:ip:10.0.0.1", day, 0))
+
+	// the counts of each day have their own key, it expires the day after
+	assert.Equal(t, 1, count(t, replicaA, "login:ip:10.0.0.1", day.AddDate(0, 0, 1), 1))
+	assert.Equal(t, 47*time.Hour, server.TTL("ratelimit:auth:daily:login:ip:10.0.0.1:2025-01-01"))
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	server, client := newRedisClient(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := now
+	server.SetTime(now)
+
+	fallback := NewClientLimiter(100, time.Minute)
+	fallback.now = func() time.Time { return now }
+	fl := NewFallbackLimiter(NewRedisLimiter(client, "ratelimit:auth:"), fallback, 5*time.Second)
+	fl.now = func() time.Time { return now }
+
+	// the primary limits while it works
+	assert.Equal(t, 1, allow(t, fl, "10.0.0.1", testLimit).Remaining)
+	assert.Equal(t, 1, count(t, fl, "10.0.0.1", day, 1))
+
+	// the fallback limits while the primary is unreachable, it has its own buckets and counts
+	addr := server.Addr()
+	server.Close()
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, allow(t, fl, "10.0.0.1", testLimit))
+	assert.Equal(t, 0, allow(t, fl, "10.0.0.1", testLimit).Remaining)
+	assert.False(t, allow(t, fl, "10.0.0.1", testLimit).Allowed)
+	assert.Equal(t, 1, count(t, fl, "10.0.0.1", day, 1))
+
+	// the primary is retried after the retry delay
+	require.NoError(t, server.StartAddr(addr))
+	server.SetTime(now)
+	assert.Equal(t, 2, count(t, fl, "10.0.0.1", day, 1))
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, RateLimit{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, allow(t, fl, "10.0.0.1", testLimit))
+	assert.Equal(t, 2, count(t, fl, "10.0.0.1", day, 1))
+}
+
+func TestRedisLimiterCanceledContext(t *testing.T) {
+	_, client := newRedisClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewRedisLimiter(client, "ratelimit:auth:").Allow(ctx, "10.0.0.1", testLimit)
+	assert.ErrorIs(t, err, context.Canceled)
+}
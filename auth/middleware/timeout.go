@@ -1,36 +1,187 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+var ErrHijackNotSupported = errors.New("the connection of a request with a timeout can not be hijacked")
+
+// RouteTimeouts the timeouts of the routes that differ from the default one, keyed by the method and the full
+// path of the route, ex: "POST /login". A zero timeout disables the timeout of the route, its
+// response is written directly so it can be streamed
+type RouteTimeouts map[string]time.Duration
+
+// TimeoutMiddleware cancels the context of the request after the timeout of its route and responds with
+// 504 Gateway Timeout. The handlers write to a buffer that is only sent when they finish before the timeout,
+// their writes after the timeout are discarded. The middleware waits for the handlers to return once the
+// timeout response is sent, so the gin.Context is not reused while they run
+func TimeoutMiddleware(timeout time.Duration, routeTimeouts RouteTimeouts) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		routeTimeout, ok := routeTimeouts[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			routeTimeout = timeout
+		}
+		if routeTimeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), routeTimeout)
 		defer cancel()
 
 		// Replace request context with the new timeout context
 		c.Request = c.Request.WithContext(ctx)
 
-		// Create a done channel to wait for handler completion
-		done := make(chan struct{})
+		responseWriter := c.Writer
+		writer := newTimeoutWriter(responseWriter)
+		c.Writer = writer
 
+		done := make(chan struct{})
+		var panicValue any
 		go func() {
-			c.Next() // Call the next handler(s)
-			close(done)
+			defer close(done)
+			// the panics are raised again in the goroutine of the request for the recovery middleware
+			defer func() {
+				panicValue = recover()
+			}()
+			c.Next()
 		}()
 
 		select {
 		case <-done:
-			// All good
 		case <-ctx.Done():
-			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
-				"error": "request timed out",
-			})
+			// the client is gone when the context of the request was canceled, only the deadline is answered
+			if writer.timeout() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				responseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+				responseWriter.WriteHeader(http.StatusGatewayTimeout)
+				_, _ = responseWriter.Write([]byte(`{"error":"request timed out"}`))
+				responseWriter.Flush()
+			}
+			// the handlers see the canceled context, the response is sent but they still use the gin.Context
+			<-done
+		}
+
+		c.Writer = responseWriter
+		if panicValue != nil {
+			panic(panicValue)
 		}
+		writer.commit()
+	}
+}
+
+// timeoutWriter buffers the response of the handlers, it is sent by commit or discarded after the timeout
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu     sync.Mutex
+	header http.Header
+	body   bytes.Buffer
+	status int
+	// wroteHeader the handlers sent the header, the status can not change, as the gin.ResponseWriter
+	wroteHeader bool
+	timedOut    bool
+}
+
+func newTimeoutWriter(responseWriter gin.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		ResponseWriter: responseWriter,
+		header:         responseWriter.Header().Clone(),
+		status:         http.StatusOK,
+	}
+}
+
+// timeout discards the response, it returns false when the handlers already sent it
+func (writer *timeoutWriter) timeout() bool {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	writer.timedOut = true
+	return !writer.ResponseWriter.Written()
+}
+
+// commit sends the buffered response, unless the request timed out
+func (writer *timeoutWriter) commit() {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if writer.timedOut {
+		return
+	}
+	header := writer.ResponseWriter.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range writer.header {
+		header[key] = values
+	}
+	writer.ResponseWriter.WriteHeader(writer.status)
+	if writer.body.Len() > 0 {
+		_, _ = writer.ResponseWriter.Write(writer.body.Bytes())
 	}
+	writer.ResponseWriter.WriteHeaderNow()
+}
+
+func (writer *timeoutWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *timeoutWriter) WriteHeader(status int) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if status <= 0 || writer.timedOut || writer.wroteHeader {
+		return
+	}
+	writer.status = status
+}
+
+func (writer *timeoutWriter) WriteHeaderNow() {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	writer.wroteHeader = true
+}
+
+func (writer *timeoutWriter) Write(data []byte) (int, error) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if writer.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	writer.wroteHeader = true
+	return writer.body.Write(data)
+}
+
+func (writer *timeoutWriter) WriteString(s string) (int, error) {
+	return writer.Write([]byte(s))
+}
+
+func (writer *timeoutWriter) Status() int {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return writer.status
+}
+
+func (writer *timeoutWriter) Size() int {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if !writer.wroteHeader {
+		return -1
+	}
+	return writer.body.Len()
+}
+
+func (writer *timeoutWriter) Written() bool {
+	return writer.Size() != -1
+}
+
+// Flush does nothing, the response is sent when the handlers finish
+func (writer *timeoutWriter) Flush() {}
+
+func (writer *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, ErrHijackNotSupported
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// InviteTTL the time an invite can be used for after it is created
+const InviteTTL = 7 * 24 * time.Hour
+
+// Invite lets one user register into the tenant with at most the scopes, the tenant and the scopes are set by the
+// operator that creates it with auth create-invite, never by the registration. Only the hash of the code is stored
+type Invite struct {
+	CodeHash  string    `bson:"code_hash"`
+	Tenant    string    `bson:"tenant"`
+	Scopes    []string  `bson:"scopes"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	// UsedBy the canonical username of the user that registered with the invite, empty while it can be used
+	UsedBy string `bson:"used_by,omitempty"`
+}
+
+// HashInviteCode returns the hash the invite of the code is stored and looked up by
+func HashInviteCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
@@ -4,6 +4,7 @@ import "github.com/golang-jwt/jwt/v5"
 
 type JWTClaims struct {
 	Username string   `json:"username"`
+	Tenant   string   `json:"tenant"`
 	Scopes   []string `json:"scopes"`
 	jwt.RegisteredClaims
 }
@@ -1,9 +1,12 @@
 package models
 
+// RegisterInput the tenant of the user is the one of the invite, the scopes are the ones of the invite unless they
+// are set, they can then only be some of the scopes of the invite
 type RegisterInput struct {
-	Username string   `json:"username" binding:"required,min=3"`
-	Password string   `json:"password" binding:"required,min=6"`
-	Scopes   []string `json:"scopes"`
+	Username   string   `json:"username" binding:"required,min=3"`
+	Password   string   `json:"password" binding:"required,min=6"`
+	InviteCode string   `json:"invite_code" binding:"required"`
+	Scopes     []string `json:"scopes"`
 }
 
 type RegisterOutput struct {
@@ -1,7 +1,31 @@
 package models
 
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
 type User struct {
-	Username       string   `bson:"username"`
-	HashedPassword string   `bson:"hashed_password"`
-	Scopes         []string `bson:"scopes"`
+	Username string `bson:"username"`
+	// UsernameCanonical the case folded username the users are unique and looked up by, see CanonicalUsername
+	UsernameCanonical string   `bson:"username_canonical,omitempty"`
+	HashedPassword    string   `bson:"hashed_password"`
+	Tenant            string   `bson:"tenant"`
+	Scopes            []string `bson:"scopes"`
+}
+
+var folder = cases.Fold()
+
+// NormalizeUsername returns the username as it is stored: NFKC normalized and trimmed, the composed and
+// decomposed forms of a letter or a full width letter are written the same
+func NormalizeUsername(username string) string {
+	return strings.TrimSpace(norm.NFKC.String(username))
+}
+
+// CanonicalUsername returns the normalized username case folded, "Alice" and "ALICE" are the same user
+func CanonicalUsername(username string) string {
+	// folding can undo the normalization of a few characters, ex: the greek ypogegrammeni
+	return norm.NFKC.String(folder.String(NormalizeUsername(username)))
 }
@@ -3,32 +3,55 @@ package repo
 import (
 	"auth/models"
 	"context"
+	"errors"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
-	DatabaseName    string = "mx-auth"
-	UsersCollection string = "users"
+	UsersCollection   string = "users"
+	InvitesCollection string = "invites"
 )
 
+// ErrUsernameTaken another user has the same canonical username
+var ErrUsernameTaken = errors.New("the username is already taken")
+
+// ErrInviteNotFound the invite does not exist, is already used or expired
+var ErrInviteNotFound = errors.New("the invite does not exist, is already used or expired")
+
 type mongoRepo struct {
 	client *mongo.Client
+	// database the name of the database of the service, ex: mx-auth
+	database string
 }
 
-func NewMongoRepo(client *mongo.Client) Repo {
+func NewMongoRepo(client *mongo.Client, database string) Repo {
 	return &mongoRepo{
-		client: client,
+		client:   client,
+		database: database,
 	}
 }
 
 func (repo *mongoRepo) GetUser(ctx context.Context, username string) (models.User, error) {
-	filter := bson.M{
-		"username": username,
+	// the exact username first: the users created before the canonical usernames, or conflicting on them, only
+	// have their exact username until the backfill or a rename
+	user, err := repo.findUser(ctx, bson.M{
+		"username": models.NormalizeUsername(username),
+	})
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return user, err
 	}
+	return repo.findUser(ctx, bson.M{
+		"username_canonical": models.CanonicalUsername(username),
+	})
+}
+
+func (repo *mongoRepo) findUser(ctx context.Context, filter bson.M) (models.User, error) {
 	user := models.User{}
-	result := repo.client.Database(DatabaseName).Collection(UsersCollection).FindOne(ctx, filter)
+	result := repo.client.Database(repo.database).Collection(UsersCollection).FindOne(ctx, filter)
 	if err := result.Err(); err != nil {
 		return user, err
 	}
@@ -42,6 +65,79 @@ func (repo *mongoRepo) GetUser(ctx context.Context, username string) (models.Use
 }
 
 func (repo *mongoRepo) InsertUser(ctx context.Context, user models.User) error {
-	_, err := repo.client.Database(DatabaseName).Collection(UsersCollection).InsertOne(ctx, user)
+	_, err := repo.client.Database(repo.database).Collection(UsersCollection).InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.Join(ErrUsernameTaken, err)
+	}
+	return err
+}
+
+func (repo *mongoRepo) ExportUsers(ctx context.Context, fn func(user models.User) error) error {
+	cursor, err := repo.client.Database(repo.database).Collection(UsersCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		user := models.User{}
+		err = cursor.Decode(&user)
+		if err != nil {
+			return err
+		}
+		err = fn(user)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (repo *mongoRepo) SetUsernameCanonical(ctx context.Context, username string, usernameCanonical string) error {
+	filter := bson.M{
+		"username": username,
+	}
+	update := bson.M{"$set": bson.M{
+		"username_canonical": usernameCanonical,
+	}}
+	_, err := repo.client.Database(repo.database).Collection(UsersCollection).UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.Join(ErrUsernameTaken, err)
+	}
+	return err
+}
+
+func (repo *mongoRepo) InsertInvite(ctx context.Context, invite models.Invite) error {
+	_, err := repo.client.Database(repo.database).Collection(InvitesCollection).InsertOne(ctx, invite)
+	return err
+}
+
+func (repo *mongoRepo) ClaimInvite(ctx context.Context, codeHash string, usernameCanonical string, now time.Time) (models.Invite, error) {
+	filter := bson.M{
+		"code_hash":  codeHash,
+		"used_by":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{
+		"used_by": usernameCanonical,
+	}}
+	invite := models.Invite{}
+	err := repo.client.Database(repo.database).Collection(InvitesCollection).
+		FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).
+		Decode(&invite)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return invite, errors.Join(ErrInviteNotFound, err)
+	}
+	return invite, err
+}
+
+func (repo *mongoRepo) ReleaseInvite(ctx context.Context, codeHash string) error {
+	filter := bson.M{
+		"code_hash": codeHash,
+	}
+	update := bson.M{"$unset": bson.M{
+		"used_by": "",
+	}}
+	_, err := repo.client.Database(repo.database).Collection(InvitesCollection).UpdateOne(ctx, filter, update)
 	return err
 }
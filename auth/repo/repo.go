@@ -3,9 +3,20 @@ package repo
 import (
 	"auth/models"
 	"context"
+	"time"
 )
 
 type Repo interface {
+	// GetUser finds the user by the canonical form of the username, see models.CanonicalUsername
 	GetUser(ctx context.Context, username string) (models.User, error)
 	InsertUser(ctx context.Context, user models.User) error
+	// ExportUsers calls fn for every user
+	ExportUsers(ctx context.Context, fn func(user models.User) error) error
+	// SetUsernameCanonical sets the canonical username of a user, ex: of the users created before it existed
+	SetUsernameCanonical(ctx context.Context, username string, usernameCanonical string) error
+	InsertInvite(ctx context.Context, invite models.Invite) error
+	// ClaimInvite marks the unused and unexpired invite of the code hash as used by the user and returns it
+	ClaimInvite(ctx context.Context, codeHash string, usernameCanonical string, now time.Time) (models.Invite, error)
+	// ReleaseInvite makes the invite of the code hash usable again, ex: when the user could not be inserted
+	ReleaseInvite(ctx context.Context, codeHash string) error
 }
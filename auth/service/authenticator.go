@@ -2,12 +2,13 @@ package service
 
 import (
 	"auth/hasher"
+	"auth/models"
 	"auth/repo"
 	"context"
 )
 
 type Authenticator interface {
-	Authenticate(ctx context.Context, username, password string) (scopes []string, success bool)
+	Authenticate(ctx context.Context, username, password string) (user models.User, success bool)
 }
 
 type authenticator struct {
@@ -22,14 +23,14 @@ func NewAuthenticator(repo repo.Repo, hasher hasher.Hash) Authenticator {
 	}
 }
 
-func (authenticatorService *authenticator) Authenticate(ctx context.Context, username, password string) ([]string, bool) {
+func (authenticatorService *authenticator) Authenticate(ctx context.Context, username, password string) (models.User, bool) {
 	user, err := authenticatorService.repo.GetUser(ctx, username)
 	if err != nil {
-		return []string{}, false
+		return models.User{}, false
 	}
 	passwordHashMatch := authenticatorService.hasher.ComparePassword(user.HashedPassword, password)
 	if !passwordHashMatch {
-		return []string{}, false
+		return models.User{}, false
 	}
-	return user.Scopes, true
+	return user, true
 }
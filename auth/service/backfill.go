@@ -0,0 +1,41 @@
+package service
+
+import (
+	"auth/consts"
+	"auth/models"
+	"auth/repo"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BackfillCanonicalUsernames sets the canonical username of the users created before it existed, it returns the
+// number of updated users and of conflicts: the users whose username only differs from another one by its case or
+// normal form, they keep logging in with their exact username until they are renamed. It can run while the
+// service is running
+func BackfillCanonicalUsernames(ctx context.Context, userRepo repo.Repo) (int, int, error) {
+	count, conflicts := 0, 0
+	err := userRepo.ExportUsers(ctx, func(user models.User) error {
+		usernameCanonical := models.CanonicalUsername(user.Username)
+		if user.UsernameCanonical == usernameCanonical {
+			return nil
+		}
+		err := userRepo.SetUsernameCanonical(ctx, user.Username, usernameCanonical)
+		if errors.Is(err, repo.ErrUsernameTaken) {
+			conflicts++
+			log.Warn().
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyUsername, user.Username).
+				Msg("the username conflicts with another user")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, conflicts, err
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"auth/models"
+	"auth/repo"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// CreateInvite creates an invite to register a user into the tenant with the scopes, it returns the code of the
+// invite, only its hash is stored. The invites are created by the operators, the registrations can not choose
+// their tenant nor give themselves more scopes
+func CreateInvite(ctx context.Context, inviteRepo repo.Repo, tenant string, scopes []string) (string, models.Invite, error) {
+	code := make([]byte, 24)
+	_, err := rand.Read(code)
+	if err != nil {
+		return "", models.Invite{}, err
+	}
+	inviteCode := base64.RawURLEncoding.EncodeToString(code)
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+	invite := models.Invite{
+		CodeHash:  models.HashInviteCode(inviteCode),
+		Tenant:    tenant,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().UTC().Add(models.InviteTTL),
+	}
+	err = inviteRepo.InsertInvite(ctx, invite)
+	if err != nil {
+		return "", models.Invite{}, err
+	}
+	return inviteCode, invite, nil
+}
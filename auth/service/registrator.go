@@ -6,13 +6,20 @@ import (
 	"auth/repo"
 	"context"
 	"errors"
+	"slices"
+	"time"
 )
 
 var ErrHashingPassword = errors.New("error hashing password")
 var ErrInsertingUser = errors.New("error inserting user in the database")
+var ErrClaimingInvite = errors.New("error claiming the invite")
+
+// ErrScopesNotAllowed the registration asks for scopes the invite does not give
+var ErrScopesNotAllowed = errors.New("the invite does not give some of the scopes")
 
 type Registrator interface {
-	Register(ctx context.Context, username string, password string, scopes []string) error
+	// Register creates the user in the tenant of the invite, with the scopes of the invite or some of them
+	Register(ctx context.Context, username string, password string, inviteCode string, scopes []string) error
 }
 
 type registrator struct {
@@ -31,6 +38,7 @@ func (registratorService *registrator) Register(
 	ctx context.Context,
 	username string,
 	password string,
+	inviteCode string,
 	scopes []string,
 ) error {
 	hashedPassword, err := registratorService.hasher.HashPassword(password)
@@ -38,15 +46,41 @@ func (registratorService *registrator) Register(
 		return errors.Join(ErrHashingPassword, err)
 	}
 
+	codeHash := models.HashInviteCode(inviteCode)
+	invite, err := registratorService.repo.ClaimInvite(ctx, codeHash, models.CanonicalUsername(username), time.Now().UTC())
+	if err != nil {
+		return errors.Join(ErrClaimingInvite, err)
+	}
+
+	if scopes == nil {
+		scopes = invite.Scopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(invite.Scopes, scope) {
+			registratorService.releaseInvite(codeHash)
+			return ErrScopesNotAllowed
+		}
+	}
+
 	user := models.User{
-		Username:       username,
-		HashedPassword: hashedPassword,
-		Scopes:         scopes,
+		Username:          models.NormalizeUsername(username),
+		UsernameCanonical: models.CanonicalUsername(username),
+		HashedPassword:    hashedPassword,
+		Tenant:            invite.Tenant,
+		Scopes:            scopes,
 	}
 
 	err = registratorService.repo.InsertUser(ctx, user)
 	if err != nil {
+		registratorService.releaseInvite(codeHash)
 		return errors.Join(ErrInsertingUser, err)
 	}
 	return nil
 }
+
+// releaseInvite the invite can be used again when the registration failed, even when the request was canceled
+func (registratorService *registrator) releaseInvite(codeHash string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = registratorService.repo.ReleaseInvite(ctx, codeHash)
+}
@@ -0,0 +1,18 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores binary content, like the company attachments, by key.
+// Keys are slash separated paths, ex: companies/<company id>/<sha256>. The size is -1 when the content
+// is streamed and its length is not known in advance
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,142 @@
+package blobstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 a minimal in memory stand-in of the S3 object API, path style requests only
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s3 *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s3.mu.Lock()
+	defer s3.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		content, err := io.ReadAll(r.Body)
+		if err == nil && strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			content, err = decodeAWSChunked(content)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s3.objects[r.URL.Path] = content
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		content, exists := s3.objects[r.URL.Path]
+		if !exists {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+			}
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		http.ServeContent(w, r, "", time.Now(), bytes.NewReader(content))
+	case http.MethodDelete:
+		delete(s3.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeAWSChunked decodes a streaming signed body, ex: "10;chunk-signature=...\r\n<16 bytes>\r\n0;chunk-signature=...\r\n\r\n"
+func decodeAWSChunked(body []byte) ([]byte, error) {
+	content := []byte{}
+	reader := bufio.NewReader(bytes.NewReader(body))
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return content, nil
+		}
+		chunk := make([]byte, size+2) // data followed by \r\n
+		_, err = io.ReadFull(reader, chunk)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, chunk[:size]...)
+	}
+}
+
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := "companies/c9efeb5d-3039-4c9a-9216-5dc54416fd61/checksum"
+	content := []byte("%PDF-1.4 content")
+
+	err := store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "application/pdf")
+	require.NoError(t, err)
+
+	reader, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	readContent, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, content, readContent)
+
+	err = store.Delete(ctx, key)
+	require.NoError(t, err)
+
+	_, err = store.Get(ctx, key)
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	// deleting a missing blob is not an error
+	err = store.Delete(ctx, key)
+	assert.NoError(t, err)
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	testBlobStore(t, store)
+
+	_, err = store.Get(context.Background(), "../outside")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestS3BlobStore(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	defer server.Close()
+
+	store, err := NewS3BlobStore(S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "access-key",
+		SecretKey: "secret-key",
+	})
+	require.NoError(t, err)
+
+	testBlobStore(t, store)
+}
@@ -0,0 +1,72 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config the connection settings of an S3 compatible object storage, ex: AWS S3, MinIO
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+type s3BlobStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3BlobStore returns a BlobStore that keeps the blobs as objects of an existing bucket
+func NewS3BlobStore(config S3Config) (BlobStore, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		// setting the region skips the bucket location lookup
+		Region:       config.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3BlobStore{
+		client: client,
+		bucket: config.Bucket,
+	}, nil
+}
+
+func (store *s3BlobStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	_, err := store.client.PutObject(ctx, store.bucket, key, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (store *s3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GetObject is lazy, Stat makes the request so a missing object is reported here
+	object, err := store.client.GetObject(ctx, store.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	_, err = object.Stat()
+	if err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, errors.Join(ErrBlobNotFound, err)
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+func (store *s3BlobStore) Delete(ctx context.Context, key string) error {
+	return store.client.RemoveObject(ctx, store.bucket, key, minio.RemoveObjectOptions{})
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Config the settings of the companies service. Every setting has a default, a YAML key, an env var and a flag,
+// ex: kafka.acks, KAFKA_ACKS and -kafka.acks
+type Config struct {
+	Port         int         `yaml:"port" usage:"the port the service listens on"`
+	Mongo        MongoConfig `yaml:"mongo"`
+	JWTSecretKey string      `yaml:"jwt_secret_key" secret:"true" usage:"the key the tokens of the auth service are signed with"`
+	Kafka        KafkaConfig `yaml:"kafka"`
+	// RedisURL the rate limits are shared by the replicas when it is set
+	RedisURL string `yaml:"redis_url" secret:"url" usage:"the Redis server the replicas share the rate limits in, ex: redis://localhost:6379/0"`
+	// TrustedProxies the client IP is the X-Forwarded-For address set by one of these proxies or networks
+	TrustedProxies   []string        `yaml:"trusted_proxies" usage:"the proxies the X-Forwarded-For header is trusted from, ex: 10.0.0.0/8,192.168.1.2"`
+	Timeouts         TimeoutsConfig  `yaml:"timeouts"`
+	RateLimit        RateLimitConfig `yaml:"rate_limit"`
+	ChangeRequestTTL time.Duration   `yaml:"change_request_ttl" usage:"the time the pending change requests expire after, ex: 24h"`
+	// OwnerOnlyWrites only the user that created a company, or a user with the companies:admin scope, can modify or delete it
+	OwnerOnlyWrites bool `yaml:"owner_only_writes" usage:"only the creator of a company or an admin can modify or delete it"`
+	JobWorkers      int  `yaml:"job_workers" usage:"the number of jobs each replica runs at the same time"`
+	// JobLease a job is taken over by another replica when its worker did not renew the lease for this long
+	JobLease time.Duration `yaml:"job_lease" usage:"the time a job is taken over by another replica after, ex: 30s"`
+	// FieldPolicyFile every user can read and write every field when it is not set
+	FieldPolicyFile string        `yaml:"field_policy_file" usage:"the JSON field policy file, ex: field-policy.json"`
+	StatsCacheTTL   time.Duration `yaml:"stats_cache_ttl" usage:"the time the company statistics are cached for, ex: 1m"`
+	// SearchIndexDir the search index is embedded so each replica has its own
+	SearchIndexDir string          `yaml:"search_index_dir" usage:"the directory of the search index"`
+	BlobStore      BlobStoreConfig `yaml:"blob_store"`
+	S3             S3Config        `yaml:"s3"`
+}
+
+type MongoConfig struct {
+	URI            string        `yaml:"uri" secret:"url" usage:"the MongoDB connection string, ex: mongodb://localhost:27017"`
+	Database       string        `yaml:"database" usage:"the MongoDB database of the service"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout" usage:"the time the connection to MongoDB can take"`
+}
+
+type KafkaConfig struct {
+	Servers  []string `yaml:"servers" usage:"the Kafka bootstrap servers, ex: localhost:9092,example.com:9092"`
+	ClientID string   `yaml:"client_id" usage:"the client id of the Kafka producer"`
+	Acks     string   `yaml:"acks" usage:"the acknowledgements the Kafka producer waits for, 0, 1 or all"`
+	Topic    string   `yaml:"topic" usage:"the Kafka topic of the company events"`
+	// SearchGroup each replica reads every event to update its own search index, so the group is unique per replica
+	SearchGroup string `yaml:"search_group" usage:"the consumer group the replica updates its search index with, companies-search-<hostname> when empty"`
+}
+
+// TimeoutsConfig the time the requests can take, a route timeout of 0 streams the responses without a timeout
+type TimeoutsConfig struct {
+	Request time.Duration `yaml:"request" usage:"the time a request can take"`
+	// Long the batches, the merges and the attachment uploads
+	Long time.Duration `yaml:"long" usage:"the time the batches, merges and attachment uploads can take, 0 for no timeout"`
+	// Import the synchronous imports are not buffered, they stop after it and their report ends with an error code
+	Import time.Duration `yaml:"import" usage:"the time a synchronous import runs before it stops, 0 for no limit"`
+}
+
+// RateLimitConfig the limits of the api and bulk route groups, the users with the companies:quota:premium scope
+// have the premium tier
+type RateLimitConfig struct {
+	// MaxClients the replica keeps the buckets of up to this number of users, for ClientTTL after their last request
+	MaxClients int           `yaml:"max_clients" usage:"the number of users the replica keeps the rate limits of"`
+	ClientTTL  time.Duration `yaml:"client_ttl" usage:"the time the replica keeps the rate limit of a user after its last request"`
+	// IP the limit of each client IP before the token is validated, the requests without a valid token are only
+	// limited by it
+	IP   TierConfig  `yaml:"ip"`
+	API  QuotaConfig `yaml:"api"`
+	Bulk QuotaConfig `yaml:"bulk"`
+}
+
+type QuotaConfig struct {
+	Standard TierConfig `yaml:"standard"`
+	Premium  TierConfig `yaml:"premium"`
+}
+
+type TierConfig struct {
+	Rate  float64 `yaml:"rate" usage:"the requests per second"`
+	Burst int     `yaml:"burst" usage:"the requests that can be sent at once"`
+	Daily int     `yaml:"daily" usage:"the requests per UTC day, 0 for no daily quota"`
+}
+
+type BlobStoreConfig struct {
+	Type string `yaml:"type" env:"BLOB_STORE" usage:"the blob store of the attachments, local or s3"`
+	Dir  string `yaml:"dir" usage:"the directory of the local blob store"`
+}
+
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" usage:"the S3 endpoint, ex: s3.amazonaws.com"`
+	Region    string `yaml:"region" usage:"the S3 region"`
+	Bucket    string `yaml:"bucket" usage:"the S3 bucket of the attachments"`
+	AccessKey string `yaml:"access_key" usage:"the S3 access key"`
+	SecretKey string `yaml:"secret_key" secret:"true" usage:"the S3 secret key"`
+	UseSSL    bool   `yaml:"use_ssl" usage:"connect to S3 with TLS"`
+}
+
+// Default the settings used when neither the config file, the env vars nor the flags set them
+func Default() Config {
+	return Config{
+		Port: 8080,
+		Mongo: MongoConfig{
+			Database:       "mx-auth",
+			ConnectTimeout: 10 * time.Second,
+		},
+		Kafka: KafkaConfig{
+			ClientID: "companies-service",
+			Acks:     "all",
+			Topic:    "companies-events",
+		},
+		Timeouts: TimeoutsConfig{
+			Request: 5 * time.Second,
+			Long:    30 * time.Second,
+			Import:  time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			MaxClients: 100_000,
+			ClientTTL:  time.Minute,
+			IP:         TierConfig{Rate: 50, Burst: 100},
+			API: QuotaConfig{
+				Standard: TierConfig{Rate: 5, Burst: 10, Daily: 10_000},
+				Premium:  TierConfig{Rate: 50, Burst: 100},
+			},
+			Bulk: QuotaConfig{
+				Standard: TierConfig{Rate: 0.2, Burst: 2, Daily: 100},
+				Premium:  TierConfig{Rate: 1, Burst: 5, Daily: 1_000},
+			},
+		},
+		ChangeRequestTTL: 72 * time.Hour,
+		JobWorkers:       2,
+		JobLease:         time.Minute,
+		StatsCacheTTL:    30 * time.Second,
+		SearchIndexDir:   "./data/search",
+		BlobStore: BlobStoreConfig{
+			Type: "local",
+			Dir:  "./data/blobs",
+		},
+		S3: S3Config{
+			UseSSL: true,
+		},
+	}
+}
+
+// Load layers the defaults, the YAML file, the env vars and the flags of the args, ex: os.Args[1:], then validates
+// the config. It returns the args left after the flags, ex: rebuild-search-index, and every invalid setting at once,
+// or flag.ErrHelp when the args ask for the usage
+func Load(args []string, getenv func(string) string) (Config, []string, error) {
+	config := Default()
+	rest, err := load("companies", &config, args, getenv)
+	if errors.Is(err, flag.ErrHelp) || errors.Is(err, ErrInvalidFlags) {
+		return config, nil, err
+	}
+	err = errors.Join(err, config.Validate())
+	if err != nil {
+		return config, rest, errors.Join(ErrInvalidConfig, err)
+	}
+	return config, rest, nil
+}
+
+// Validate returns every invalid setting at once
+func (config Config) Validate() error {
+	var errs []error
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(config.Port > 0 && config.Port <= 65535, "port (PORT) must be between 1 and 65535, got %d", config.Port)
+	check(config.Mongo.URI != "", "mongo.uri (MONGO_URI) must be set, ex: mongodb://localhost:27017")
+	check(config.Mongo.Database != "", "mongo.database (MONGO_DATABASE) must be set")
+	check(config.Mongo.ConnectTimeout > 0, "mongo.connect_timeout (MONGO_CONNECT_TIMEOUT) must be positive, got %s", config.Mongo.ConnectTimeout)
+	check(config.JWTSecretKey != "", "jwt_secret_key (JWT_SECRET_KEY) must be set")
+	check(len(config.Kafka.Servers) > 0, "kafka.servers (KAFKA_SERVERS) must be set, ex: localhost:9092,example.com:9092")
+	check(config.Kafka.ClientID != "", "kafka.client_id (KAFKA_CLIENT_ID) must be set")
+	check(slices.Contains([]string{"0", "1", "all", "-1"}, config.Kafka.Acks), "kafka.acks (KAFKA_ACKS) must be 0, 1 or all, got %q", config.Kafka.Acks)
+	check(config.Kafka.Topic != "", "kafka.topic (KAFKA_TOPIC) must be set")
+	if config.RedisURL != "" {
+		redisURL, err := url.Parse(config.RedisURL)
+		check(err == nil && (redisURL.Scheme == "redis" || redisURL.Scheme == "rediss"),
+			"redis_url (REDIS_URL) must be a redis:// or rediss:// URL, ex: redis://localhost:6379/0")
+	}
+	for _, proxy := range config.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil,
+			"trusted_proxies (TRUSTED_PROXIES) must be IP addresses or CIDR ranges, got %q", proxy)
+	}
+
+	check(config.Timeouts.Request > 0, "timeouts.request (TIMEOUTS_REQUEST) must be positive, got %s", config.Timeouts.Request)
+	check(config.Timeouts.Long >= 0, "timeouts.long (TIMEOUTS_LONG) must not be negative, got %s", config.Timeouts.Long)
+	check(config.Timeouts.Import >= 0, "timeouts.import (TIMEOUTS_IMPORT) must not be negative, got %s", config.Timeouts.Import)
+
+	check(config.RateLimit.MaxClients > 0, "rate_limit.max_clients (RATE_LIMIT_MAX_CLIENTS) must be positive, got %d", config.RateLimit.MaxClients)
+	check(config.RateLimit.ClientTTL > 0, "rate_limit.client_ttl (RATE_LIMIT_CLIENT_TTL) must be positive, got %s", config.RateLimit.ClientTTL)
+	errs = append(errs, config.RateLimit.IP.validate("rate_limit.ip")...)
+	errs = append(errs, config.RateLimit.API.Standard.validate("rate_limit.api.standard")...)
+	errs = append(errs, config.RateLimit.API.Premium.validate("rate_limit.api.premium")...)
+	errs = append(errs, config.RateLimit.Bulk.Standard.validate("rate_limit.bulk.standard")...)
+	errs = append(errs, config.RateLimit.Bulk.Premium.validate("rate_limit.bulk.premium")...)
+
+	check(config.ChangeRequestTTL > 0, "change_request_ttl (CHANGE_REQUEST_TTL) must be positive, ex: 24h, got %s", config.ChangeRequestTTL)
+	check(config.JobWorkers > 0, "job_workers (JOB_WORKERS) must be positive, got %d", config.JobWorkers)
+	check(config.JobLease > 0, "job_lease (JOB_LEASE) must be positive, ex: 30s, got %s", config.JobLease)
+	check(config.StatsCacheTTL > 0, "stats_cache_ttl (STATS_CACHE_TTL) must be positive, ex: 1m, got %s", config.StatsCacheTTL)
+	check(config.SearchIndexDir != "", "search_index_dir (SEARCH_INDEX_DIR) must be set")
+
+	switch config.BlobStore.Type {
+	case "local":
+		check(config.BlobStore.Dir != "", "blob_store.dir (BLOB_STORE_DIR) must be set when the blob store is local")
+	case "s3":
+		check(config.S3.Endpoint != "" && config.S3.Bucket != "", "s3.endpoint (S3_ENDPOINT) and s3.bucket (S3_BUCKET) must be set when the blob store is s3")
+	default:
+		errs = append(errs, fmt.Errorf("blob_store.type (BLOB_STORE) must be local or s3, got %q", config.BlobStore.Type))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (tier TierConfig) validate(path string) []error {
+	var errs []error
+	if tier.Rate <= 0 {
+		errs = append(errs, fmt.Errorf("%s.rate must be positive, got %g", path, tier.Rate))
+	}
+	if tier.Burst < 1 {
+		errs = append(errs, fmt.Errorf("%s.burst must be at least 1, got %d", path, tier.Burst))
+	}
+	if tier.Daily < 0 {
+		errs = append(errs, fmt.Errorf("%s.daily must not be negative, got %d", path, tier.Daily))
+	}
+	return errs
+}
+
+// Redacted the settings by YAML key with the secrets redacted, to be logged
+func (config Config) Redacted() map[string]any {
+	return redacted(&config)
+}
+
+// WriteRedacted writes the settings as YAML with the secrets redacted
+func (config Config) WriteRedacted(w io.Writer) error {
+	return writeRedacted(w, &config)
+}
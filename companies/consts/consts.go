@@ -1,9 +1,26 @@
 package consts
 
 const (
-	LogKeyTimeUTC        = "time_utc"
-	LogKeyErrorCode      = "error_code"
-	LogKeyStatusCode     = "status_code"
-	LogKeyCompanyId      = "company_id"
-	LogKeyKafkaEventType = "kafka_event_type"
+	LogKeyTimeUTC          = "time_utc"
+	LogKeyErrorCode        = "error_code"
+	LogKeyStatusCode       = "status_code"
+	LogKeyCompanyId        = "company_id"
+	LogKeyCompanyName      = "company_name"
+	LogKeySourceCompanyId  = "source_company_id"
+	LogKeyKafkaEventType   = "kafka_event_type"
+	LogKeyIdentifierScheme = "identifier_scheme"
+	LogKeyAttachmentId     = "attachment_id"
+	LogKeyBlobKey          = "blob_key"
+	LogKeyTransition       = "transition"
+	LogKeyChangeRequestId  = "change_request_id"
+	LogKeyFields           = "fields"
+	LogKeyBatchIndex       = "batch_index"
+	LogKeyImportRow        = "import_row"
+	LogKeyDryRun           = "dry_run"
+	LogKeyExportRows       = "export_rows"
+	LogKeyJobId            = "job_id"
+	LogKeyJobType          = "job_type"
+	LogKeyJobStatus        = "job_status"
+	LogKeyQuotaGroup       = "quota_group"
+	LogKeyConfig           = "config"
 )
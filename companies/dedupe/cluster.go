@@ -0,0 +1,110 @@
+package dedupe
+
+import "slices"
+
+// maxPostings the keys that only share trigrams with more than maxPostings other keys are not compared,
+// the common trigrams, ex: the start of the words beginning with an a, would compare every pair of keys
+const maxPostings = 1000
+
+// Cluster the indexes of keys linked by a similarity of at least the threshold, directly or through other keys
+type Cluster struct {
+	Members []int
+	// Scores the best similarity of each member to another member
+	Scores []float64
+	// MinScore the lowest similarity of the links of the cluster
+	MinScore float64
+}
+
+// FindClusters groups the similar keys, the keys are only compared with the keys they share a trigram with.
+// The clusters have at least two members, the largest clusters first
+func FindClusters(keys []string, threshold float64) []Cluster {
+	parents := make([]int, len(keys))
+	for i := range parents {
+		parents[i] = i
+	}
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+
+	scores := make([]float64, len(keys))
+	minScores := map[int]float64{}
+	link := func(i, j int, score float64) {
+		scores[i] = max(scores[i], score)
+		scores[j] = max(scores[j], score)
+		rootI, rootJ := find(i), find(j)
+		minScore := score
+		for _, root := range []int{rootI, rootJ} {
+			if rootScore, ok := minScores[root]; ok {
+				minScore = min(minScore, rootScore)
+			}
+		}
+		delete(minScores, rootI)
+		delete(minScores, rootJ)
+		parents[rootI] = rootJ
+		minScores[rootJ] = minScore
+	}
+
+	firstOfKey := map[string]int{}
+	postings := map[string][]int{}
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		if first, ok := firstOfKey[key]; ok {
+			link(i, first, 1)
+			continue
+		}
+		firstOfKey[key] = i
+
+		trigrams := Trigrams(key)
+		compared := map[int]bool{}
+		for _, trigram := range trigrams {
+			if len(postings[trigram]) > maxPostings {
+				continue
+			}
+			for _, j := range postings[trigram] {
+				if compared[j] {
+					continue
+				}
+				compared[j] = true
+				score := Similarity(key, keys[j])
+				if score >= threshold {
+					link(i, j, score)
+				}
+			}
+		}
+		for _, trigram := range trigrams {
+			postings[trigram] = append(postings[trigram], i)
+		}
+	}
+
+	clustersByRoot := map[int]*Cluster{}
+	clusters := []*Cluster{}
+	for i := range keys {
+		root := find(i)
+		if _, linked := minScores[root]; !linked {
+			continue
+		}
+		cluster, ok := clustersByRoot[root]
+		if !ok {
+			cluster = &Cluster{MinScore: minScores[root]}
+			clustersByRoot[root] = cluster
+			clusters = append(clusters, cluster)
+		}
+		cluster.Members = append(cluster.Members, i)
+		cluster.Scores = append(cluster.Scores, scores[i])
+	}
+
+	output := make([]Cluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		output = append(output, *cluster)
+	}
+	slices.SortStableFunc(output, func(a, b Cluster) int {
+		return len(b.Members) - len(a.Members)
+	})
+	return output
+}
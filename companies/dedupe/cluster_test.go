@@ -0,0 +1,41 @@
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindClusters(t *testing.T) {
+	names := []string{"Acme Ltd", "Initech", "ACME Limited", "Globex Holdings", "Acme", "Globex Holdigns", "Umbrella", ""}
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, Key(name))
+	}
+
+	clusters := FindClusters(keys, DefaultThreshold)
+
+	if assert.Len(t, clusters, 2) {
+		assert.Equal(t, []int{0, 2, 4}, clusters[0].Members)
+		assert.Equal(t, []float64{1, 1, 1}, clusters[0].Scores)
+		assert.Equal(t, float64(1), clusters[0].MinScore)
+
+		assert.Equal(t, []int{3, 5}, clusters[1].Members)
+		assert.Less(t, clusters[1].MinScore, float64(1))
+		assert.GreaterOrEqual(t, clusters[1].MinScore, DefaultThreshold)
+	}
+}
+
+func TestFindClustersMinScore(t *testing.T) {
+	// the cluster is as similar as its weakest link
+	keys := []string{"globex", "globexx", "globexxx"}
+
+	clusters := FindClusters(keys, DefaultThreshold)
+
+	if assert.Len(t, clusters, 1) {
+		assert.Equal(t, []int{0, 1, 2}, clusters[0].Members)
+		assert.InDelta(t, Similarity("globex", "globexxx"), clusters[0].MinScore, 0.001)
+	}
+
+	assert.Empty(t, FindClusters(keys, 1))
+}
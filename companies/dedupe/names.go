@@ -0,0 +1,80 @@
+package dedupe
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// legalSuffixes the legal forms stripped from the end of the names, after the dots are removed,
+// ex: "Acme Ltd.", "ACME Limited" and "Acme GmbH & Co. KG" all have the key acme
+var legalSuffixes = [][]string{
+	{"limited", "liability", "company"},
+	{"public", "limited", "company"},
+	{"ab"}, {"ag"}, {"aps"}, {"as"}, {"asa"}, {"bv"}, {"co"}, {"company"}, {"corp"}, {"corporation"},
+	{"gmbh"}, {"inc"}, {"incorporated"}, {"kg"}, {"kk"}, {"limited"}, {"llc"}, {"llp"}, {"lp"}, {"ltd"},
+	{"nv"}, {"oy"}, {"oyj"}, {"plc"}, {"pte"}, {"pty"}, {"pvt"}, {"sa"}, {"sarl"}, {"sas"}, {"se"}, {"sl"},
+	{"spa"}, {"srl"},
+}
+
+var folder = cases.Fold()
+
+// NormalizeName returns the name as it is stored: NFKC normalized and trimmed, the composed and decomposed forms
+// of a letter or a full width letter are written the same
+func NormalizeName(name string) string {
+	return strings.TrimSpace(norm.NFKC.String(name))
+}
+
+// CanonicalName returns the normalized name case folded, the names of the companies of a tenant are unique on it:
+// "Acme" and "ACME" are the same name, "Acme" and "Acme Ltd" are not, see Key for the possible duplicates
+func CanonicalName(name string) string {
+	// folding can undo the normalization of a few characters, ex: the greek ypogegrammeni
+	return norm.NFKC.String(folder.String(NormalizeName(name)))
+}
+
+// Key returns the normalized form of a company name the duplicates are compared on: case folded, without accents,
+// punctuation and legal form suffixes, ex: "ACME Ltd." -> "acme". A name that is only a legal form keeps it
+func Key(name string) string {
+	var builder strings.Builder
+	for _, char := range norm.NFKD.String(folder.String(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, char):
+			// the accents are split from their letters by the decomposition
+		case char == '.' || char == '\'' || char == '’':
+			// L.L.C. is llc and O'Neil is oneil
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			builder.WriteRune(char)
+		default:
+			builder.WriteRune(' ')
+		}
+	}
+	words := strings.Fields(builder.String())
+	for stripped := true; stripped; {
+		stripped = false
+		for _, suffix := range legalSuffixes {
+			if len(words) > len(suffix) && slices.Equal(words[len(words)-len(suffix):], suffix) {
+				words = words[:len(words)-len(suffix)]
+				stripped = true
+				break
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Trigrams returns the sorted distinct trigrams of the words of a key, each word is padded with two spaces
+// before and one after so the short words and the word starts count, ex: "acme" -> "  a", " ac", "acm", "cme", "me "
+func Trigrams(key string) []string {
+	trigrams := []string{}
+	for _, word := range strings.Fields(key) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			trigrams = append(trigrams, string(padded[i:i+3]))
+		}
+	}
+	slices.Sort(trigrams)
+	return slices.Compact(trigrams)
+}
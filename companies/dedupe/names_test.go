@@ -0,0 +1,63 @@
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "legal form", input: "Acme Ltd", expected: "acme"},
+		{name: "case and dots", input: "ACME Ltd.", expected: "acme"},
+		{name: "long legal form", input: "Acme Limited", expected: "acme"},
+		{name: "stacked legal forms", input: "Acme GmbH & Co. KG", expected: "acme"},
+		{name: "multi word legal form", input: "Acme Limited Liability Company", expected: "acme"},
+		{name: "dotted legal form", input: "Acme L.L.C.", expected: "acme"},
+		{name: "accents", input: "Société Générale SA", expected: "societe generale"},
+		{name: "case folding", input: "Straße AG", expected: "strasse"},
+		{name: "punctuation", input: "  Acme-Widgets, Inc. ", expected: "acme widgets"},
+		{name: "apostrophe", input: "O'Neil Co", expected: "oneil"},
+		{name: "only a legal form", input: "Company", expected: "company"},
+		{name: "legal form inside the name", input: "Co Op Foods", expected: "co op foods"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, Key(testCase.input))
+		})
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	assert.Equal(t, []string{"  a", " ac", "acm", "cme", "me "}, Trigrams("acme"))
+	assert.Equal(t, []string{"  a", "  b", " a ", " b "}, Trigrams("a b a"))
+	assert.Empty(t, Trigrams(""))
+}
+
+func TestCanonicalName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "case", input: "ACME", expected: "acme"},
+		{name: "trimmed", input: "  Acme \t", expected: "acme"},
+		{name: "decomposed accent", input: "Cafe\u0301", expected: "caf\u00e9"},
+		{name: "full width letters", input: "Ａｃｍｅ", expected: "acme"},
+		{name: "case folding", input: "STRASSE", expected: "strasse"},
+		{name: "legal form kept", input: "Acme Ltd.", expected: "acme ltd."},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, CanonicalName(testCase.input))
+		})
+	}
+	assert.Equal(t, CanonicalName("Straße"), CanonicalName("STRASSE"))
+	assert.Equal(t, "Caf\u00e9", NormalizeName(" Cafe\u0301 "))
+}
@@ -0,0 +1,89 @@
+package dedupe
+
+// DefaultThreshold the similarity from which two names are reported as possible duplicates
+const DefaultThreshold = 0.9
+
+// Similarity returns how similar two keys are, from 0 to 1. Jaro-Winkler catches the typos and the words added
+// at the end, the trigrams catch the words in a different order
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	return max(JaroWinkler(a, b), TrigramSimilarity(a, b))
+}
+
+// JaroWinkler returns the Jaro similarity of the strings, raised for a common prefix of up to 4 characters
+func JaroWinkler(a, b string) float64 {
+	runesA, runesB := []rune(a), []rune(b)
+	if len(runesA) == 0 || len(runesB) == 0 {
+		if len(runesA) == len(runesB) {
+			return 1
+		}
+		return 0
+	}
+
+	// the characters match when they are equal and not farther apart than half the longest string
+	window := max(max(len(runesA), len(runesB))/2-1, 0)
+	matchedA := make([]bool, len(runesA))
+	matchedB := make([]bool, len(runesB))
+	matches := 0
+	for i, char := range runesA {
+		for j := max(0, i-window); j < min(len(runesB), i+window+1); j++ {
+			if !matchedB[j] && runesB[j] == char {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// half the matched characters that are not in the same order
+	transpositions := 0
+	j := 0
+	for i, char := range runesA {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if char != runesB[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(runesA)) + m/float64(len(runesB)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(runesA), len(runesB)) && runesA[prefix] == runesB[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// TrigramSimilarity returns the share of the trigrams of the keys they have in common
+func TrigramSimilarity(a, b string) float64 {
+	trigramsA, trigramsB := Trigrams(a), Trigrams(b)
+	if len(trigramsA) == 0 || len(trigramsB) == 0 {
+		return 0
+	}
+	common := 0
+	i, j := 0, 0
+	for i < len(trigramsA) && j < len(trigramsB) {
+		switch {
+		case trigramsA[i] == trigramsB[j]:
+			common++
+			i++
+			j++
+		case trigramsA[i] < trigramsB[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(common) / float64(len(trigramsA)+len(trigramsB)-common)
+}
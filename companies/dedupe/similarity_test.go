@@ -0,0 +1,52 @@
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected float64
+	}{
+		{a: "martha", b: "marhta", expected: 0.961},
+		{a: "dwayne", b: "duane", expected: 0.84},
+		{a: "dixon", b: "dicksonx", expected: 0.813},
+		{a: "acme", b: "acme", expected: 1},
+		{a: "acme", b: "xyz", expected: 0},
+		{a: "", b: "", expected: 1},
+		{a: "acme", b: "", expected: 0},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.a+"/"+testCase.b, func(t *testing.T) {
+			assert.InDelta(t, testCase.expected, JaroWinkler(testCase.a, testCase.b), 0.001)
+			assert.InDelta(t, testCase.expected, JaroWinkler(testCase.b, testCase.a), 0.001)
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	testCases := []struct {
+		name       string
+		a, b       string
+		duplicates bool
+	}{
+		{name: "same key", a: "Acme Ltd", b: "ACME Limited", duplicates: true},
+		{name: "typo", a: "Globex Holdings", b: "Globex Holdigns", duplicates: true},
+		{name: "plural", a: "Acme Widgets", b: "Acme Widget", duplicates: true},
+		{name: "words swapped", a: "Widgets Acme", b: "Acme Widgets", duplicates: true},
+		{name: "different companies", a: "Acme", b: "Acne", duplicates: false},
+		{name: "shared word", a: "Acme Foods", b: "Acme Logistics", duplicates: false},
+		{name: "unrelated", a: "Initech", b: "Globex", duplicates: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			similarity := Similarity(Key(testCase.a), Key(testCase.b))
+			assert.Equal(t, testCase.duplicates, similarity >= DefaultThreshold, "similarity %f", similarity)
+		})
+	}
+}
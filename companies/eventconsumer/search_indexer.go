@@ -0,0 +1,88 @@
+package eventconsumer
+
+import (
+	"companies/consts"
+	"companies/repo"
+	"companies/search"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/rs/zerolog/log"
+)
+
+// pollTimeout the time a read waits for a message, the context is checked between the reads
+const pollTimeout = time.Second
+
+// MessageReader reads the messages of the subscribed topics, ex: a *kafka.Consumer
+type MessageReader interface {
+	ReadMessage(timeout time.Duration) (*kafka.Message, error)
+}
+
+// SearchIndexer updates the search index of the replica with the company changes of every replica, it reads the
+// companies events topic with a consumer group of its own
+type SearchIndexer struct {
+	reader      MessageReader
+	index       search.Index
+	companyRepo repo.CompanyRepo
+	wait        sync.WaitGroup
+}
+
+func NewSearchIndexer(reader MessageReader, index search.Index, companyRepo repo.CompanyRepo) *SearchIndexer {
+	return &SearchIndexer{
+		reader:      reader,
+		index:       index,
+		companyRepo: companyRepo,
+	}
+}
+
+// Start reads the events until the context is canceled
+func (indexer *SearchIndexer) Start(ctx context.Context) {
+	indexer.wait.Add(1)
+	go func() {
+		defer indexer.wait.Done()
+		indexer.consume(ctx)
+	}()
+}
+
+// Wait waits for the event being indexed, the reader can be closed then
+func (indexer *SearchIndexer) Wait() {
+	indexer.wait.Wait()
+}
+
+func (indexer *SearchIndexer) consume(ctx context.Context) {
+	for ctx.Err() == nil {
+		message, err := indexer.reader.ReadMessage(pollTimeout)
+		if err != nil {
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrTimedOut {
+				continue
+			}
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("error while reading the company events")
+			// the errors of the broker are retried by the client, the loop does not spin on them
+			time.Sleep(pollTimeout)
+			continue
+		}
+		indexer.indexEvent(ctx, message.Value)
+	}
+}
+
+// indexEvent an event that fails to be indexed is only logged, the company is indexed again by its next change or
+// by a rebuild of the index
+func (indexer *SearchIndexer) indexEvent(ctx context.Context, value []byte) {
+	companyIds, err := search.EventCompanyIds(value)
+	if err == nil && len(companyIds) > 0 {
+		err = search.Refresh(ctx, indexer.index, indexer.companyRepo, companyIds)
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("error while indexing a company event")
+	}
+}
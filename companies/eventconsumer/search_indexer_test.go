@@ -0,0 +1,64 @@
+package eventconsumer
+
+import (
+	"companies/mocks"
+	"companies/models"
+	"companies/search"
+	"companies/tenancy"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// channelReader returns the messages of the channel, a read times out when it is empty
+type channelReader chan *kafka.Message
+
+func (reader channelReader) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	select {
+	case message := <-reader:
+		return message, nil
+	case <-time.After(timeout):
+		return nil, kafka.NewError(kafka.ErrTimedOut, "timed out", false)
+	}
+}
+
+func TestSearchIndexer(t *testing.T) {
+	// the company was created through another replica, only its event is read
+	acme := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme", Description: "Rockets and anvils"}
+	ctx := tenancy.WithScope(context.Background(), tenancy.Scope{Tenant: "tenant-a"})
+
+	index, err := search.NewMemoryIndex()
+	require.NoError(t, err)
+	defer index.Close()
+
+	companyRepo := new(mocks.CompanyRepo)
+	companyRepo.On("GetCompanies", mock.Anything, []uuid.UUID{acme.ID}).
+		Return([]models.Company{acme}, nil)
+
+	output := models.CompanyOutput{}
+	output.FromCompany(acme)
+	value, err := json.Marshal(models.KafkaEvent{Type: models.KafkaEventTypeCompanyCreate, Data: output})
+	require.NoError(t, err)
+	reader := make(channelReader, 1)
+	reader <- &kafka.Message{Value: value}
+
+	indexerCtx, cancel := context.WithCancel(context.Background())
+	indexer := NewSearchIndexer(reader, index, companyRepo)
+	indexer.Start(indexerCtx)
+
+	assert.Eventually(t, func() bool {
+		hits, err := index.Search(ctx, "acme", search.Fields, 10, 0)
+		return err == nil && len(hits) == 1 && hits[0].ID == acme.ID
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	indexer.Wait()
+	companyRepo.AssertExpectations(t)
+}
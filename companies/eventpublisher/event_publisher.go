@@ -10,19 +10,20 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-var CompaniesEventsKafkaTopic string = "companies-events"
-
 type EventPublisher interface {
 	PublishEvent(event models.KafkaEvent)
 }
 
 type eventPublisher struct {
 	kafkaProducer *kafka.Producer
+	// topic the topic of the events, ex: companies-events
+	topic string
 }
 
-func NewEventPublisher(kafkaProducer *kafka.Producer) EventPublisher {
+func NewEventPublisher(kafkaProducer *kafka.Producer, topic string) EventPublisher {
 	return &eventPublisher{
 		kafkaProducer: kafkaProducer,
+		topic:         topic,
 	}
 }
 
@@ -44,7 +45,7 @@ func (publisher *eventPublisher) PublishEvent(event models.KafkaEvent) {
 	err = publisher.kafkaProducer.Produce(
 		&kafka.Message{
 			TopicPartition: kafka.TopicPartition{
-				Topic:     &CompaniesEventsKafkaTopic,
+				Topic:     &publisher.topic,
 				Partition: kafka.PartitionAny,
 			},
 			Value: jsonEvent,
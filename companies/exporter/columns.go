@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"companies/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var ErrUnknownColumn = errors.New("unknown company column")
+
+// Column the JSON path of a company field, split on the dots, ex: registered_address.city
+type Column []string
+
+func (column Column) String() string {
+	return strings.Join(column, ".")
+}
+
+// companyColumns every path of the CompanyOutput fields, including the objects, and defaultColumns the paths of
+// the values in their declaration order, the attachments are only exported when selected
+var companyColumns, defaultColumns = outputColumns(reflect.TypeOf(models.CompanyOutput{}), "")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func outputColumns(structType reflect.Type, prefix string) (map[string]bool, []Column) {
+	columns := map[string]bool{}
+	defaults := []Column{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		columns[path] = true
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case fieldType.Kind() == reflect.Struct && fieldType != timeType:
+			nestedColumns, nestedDefaults := outputColumns(fieldType, path+".")
+			for nestedPath := range nestedColumns {
+				columns[nestedPath] = true
+			}
+			defaults = append(defaults, nestedDefaults...)
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct:
+			// only selectable as a whole
+		default:
+			defaults = append(defaults, strings.Split(path, "."))
+		}
+	}
+	return columns, defaults
+}
+
+// ParseColumns returns the comma separated columns, or every company value when none is selected
+func ParseColumns(selected string) ([]Column, error) {
+	if strings.TrimSpace(selected) == "" {
+		return defaultColumns, nil
+	}
+	paths := strings.Split(selected, ",")
+	columns := make([]Column, 0, len(paths))
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if !companyColumns[path] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, path)
+		}
+		columns = append(columns, strings.Split(path, "."))
+	}
+	return columns, nil
+}
+
+// ToObject returns the JSON object of a company, the field policy already returns one when it filters the fields
+func ToObject(company any) (map[string]any, error) {
+	object, ok := company.(map[string]any)
+	if ok {
+		return object, nil
+	}
+	content, err := json.Marshal(company)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(content, &object)
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// lookup returns the value of the column, nil when it is not set. A masked object, replaced by a string,
+// is the value of all its columns
+func lookup(object map[string]any, column Column) any {
+	var value any = object
+	for _, key := range column {
+		nested, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		value = nested[key]
+	}
+	return value
+}
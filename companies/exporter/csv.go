@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []Column
+	record  []string
+}
+
+func newCSVWriter(output io.Writer, columns []Column) (Writer, error) {
+	writer := &csvWriter{
+		writer:  csv.NewWriter(output),
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+	for i, column := range columns {
+		writer.record[i] = column.String()
+	}
+	err := writer.writer.Write(writer.record)
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *csvWriter) WriteCompany(company map[string]any) error {
+	for i, column := range writer.columns {
+		writer.record[i] = escapeFormula(cellText(lookup(company, column)))
+	}
+	err := writer.writer.Write(writer.record)
+	if err != nil {
+		return err
+	}
+	// the csv writer buffers the records, flushing hands them to the response at once
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+func (writer *csvWriter) Close() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+// escapeFormula prefixes the text a spreadsheet would run as a formula, ex: =HYPERLINK(...), with a quote
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	columns []Column
+}
+
+// newNDJSONWriter writes one JSON object per line, only with the columns when they are set
+func newNDJSONWriter(output io.Writer, columns []Column) Writer {
+	return &ndjsonWriter{
+		encoder: json.NewEncoder(output),
+		columns: columns,
+	}
+}
+
+func (writer *ndjsonWriter) WriteCompany(company map[string]any) error {
+	if writer.columns == nil {
+		return writer.encoder.Encode(company)
+	}
+	selected := map[string]any{}
+	for _, column := range writer.columns {
+		value := lookup(company, column)
+		if value == nil {
+			continue
+		}
+		setPath(selected, column, value)
+	}
+	return writer.encoder.Encode(selected)
+}
+
+func (writer *ndjsonWriter) Close() error {
+	return nil
+}
+
+// setPath sets the value in the nested objects of the column
+func setPath(object map[string]any, column Column, value any) {
+	for _, key := range column[:len(column)-1] {
+		nested, ok := object[key].(map[string]any)
+		if !ok {
+			nested = map[string]any{}
+			object[key] = nested
+		}
+		object = nested
+	}
+	object[column[len(column)-1]] = value
+}
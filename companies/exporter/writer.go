@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The export formats, selected by the format query parameter or the Accept header
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// The content types of the export formats
+const (
+	MIMECSV    = "text/csv"
+	MIMENDJSON = "application/x-ndjson"
+	MIMEXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// FormatMIME the content type of each format
+var FormatMIME = map[string]string{
+	FormatCSV:    MIMECSV,
+	FormatNDJSON: MIMENDJSON,
+	FormatXLSX:   MIMEXLSX,
+}
+
+// ListSeparator joins the values of a list in a CSV or XLSX cell, the separator the import splits them on
+const ListSeparator = ";"
+
+// Writer writes the companies one at a time, nothing is kept once written
+type Writer interface {
+	WriteCompany(company map[string]any) error
+	// Close writes the end of the export, it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns the writer of the format, the CSV and XLSX header is written at once
+func NewWriter(format string, output io.Writer, columns []Column, allColumns bool) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		if allColumns {
+			return newNDJSONWriter(output, nil), nil
+		}
+		return newNDJSONWriter(output, columns), nil
+	case FormatXLSX:
+		return newXLSXWriter(output, columns)
+	}
+	return newCSVWriter(output, columns)
+}
+
+// cellText the text of a value in a CSV or XLSX cell, the objects are written as JSON
+func cellText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, element := range value {
+			text, ok := element.(string)
+			if !ok {
+				return jsonText(value)
+			}
+			values = append(values, text)
+		}
+		return strings.Join(values, ListSeparator)
+	}
+	return jsonText(value)
+}
+
+func jsonText(value any) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
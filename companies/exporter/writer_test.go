@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCompanies() []map[string]any {
+	return []map[string]any{
+		{
+			"name":                "acme",
+			"number_of_employees": float64(10),
+			"registered":          true,
+			"tags":                []any{"prospect", "vip"},
+			"registered_address":  map[string]any{"city": "Paris", "country": "FR"},
+		},
+		{
+			"name":               "=cmd",
+			"registered":         false,
+			"registered_address": "***",
+		},
+	}
+}
+
+func writeAll(t *testing.T, format string, selected string) string {
+	columns, err := ParseColumns(selected)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	writer, err := NewWriter(format, &output, columns, selected == "")
+	require.NoError(t, err)
+	for _, company := range testCompanies() {
+		require.NoError(t, writer.WriteCompany(company))
+	}
+	require.NoError(t, writer.Close())
+	return output.String()
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("name, registered_address.city,attachments")
+	require.NoError(t, err)
+	assert.Equal(t, []Column{{"name"}, {"registered_address", "city"}, {"attachments"}}, columns)
+
+	_, err = ParseColumns("name,revenue")
+	assert.ErrorIs(t, err, ErrUnknownColumn)
+
+	columns, err = ParseColumns("")
+	require.NoError(t, err)
+	assert.Equal(t, Column{"id"}, columns[0])
+	assert.Contains(t, columns, Column{"registered_address", "city"})
+	assert.Contains(t, columns, Column{"created_at"})
+	assert.NotContains(t, columns, Column{"registered_address"})
+	assert.NotContains(t, columns, Column{"attachments"})
+}
+
+func TestCSVWriter(t *testing.T) {
+	output := writeAll(t, FormatCSV, "name,number_of_employees,registered,tags,registered_address.city")
+
+	assert.Equal(t, "name,number_of_employees,registered,tags,registered_address.city\n"+
+		"acme,10,true,prospect;vip,Paris\n"+
+		"'=cmd,,false,,***\n", output)
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	output := writeAll(t, FormatNDJSON, "name,registered_address.city")
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"name":"acme","registered_address":{"city":"Paris"}}`, lines[0])
+	assert.JSONEq(t, `{"name":"=cmd","registered_address":{"city":"***"}}`, lines[1])
+
+	output = writeAll(t, FormatNDJSON, "")
+
+	lines = strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"name":"acme","number_of_employees":10,"registered":true,"tags":["prospect","vip"],"registered_address":{"city":"Paris","country":"FR"}}`, lines[0])
+}
+
+func TestXLSXWriter(t *testing.T) {
+	output := writeAll(t, FormatXLSX, "name,number_of_employees,registered,tags")
+
+	reader, err := zip.NewReader(strings.NewReader(output), int64(len(output)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range reader.File {
+		content, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		files[file.Name] = string(data)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files, "xl/workbook.xml")
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>10</v></c><c r="C2" t="b"><v>1</v></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">prospect;vip</t>`)
+	// a spreadsheet never runs an inline string as a formula
+	assert.Contains(t, sheet, `<t xml:space="preserve">=cmd</t>`)
+	assert.True(t, strings.HasSuffix(sheet, `</sheetData></worksheet>`))
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+}
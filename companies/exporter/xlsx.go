@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// the parts of a workbook with a single sheet, the sheet is written last so it can be streamed
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{
+		name: "[Content_Types].xml",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`,
+	},
+	{
+		name: "_rels/.rels",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`,
+	},
+	{
+		name: "xl/workbook.xml",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="companies" sheetId="1" r:id="rId1"/></sheets>` +
+			`</workbook>`,
+	},
+	{
+		name: "xl/_rels/workbook.xml.rels",
+		content: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`,
+	},
+}
+
+// xlsxWriter writes the companies as the rows of a sheet, the header is the first row. The strings are inline
+// so nothing has to be kept until the end of the export
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	columns []Column
+	row     int
+}
+
+func newXLSXWriter(output io.Writer, columns []Column) (Writer, error) {
+	writer := &xlsxWriter{
+		zip:     zip.NewWriter(output),
+		columns: columns,
+	}
+	for _, part := range xlsxParts {
+		partWriter, err := writer.zip.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.WriteString(partWriter, part.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sheetWriter, err := writer.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	writer.sheet = bufio.NewWriter(sheetWriter)
+	_, err = writer.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column.String()
+	}
+	err = writer.writeRow(header)
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *xlsxWriter) WriteCompany(company map[string]any) error {
+	values := make([]any, len(writer.columns))
+	for i, column := range writer.columns {
+		values[i] = lookup(company, column)
+	}
+	err := writer.writeRow(values)
+	if err != nil {
+		return err
+	}
+	return writer.sheet.Flush()
+}
+
+func (writer *xlsxWriter) writeRow(values []any) error {
+	writer.row++
+	row := strconv.Itoa(writer.row)
+	_, err := writer.sheet.WriteString(`<row r="` + row + `">`)
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		reference := columnName(i) + row
+		switch value := value.(type) {
+		case nil:
+			continue
+		case float64:
+			_, err = writer.sheet.WriteString(`<c r="` + reference + `"><v>` + strconv.FormatFloat(value, 'f', -1, 64) + `</v></c>`)
+		case bool:
+			boolean := "0"
+			if value {
+				boolean = "1"
+			}
+			_, err = writer.sheet.WriteString(`<c r="` + reference + `" t="b"><v>` + boolean + `</v></c>`)
+		default:
+			_, err = writer.sheet.WriteString(`<c r="` + reference + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err != nil {
+				return err
+			}
+			// EscapeText also replaces the characters XML does not allow
+			err = xml.EscapeText(writer.sheet, []byte(cellText(value)))
+			if err != nil {
+				return err
+			}
+			_, err = writer.sheet.WriteString(`</t></is></c>`)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = writer.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (writer *xlsxWriter) Close() error {
+	_, err := writer.sheet.WriteString(`</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+	err = writer.sheet.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.zip.Close()
+}
+
+// columnName the spreadsheet name of a zero based column index, ex: 0 is A and 26 is AA
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,117 @@
+package fieldpolicy
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sort"
+)
+
+// Rule the scopes that can read and write a field, an empty list means every user can
+type Rule struct {
+	Read  []string `json:"read"`
+	Write []string `json:"write"`
+	// Mask replaces the value of the field for the users that can not read it, the field is dropped when nil
+	Mask *string `json:"mask"`
+}
+
+// Policy the read and write rules of the restricted fields, keyed by the JSON field name.
+// The fields without a rule can be read and written by every user
+type Policy struct {
+	Fields map[string]Rule `json:"fields"`
+}
+
+// Load reads a JSON policy file, ex:
+//
+//	{"fields": {"internal_notes": {"read": ["companies:internal"], "write": ["companies:internal"], "mask": "***"}}}
+func Load(path string) (Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	var policy Policy
+	err = json.Unmarshal(content, &policy)
+	if err != nil {
+		return Policy{}, err
+	}
+	return policy, nil
+}
+
+// CanRead reports whether a user with the scopes can read the field
+func (policy Policy) CanRead(field string, scopes []string) bool {
+	rule, ok := policy.Fields[field]
+	return !ok || hasAnyScope(rule.Read, scopes)
+}
+
+// CanWrite reports whether a user with the scopes can write the field
+func (policy Policy) CanWrite(field string, scopes []string) bool {
+	rule, ok := policy.Fields[field]
+	return !ok || hasAnyScope(rule.Write, scopes)
+}
+
+// Unwritable returns the sorted fields that a user with the scopes can not write
+func (policy Policy) Unwritable(fields []string, scopes []string) []string {
+	unwritable := []string{}
+	for _, field := range fields {
+		if !policy.CanWrite(field, scopes) {
+			unwritable = append(unwritable, field)
+		}
+	}
+	sort.Strings(unwritable)
+	return unwritable
+}
+
+// Filter returns the JSON representation of a value, or of each element of a slice, without the fields
+// a user with the scopes can not read, or with their masked values
+func (policy Policy) Filter(value any, scopes []string) (any, error) {
+	if len(policy.Fields) == 0 {
+		return value, nil
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	err = json.Unmarshal(content, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	switch decoded := decoded.(type) {
+	case map[string]any:
+		policy.filterObject(decoded, scopes)
+	case []any:
+		for _, element := range decoded {
+			object, ok := element.(map[string]any)
+			if ok {
+				policy.filterObject(object, scopes)
+			}
+		}
+	}
+	return decoded, nil
+}
+
+func (policy Policy) filterObject(object map[string]any, scopes []string) {
+	for field, rule := range policy.Fields {
+		_, ok := object[field]
+		if !ok || hasAnyScope(rule.Read, scopes) {
+			continue
+		}
+		if rule.Mask != nil {
+			object[field] = *rule.Mask
+		} else {
+			delete(object, field)
+		}
+	}
+}
+
+func hasAnyScope(allowed []string, scopes []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		if slices.Contains(allowed, scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package fieldpolicy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy() Policy {
+	mask := "***"
+	return Policy{
+		Fields: map[string]Rule{
+			"number_of_employees": {Read: []string{"companies:headcount"}},
+			"internal_notes":      {Read: []string{"companies:internal"}, Write: []string{"companies:internal"}, Mask: &mask},
+			"registered":          {Write: []string{"finance"}},
+		},
+	}
+}
+
+type company struct {
+	Name              string `json:"name"`
+	NumberOfEmployees int    `json:"number_of_employees"`
+	InternalNotes     string `json:"internal_notes,omitempty"`
+	Registered        bool   `json:"registered"`
+}
+
+func TestFilter(t *testing.T) {
+	value := company{Name: "acme", NumberOfEmployees: 10, InternalNotes: "notes", Registered: true}
+
+	testCases := []struct {
+		name     string
+		value    any
+		scopes   []string
+		expected string
+	}{
+		{
+			name:     "unreadable fields are dropped or masked",
+			value:    value,
+			scopes:   nil,
+			expected: `{"name": "acme", "internal_notes": "***", "registered": true}`,
+		},
+		{
+			name:     "readable fields are kept",
+			value:    value,
+			scopes:   []string{"companies:headcount", "companies:internal"},
+			expected: `{"name": "acme", "number_of_employees": 10, "internal_notes": "notes", "registered": true}`,
+		},
+		{
+			name:     "absent fields are not masked",
+			value:    []company{{Name: "acme"}},
+			scopes:   []string{"companies:headcount"},
+			expected: `[{"name": "acme", "number_of_employees": 0, "registered": false}]`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filtered, err := testPolicy().Filter(testCase.value, testCase.scopes)
+			require.NoError(t, err)
+			content, err := json.Marshal(filtered)
+			require.NoError(t, err)
+			assert.JSONEq(t, testCase.expected, string(content))
+		})
+	}
+}
+
+func TestUnwritable(t *testing.T) {
+	policy := testPolicy()
+
+	assert.Equal(t, []string{"internal_notes", "registered"}, policy.Unwritable([]string{"registered", "name", "internal_notes"}, nil))
+	assert.Equal(t, []string{"internal_notes"}, policy.Unwritable([]string{"registered", "internal_notes"}, []string{"finance"}))
+	assert.Empty(t, Policy{}.Unwritable([]string{"registered"}, nil))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	err := os.WriteFile(path, []byte(`{"fields": {"registered": {"write": ["finance"]}}}`), 0o600)
+	require.NoError(t, err)
+
+	policy, err := Load(path)
+	require.NoError(t, err)
+	assert.False(t, policy.CanWrite("registered", []string{"sales"}))
+	assert.True(t, policy.CanWrite("registered", []string{"finance"}))
+	assert.True(t, policy.CanRead("registered", nil))
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// multipartOverhead the room left for the multipart boundaries and form fields on top of the file size
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler interface {
+	UploadAttachment(c *gin.Context)
+	ListAttachments(c *gin.Context)
+	GetAttachment(c *gin.Context)
+	DeleteAttachment(c *gin.Context)
+}
+
+type attachmentHandler struct {
+	service service.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService service.AttachmentService) AttachmentHandler {
+	return &attachmentHandler{
+		service: attachmentService,
+	}
+}
+
+func (handler *attachmentHandler) UploadAttachment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	// the largest attachment kind bounds the request body before the kind is known
+	maxSize := models.AttachmentRules[models.AttachmentKindDocument].MaxSize
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	var attachmentInput models.AttachmentInput
+	err = c.ShouldBind(&attachmentInput)
+	if err != nil {
+		handler.abortUpload(c, companyId, err)
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		handler.abortUpload(c, companyId, err)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		handler.abortUpload(c, companyId, err)
+		return
+	}
+	defer file.Close()
+
+	// read one byte more than allowed to detect files that are too large
+	rule := models.AttachmentRules[attachmentInput.Kind]
+	content, err := io.ReadAll(io.LimitReader(file, rule.MaxSize+1))
+	if err != nil {
+		handler.abortUpload(c, companyId, err)
+		return
+	}
+
+	fileName := filepath.Base(fileHeader.Filename)
+	attachment, created, err := handler.service.UploadAttachment(ctx, companyId, attachmentInput.Kind, fileName, content, principal(c))
+	if err != nil {
+		handler.abortUpload(c, companyId, err)
+		return
+	}
+
+	statusCode := http.StatusCreated
+	if !created {
+		statusCode = http.StatusOK
+	}
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, statusCode).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Str(consts.LogKeyAttachmentId, attachment.ID.String()).
+		Msg("upload attachment executed successfully")
+	c.JSON(statusCode, attachment)
+}
+
+// abortUpload maps the upload errors to their status and error codes
+func (handler *attachmentHandler) abortUpload(c *gin.Context, companyId uuid.UUID, err error) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeUploadAttachment,
+		Errors:    fieldErrors(err),
+	}
+	statusCode := http.StatusInternalServerError
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errOutput.Errors != nil, errors.Is(err, http.ErrMissingFile):
+		errOutput.ErrorCode = ErrCodeInvalidInput
+		statusCode = http.StatusBadRequest
+	case errors.As(err, &maxBytesError), errors.Is(err, service.ErrAttachmentTooLarge):
+		errOutput.ErrorCode = ErrCodeAttachmentTooLarge
+		statusCode = http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedContentType):
+		errOutput.ErrorCode = ErrCodeUnsupportedType
+		statusCode = http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrTooManyAttachments):
+		errOutput.ErrorCode = ErrCodeTooManyAttachments
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrNotOwner):
+		errOutput.ErrorCode = ErrCodeNotOwner
+		statusCode = http.StatusForbidden
+	case errors.Is(err, mongo.ErrNoDocuments):
+		statusCode = http.StatusNotFound
+	}
+	err = errors.Join(ErrUploadAttachment, err)
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, statusCode).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Msg("error while trying to upload attachment")
+	c.JSON(statusCode, errOutput)
+}
+
+func (handler *attachmentHandler) ListAttachments(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	attachments, err := handler.service.ListAttachments(ctx, companyId)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeListAttachments,
+		}
+		err = errors.Join(ErrListAttachments, err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to list attachments")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Msg("list attachments executed successfully")
+	c.JSON(http.StatusOK, attachments)
+}
+
+func (handler *attachmentHandler) GetAttachment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyId, attachmentId, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	attachment, content, err := handler.service.GetAttachment(ctx, companyId, attachmentId)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeGetAttachment,
+		}
+		err = errors.Join(ErrGetAttachment, err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, repo.ErrAttachmentNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Str(consts.LogKeyAttachmentId, attachmentId.String()).
+			Msg("error while trying to get attachment")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+	defer content.Close()
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Str(consts.LogKeyAttachmentId, attachmentId.String()).
+		Msg("get attachment executed successfully")
+	headers := map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"ETag":                   fmt.Sprintf("%q", attachment.SHA256),
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, headers)
+}
+
+func (handler *attachmentHandler) DeleteAttachment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyId, attachmentId, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	err := handler.service.DeleteAttachment(ctx, companyId, attachmentId, principal(c))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeDeleteAttachment,
+		}
+		err = errors.Join(ErrDeleteAttachment, err)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrNotOwner):
+			errOutput.ErrorCode = ErrCodeNotOwner
+			statusCode = http.StatusForbidden
+		case errors.Is(err, repo.ErrAttachmentNotFound), errors.Is(err, mongo.ErrNoDocuments):
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Str(consts.LogKeyAttachmentId, attachmentId.String()).
+			Msg("error while trying to delete attachment")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusNoContent).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Str(consts.LogKeyAttachmentId, attachmentId.String()).
+		Msg("delete attachment executed successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// parseAttachmentParams parses the id and attachmentId params, the error response is written when ok is false
+func parseAttachmentParams(c *gin.Context) (companyId uuid.UUID, attachmentId uuid.UUID, ok bool) {
+	for _, param := range []struct {
+		name  string
+		value *uuid.UUID
+	}{
+		{name: "id", value: &companyId},
+		{name: "attachmentId", value: &attachmentId},
+	} {
+		paramValue := c.Param(param.name)
+		parsed, err := uuid.Parse(paramValue)
+		if err != nil {
+			errOutput := models.ErrorOutput{
+				ErrorCode: ErrCodeInvalidId,
+			}
+			err = errors.Join(ErrInvalidId, err)
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+				Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+				Str(param.name, paramValue).
+				Msg("error while trying to parse the id params")
+			c.JSON(http.StatusBadRequest, errOutput)
+			return uuid.Nil, uuid.Nil, false
+		}
+		*param.value = parsed
+	}
+	return companyId, attachmentId, true
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/mocks"
+	"companies/models"
+	"companies/service"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestUploadAttachment(t *testing.T) {
+	companyId := uuid.New()
+	attachment := models.Attachment{
+		ID:          uuid.New(),
+		Kind:        models.AttachmentKindLogo,
+		FileName:    "logo.png",
+		ContentType: "image/png",
+		Size:        4,
+		SHA256:      "aa",
+		UploadedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	attachmentJSON := fmt.Sprintf(`{
+		"id": "%s",
+		"kind": "logo",
+		"file_name": "logo.png",
+		"content_type": "image/png",
+		"size": 4,
+		"sha256": "aa",
+		"uploaded_at": "2024-01-02T03:04:05Z"
+	}`, attachment.ID)
+
+	testCases := []struct {
+		name                 string
+		kind                 string
+		withFile             bool
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.AttachmentService)
+	}{
+		{
+			name:                 "success test case",
+			kind:                 models.AttachmentKindLogo,
+			withFile:             true,
+			expectedStatusCode:   http.StatusCreated,
+			expectedResponseBody: attachmentJSON,
+			stubMocks: func(s *mocks.AttachmentService) {
+				s.On("UploadAttachment", mock.Anything, companyId, models.AttachmentKindLogo, "logo.png", []byte("file"), mock.AnythingOfType("models.Principal")).
+					Return(attachment, true, nil)
+			},
+		},
+		{
+			name:                 "the same content was already uploaded",
+			kind:                 models.AttachmentKindLogo,
+			withFile:             true,
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: attachmentJSON,
+			stubMocks: func(s *mocks.AttachmentService) {
+				s.On("UploadAttachment", mock.Anything, companyId, models.AttachmentKindLogo, "logo.png", []byte("file"), mock.AnythingOfType("models.Principal")).
+					Return(attachment, false, nil)
+			},
+		},
+		{
+			name:               "invalid kind",
+			kind:               "avatar",
+			withFile:           true,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "kind", "rule": "oneof"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.AttachmentService) {},
+		},
+		{
+			name:               "missing file",
+			kind:               models.AttachmentKindLogo,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.AttachmentService) {},
+		},
+		{
+			name:               "unsupported content type",
+			kind:               models.AttachmentKindLogo,
+			withFile:           true,
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeUnsupportedType),
+			stubMocks: func(s *mocks.AttachmentService) {
+				s.On("UploadAttachment", mock.Anything, companyId, models.AttachmentKindLogo, "logo.png", []byte("file"), mock.AnythingOfType("models.Principal")).
+					Return(models.Attachment{}, false, service.ErrUnsupportedContentType)
+			},
+		},
+		{
+			name:               "too many attachments",
+			kind:               models.AttachmentKindLogo,
+			withFile:           true,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeTooManyAttachments),
+			stubMocks: func(s *mocks.AttachmentService) {
+				s.On("UploadAttachment", mock.Anything, companyId, models.AttachmentKindLogo, "logo.png", []byte("file"), mock.AnythingOfType("models.Principal")).
+					Return(models.Attachment{}, false, service.ErrTooManyAttachments)
+			},
+		},
+		{
+			name:               "company not found",
+			kind:               models.AttachmentKindLogo,
+			withFile:           true,
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeUploadAttachment),
+			stubMocks: func(s *mocks.AttachmentService) {
+				s.On("UploadAttachment", mock.Anything, companyId, models.AttachmentKindLogo, "logo.png", []byte("file"), mock.AnythingOfType("models.Principal")).
+					Return(models.Attachment{}, false, mongo.ErrNoDocuments)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewAttachmentService(t)
+
+			handler := NewAttachmentHandler(s)
+
+			testCase.stubMocks(s)
+
+			gin.SetMode(gin.TestMode)
+
+			router := gin.Default()
+			router.POST("/v1/company/:id/attachments", handler.UploadAttachment)
+
+			buf := &bytes.Buffer{}
+			writer := multipart.NewWriter(buf)
+			_ = writer.WriteField("kind", testCase.kind)
+			if testCase.withFile {
+				part, _ := writer.CreateFormFile("file", "logo.png")
+				_, _ = part.Write([]byte("file"))
+			}
+			_ = writer.Close()
+
+			url := fmt.Sprintf("/v1/company/%s/attachments", companyId)
+			req, _ := http.NewRequest(http.MethodPost, url, buf)
+			req.Header.Set("content-type", writer.FormDataContentType())
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestGetAttachment(t *testing.T) {
+	companyId := uuid.New()
+	attachment := models.Attachment{
+		ID:          uuid.New(),
+		Kind:        models.AttachmentKindDocument,
+		FileName:    "report 2024.pdf",
+		ContentType: "application/pdf",
+		Size:        8,
+		SHA256:      "aa",
+	}
+
+	s := mocks.NewAttachmentService(t)
+	s.On("GetAttachment", mock.Anything, companyId, attachment.ID).
+		Return(attachment, io.NopCloser(strings.NewReader("%PDF-1.7")), nil)
+
+	handler := NewAttachmentHandler(s)
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/v1/company/:id/attachments/:attachmentId", handler.GetAttachment)
+
+	url := fmt.Sprintf("/v1/company/%s/attachments/%s", companyId, attachment.ID)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "%PDF-1.7", rr.Body.String())
+	assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="report 2024.pdf"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/service"
+	"companies/xss"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// BatchCompanies runs the create, patch and delete operations of the body, either all or nothing in a transaction
+// or each on its own. Every operation gets the status and error codes of the single company endpoint
+func (handler *companyHandler) BatchCompanies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// gin routes can not have a literal colon, the method param is the rest of the companies path segment
+	if c.Param("method") != ":batch" {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	var batchQuery models.BatchQuery
+	err := c.ShouldBindQuery(&batchQuery)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    []models.FieldError{{Field: "force", Rule: "boolean"}},
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	var batchInput models.BatchInput
+	err = c.ShouldBindJSON(&batchInput)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind JSON input")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	user := principal(c)
+	allOrNothing := batchInput.Mode == models.BatchModeAllOrNothing
+	results := make([]models.BatchResult, len(batchInput.Operations))
+	operations := make([]models.BatchOperation, 0, len(batchInput.Operations))
+	// resultIndexes the index in results of each valid operation
+	resultIndexes := make([]int, 0, len(batchInput.Operations))
+	for i, operationInput := range batchInput.Operations {
+		operation, result, err := handler.validateBatchOperation(ctx, operationInput, batchQuery.Force, user)
+		if err != nil {
+			logBatchOperationError(err, i, result)
+			results[i] = result
+			continue
+		}
+		operations = append(operations, operation)
+		resultIndexes = append(resultIndexes, i)
+	}
+
+	if allOrNothing && len(operations) < len(results) {
+		// nothing is applied when an operation is invalid
+		for _, i := range resultIndexes {
+			results[i] = batchAbortedResult()
+		}
+		handler.writeBatch(c, allOrNothing, results)
+		return
+	}
+
+	operationResults, err := handler.service.BatchCompanies(ctx, operations, allOrNothing, user)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeBatchCompanies,
+		}
+		err = errors.Join(ErrBatchCompanies, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while trying to run the batch")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	for j, operationResult := range operationResults {
+		i := resultIndexes[j]
+		result, err := handler.batchResult(operations[j].Operation, operationResult, user.Scopes)
+		if err != nil {
+			logBatchOperationError(err, i, result)
+		}
+		results[i] = result
+	}
+	handler.writeBatch(c, allOrNothing, results)
+}
+
+// validateBatchOperation runs the binding, XSS, field policy and possible duplicates checks of the single company
+// endpoints on the operation data, the result has the error output of the endpoint when the operation is invalid
+func (handler *companyHandler) validateBatchOperation(ctx context.Context, input models.BatchOperationInput, force bool, user models.Principal) (models.BatchOperation, models.BatchResult, error) {
+	operation := models.BatchOperation{
+		Operation: input.Operation,
+	}
+	if input.ID != nil {
+		operation.CompanyID = *input.ID
+	}
+
+	var err error
+	var freeTextFields []string
+	// writtenFields the fields checked against the write rules of the field policy
+	var writtenFields []string
+	// name the name checked for possible duplicates, nil when the operation does not set it
+	var name *string
+	var excludeId *uuid.UUID
+	switch input.Operation {
+	case models.BatchOperationCreate:
+		err = binding.JSON.BindBody(input.Data, &operation.CompanyData)
+		freeTextFields = operation.CompanyData.FreeTextFields()
+		writtenFields = operation.CompanyData.SetFields()
+		name = &operation.CompanyData.Name
+	case models.BatchOperationPatch:
+		err = binding.JSON.BindBody(input.Data, &operation.PatchData)
+		freeTextFields = operation.PatchData.FreeTextFields()
+		writtenFields = operation.PatchData.ChangedFields()
+		name = operation.PatchData.Name
+		excludeId = &operation.CompanyID
+	}
+	if err != nil {
+		result := models.BatchResult{
+			StatusCode: http.StatusBadRequest,
+			ErrorCode:  ErrCodeInvalidInput,
+			Errors:     fieldErrors(err),
+		}
+		return operation, result, errors.Join(ErrInvalidInput, err)
+	}
+
+	err = xss.CheckForXSS(freeTextFields...)
+	if err != nil {
+		result := models.BatchResult{
+			StatusCode: http.StatusBadRequest,
+			ErrorCode:  ErrCodeInvalidInput,
+		}
+		return operation, result, errors.Join(ErrInvalidInput, err)
+	}
+
+	unwritableFields := handler.fieldPolicy.Unwritable(writtenFields, user.Scopes)
+	if len(unwritableFields) > 0 {
+		errOutput := fieldsNotWritableError(unwritableFields)
+		result := models.BatchResult{
+			StatusCode: http.StatusForbidden,
+			ErrorCode:  errOutput.ErrorCode,
+			Errors:     errOutput.Errors,
+		}
+		return operation, result, ErrFieldsNotWritable
+	}
+
+	if name != nil && !force {
+		candidates, err := handler.findDuplicates(ctx, *name, excludeId, user.Scopes)
+		if err != nil {
+			result := models.BatchResult{
+				StatusCode: http.StatusInternalServerError,
+				ErrorCode:  ErrCodeFindDuplicates,
+			}
+			return operation, result, errors.Join(ErrFindDuplicates, err)
+		}
+		if len(candidates) > 0 {
+			result := models.BatchResult{
+				StatusCode: http.StatusConflict,
+				ErrorCode:  ErrCodePossibleDuplicates,
+				Candidates: candidates,
+			}
+			return operation, result, ErrPossibleDuplicates
+		}
+	}
+	return operation, models.BatchResult{}, nil
+}
+
+// batchResult maps the result of an operation to the response of the single company endpoint
+func (handler *companyHandler) batchResult(operation string, operationResult models.BatchOperationResult, scopes []string) (models.BatchResult, error) {
+	if operationResult.Err != nil {
+		if errors.Is(operationResult.Err, service.ErrBatchAborted) {
+			return batchAbortedResult(), nil
+		}
+		var statusCode int
+		var errOutput models.ErrorOutput
+		switch operation {
+		case models.BatchOperationCreate:
+			statusCode, errOutput = createCompanyError(operationResult.Err)
+		case models.BatchOperationPatch:
+			statusCode, errOutput = patchCompanyError(operationResult.Err)
+		default:
+			statusCode, errOutput = deleteCompanyError(operationResult.Err)
+		}
+		result := models.BatchResult{
+			StatusCode: statusCode,
+			ErrorCode:  errOutput.ErrorCode,
+			Errors:     errOutput.Errors,
+		}
+		return result, operationResult.Err
+	}
+
+	switch {
+	case operationResult.ChangeRequest != nil:
+		changeRequest, err := handler.filterChangeRequest(*operationResult.ChangeRequest, scopes)
+		if err != nil {
+			return models.BatchResult{
+				StatusCode: http.StatusAccepted,
+				ErrorCode:  ErrCodeFilterFields,
+			}, errors.Join(ErrFilterFields, err)
+		}
+		return models.BatchResult{
+			StatusCode:    http.StatusAccepted,
+			ChangeRequest: changeRequest,
+		}, nil
+	case operationResult.Company != nil:
+		statusCode := http.StatusAccepted
+		if operation == models.BatchOperationCreate {
+			statusCode = http.StatusCreated
+		}
+		company, err := handler.fieldPolicy.Filter(*operationResult.Company, scopes)
+		if err != nil {
+			// the operation was applied, only its company can not be returned
+			return models.BatchResult{
+				StatusCode: statusCode,
+				ErrorCode:  ErrCodeFilterFields,
+			}, errors.Join(ErrFilterFields, err)
+		}
+		return models.BatchResult{
+			StatusCode: statusCode,
+			Company:    company,
+		}, nil
+	}
+	return models.BatchResult{
+		StatusCode: http.StatusNoContent,
+	}, nil
+}
+
+func batchAbortedResult() models.BatchResult {
+	return models.BatchResult{
+		StatusCode: http.StatusFailedDependency,
+		ErrorCode:  ErrCodeBatchAborted,
+	}
+}
+
+// writeBatch the status is 200, except for an all or nothing batch that failed which gets the status of the failed operation
+func (handler *companyHandler) writeBatch(c *gin.Context, allOrNothing bool, results []models.BatchResult) {
+	statusCode := http.StatusOK
+	if allOrNothing {
+		for _, result := range results {
+			if result.ErrorCode != 0 && result.ErrorCode != ErrCodeBatchAborted {
+				statusCode = result.StatusCode
+				break
+			}
+		}
+	}
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, statusCode).
+		Msg("batch companies executed")
+	c.JSON(statusCode, models.BatchOutput{Results: results})
+}
+
+func logBatchOperationError(err error, index int, result models.BatchResult) {
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, result.ErrorCode).
+		Int(consts.LogKeyStatusCode, result.StatusCode).
+		Int(consts.LogKeyBatchIndex, index).
+		Msg("error in batch operation")
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCompanies(t *testing.T) {
+	companyId := uuid.New()
+
+	type expectedResult struct {
+		StatusCode int `json:"status_code"`
+		ErrorCode  int `json:"error_code"`
+	}
+
+	createAndDelete := func(mode string, name string) string {
+		return fmt.Sprintf(`{
+			"mode": %q,
+			"operations": [
+				{"operation": "create", "data": {"name": %q, "number_of_employees": 10, "registered": true, "type": "Corporations"}},
+				{"operation": "delete", "id": %q}
+			]
+		}`, mode, name, companyId)
+	}
+
+	testCases := []struct {
+		name               string
+		path               string
+		requestBody        string
+		expectedStatusCode int
+		expectedResults    []expectedResult
+		stubMocks          func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "best effort",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeBestEffort, "company-name"),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusCreated},
+				{StatusCode: http.StatusNotFound, ErrorCode: ErrCodeDeleteCompany},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), false, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{
+						{Company: &models.CompanyOutput{ID: uuid.New(), Name: "company-name"}},
+						{Err: repo.ErrDocumentNotFound},
+					}, nil)
+			},
+		},
+		{
+			name:               "best effort with a name another company of the tenant has",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeBestEffort, "company-name"),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusConflict, ErrorCode: ErrCodeNameTaken},
+				{StatusCode: http.StatusNoContent},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), false, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{
+						{Err: repo.ErrNameTaken},
+						{},
+					}, nil)
+			},
+		},
+		{
+			name:               "all or nothing committed",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeAllOrNothing, "company-name"),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusCreated},
+				{StatusCode: http.StatusNoContent},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), true, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{
+						{Company: &models.CompanyOutput{ID: uuid.New(), Name: "company-name"}},
+						{},
+					}, nil)
+			},
+		},
+		{
+			name:               "all or nothing aborted by the service",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeAllOrNothing, "company-name"),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusFailedDependency, ErrorCode: ErrCodeBatchAborted},
+				{StatusCode: http.StatusNotFound, ErrorCode: ErrCodeDeleteCompany},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), true, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{
+						{Err: service.ErrBatchAborted},
+						{Err: repo.ErrDocumentNotFound},
+					}, nil)
+			},
+		},
+		{
+			name:               "all or nothing with an invalid operation",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeAllOrNothing, "<script>alert('secret')</script>"),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusBadRequest, ErrorCode: ErrCodeInvalidInput},
+				{StatusCode: http.StatusFailedDependency, ErrorCode: ErrCodeBatchAborted},
+			},
+			stubMocks: func(s *mocks.CompanyService) {},
+		},
+		{
+			name: "best effort skips the invalid operation",
+			path: "/v1/companies:batch",
+			requestBody: fmt.Sprintf(`{
+				"mode": "best_effort",
+				"operations": [
+					{"operation": "create", "data": {"name": "company-name"}},
+					{"operation": "patch", "id": %q, "data": {"registered": false}}
+				]
+			}`, companyId),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusBadRequest, ErrorCode: ErrCodeInvalidInput},
+				{StatusCode: http.StatusAccepted},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("BatchCompanies", mock.Anything, mock.MatchedBy(func(operations []models.BatchOperation) bool {
+					return len(operations) == 1 && operations[0].CompanyID == companyId
+				}), false, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{
+						{ChangeRequest: &models.ChangeRequest{ID: uuid.New(), CompanyID: companyId}},
+					}, nil)
+			},
+		},
+		{
+			name:               "all or nothing with possible duplicates",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeAllOrNothing, "company-name"),
+			expectedStatusCode: http.StatusConflict,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusConflict, ErrorCode: ErrCodePossibleDuplicates},
+				{StatusCode: http.StatusFailedDependency, ErrorCode: ErrCodeBatchAborted},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{{ID: uuid.New(), Name: "Company Name Ltd", Score: 1, Exact: true}}, nil)
+			},
+		},
+		{
+			name: "rename with possible duplicates",
+			path: "/v1/companies:batch",
+			requestBody: fmt.Sprintf(`{
+				"mode": "best_effort",
+				"operations": [{"operation": "patch", "id": %q, "data": {"name": "company-name"}}]
+			}`, companyId),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusConflict, ErrorCode: ErrCodePossibleDuplicates},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", &companyId).
+					Return([]models.DuplicateCandidate{{ID: uuid.New(), Name: "Company Name Ltd", Score: 1, Exact: true}}, nil)
+				s.On("BatchCompanies", mock.Anything, []models.BatchOperation{}, false, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{}, nil)
+			},
+		},
+		{
+			name:               "force skips the possible duplicates",
+			path:               "/v1/companies:batch?force=true",
+			requestBody:        createAndDelete(models.BatchModeAllOrNothing, "company-name"),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusCreated},
+				{StatusCode: http.StatusNoContent},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), true, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{
+						{Company: &models.CompanyOutput{ID: uuid.New(), Name: "company-name"}},
+						{},
+					}, nil)
+			},
+		},
+		{
+			name:               "finding the duplicates failed",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeBestEffort, "company-name"),
+			expectedStatusCode: http.StatusOK,
+			expectedResults: []expectedResult{
+				{StatusCode: http.StatusInternalServerError, ErrorCode: ErrCodeFindDuplicates},
+				{StatusCode: http.StatusNoContent},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return(nil, assert.AnError)
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), false, mock.AnythingOfType("models.Principal")).
+					Return([]models.BatchOperationResult{{}}, nil)
+			},
+		},
+		{
+			name:               "invalid force",
+			path:               "/v1/companies:batch?force=maybe",
+			requestBody:        createAndDelete(models.BatchModeBestEffort, "company-name"),
+			expectedStatusCode: http.StatusBadRequest,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "delete without id",
+			path:               "/v1/companies:batch",
+			requestBody:        `{"mode": "best_effort", "operations": [{"operation": "delete"}]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "service returned an error",
+			path:               "/v1/companies:batch",
+			requestBody:        createAndDelete(models.BatchModeAllOrNothing, "company-name"),
+			expectedStatusCode: http.StatusInternalServerError,
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("BatchCompanies", mock.Anything, mock.AnythingOfType("[]models.BatchOperation"), true, mock.AnythingOfType("models.Principal")).
+					Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:               "other method",
+			path:               "/v1/companies:merge",
+			requestBody:        createAndDelete(models.BatchModeBestEffort, "company-name"),
+			expectedStatusCode: http.StatusNotFound,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.POST("/v1/companies:method", handler.BatchCompanies)
+
+			buf := bytes.NewBuffer([]byte(testCase.requestBody))
+
+			req, _ := http.NewRequest(http.MethodPost, testCase.path, buf)
+			req.Header.Set("content-type", "application/json")
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedResults != nil {
+				var output struct {
+					Results []expectedResult `json:"results"`
+				}
+				err := json.Unmarshal(rr.Body.Bytes(), &output)
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expectedResults, output.Results)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"companies/xss"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ListChangeRequests lists the pending change requests, the company_id query parameter filters them by company
+func (handler *companyHandler) ListChangeRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var companyId *uuid.UUID
+	companyIdParam := c.Query("company_id")
+	if companyIdParam != "" {
+		parsed, err := uuid.Parse(companyIdParam)
+		if err != nil {
+			errOutput := models.ErrorOutput{
+				ErrorCode: ErrCodeInvalidId,
+			}
+			err = errors.Join(ErrInvalidId, err)
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+				Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+				Str(consts.LogKeyCompanyId, companyIdParam).
+				Msg("error while trying to parse companyId")
+			c.JSON(http.StatusBadRequest, errOutput)
+			return
+		}
+		companyId = &parsed
+	}
+
+	changeRequests, err := handler.service.ListChangeRequests(ctx, companyId)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeListChangeRequests,
+		}
+		err = errors.Join(ErrListChangeRequests, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while trying to list change requests")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("list change requests executed successfully")
+	outputs := make([]any, len(changeRequests))
+	for i, changeRequest := range changeRequests {
+		outputs[i], err = handler.filterChangeRequest(changeRequest, principal(c).Scopes)
+		if err != nil {
+			abortFilterFields(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, outputs)
+}
+
+func (handler *companyHandler) GetChangeRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	changeRequestId, ok := parseChangeRequestId(c)
+	if !ok {
+		return
+	}
+
+	changeRequest, err := handler.service.GetChangeRequest(ctx, changeRequestId)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeGetChangeRequest,
+		}
+		err = errors.Join(ErrGetChangeRequest, err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+			Msg("error while trying to get change request")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+		Msg("get change request executed successfully")
+	handler.writeChangeRequest(c, http.StatusOK, changeRequest)
+}
+
+// ApproveChangeRequest applies the changes and returns the updated company
+func (handler *companyHandler) ApproveChangeRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	changeRequestId, ok := parseChangeRequestId(c)
+	if !ok {
+		return
+	}
+
+	companyOutput, err := handler.service.ApproveChangeRequest(ctx, changeRequestId, principal(c))
+	if err != nil {
+		abortDecideChangeRequest(c, changeRequestId, err)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+		Str(consts.LogKeyCompanyId, companyOutput.ID.String()).
+		Msg("approve change request executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+func (handler *companyHandler) RejectChangeRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	changeRequestId, ok := parseChangeRequestId(c)
+	if !ok {
+		return
+	}
+
+	// the reason is optional, an empty body is accepted
+	var rejectInput models.RejectChangeRequestInput
+	if c.Request.ContentLength != 0 {
+		err := c.ShouldBindJSON(&rejectInput)
+		if err == nil {
+			err = xss.CheckForXSS(rejectInput.Reason)
+		}
+		if err != nil {
+			errOutput := models.ErrorOutput{
+				ErrorCode: ErrCodeInvalidInput,
+				Errors:    fieldErrors(err),
+			}
+			err = errors.Join(ErrInvalidInput, err)
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+				Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+				Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+				Msg("error while trying to bind JSON input")
+			c.JSON(http.StatusBadRequest, errOutput)
+			return
+		}
+	}
+
+	changeRequest, err := handler.service.RejectChangeRequest(ctx, changeRequestId, rejectInput, principal(c))
+	if err != nil {
+		abortDecideChangeRequest(c, changeRequestId, err)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+		Msg("reject change request executed successfully")
+	handler.writeChangeRequest(c, http.StatusOK, changeRequest)
+}
+
+// changeRequestOutput the change request with the filtered changes, they replace the ones of the ChangeRequest
+type changeRequestOutput struct {
+	models.ChangeRequest
+	Changes any `json:"changes"`
+}
+
+// filterChangeRequest returns the change request without the changes of the fields the user can not read, or with
+// their masked values
+func (handler *companyHandler) filterChangeRequest(changeRequest models.ChangeRequest, scopes []string) (any, error) {
+	changes, err := handler.fieldPolicy.Filter(changeRequest.Changes, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return changeRequestOutput{
+		ChangeRequest: changeRequest,
+		Changes:       changes,
+	}, nil
+}
+
+func (handler *companyHandler) writeChangeRequest(c *gin.Context, statusCode int, changeRequest models.ChangeRequest) {
+	output, err := handler.filterChangeRequest(changeRequest, principal(c).Scopes)
+	if err != nil {
+		abortFilterFields(c, err)
+		return
+	}
+	c.JSON(statusCode, output)
+}
+
+// abortDecideChangeRequest maps the approve and reject errors to their status and error codes
+func abortDecideChangeRequest(c *gin.Context, changeRequestId uuid.UUID, err error) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeDecideChangeRequest,
+	}
+	statusCode := http.StatusInternalServerError
+	var identifierTaken repo.IdentifierTakenError
+	switch {
+	case errors.Is(err, service.ErrApproverScopeRequired):
+		errOutput.ErrorCode = ErrCodeApproverRequired
+		statusCode = http.StatusForbidden
+	case errors.Is(err, service.ErrSelfApproval):
+		errOutput.ErrorCode = ErrCodeSelfApproval
+		statusCode = http.StatusForbidden
+	case errors.Is(err, repo.ErrChangeRequestNotPending):
+		errOutput.ErrorCode = ErrCodeChangeRequestDecided
+		statusCode = http.StatusConflict
+	case errors.Is(err, repo.ErrVersionConflict):
+		errOutput.ErrorCode = ErrCodeVersionConflict
+		statusCode = http.StatusConflict
+	case errors.Is(err, service.ErrParentNotFound):
+		errOutput.ErrorCode = ErrCodeParentNotFound
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrHierarchyCycle):
+		errOutput.ErrorCode = ErrCodeHierarchyCycle
+		statusCode = http.StatusConflict
+	case errors.As(err, &identifierTaken):
+		errOutput = identifierTakenError(identifierTaken)
+		statusCode = http.StatusConflict
+	case errors.Is(err, repo.ErrNameTaken):
+		errOutput.ErrorCode = ErrCodeNameTaken
+		statusCode = http.StatusConflict
+	case errors.Is(err, repo.ErrAddressIncomplete):
+		errOutput.ErrorCode = ErrCodeAddressIncomplete
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, repo.ErrOwnershipWithoutParent):
+		errOutput.ErrorCode = ErrCodeOwnershipNoParent
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, mongo.ErrNoDocuments):
+		statusCode = http.StatusNotFound
+	}
+	err = errors.Join(ErrDecideChangeRequest, err)
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, statusCode).
+		Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+		Msg("error while trying to decide change request")
+	c.JSON(statusCode, errOutput)
+}
+
+// parseChangeRequestId parses the requestId param, the error response is written when ok is false
+func parseChangeRequestId(c *gin.Context) (uuid.UUID, bool) {
+	changeRequestIdParam := c.Param("requestId")
+	changeRequestId, err := uuid.Parse(changeRequestIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyChangeRequestId, changeRequestIdParam).
+			Msg("error while trying to parse changeRequestId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return uuid.Nil, false
+	}
+	return changeRequestId, true
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatchCompanyPendingApproval(t *testing.T) {
+	companyId := uuid.New()
+	name := "new-name"
+	changeRequest := models.ChangeRequest{
+		ID:          uuid.New(),
+		CompanyID:   companyId,
+		Changes:     models.UpdateCompanyInput{Name: &name},
+		BaseVersion: 2,
+		Status:      models.ChangeRequestStatusPending,
+		RequestedBy: "alice",
+		RequestedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ExpiresAt:   time.Date(2024, 1, 5, 3, 4, 5, 0, time.UTC),
+	}
+
+	s := mocks.NewCompanyService(t)
+	s.On("FindDuplicates", mock.Anything, name, &companyId).
+		Return([]models.DuplicateCandidate{}, nil)
+	s.On("PatchCompany", mock.Anything, companyId, models.UpdateCompanyInput{Name: &name}, models.Principal{Username: "alice"}).
+		Return(models.CompanyOutput{}, &changeRequest, nil)
+
+	handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PATCH("/v1/company/:id", func(c *gin.Context) {
+		c.Set("username", "alice")
+	}, handler.PatchCompany)
+
+	buf := bytes.NewBuffer([]byte(`{"name": "new-name"}`))
+
+	url := fmt.Sprintf("/v1/company/%s", companyId)
+	req, _ := http.NewRequest(http.MethodPatch, url, buf)
+	req.Header.Set("content-type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, "/v1/change-requests/"+changeRequest.ID.String(), rr.Header().Get("Location"))
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"id": "%s",
+		"company_id": "%s",
+		"changes": {
+			"name": "new-name"
+		},
+		"base_version": 2,
+		"status": "pending",
+		"requested_by": "alice",
+		"requested_at": "2024-01-02T03:04:05Z",
+		"expires_at": "2024-01-05T03:04:05Z"
+	}`, changeRequest.ID, companyId), rr.Body.String())
+}
+
+func TestApproveChangeRequest(t *testing.T) {
+	changeRequestId := uuid.New()
+	companyId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "success test case",
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"new-name",
+				"description":"",
+				"number_of_employees": 0,
+				"registered": false,
+				"type": "Corporations"
+			}`, companyId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ApproveChangeRequest", mock.Anything, changeRequestId, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{ID: companyId, Name: "new-name", Type: "Corporations"}, nil)
+			},
+		},
+		{
+			name:               "self approval",
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeSelfApproval),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ApproveChangeRequest", mock.Anything, changeRequestId, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, service.ErrSelfApproval)
+			},
+		},
+		{
+			name:               "missing approver scope",
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeApproverRequired),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ApproveChangeRequest", mock.Anything, changeRequestId, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, service.ErrApproverScopeRequired)
+			},
+		},
+		{
+			name:               "already decided",
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeChangeRequestDecided),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ApproveChangeRequest", mock.Anything, changeRequestId, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, repo.ErrChangeRequestNotPending)
+			},
+		},
+		{
+			name:               "the company changed",
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeVersionConflict),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ApproveChangeRequest", mock.Anything, changeRequestId, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, repo.ErrVersionConflict)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s)
+
+			gin.SetMode(gin.TestMode)
+
+			router := gin.Default()
+			router.POST("/v1/change-requests/:requestId/approve", handler.ApproveChangeRequest)
+
+			url := fmt.Sprintf("/v1/change-requests/%s/approve", changeRequestId)
+			req, _ := http.NewRequest(http.MethodPost, url, nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
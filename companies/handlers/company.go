@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"companies/consts"
+	"companies/fieldpolicy"
 	"companies/models"
 	"companies/repo"
+	"companies/rsql"
 	"companies/service"
 	"companies/xss"
+	"context"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,19 +24,108 @@ type CompanyHandler interface {
 	CreateCompany(c *gin.Context)
 	PatchCompany(c *gin.Context)
 	GetCompany(c *gin.Context)
+	GetCompanyByIdentifier(c *gin.Context)
 	DeleteCompany(c *gin.Context)
+	GetAncestors(c *gin.Context)
+	GetChildren(c *gin.Context)
+	GetSubtree(c *gin.Context)
+	RemoveParent(c *gin.Context)
+	AddTags(c *gin.Context)
+	RemoveTag(c *gin.Context)
+	CountTags(c *gin.Context)
+	ListCompanies(c *gin.Context)
+	TransitionCompany(c *gin.Context)
+	ListChangeRequests(c *gin.Context)
+	GetChangeRequest(c *gin.Context)
+	ApproveChangeRequest(c *gin.Context)
+	RejectChangeRequest(c *gin.Context)
+	BatchCompanies(c *gin.Context)
+	ImportCompanies(c *gin.Context)
+	ExportCompanies(c *gin.Context)
+	SearchCompanies(c *gin.Context)
+	CompanyStats(c *gin.Context)
+	EmployeeStats(c *gin.Context)
+	ReportDuplicates(c *gin.Context)
+	MergeCompanies(c *gin.Context)
 }
 
 type companyHandler struct {
 	service service.CompanyService
+	// jobService queues the imports and exports asked to run as a job
+	jobService  service.JobService
+	fieldPolicy fieldpolicy.Policy
 }
 
-func NewCompanyHandler(companyService service.CompanyService) CompanyHandler {
+func NewCompanyHandler(companyService service.CompanyService, jobService service.JobService, fieldPolicy fieldpolicy.Policy) CompanyHandler {
 	return &companyHandler{
-		service: companyService,
+		service:     companyService,
+		jobService:  jobService,
+		fieldPolicy: fieldPolicy,
 	}
 }
 
+// writeCompanies writes the JSON of one or more companies without the fields the user can not read
+func (handler *companyHandler) writeCompanies(c *gin.Context, statusCode int, value any) {
+	filtered, err := handler.fieldPolicy.Filter(value, principal(c).Scopes)
+	if err != nil {
+		abortFilterFields(c, err)
+		return
+	}
+	c.JSON(statusCode, filtered)
+}
+
+func abortFilterFields(c *gin.Context, err error) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeFilterFields,
+	}
+	err = errors.Join(ErrFilterFields, err)
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+		Msg("error while filtering the unreadable fields")
+	c.JSON(http.StatusInternalServerError, errOutput)
+}
+
+// checkFilter responds 400 with the location of the error when the filter expression of the query is invalid.
+// The fields the user can not read are unknown to the expression, they could be probed otherwise
+func (handler *companyHandler) checkFilter(c *gin.Context, query models.CompanyQuery) bool {
+	if query.Filter == "" {
+		return true
+	}
+	scopes := principal(c).Scopes
+	schema := models.CompanyFilterFields.Restrict(func(selector string) bool {
+		field, _, _ := strings.Cut(selector, ".")
+		return handler.fieldPolicy.CanRead(field, scopes)
+	})
+	_, err := rsql.Compile(query.Filter, schema, models.CompanyFilterLimits)
+	if err == nil {
+		return true
+	}
+
+	fieldError := models.FieldError{Field: "filter", Rule: rsql.KindSyntax}
+	var filterError *rsql.Error
+	if errors.As(err, &filterError) {
+		fieldError.Rule = filterError.Kind
+		fieldError.Message = filterError.Message
+		fieldError.Column = filterError.Column
+	}
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeInvalidInput,
+		Errors:    []models.FieldError{fieldError},
+	}
+	err = errors.Join(ErrInvalidInput, err)
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+		Msg("error while trying to parse the filter")
+	c.JSON(http.StatusBadRequest, errOutput)
+	return false
+}
+
 func (handler *companyHandler) CreateCompany(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -41,6 +134,7 @@ func (handler *companyHandler) CreateCompany(c *gin.Context) {
 	if err != nil {
 		errOutput := models.ErrorOutput{
 			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
 		}
 		err = errors.Join(ErrInvalidInput, err)
 		log.Error().
@@ -54,7 +148,7 @@ func (handler *companyHandler) CreateCompany(c *gin.Context) {
 	}
 
 	// Name field only has 15 chars, it is not long enough to contain XSS content
-	err = xss.CheckForXSS(companyInput.Description)
+	err = xss.CheckForXSS(companyInput.FreeTextFields()...)
 	if err != nil {
 		errOutput := models.ErrorOutput{
 			ErrorCode: ErrCodeInvalidInput,
@@ -70,19 +164,35 @@ func (handler *companyHandler) CreateCompany(c *gin.Context) {
 		return
 	}
 
-	companyOutput, err := handler.service.CreateCompany(ctx, companyInput)
+	user := principal(c)
+	unwritableFields := handler.fieldPolicy.Unwritable(companyInput.SetFields(), user.Scopes)
+	if len(unwritableFields) > 0 {
+		errOutput := fieldsNotWritableError(unwritableFields)
+		log.Error().
+			Err(ErrFieldsNotWritable).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusForbidden).
+			Strs(consts.LogKeyFields, unwritableFields).
+			Msg("error while checking the writable fields")
+		c.JSON(http.StatusForbidden, errOutput)
+		return
+	}
+	if !handler.checkDuplicates(c, companyInput.Name, nil) {
+		return
+	}
+
+	companyOutput, err := handler.service.CreateCompany(ctx, companyInput, user)
 	if err != nil {
-		errOutput := models.ErrorOutput{
-			ErrorCode: ErrCodeCouldNotCreateCompany,
-		}
+		statusCode, errOutput := createCompanyError(err)
 		err = errors.Join(ErrCouldNotCreateCompany, err)
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
-			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Int(consts.LogKeyStatusCode, statusCode).
 			Msg("error while trying to create company")
-		c.JSON(http.StatusInternalServerError, errOutput)
+		c.JSON(statusCode, errOutput)
 		return
 	}
 
@@ -90,7 +200,7 @@ func (handler *companyHandler) CreateCompany(c *gin.Context) {
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Int(consts.LogKeyStatusCode, http.StatusCreated).
 		Msg("create company executed successfully")
-	c.JSON(http.StatusCreated, companyOutput)
+	handler.writeCompanies(c, http.StatusCreated, companyOutput)
 }
 
 func (handler *companyHandler) PatchCompany(c *gin.Context) {
@@ -119,6 +229,7 @@ func (handler *companyHandler) PatchCompany(c *gin.Context) {
 	if err != nil {
 		errOutput := models.ErrorOutput{
 			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
 		}
 		err = errors.Join(ErrInvalidInput, err)
 		log.Error().
@@ -133,34 +244,45 @@ func (handler *companyHandler) PatchCompany(c *gin.Context) {
 	}
 
 	// Name field only has 15 chars, it is not long enough to contain XSS content
-	if updateCompanyInput.Description != nil {
-		err = xss.CheckForXSS(*updateCompanyInput.Description)
-		if err != nil {
-			errOutput := models.ErrorOutput{
-				ErrorCode: ErrCodeInvalidInput,
-			}
-			err = errors.Join(ErrInvalidInput, err)
-			log.Error().
-				Err(err).
-				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
-				Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
-				Int(consts.LogKeyStatusCode, http.StatusBadRequest).
-				Msg("error while checking for XSS content")
-			c.JSON(http.StatusBadRequest, errOutput)
-			return
+	err = xss.CheckForXSS(updateCompanyInput.FreeTextFields()...)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
 		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while checking for XSS content")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
 	}
 
-	companyOutput, err := handler.service.PatchCompany(ctx, companyId, updateCompanyInput)
+	user := principal(c)
+	unwritableFields := handler.fieldPolicy.Unwritable(updateCompanyInput.ChangedFields(), user.Scopes)
+	if len(unwritableFields) > 0 {
+		errOutput := fieldsNotWritableError(unwritableFields)
+		log.Error().
+			Err(ErrFieldsNotWritable).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusForbidden).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Strs(consts.LogKeyFields, unwritableFields).
+			Msg("error while checking the writable fields")
+		c.JSON(http.StatusForbidden, errOutput)
+		return
+	}
+	if updateCompanyInput.Name != nil && !handler.checkDuplicates(c, *updateCompanyInput.Name, &companyId) {
+		return
+	}
+
+	companyOutput, changeRequest, err := handler.service.PatchCompany(ctx, companyId, updateCompanyInput, user)
 	if err != nil {
-		errOutput := models.ErrorOutput{
-			ErrorCode: ErrCodePatchCompany,
-		}
+		errorCode, errOutput := patchCompanyError(err)
 		err = errors.Join(ErrPatchCompany, err)
-		errorCode := http.StatusInternalServerError
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			errorCode = http.StatusNotFound
-		}
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
@@ -172,12 +294,24 @@ func (handler *companyHandler) PatchCompany(c *gin.Context) {
 		return
 	}
 
+	if changeRequest != nil {
+		log.Info().
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyStatusCode, http.StatusAccepted).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Str(consts.LogKeyChangeRequestId, changeRequest.ID.String()).
+			Msg("patch company is waiting for approval")
+		c.Header("Location", "/v1/change-requests/"+changeRequest.ID.String())
+		handler.writeChangeRequest(c, http.StatusAccepted, *changeRequest)
+		return
+	}
+
 	log.Info().
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Int(consts.LogKeyStatusCode, http.StatusAccepted).
 		Str(consts.LogKeyCompanyId, companyId.String()).
 		Msg("patch company executed successfully")
-	c.JSON(http.StatusAccepted, companyOutput)
+	handler.writeCompanies(c, http.StatusAccepted, companyOutput)
 }
 
 func (handler *companyHandler) GetCompany(c *gin.Context) {
@@ -202,6 +336,17 @@ func (handler *companyHandler) GetCompany(c *gin.Context) {
 	}
 
 	companyOutput, err := handler.service.GetCompany(ctx, companyId)
+	var mergedErr *service.CompanyMergedError
+	if errors.As(err, &mergedErr) {
+		log.Info().
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyStatusCode, http.StatusMovedPermanently).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("get company redirected to the company it was merged into")
+		c.Header("Location", "/v1/company/"+mergedErr.Tombstone.MergedInto.String())
+		c.JSON(http.StatusMovedPermanently, mergedErr.Tombstone)
+		return
+	}
 	if err != nil {
 		errOutput := models.ErrorOutput{
 			ErrorCode: ErrCodeGetCompany,
@@ -227,7 +372,104 @@ func (handler *companyHandler) GetCompany(c *gin.Context) {
 		Int(consts.LogKeyStatusCode, http.StatusOK).
 		Str(consts.LogKeyCompanyId, companyId.String()).
 		Msg("get company executed successfully")
-	c.JSON(http.StatusOK, companyOutput)
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+func (handler *companyHandler) GetCompanyByIdentifier(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	scheme := c.Param("scheme")
+	if !models.IsIdentifierScheme(scheme) {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidIdentifier,
+		}
+		log.Error().
+			Err(ErrInvalidIdentifier).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyIdentifierScheme, scheme).
+			Msg("error while trying to parse the identifier scheme")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+	// identifiers are stored upper case
+	value := strings.ToUpper(c.Param("value"))
+
+	companyOutput, err := handler.service.GetCompanyByIdentifier(ctx, scheme, value)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeGetCompany,
+		}
+		err = errors.Join(ErrGetCompany, err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyIdentifierScheme, scheme).
+			Msg("error while trying to get company by identifier")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyOutput.ID.String()).
+		Msg("get company by identifier executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+func (handler *companyHandler) ListCompanies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.CompanyQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+	if !handler.checkFilter(c, query) {
+		return
+	}
+
+	companyOutputs, err := handler.service.ListCompanies(ctx, query)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeListCompanies,
+		}
+		err = errors.Join(ErrListCompanies, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while trying to list companies")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("list companies executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutputs)
 }
 
 func (handler *companyHandler) DeleteCompany(c *gin.Context) {
@@ -251,16 +493,10 @@ func (handler *companyHandler) DeleteCompany(c *gin.Context) {
 		return
 	}
 
-	err = handler.service.DeleteCompany(ctx, companyId)
+	err = handler.service.DeleteCompany(ctx, companyId, principal(c))
 	if err != nil {
-		errOutput := models.ErrorOutput{
-			ErrorCode: ErrCodeDeleteCompany,
-		}
+		statusCode, errOutput := deleteCompanyError(err)
 		err = errors.Join(ErrDeleteCompany, err)
-		statusCode := http.StatusInternalServerError
-		if errors.Is(err, repo.ErrDocumentNotFound) {
-			statusCode = http.StatusNotFound
-		}
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
@@ -279,3 +515,214 @@ func (handler *companyHandler) DeleteCompany(c *gin.Context) {
 		Msg("delete company executed successfully")
 	c.JSON(http.StatusNoContent, nil)
 }
+
+func (handler *companyHandler) GetAncestors(c *gin.Context) {
+	handler.getHierarchy(c, handler.service.GetAncestors, "ancestors")
+}
+
+func (handler *companyHandler) GetChildren(c *gin.Context) {
+	handler.getHierarchy(c, handler.service.GetChildren, "children")
+}
+
+func (handler *companyHandler) GetSubtree(c *gin.Context) {
+	handler.getHierarchy(c, handler.service.GetSubtree, "subtree")
+}
+
+// getHierarchy runs one of the hierarchy queries of the service for the company in the id param
+func (handler *companyHandler) getHierarchy(
+	c *gin.Context,
+	query func(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error),
+	relation string,
+) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	nodes, err := query(ctx, companyId)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeGetHierarchy,
+		}
+		err = errors.Join(ErrGetHierarchy, err)
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msgf("error while trying to get the company %s", relation)
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Msgf("get company %s executed successfully", relation)
+	handler.writeCompanies(c, http.StatusOK, nodes)
+}
+
+func (handler *companyHandler) RemoveParent(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	companyOutput, err := handler.service.RemoveParent(ctx, companyId, principal(c))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeRemoveParent,
+		}
+		err = errors.Join(ErrRemoveParent, err)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrNotOwner):
+			errOutput.ErrorCode = ErrCodeNotOwner
+			statusCode = http.StatusForbidden
+		case errors.Is(err, mongo.ErrNoDocuments):
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to remove the parent company")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusAccepted).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Msg("remove parent company executed successfully")
+	handler.writeCompanies(c, http.StatusAccepted, companyOutput)
+}
+
+// createCompanyError maps the errors of creating a company to their status and error codes
+func createCompanyError(err error) (int, models.ErrorOutput) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeCouldNotCreateCompany,
+	}
+	statusCode := http.StatusInternalServerError
+	var identifierTaken repo.IdentifierTakenError
+	switch {
+	case errors.Is(err, service.ErrParentNotFound):
+		errOutput.ErrorCode = ErrCodeParentNotFound
+		statusCode = http.StatusUnprocessableEntity
+	case errors.As(err, &identifierTaken):
+		errOutput = identifierTakenError(identifierTaken)
+		statusCode = http.StatusConflict
+	case errors.Is(err, repo.ErrNameTaken):
+		errOutput.ErrorCode = ErrCodeNameTaken
+		statusCode = http.StatusConflict
+	}
+	return statusCode, errOutput
+}
+
+// identifierTakenError names the identifier that another company of the tenant already has
+func identifierTakenError(err repo.IdentifierTakenError) models.ErrorOutput {
+	return models.ErrorOutput{
+		ErrorCode: ErrCodeIdentifierTaken,
+		Errors:    []models.FieldError{{Field: err.Field, Rule: "unique"}},
+	}
+}
+
+// patchCompanyError maps the errors of patching a company to their status and error codes
+func patchCompanyError(err error) (int, models.ErrorOutput) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodePatchCompany,
+	}
+	statusCode := http.StatusInternalServerError
+	var identifierTaken repo.IdentifierTakenError
+	switch {
+	case errors.Is(err, service.ErrParentNotFound):
+		errOutput.ErrorCode = ErrCodeParentNotFound
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrHierarchyCycle):
+		errOutput.ErrorCode = ErrCodeHierarchyCycle
+		statusCode = http.StatusConflict
+	case errors.As(err, &identifierTaken):
+		errOutput = identifierTakenError(identifierTaken)
+		statusCode = http.StatusConflict
+	case errors.Is(err, repo.ErrNameTaken):
+		errOutput.ErrorCode = ErrCodeNameTaken
+		statusCode = http.StatusConflict
+	case errors.Is(err, service.ErrNotOwner):
+		errOutput.ErrorCode = ErrCodeNotOwner
+		statusCode = http.StatusForbidden
+	case errors.Is(err, repo.ErrAddressIncomplete):
+		errOutput.ErrorCode = ErrCodeAddressIncomplete
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, repo.ErrOwnershipWithoutParent):
+		errOutput.ErrorCode = ErrCodeOwnershipNoParent
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, mongo.ErrNoDocuments):
+		statusCode = http.StatusNotFound
+	}
+	return statusCode, errOutput
+}
+
+// deleteCompanyError maps the errors of deleting a company to their status and error codes
+func deleteCompanyError(err error) (int, models.ErrorOutput) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeDeleteCompany,
+	}
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrNotOwner):
+		errOutput.ErrorCode = ErrCodeNotOwner
+		statusCode = http.StatusForbidden
+	case errors.Is(err, repo.ErrDocumentNotFound):
+		statusCode = http.StatusNotFound
+	}
+	return statusCode, errOutput
+}
+
+func fieldsNotWritableError(unwritableFields []string) models.ErrorOutput {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeFieldsNotWritable,
+	}
+	for _, field := range unwritableFields {
+		errOutput.Errors = append(errOutput.Errors, models.FieldError{Field: field, Rule: "write_scope"})
+	}
+	return errOutput
+}
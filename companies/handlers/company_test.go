@@ -2,22 +2,39 @@ package handlers
 
 import (
 	"bytes"
+	"companies/fieldpolicy"
 	"companies/mocks"
 	"companies/models"
 	"companies/repo"
+	"companies/service"
+	"companies/validators"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+func TestMain(m *testing.M) {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		err := validators.RegisterValidators(v)
+		if err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
 func TestCreateCompany(t *testing.T) {
 	companyId := uuid.New()
 
@@ -56,7 +73,9 @@ func TestCreateCompany(t *testing.T) {
 				"type": "Corporations"
 			}`, companyId),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
-				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput")).
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), mock.AnythingOfType("models.Principal")).
 					Return(companyOutput, nil)
 			},
 		},
@@ -71,13 +90,174 @@ func TestCreateCompany(t *testing.T) {
 			companyOutput:      models.CompanyOutput{},
 			expectedStatusCode: http.StatusBadRequest,
 			expectedResponseBody: fmt.Sprintf(`{
-				"error_code": %d
+				"error_code": %d,
+				"errors": [{"field": "name", "rule": "required"}]
 			}`, ErrCodeInvalidInput),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
 
 			},
 		},
 		// TODO test case for each validation field
+		{
+			name: "success test case with profile fields",
+			requestBody: `{
+				"name": "company-name",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"registered_address": {
+					"street": "1 Main Street",
+					"city": "Dublin",
+					"country": "IE"
+				},
+				"website": "https://example.com",
+				"industry_codes": {"nace": ["62.01"], "sic": ["7372"]},
+				"founded_on": "2001-02-03",
+				"contact_email": "contact@example.com"
+			}`,
+			companyOutput: models.CompanyOutput{
+				ID:                companyId,
+				Name:              "company-name",
+				NumberOfEmployees: 100,
+				Registered:        true,
+				Type:              "Corporations",
+				RegisteredAddress: &models.Address{
+					Street:  "1 Main Street",
+					City:    "Dublin",
+					Country: "IE",
+				},
+				Website:       "https://example.com",
+				IndustryCodes: &models.IndustryCodes{NACE: []string{"62.01"}, SIC: []string{"7372"}},
+				FoundedOn:     "2001-02-03",
+				ContactEmail:  "contact@example.com",
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"company-name",
+				"description":"",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"registered_address": {
+					"street": "1 Main Street",
+					"city": "Dublin",
+					"country": "IE"
+				},
+				"website": "https://example.com",
+				"industry_codes": {"nace": ["62.01"], "sic": ["7372"]},
+				"founded_on": "2001-02-03",
+				"contact_email": "contact@example.com"
+			}`, companyId),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(companyOutput, nil)
+			},
+		},
+		{
+			name: "invalid country code",
+			requestBody: `{
+				"name": "company-name",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"operating_address": {
+					"street": "1 Main Street",
+					"city": "Dublin",
+					"country": "Ireland"
+				}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "operating_address.country", "rule": "iso3166_1_alpha2"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name: "invalid NACE code",
+			requestBody: `{
+				"name": "company-name",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"industry_codes": {"nace": ["62-01"]}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "industry_codes.nace[0]", "rule": "nace"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name: "founding date in the future",
+			requestBody: `{
+				"name": "company-name",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"founded_on": "2999-01-01"
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "founded_on", "rule": "pastdate"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name: "invalid LEI checksum",
+			requestBody: `{
+				"name": "company-name",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"identifiers": {"lei": "5493001KJTIIGC8Y1R13", "duns": "150483782"}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "identifiers.lei", "rule": "lei"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name: "xss content in address",
+			requestBody: `{
+				"name": "company-name",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"registered_address": {
+					"street": "<script>alert('secret')</script>",
+					"city": "Dublin",
+					"country": "IE"
+				}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
 		{
 			name: "xss content in description",
 			requestBody: `{
@@ -96,6 +276,49 @@ func TestCreateCompany(t *testing.T) {
 
 			},
 		},
+		{
+			name: "identifier of another company of the tenant",
+			requestBody: `{
+				"name": "company-name",
+				"description": "company-description",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations"
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "identifiers.lei", "rule": "unique"}]
+			}`, ErrCodeIdentifierTaken),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, errors.Join(repo.IdentifierTakenError{Field: "identifiers.lei"}, assert.AnError))
+			},
+		},
+		{
+			name: "name of another company of the tenant",
+			requestBody: `{
+				"name": "company-name",
+				"description": "company-description",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations"
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeNameTaken),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, errors.Join(repo.ErrNameTaken, assert.AnError))
+			},
+		},
 		{
 			name: "service returns an 500 error",
 			requestBody: `{
@@ -111,7 +334,9 @@ func TestCreateCompany(t *testing.T) {
 				"error_code": %d
 			}`, ErrCodeCouldNotCreateCompany),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
-				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput")).
+				s.On("FindDuplicates", mock.Anything, "company-name", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), mock.AnythingOfType("models.Principal")).
 					Return(models.CompanyOutput{}, assert.AnError)
 			},
 		},
@@ -121,7 +346,7 @@ func TestCreateCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			s := new(mocks.CompanyService)
 
-			handler := NewCompanyHandler(s)
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
 
 			testCase.stubMocks(s, testCase.companyOutput)
 
@@ -188,8 +413,10 @@ func TestPatchCompany(t *testing.T) {
 				"type": "NonProfit"
 			}`, companyId.String()),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
-				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput")).
-					Return(companyOutput, nil)
+				s.On("FindDuplicates", mock.Anything, "company-name", &companyId).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(companyOutput, (*models.ChangeRequest)(nil), nil)
 			},
 		},
 		{
@@ -214,7 +441,72 @@ func TestPatchCompany(t *testing.T) {
 			companyOutput:      models.CompanyOutput{},
 			expectedStatusCode: http.StatusBadRequest,
 			expectedResponseBody: fmt.Sprintf(`{
-				"error_code": %d
+				"error_code": %d,
+				"errors": [{"field": "name", "rule": "max"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name:      "status can only be changed by a transition",
+			companyId: companyId.String(),
+			requestBody: `{
+				"status": "active"
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "status", "rule": "isdefault"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name:      "invalid website",
+			companyId: companyId.String(),
+			requestBody: `{
+				"website": "javascript:alert(1)"
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "website", "rule": "http_url"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name:      "invalid nested address country",
+			companyId: companyId.String(),
+			requestBody: `{
+				"registered_address": {"country": "ie"}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "registered_address.country", "rule": "iso3166_1_alpha2"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name:      "empty identifier",
+			companyId: companyId.String(),
+			requestBody: `{
+				"identifiers": {"lei": ""}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "identifiers.lei", "rule": "lei"}]
 			}`, ErrCodeInvalidInput),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
 
@@ -247,8 +539,91 @@ func TestPatchCompany(t *testing.T) {
 				"error_code": %d
 			}`, ErrCodePatchCompany),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
-				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput")).
-					Return(models.CompanyOutput{}, errors.Join(assert.AnError, mongo.ErrNoDocuments))
+				s.On("FindDuplicates", mock.Anything, "company-name", &companyId).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), errors.Join(assert.AnError, mongo.ErrNoDocuments))
+			},
+		},
+		{
+			name:      "parent is a descendant",
+			companyId: companyId.String(),
+			requestBody: fmt.Sprintf(`{
+				"parent_id": "%s"
+			}`, uuid.New()),
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeHierarchyCycle),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), service.ErrHierarchyCycle)
+			},
+		},
+		{
+			name:      "parent not found",
+			companyId: companyId.String(),
+			requestBody: fmt.Sprintf(`{
+				"parent_id": "%s"
+			}`, uuid.New()),
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeParentNotFound),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), errors.Join(service.ErrParentNotFound, mongo.ErrNoDocuments))
+			},
+		},
+		{
+			name:      "identifier of another company of the tenant",
+			companyId: companyId.String(),
+			requestBody: `{
+				"description": "company-description"
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "identifiers.vat", "rule": "unique"}]
+			}`, ErrCodeIdentifierTaken),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), errors.Join(repo.ErrFindOneAndUpdate, repo.IdentifierTakenError{Field: "identifiers.vat"}))
+			},
+		},
+		{
+			name:      "ownership percentage without a parent",
+			companyId: companyId.String(),
+			requestBody: `{
+				"ownership_percentage": 50
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeOwnershipNoParent),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), repo.ErrOwnershipWithoutParent)
+			},
+		},
+		{
+			name:      "partial address without a stored address",
+			companyId: companyId.String(),
+			requestBody: `{
+				"registered_address": {"city": "Dublin"}
+			}`,
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeAddressIncomplete),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), repo.ErrAddressIncomplete)
 			},
 		},
 		{
@@ -263,8 +638,10 @@ func TestPatchCompany(t *testing.T) {
 				"error_code": %d
 			}`, ErrCodePatchCompany),
 			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
-				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput")).
-					Return(models.CompanyOutput{}, assert.AnError)
+				s.On("FindDuplicates", mock.Anything, "company-name", &companyId).
+					Return([]models.DuplicateCandidate{}, nil)
+				s.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, (*models.ChangeRequest)(nil), assert.AnError)
 			},
 		},
 	}
@@ -273,7 +650,7 @@ func TestPatchCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			s := new(mocks.CompanyService)
 
-			handler := NewCompanyHandler(s)
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
 
 			testCase.stubMocks(s, testCase.companyOutput)
 
@@ -303,6 +680,7 @@ func TestPatchCompany(t *testing.T) {
 
 func TestGetCompany(t *testing.T) {
 	companyId := uuid.New()
+	survivorId := uuid.New()
 
 	testCases := []struct {
 		name                 string
@@ -362,6 +740,28 @@ func TestGetCompany(t *testing.T) {
 					Return(models.CompanyOutput{}, errors.Join(assert.AnError, mongo.ErrNoDocuments))
 			},
 		},
+		{
+			name:               "the company was merged",
+			companyId:          companyId.String(),
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"merged_into": "%s",
+				"merged_at": "2024-01-02T03:04:05Z",
+				"merged_by": "alice"
+			}`, companyId, survivorId),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				tombstone := models.CompanyTombstone{
+					ID:         companyId,
+					MergedInto: survivorId,
+					MergedAt:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+					MergedBy:   "alice",
+				}
+				s.On("GetCompany", mock.Anything, companyId).
+					Return(models.CompanyOutput{}, &service.CompanyMergedError{Tombstone: tombstone})
+			},
+		},
 		{
 			name:               "test case 500",
 			companyId:          companyId.String(),
@@ -381,7 +781,7 @@ func TestGetCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			s := new(mocks.CompanyService)
 
-			handler := NewCompanyHandler(s)
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
 
 			testCase.stubMocks(s, testCase.companyOutput)
 
@@ -400,6 +800,103 @@ func TestGetCompany(t *testing.T) {
 			// Perform the request
 			router.ServeHTTP(rr, req)
 
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+			if testCase.expectedStatusCode == http.StatusMovedPermanently {
+				assert.Equal(t, "/v1/company/"+survivorId.String(), rr.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestGetCompanyByIdentifier(t *testing.T) {
+	companyId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		url                  string
+		companyOutput        models.CompanyOutput
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService, companyOutput models.CompanyOutput)
+	}{
+		{
+			name: "success test case",
+			url:  "/v1/company/by-identifier/lei/5493001kjtiigc8y1r12",
+			companyOutput: models.CompanyOutput{
+				ID:                companyId,
+				Name:              "company-name",
+				Description:       "company-description",
+				NumberOfEmployees: 100,
+				Registered:        true,
+				Type:              "Corporations",
+				Identifiers:       &models.Identifiers{LEI: "5493001KJTIIGC8Y1R12"},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"company-name",
+				"description":"company-description",
+				"number_of_employees": 100,
+				"registered": true,
+				"type": "Corporations",
+				"identifiers": {"lei": "5493001KJTIIGC8Y1R12"}
+			}`, companyId.String()),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("GetCompanyByIdentifier", mock.Anything, models.IdentifierSchemeLEI, "5493001KJTIIGC8Y1R12").
+					Return(companyOutput, nil)
+			},
+		},
+		{
+			name:               "unknown scheme",
+			url:                "/v1/company/by-identifier/isin/US0378331005",
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeInvalidIdentifier),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name:               "test case 404",
+			url:                "/v1/company/by-identifier/duns/150483782",
+			companyOutput:      models.CompanyOutput{},
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeGetCompany),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("GetCompanyByIdentifier", mock.Anything, models.IdentifierSchemeDUNS, "150483782").
+					Return(models.CompanyOutput{}, errors.Join(assert.AnError, mongo.ErrNoDocuments))
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := new(mocks.CompanyService)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s, testCase.companyOutput)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Both routes are registered like in main.go
+			router := gin.Default()
+			router.GET("/v1/company/:id", handler.GetCompany)
+			router.GET("/v1/company/by-identifier/:scheme/:value", handler.GetCompanyByIdentifier)
+
+			req, _ := http.NewRequest(http.MethodGet, testCase.url, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
 			// Assertions
 			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
 			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
@@ -421,7 +918,7 @@ func TestDeleteCompany(t *testing.T) {
 			companyId:          companyId.String(),
 			expectedStatusCode: http.StatusNoContent,
 			stubMocks: func(s *mocks.CompanyService) {
-				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.Principal")).
 					Return(nil)
 			},
 		},
@@ -438,16 +935,25 @@ func TestDeleteCompany(t *testing.T) {
 			companyId:          companyId.String(),
 			expectedStatusCode: http.StatusNotFound,
 			stubMocks: func(s *mocks.CompanyService) {
-				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.Principal")).
 					Return(errors.Join(assert.AnError, repo.ErrDocumentNotFound))
 			},
 		},
+		{
+			name:               "test case 403 not the owner",
+			companyId:          companyId.String(),
+			expectedStatusCode: http.StatusForbidden,
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.Principal")).
+					Return(service.ErrNotOwner)
+			},
+		},
 		{
 			name:               "test case 500",
 			companyId:          companyId.String(),
 			expectedStatusCode: http.StatusInternalServerError,
 			stubMocks: func(s *mocks.CompanyService) {
-				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+				s.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.Principal")).
 					Return(assert.AnError)
 			},
 		},
@@ -457,7 +963,7 @@ func TestDeleteCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			s := new(mocks.CompanyService)
 
-			handler := NewCompanyHandler(s)
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
 
 			testCase.stubMocks(s)
 
@@ -481,3 +987,111 @@ func TestDeleteCompany(t *testing.T) {
 		})
 	}
 }
+
+func TestGetSubtree(t *testing.T) {
+	companyId := uuid.New()
+	childId := uuid.New()
+	grandchildId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		companyId            string
+		nodes                []models.CompanyNodeOutput
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService, nodes []models.CompanyNodeOutput)
+	}{
+		{
+			name:      "success test case",
+			companyId: companyId.String(),
+			nodes: []models.CompanyNodeOutput{
+				{
+					CompanyOutput: models.CompanyOutput{ID: childId, Name: "child", Type: "Corporations", ParentID: &companyId},
+					Depth:         0,
+				},
+				{
+					CompanyOutput: models.CompanyOutput{ID: grandchildId, Name: "grandchild", Type: "Corporations", ParentID: &childId},
+					Depth:         1,
+				},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`[
+				{
+					"id": "%s",
+					"name": "child",
+					"description": "",
+					"number_of_employees": 0,
+					"registered": false,
+					"type": "Corporations",
+					"parent_id": "%s",
+					"depth": 0
+				},
+				{
+					"id": "%s",
+					"name": "grandchild",
+					"description": "",
+					"number_of_employees": 0,
+					"registered": false,
+					"type": "Corporations",
+					"parent_id": "%s",
+					"depth": 1
+				}
+			]`, childId, companyId, grandchildId, childId),
+			stubMocks: func(s *mocks.CompanyService, nodes []models.CompanyNodeOutput) {
+				s.On("GetSubtree", mock.Anything, companyId).
+					Return(nodes, nil)
+			},
+		},
+		{
+			name:               "invalid companyId",
+			companyId:          "abc",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeInvalidId),
+			stubMocks: func(s *mocks.CompanyService, nodes []models.CompanyNodeOutput) {
+
+			},
+		},
+		{
+			name:               "test case 404",
+			companyId:          companyId.String(),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeGetHierarchy),
+			stubMocks: func(s *mocks.CompanyService, nodes []models.CompanyNodeOutput) {
+				s.On("GetSubtree", mock.Anything, companyId).
+					Return(nil, errors.Join(repo.ErrDocumentNotFound, mongo.ErrNoDocuments))
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := new(mocks.CompanyService)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s, testCase.nodes)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/company/:id/subtree", handler.GetSubtree)
+
+			url := fmt.Sprintf("/v1/company/%s/subtree", testCase.companyId)
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
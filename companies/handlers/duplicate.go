@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/fieldpolicy"
+	"companies/jobs"
+	"companies/models"
+	"companies/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// checkDuplicates responds 409 with the companies whose name is similar to the name, unless the force query
+// parameter is true. excludeId is the company being renamed
+func (handler *companyHandler) checkDuplicates(c *gin.Context, name string, excludeId *uuid.UUID) bool {
+	ctx := c.Request.Context()
+
+	var forceQuery models.ForceQuery
+	err := c.ShouldBindQuery(&forceQuery)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    []models.FieldError{{Field: "force", Rule: "boolean"}},
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return false
+	}
+	if forceQuery.Force {
+		return true
+	}
+
+	candidates, err := handler.findDuplicates(ctx, name, excludeId, principal(c).Scopes)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeFindDuplicates,
+		}
+		err = errors.Join(ErrFindDuplicates, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while trying to find the duplicates")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return false
+	}
+	if len(candidates) == 0 {
+		return true
+	}
+
+	errOutput := models.DuplicatesOutput{
+		ErrorCode:  ErrCodePossibleDuplicates,
+		Candidates: candidates,
+	}
+	log.Error().
+		Err(ErrPossibleDuplicates).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, http.StatusConflict).
+		Msg("the name has possible duplicates")
+	c.JSON(http.StatusConflict, errOutput)
+	return false
+}
+
+// findDuplicates returns the companies whose name is similar to the name, with the names the scopes can read
+func (handler *companyHandler) findDuplicates(ctx context.Context, name string, excludeId *uuid.UUID, scopes []string) ([]models.DuplicateCandidate, error) {
+	candidates, err := handler.service.FindDuplicates(ctx, name, excludeId)
+	if err != nil {
+		return nil, err
+	}
+	return handler.readableCandidates(candidates, scopes), nil
+}
+
+// readableCandidates drops the names of the candidates when the scopes can not read the names
+func (handler *companyHandler) readableCandidates(candidates []models.DuplicateCandidate, scopes []string) []models.DuplicateCandidate {
+	if handler.fieldPolicy.CanRead("name", scopes) {
+		return candidates
+	}
+	output := make([]models.DuplicateCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidate.Name = ""
+		output = append(output, candidate)
+	}
+	return output
+}
+
+// ReportDuplicates queues a companies.duplicates job, the report is the job result
+func (handler *companyHandler) ReportDuplicates(c *gin.Context) {
+	var params models.DuplicatesJobParams
+	err := c.ShouldBindQuery(&params)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	handler.createJob(c, models.JobTypeCompaniesDuplicates, params, nil)
+}
+
+// duplicatesJob runs the companies.duplicates jobs, the result is a JSON models.DuplicatesReport
+type duplicatesJob struct {
+	handler *companyHandler
+}
+
+func NewDuplicatesJob(companyService service.CompanyService, fieldPolicy fieldpolicy.Policy) jobs.Handler {
+	return &duplicatesJob{
+		handler: &companyHandler{
+			service:     companyService,
+			fieldPolicy: fieldPolicy,
+		},
+	}
+}
+
+func (duplicatesJob *duplicatesJob) ContentType(job models.Job) string {
+	return "application/json"
+}
+
+// Run the names are dropped when the user that created the job can not read them
+func (duplicatesJob *duplicatesJob) Run(ctx context.Context, job models.Job, input io.Reader, output io.Writer, progress *jobs.Progress) error {
+	var params models.DuplicatesJobParams
+	err := json.Unmarshal(job.Params, &params)
+	if err != nil {
+		return err
+	}
+	report, err := duplicatesJob.handler.service.ReportDuplicates(ctx, params.Threshold, func() {
+		progress.Add(1)
+	})
+	if err != nil {
+		return errors.Join(ErrFindDuplicates, err)
+	}
+	for i, cluster := range report.Clusters {
+		report.Clusters[i].Companies = duplicatesJob.handler.readableCandidates(cluster.Companies, job.Scopes)
+	}
+	return json.NewEncoder(output).Encode(report)
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/jobs"
+	"companies/mocks"
+	"companies/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckDuplicates(t *testing.T) {
+	companyId := uuid.New()
+	duplicateId := uuid.New()
+	candidates := []models.DuplicateCandidate{{ID: duplicateId, Name: "Acme Limited", Score: 1, Exact: true}}
+
+	testCases := []struct {
+		name                 string
+		method               string
+		path                 string
+		requestBody          string
+		fieldPolicy          fieldpolicy.Policy
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "create a company with possible duplicates",
+			method:             http.MethodPost,
+			path:               "/v1/company",
+			requestBody:        `{"name": "Acme Ltd", "number_of_employees": 1, "registered": true, "type": "Corporations"}`,
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"candidates": [{"id": "%s", "name": "Acme Limited", "score": 1, "exact": true}]
+			}`, ErrCodePossibleDuplicates, duplicateId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "Acme Ltd", (*uuid.UUID)(nil)).
+					Return(candidates, nil)
+			},
+		},
+		{
+			name:               "force skips the check",
+			method:             http.MethodPost,
+			path:               "/v1/company?force=true",
+			requestBody:        `{"name": "Acme Ltd", "number_of_employees": 1, "registered": true, "type": "Corporations"}`,
+			expectedStatusCode: http.StatusCreated,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name": "Acme Ltd",
+				"description": "",
+				"number_of_employees": 1,
+				"registered": true,
+				"type": "Corporations"
+			}`, companyId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{ID: companyId, Name: "Acme Ltd", NumberOfEmployees: 1, Registered: true, Type: "Corporations"}, nil)
+			},
+		},
+		{
+			name:               "invalid force",
+			method:             http.MethodPost,
+			path:               "/v1/company?force=yes",
+			requestBody:        `{"name": "Acme Ltd", "number_of_employees": 1, "registered": true, "type": "Corporations"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "force", "rule": "boolean"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:        "the names of the candidates are dropped when unreadable",
+			method:      http.MethodPost,
+			path:        "/v1/company",
+			requestBody: `{"name": "Acme Ltd", "number_of_employees": 1, "registered": true, "type": "Corporations"}`,
+			fieldPolicy: fieldpolicy.Policy{
+				Fields: map[string]fieldpolicy.Rule{
+					"name": {Read: []string{"admin"}},
+				},
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"candidates": [{"id": "%s", "score": 1, "exact": true}]
+			}`, ErrCodePossibleDuplicates, duplicateId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "Acme Ltd", (*uuid.UUID)(nil)).
+					Return(candidates, nil)
+			},
+		},
+		{
+			name:               "rename a company with possible duplicates",
+			method:             http.MethodPatch,
+			path:               "/v1/company/" + companyId.String(),
+			requestBody:        `{"name": "Acme Ltd"}`,
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"candidates": [{"id": "%s", "name": "Acme Limited", "score": 1, "exact": true}]
+			}`, ErrCodePossibleDuplicates, duplicateId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "Acme Ltd", &companyId).
+					Return(candidates, nil)
+			},
+		},
+		{
+			name:               "the duplicates can not be found",
+			method:             http.MethodPatch,
+			path:               "/v1/company/" + companyId.String(),
+			requestBody:        `{"name": "Acme Ltd"}`,
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeFindDuplicates),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "Acme Ltd", &companyId).
+					Return(nil, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, testCase.fieldPolicy)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.POST("/v1/company", handler.CreateCompany)
+			router.PATCH("/v1/company/:id", handler.PatchCompany)
+
+			buf := bytes.NewBuffer([]byte(testCase.requestBody))
+
+			req, _ := http.NewRequest(testCase.method, testCase.path, buf)
+			req.Header.Set("content-type", "application/json")
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestReportDuplicates(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		stubMocks          func(j *mocks.JobService)
+	}{
+		{
+			name:               "success test case",
+			query:              "threshold=0.8",
+			expectedStatusCode: http.StatusAccepted,
+			stubMocks: func(j *mocks.JobService) {
+				j.On("CreateJob", mock.Anything, models.JobTypeCompaniesDuplicates, models.DuplicatesJobParams{Threshold: 0.8}, (*models.JobInput)(nil), models.Principal{Username: "alice"}).
+					Return(models.Job{ID: jobId, Status: models.JobStatusQueued}, nil)
+			},
+		},
+		{
+			name:               "threshold too low",
+			query:              "threshold=0.1",
+			expectedStatusCode: http.StatusBadRequest,
+			stubMocks:          func(j *mocks.JobService) {},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+			j := mocks.NewJobService(t)
+
+			handler := NewCompanyHandler(s, j, fieldpolicy.Policy{})
+
+			testCase.stubMocks(j)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "alice")
+			})
+			router.POST("/v1/companies/duplicates/report", handler.ReportDuplicates)
+
+			req, _ := http.NewRequest(http.MethodPost, "/v1/companies/duplicates/report?"+testCase.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedStatusCode == http.StatusAccepted {
+				assert.Equal(t, "/v1/jobs/"+jobId.String(), rr.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestDuplicatesJob(t *testing.T) {
+	duplicateIds := []uuid.UUID{uuid.New(), uuid.New()}
+	report := models.DuplicatesReport{
+		Companies: 3,
+		Clusters: []models.DuplicateCluster{{
+			Companies: []models.DuplicateCandidate{
+				{ID: duplicateIds[0], Name: "Acme Ltd", Score: 1, Exact: true},
+				{ID: duplicateIds[1], Name: "ACME Limited", Score: 1, Exact: true},
+			},
+			MinScore: 1,
+		}},
+	}
+
+	s := mocks.NewCompanyService(t)
+	s.On("ReportDuplicates", mock.Anything, 0.95, mock.Anything).
+		Run(func(args mock.Arguments) {
+			progress := args.Get(2).(func())
+			progress()
+			progress()
+			progress()
+		}).
+		Return(report, nil)
+
+	job := NewDuplicatesJob(s, fieldpolicy.Policy{
+		Fields: map[string]fieldpolicy.Rule{
+			"name": {Read: []string{"admin"}},
+		},
+	})
+	progress := &jobs.Progress{}
+	output := &bytes.Buffer{}
+
+	err := job.Run(context.Background(), models.Job{Params: json.RawMessage(`{"threshold": 0.95}`)}, nil, output, progress)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, progress.Value())
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"companies": 3,
+		"clusters": [{
+			"companies": [
+				{"id": "%s", "score": 1, "exact": true},
+				{"id": "%s", "score": 1, "exact": true}
+			],
+			"min_score": 1
+		}]
+	}`, duplicateIds[0], duplicateIds[1]), output.String())
+}
@@ -1,6 +1,12 @@
 package handlers
 
-import "errors"
+import (
+	"companies/models"
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
 
 const (
 	errMessageInvalidInput          string = "invalid input"
@@ -9,6 +15,40 @@ const (
 	errMessageGetCompany            string = "error while getting company"
 	errMessagePatchCompany          string = "error while patching company"
 	errMessageDeleteCompany         string = "error while deleting company"
+	errMessageInvalidIdentifier     string = "invalid identifier scheme"
+	errMessageGetHierarchy          string = "error while getting the company hierarchy"
+	errMessageRemoveParent          string = "error while removing the parent company"
+	errMessageAddTags               string = "error while adding tags"
+	errMessageRemoveTag             string = "error while removing tag"
+	errMessageListCompanies         string = "error while listing companies"
+	errMessageCountTags             string = "error while counting tags"
+	errMessageUploadAttachment      string = "error while uploading attachment"
+	errMessageListAttachments       string = "error while listing attachments"
+	errMessageGetAttachment         string = "error while getting attachment"
+	errMessageDeleteAttachment      string = "error while deleting attachment"
+	errMessageTransitionCompany     string = "error while applying the company transition"
+	errMessageListChangeRequests    string = "error while listing change requests"
+	errMessageGetChangeRequest      string = "error while getting change request"
+	errMessageDecideChangeRequest   string = "error while deciding change request"
+	errMessageFieldsNotWritable     string = "the user can not write some of the fields"
+	errMessageFilterFields          string = "error while filtering the unreadable fields"
+	errMessageBatchCompanies        string = "error while running the batch"
+	errMessageUnsupportedFormat     string = "unsupported import format"
+	errMessageInvalidImport         string = "invalid import"
+	errMessageImportAborted         string = "the import could not be read to the end"
+	errMessageExportCompanies       string = "error while exporting companies"
+	errMessageCreateJob             string = "error while creating job"
+	errMessageGetJob                string = "error while getting job"
+	errMessageCancelJob             string = "error while canceling job"
+	errMessageRetryJob              string = "error while retrying job"
+	errMessageGetJobResult          string = "error while getting the job result"
+	errMessageSearchCompanies       string = "error while searching companies"
+	errMessageCompanyStats          string = "error while computing the company statistics"
+	errMessageFieldsNotReadable     string = "the user can not read some of the fields"
+	errMessagePossibleDuplicates    string = "the name is similar to the names of existing companies"
+	errMessageFindDuplicates        string = "error while looking for companies with a similar name"
+	errMessageMergeCompanies        string = "error while merging companies"
+	errMessageGetQuota              string = "error while reading the quotas"
 )
 
 var (
@@ -18,6 +58,40 @@ var (
 	ErrGetCompany            = errors.New(errMessageGetCompany)
 	ErrPatchCompany          = errors.New(errMessagePatchCompany)
 	ErrDeleteCompany         = errors.New(errMessageDeleteCompany)
+	ErrInvalidIdentifier     = errors.New(errMessageInvalidIdentifier)
+	ErrGetHierarchy          = errors.New(errMessageGetHierarchy)
+	ErrRemoveParent          = errors.New(errMessageRemoveParent)
+	ErrAddTags               = errors.New(errMessageAddTags)
+	ErrRemoveTag             = errors.New(errMessageRemoveTag)
+	ErrListCompanies         = errors.New(errMessageListCompanies)
+	ErrCountTags             = errors.New(errMessageCountTags)
+	ErrUploadAttachment      = errors.New(errMessageUploadAttachment)
+	ErrListAttachments       = errors.New(errMessageListAttachments)
+	ErrGetAttachment         = errors.New(errMessageGetAttachment)
+	ErrDeleteAttachment      = errors.New(errMessageDeleteAttachment)
+	ErrTransitionCompany     = errors.New(errMessageTransitionCompany)
+	ErrListChangeRequests    = errors.New(errMessageListChangeRequests)
+	ErrGetChangeRequest      = errors.New(errMessageGetChangeRequest)
+	ErrDecideChangeRequest   = errors.New(errMessageDecideChangeRequest)
+	ErrFieldsNotWritable     = errors.New(errMessageFieldsNotWritable)
+	ErrFilterFields          = errors.New(errMessageFilterFields)
+	ErrBatchCompanies        = errors.New(errMessageBatchCompanies)
+	ErrUnsupportedFormat     = errors.New(errMessageUnsupportedFormat)
+	ErrInvalidImport         = errors.New(errMessageInvalidImport)
+	ErrImportAborted         = errors.New(errMessageImportAborted)
+	ErrExportCompanies       = errors.New(errMessageExportCompanies)
+	ErrCreateJob             = errors.New(errMessageCreateJob)
+	ErrGetJob                = errors.New(errMessageGetJob)
+	ErrCancelJob             = errors.New(errMessageCancelJob)
+	ErrRetryJob              = errors.New(errMessageRetryJob)
+	ErrGetJobResult          = errors.New(errMessageGetJobResult)
+	ErrSearchCompanies       = errors.New(errMessageSearchCompanies)
+	ErrCompanyStats          = errors.New(errMessageCompanyStats)
+	ErrFieldsNotReadable     = errors.New(errMessageFieldsNotReadable)
+	ErrPossibleDuplicates    = errors.New(errMessagePossibleDuplicates)
+	ErrFindDuplicates        = errors.New(errMessageFindDuplicates)
+	ErrMergeCompanies        = errors.New(errMessageMergeCompanies)
+	ErrGetQuota              = errors.New(errMessageGetQuota)
 )
 
 const (
@@ -27,4 +101,82 @@ const (
 	ErrCodeGetCompany            int = 4
 	ErrCodePatchCompany          int = 5
 	ErrCodeDeleteCompany         int = 6
+	ErrCodeAddressIncomplete     int = 7
+	ErrCodeInvalidIdentifier     int = 8
+	ErrCodeParentNotFound        int = 9
+	ErrCodeHierarchyCycle        int = 10
+	ErrCodeGetHierarchy          int = 11
+	ErrCodeRemoveParent          int = 12
+	ErrCodeTooManyTags           int = 13
+	ErrCodeAddTags               int = 14
+	ErrCodeRemoveTag             int = 15
+	ErrCodeListCompanies         int = 16
+	ErrCodeCountTags             int = 17
+	ErrCodeUploadAttachment      int = 18
+	ErrCodeUnsupportedType       int = 19
+	ErrCodeAttachmentTooLarge    int = 20
+	ErrCodeTooManyAttachments    int = 21
+	ErrCodeListAttachments       int = 22
+	ErrCodeGetAttachment         int = 23
+	ErrCodeDeleteAttachment      int = 24
+	ErrCodeTransitionCompany     int = 25
+	ErrCodeReasonRequired        int = 26
+	ErrCodeTransitionForbidden   int = 27
+	ErrCodeTransitionNotAllowed  int = 28
+	ErrCodeListChangeRequests    int = 29
+	ErrCodeGetChangeRequest      int = 30
+	ErrCodeDecideChangeRequest   int = 31
+	ErrCodeApproverRequired      int = 32
+	ErrCodeSelfApproval          int = 33
+	ErrCodeChangeRequestDecided  int = 34
+	ErrCodeVersionConflict       int = 35
+	ErrCodeFieldsNotWritable     int = 36
+	ErrCodeFilterFields          int = 37
+	ErrCodeNotOwner              int = 38
+	ErrCodeBatchCompanies        int = 39
+	ErrCodeBatchAborted          int = 40
+	ErrCodeUnsupportedFormat     int = 41
+	ErrCodeInvalidImport         int = 42
+	ErrCodeDuplicateName         int = 43
+	ErrCodeImportAborted         int = 44
+	ErrCodeExportCompanies       int = 45
+	ErrCodeCreateJob             int = 46
+	ErrCodeGetJob                int = 47
+	ErrCodeCancelJob             int = 48
+	ErrCodeRetryJob              int = 49
+	ErrCodeGetJobResult          int = 50
+	ErrCodeJobNotCancelable      int = 51
+	ErrCodeJobNotRetryable       int = 52
+	ErrCodeJobResultNotReady     int = 53
+	ErrCodeSearchCompanies       int = 54
+	ErrCodeCompanyStats          int = 55
+	ErrCodeFieldsNotReadable     int = 56
+	ErrCodePossibleDuplicates    int = 57
+	ErrCodeFindDuplicates        int = 58
+	ErrCodeMergeCompanies        int = 59
+	ErrCodeInvalidMerge          int = 60
+	ErrCodeMergeSourceNotFound   int = 61
+	ErrCodeGetQuota              int = 62
+	ErrCodeIdentifierTaken       int = 63
+	ErrCodeNameTaken             int = 64
+	ErrCodeOwnershipNoParent     int = 65
 )
+
+// fieldErrors lists the failed validation rules of a binding error, other errors return nil
+func fieldErrors(err error) []models.FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+	output := make([]models.FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		// drop the struct name, ex: CompanyInput.identifiers.lei -> identifiers.lei
+		field := fieldError.Namespace()
+		_, field, _ = strings.Cut(field, ".")
+		output = append(output, models.FieldError{
+			Field: field,
+			Rule:  fieldError.Tag(),
+		})
+	}
+	return output
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/exporter"
+	"companies/fieldpolicy"
+	"companies/jobs"
+	"companies/models"
+	"companies/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ExportErrorTrailer the trailer with the error code of an export that failed after the response was started
+const ExportErrorTrailer = "X-Export-Error-Code"
+
+// exportFlushRows the number of companies sent to the client at once
+const exportFlushRows = 100
+
+// ExportCompanies streams the companies of the query filters in the format of the format parameter or the Accept
+// header. Each company is read from the cursor, filtered by the field policy and written before the next one.
+// With async=true the export runs as a job in the format parameter, csv by default, the file is the job result
+func (handler *companyHandler) ExportCompanies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.CompanyQuery
+	var exportQuery models.ExportQuery
+	var jobQuery models.JobQuery
+	err := c.ShouldBindQuery(&query)
+	if err == nil {
+		err = c.ShouldBindQuery(&exportQuery)
+	}
+	if err == nil {
+		err = c.ShouldBindQuery(&jobQuery)
+	}
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+	if !handler.checkFilter(c, query) {
+		return
+	}
+
+	columns, err := exporter.ParseColumns(exportQuery.Columns)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    []models.FieldError{{Field: "columns", Rule: "column"}},
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to parse the columns")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	if jobQuery.Async {
+		// the Accept header is the one of the job response
+		params := models.ExportJobParams{
+			Query:   query,
+			Format:  exportQuery.Format,
+			Columns: exportQuery.Columns,
+		}
+		if params.Format == "" {
+			params.Format = exporter.FormatCSV
+		}
+		handler.createJob(c, models.JobTypeCompaniesExport, params, nil)
+		return
+	}
+
+	format := exportQuery.Format
+	if format == "" {
+		// the first format is the default when the client accepts any
+		switch c.NegotiateFormat(exporter.MIMECSV, exporter.MIMENDJSON, exporter.MIMEXLSX) {
+		case exporter.MIMECSV:
+			format = exporter.FormatCSV
+		case exporter.MIMENDJSON:
+			format = exporter.FormatNDJSON
+		case exporter.MIMEXLSX:
+			format = exporter.FormatXLSX
+		default:
+			errOutput := models.ErrorOutput{
+				ErrorCode: ErrCodeUnsupportedFormat,
+			}
+			log.Error().
+				Err(ErrUnsupportedFormat).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+				Int(consts.LogKeyStatusCode, http.StatusNotAcceptable).
+				Msg("error while trying to negotiate the export format")
+			c.JSON(http.StatusNotAcceptable, errOutput)
+			return
+		}
+	}
+
+	output := exportOutput{
+		start: func() io.Writer {
+			c.Header("Content-Type", exporter.FormatMIME[format])
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="companies.%s"`, format))
+			c.Header("Trailer", ExportErrorTrailer)
+			c.Status(http.StatusOK)
+			return c.Writer
+		},
+		written: func(rows int) {
+			if rows%exportFlushRows == 0 {
+				c.Writer.Flush()
+			}
+		},
+	}
+	rows, started, err := handler.exportCompanies(ctx, query, format, columns, exportQuery.Columns == "", principal(c).Scopes, output)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeExportCompanies,
+		}
+		err = errors.Join(ErrExportCompanies, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Int(consts.LogKeyExportRows, rows).
+			Msg("error while trying to export companies")
+		if !started {
+			c.JSON(http.StatusInternalServerError, errOutput)
+			return
+		}
+		// the status is already sent, the trailer tells the client the export is incomplete
+		c.Writer.Header().Set(ExportErrorTrailer, strconv.Itoa(errOutput.ErrorCode))
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Int(consts.LogKeyExportRows, rows).
+		Msg("export companies executed successfully")
+}
+
+// exportOutput where an export is written. start is only called with the first company, or at the end of an
+// empty export, so an error before it can still be reported. written is called with the number of companies
+// written after each one
+type exportOutput struct {
+	start   func() io.Writer
+	written func(rows int)
+}
+
+// exportCompanies writes the companies of the query without the fields the scopes can not read, it returns the
+// number of companies written and whether the output was started
+func (handler *companyHandler) exportCompanies(ctx context.Context, query models.CompanyQuery, format string, columns []exporter.Column, allColumns bool, scopes []string, output exportOutput) (int, bool, error) {
+	var writer exporter.Writer
+	start := func() error {
+		var err error
+		writer, err = exporter.NewWriter(format, output.start(), columns, allColumns)
+		return err
+	}
+	rows := 0
+	err := handler.service.ExportCompanies(ctx, query, func(companyOutput models.CompanyOutput) error {
+		if writer == nil {
+			err := start()
+			if err != nil {
+				return err
+			}
+		}
+		filtered, err := handler.fieldPolicy.Filter(companyOutput, scopes)
+		if err != nil {
+			return errors.Join(ErrFilterFields, err)
+		}
+		company, err := exporter.ToObject(filtered)
+		if err != nil {
+			return err
+		}
+		err = writer.WriteCompany(company)
+		if err != nil {
+			return err
+		}
+		rows++
+		output.written(rows)
+		// the request timeout, the client going away or the job being stopped ends the export
+		return ctx.Err()
+	})
+	if err == nil && writer == nil {
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	return rows, writer != nil, err
+}
+
+// exportJob runs the companies.export jobs, the exported file is the job result
+type exportJob struct {
+	handler *companyHandler
+}
+
+func NewExportJob(companyService service.CompanyService, fieldPolicy fieldpolicy.Policy) jobs.Handler {
+	return &exportJob{
+		handler: &companyHandler{
+			service:     companyService,
+			fieldPolicy: fieldPolicy,
+		},
+	}
+}
+
+func (exportJob *exportJob) ContentType(job models.Job) string {
+	var params models.ExportJobParams
+	_ = json.Unmarshal(job.Params, &params)
+	return exporter.FormatMIME[params.Format]
+}
+
+// Run the fields are filtered with the scopes of the user that created the job
+func (exportJob *exportJob) Run(ctx context.Context, job models.Job, input io.Reader, output io.Writer, progress *jobs.Progress) error {
+	var params models.ExportJobParams
+	err := json.Unmarshal(job.Params, &params)
+	if err != nil {
+		return err
+	}
+	columns, err := exporter.ParseColumns(params.Columns)
+	if err != nil {
+		return err
+	}
+
+	jobOutput := exportOutput{
+		start: func() io.Writer {
+			return output
+		},
+		written: func(rows int) {
+			progress.Add(1)
+		},
+	}
+	_, _, err = exportJob.handler.exportCompanies(ctx, params.Query, params.Format, columns, params.Columns == "", job.Scopes, jobOutput)
+	if err != nil {
+		return errors.Join(ErrExportCompanies, err)
+	}
+	return nil
+}
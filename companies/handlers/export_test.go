@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportCompanies(t *testing.T) {
+	companyId := uuid.New()
+	companies := []models.CompanyOutput{
+		{ID: companyId, Name: "acme", NumberOfEmployees: 10, Registered: true, Type: "Corporations", InternalNotes: "secret"},
+		{ID: companyId, Name: "globex", NumberOfEmployees: 20, Type: "NonProfit"},
+	}
+	exportAll := func(ctx context.Context, query models.CompanyQuery, fn func(models.CompanyOutput) error) error {
+		for _, company := range companies {
+			err := fn(company)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		name                 string
+		query                string
+		accept               string
+		fieldPolicy          fieldpolicy.Policy
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+		expectedTrailer      string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:                "csv by default with the masked fields",
+			query:               "?columns=name,number_of_employees,internal_notes&type=Corporations&limit=1",
+			fieldPolicy:         testFieldPolicy(),
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedResponseBody: "name,number_of_employees,internal_notes\n" +
+				"acme,,***\n" +
+				"globex,,\n",
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ExportCompanies", mock.Anything, models.CompanyQuery{Type: "Corporations", Limit: 1}, mock.Anything).
+					Return(exportAll)
+			},
+		},
+		{
+			name:                "ndjson from the accept header",
+			query:               "?columns=name,registered",
+			accept:              "application/x-ndjson",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedResponseBody: `{"name":"acme","registered":true}` + "\n" +
+				`{"name":"globex","registered":false}` + "\n",
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+					Return(exportAll)
+			},
+		},
+		{
+			name:                "format parameter overrides the accept header",
+			query:               "?format=xlsx",
+			accept:              "text/csv",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+					Return(exportAll)
+			},
+		},
+		{
+			name:                 "empty export",
+			query:                "?columns=name",
+			expectedStatusCode:   http.StatusOK,
+			expectedContentType:  "text/csv",
+			expectedResponseBody: "name\n",
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+					Return(nil)
+			},
+		},
+		{
+			name:               "unknown column",
+			query:              "?columns=name,revenue",
+			expectedStatusCode: http.StatusBadRequest,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "unsupported accept header",
+			accept:             "application/json",
+			expectedStatusCode: http.StatusNotAcceptable,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "service returned an error before the first company",
+			expectedStatusCode: http.StatusInternalServerError,
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+					Return(assert.AnError)
+			},
+		},
+		{
+			name:                 "service returned an error after the first company",
+			query:                "?columns=name",
+			expectedStatusCode:   http.StatusOK,
+			expectedContentType:  "text/csv",
+			expectedResponseBody: "name\nacme\n",
+			expectedTrailer:      strconv.Itoa(ErrCodeExportCompanies),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+					Return(func(ctx context.Context, query models.CompanyQuery, fn func(models.CompanyOutput) error) error {
+						err := fn(companies[0])
+						if err != nil {
+							return err
+						}
+						return assert.AnError
+					})
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, testCase.fieldPolicy)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/companies/export", handler.ExportCompanies)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/companies/export"+testCase.query, nil)
+			if testCase.accept != "" {
+				req.Header.Set("accept", testCase.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedContentType != "" {
+				assert.Equal(t, testCase.expectedContentType, rr.Header().Get("Content-Type"))
+			}
+			if testCase.expectedResponseBody != "" {
+				assert.Equal(t, testCase.expectedResponseBody, rr.Body.String())
+			}
+			assert.Equal(t, testCase.expectedTrailer, rr.Result().Trailer.Get(ExportErrorTrailer))
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testFieldPolicy() fieldpolicy.Policy {
+	mask := "***"
+	return fieldpolicy.Policy{
+		Fields: map[string]fieldpolicy.Rule{
+			"number_of_employees": {Read: []string{"companies:headcount"}},
+			"internal_notes":      {Read: []string{"companies:internal"}, Write: []string{"companies:internal"}, Mask: &mask},
+			"registered":          {Write: []string{"finance"}},
+		},
+	}
+}
+
+func TestGetCompanyFieldPolicy(t *testing.T) {
+	companyId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		scopes               []string
+		expectedResponseBody string
+	}{
+		{
+			name:   "unreadable fields are dropped or masked",
+			scopes: []string{"companies:read"},
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"company-name",
+				"description":"",
+				"registered": true,
+				"type": "Corporations",
+				"internal_notes": "***"
+			}`, companyId),
+		},
+		{
+			name:   "readable fields are returned",
+			scopes: []string{"companies:headcount", "companies:internal"},
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"company-name",
+				"description":"",
+				"number_of_employees": 10,
+				"registered": true,
+				"type": "Corporations",
+				"internal_notes": "pending audit"
+			}`, companyId),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+			s.On("GetCompany", mock.Anything, companyId).
+				Return(models.CompanyOutput{
+					ID:                companyId,
+					Name:              "company-name",
+					NumberOfEmployees: 10,
+					Registered:        true,
+					Type:              "Corporations",
+					InternalNotes:     "pending audit",
+				}, nil)
+
+			handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+			gin.SetMode(gin.TestMode)
+
+			router := gin.Default()
+			router.GET("/v1/company/:id", func(c *gin.Context) {
+				c.Set("scopes", testCase.scopes)
+			}, handler.GetCompany)
+
+			url := fmt.Sprintf("/v1/company/%s", companyId)
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestPatchCompanyFieldPolicy(t *testing.T) {
+	companyId := uuid.New()
+
+	s := mocks.NewCompanyService(t)
+
+	handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.PATCH("/v1/company/:id", func(c *gin.Context) {
+		c.Set("scopes", []string{"companies:write"})
+	}, handler.PatchCompany)
+
+	buf := bytes.NewBuffer([]byte(`{"registered": true, "internal_notes": "notes", "description": "updated"}`))
+
+	url := fmt.Sprintf("/v1/company/%s", companyId)
+	req, _ := http.NewRequest(http.MethodPatch, url, buf)
+	req.Header.Set("content-type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"error_code": %d,
+		"errors": [
+			{"field": "internal_notes", "rule": "write_scope"},
+			{"field": "registered", "rule": "write_scope"}
+		]
+	}`, ErrCodeFieldsNotWritable), rr.Body.String())
+}
+
+func TestListCompaniesFilterFieldPolicy(t *testing.T) {
+	s := mocks.NewCompanyService(t)
+
+	handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/v1/companies", func(c *gin.Context) {
+		c.Set("scopes", []string{"companies:read"})
+	}, handler.ListCompanies)
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/companies?filter="+url.QueryEscape("name==Acme;number_of_employees=gt=50"), nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"error_code": %d,
+		"errors": [{"field": "filter", "rule": "field", "message": "unknown field number_of_employees", "column": 12}]
+	}`, ErrCodeInvalidInput), rr.Body.String())
+}
+
+func TestListChangeRequestsFieldPolicy(t *testing.T) {
+	changeRequestId := uuid.New()
+	companyId := uuid.New()
+	name := "new-name"
+	employees := 10
+	notes := "pending audit"
+
+	s := mocks.NewCompanyService(t)
+	s.On("ListChangeRequests", mock.Anything, (*uuid.UUID)(nil)).
+		Return([]models.ChangeRequest{
+			{
+				ID:        changeRequestId,
+				CompanyID: companyId,
+				Changes:   models.UpdateCompanyInput{Name: &name, NumberOfEmployees: &employees, InternalNotes: &notes},
+				Status:    models.ChangeRequestStatusPending,
+			},
+		}, nil)
+
+	handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.GET("/v1/change-requests", func(c *gin.Context) {
+		c.Set("scopes", []string{"companies:read"})
+	}, handler.ListChangeRequests)
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/change-requests", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`[{
+		"id": "%s",
+		"company_id": "%s",
+		"changes": {
+			"name": "new-name",
+			"internal_notes": "***"
+		},
+		"base_version": 0,
+		"status": "pending",
+		"requested_by": "",
+		"requested_at": "0001-01-01T00:00:00Z",
+		"expires_at": "0001-01-01T00:00:00Z"
+	}]`, changeRequestId, companyId), rr.Body.String())
+}
+
+func TestCreateCompanyFieldPolicy(t *testing.T) {
+	s := mocks.NewCompanyService(t)
+
+	handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/v1/company", func(c *gin.Context) {
+		c.Set("scopes", []string{"companies:write"})
+	}, handler.CreateCompany)
+
+	buf := bytes.NewBuffer([]byte(`{"name": "acme", "number_of_employees": 10, "registered": true, "type": "Corporations", "internal_notes": "notes"}`))
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/company", buf)
+	req.Header.Set("content-type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"error_code": %d,
+		"errors": [
+			{"field": "internal_notes", "rule": "write_scope"},
+			{"field": "registered", "rule": "write_scope"}
+		]
+	}`, ErrCodeFieldsNotWritable), rr.Body.String())
+}
+
+func TestBatchCompaniesFieldPolicy(t *testing.T) {
+	s := mocks.NewCompanyService(t)
+
+	handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/v1/companies:method", func(c *gin.Context) {
+		c.Set("scopes", []string{"companies:write"})
+	}, handler.BatchCompanies)
+
+	buf := bytes.NewBuffer([]byte(`{
+		"mode": "all_or_nothing",
+		"operations": [
+			{"operation": "create", "data": {"name": "acme", "number_of_employees": 10, "registered": true, "type": "Corporations"}}
+		]
+	}`))
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/companies:batch", buf)
+	req.Header.Set("content-type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"results": [{
+			"status_code": 403,
+			"error_code": %d,
+			"errors": [{"field": "registered", "rule": "write_scope"}]
+		}]
+	}`, ErrCodeFieldsNotWritable), rr.Body.String())
+}
+
+func TestImportCompaniesFieldPolicy(t *testing.T) {
+	s := mocks.NewCompanyService(t)
+	s.On("FindDuplicates", mock.Anything, "beta", (*uuid.UUID)(nil)).
+		Return(nil, nil).Once()
+	s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "beta" }), true, mock.AnythingOfType("models.Principal")).
+		Return(models.CompanyOutput{Name: "beta"}, nil).Once()
+
+	handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.Default()
+	router.POST("/v1/companies/import", func(c *gin.Context) {
+		c.Set("scopes", []string{"companies:write"})
+	}, handler.ImportCompanies)
+
+	buf := bytes.NewBuffer([]byte(`{"name": "acme", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n" +
+		`{"name": "beta", "number_of_employees": 10, "registered": false, "type": "Corporations"}` + "\n"))
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/companies/import?dry_run=true", buf)
+	req.Header.Set("content-type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"dry_run": true,
+		"rows": [
+			{"row": 1, "status": "rejected", "error_code": %d, "errors": [{"field": "registered", "rule": "write_scope"}]},
+			{"row": 2, "status": "accepted"}
+		],
+		"summary": {"accepted": 1, "rejected": 1, "duplicate_name": 0}
+	}`, ErrCodeFieldsNotWritable), rr.Body.String())
+}
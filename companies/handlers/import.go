@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/dedupe"
+	"companies/fieldpolicy"
+	"companies/importer"
+	"companies/jobs"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"companies/xss"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rs/zerolog/log"
+)
+
+// ImportCompanies creates a company for each row of the CSV or NDJSON body, the rows are validated like the body of
+// CreateCompany. The body is read and the report is written one row at a time so the import is never held in memory.
+// With async=true the body is stored and the import runs as a job, its report is the job result
+func (handler *companyHandler) ImportCompanies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.ImportQuery
+	var jobQuery models.JobQuery
+	err := c.ShouldBindQuery(&query)
+	if err == nil {
+		err = c.ShouldBindQuery(&jobQuery)
+	}
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the import query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	format := c.ContentType()
+	if !slices.Contains(importer.Formats, format) {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeUnsupportedFormat,
+		}
+		log.Error().
+			Err(ErrUnsupportedFormat).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusUnsupportedMediaType).
+			Msg("error while trying to read the import")
+		c.JSON(http.StatusUnsupportedMediaType, errOutput)
+		return
+	}
+
+	if jobQuery.Async {
+		params := models.ImportJobParams{
+			Format:  format,
+			Mapping: c.QueryMap("mapping"),
+			DryRun:  query.DryRun,
+			Force:   query.Force,
+		}
+		input := &models.JobInput{
+			Content:     c.Request.Body,
+			Size:        c.Request.ContentLength,
+			ContentType: format,
+		}
+		handler.createJob(c, models.JobTypeCompaniesImport, params, input)
+		return
+	}
+
+	reader, err := importer.NewReader(format, c.Request.Body, c.QueryMap("mapping"))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidImport,
+		}
+		err = errors.Join(ErrInvalidImport, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to read the CSV header")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	c.Header("Content-Type", importReportContentType)
+	c.Status(http.StatusOK)
+	report := newImportReport(c.Writer, query.DryRun, nil)
+	// the deadline of the import or the client going away stops the import after the current row
+	err = handler.importRows(ctx, reader, query, principal(c), report)
+	errorCode := 0
+	if err != nil {
+		errorCode = ErrCodeImportAborted
+		err = errors.Join(ErrImportAborted, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errorCode).
+			Bool(consts.LogKeyDryRun, query.DryRun).
+			Msg("error while trying to read the import")
+	}
+	report.close(errorCode)
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Bool(consts.LogKeyDryRun, query.DryRun).
+		Msg("import companies executed")
+}
+
+// importRows imports the rows until the end of the input, the error is the reason the import stopped before it
+func (handler *companyHandler) importRows(ctx context.Context, reader importer.Reader, query models.ImportQuery, user models.Principal, report *importReport) error {
+	// only the canonical names of the accepted rows are kept, a name is at most 15 characters
+	names := map[string]bool{}
+	for {
+		err := ctx.Err()
+		if err == nil {
+			err = report.err
+		}
+		if err != nil {
+			return err
+		}
+		row, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		rowReport, rowErr := handler.importRow(ctx, row, names, query, user)
+		if rowErr != nil {
+			log.Error().
+				Err(rowErr).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Int(consts.LogKeyErrorCode, rowReport.ErrorCode).
+				Int(consts.LogKeyImportRow, row.Number).
+				Bool(consts.LogKeyDryRun, query.DryRun).
+				Msg("error in import row")
+		}
+		report.writeRow(rowReport)
+	}
+}
+
+// importRow runs the CreateCompany checks on the row and imports it, the error is the reason of a rejected row
+func (handler *companyHandler) importRow(ctx context.Context, row importer.Row, names map[string]bool, query models.ImportQuery, user models.Principal) (models.ImportRow, error) {
+	rowReport := models.ImportRow{
+		Row:    row.Number,
+		Status: models.ImportRowRejected,
+	}
+	if row.Err != nil {
+		rowReport.ErrorCode = ErrCodeInvalidInput
+		var valueError importer.ValueError
+		if errors.As(row.Err, &valueError) {
+			rowReport.Errors = []models.FieldError{{Field: valueError.Field, Rule: "type"}}
+		}
+		return rowReport, errors.Join(ErrInvalidInput, row.Err)
+	}
+
+	var companyInput models.CompanyInput
+	err := binding.JSON.BindBody(row.Data, &companyInput)
+	if err != nil {
+		rowReport.ErrorCode = ErrCodeInvalidInput
+		rowReport.Errors = fieldErrors(err)
+		return rowReport, errors.Join(ErrInvalidInput, err)
+	}
+
+	err = xss.CheckForXSS(companyInput.FreeTextFields()...)
+	if err != nil {
+		rowReport.ErrorCode = ErrCodeInvalidInput
+		return rowReport, errors.Join(ErrInvalidInput, err)
+	}
+
+	unwritableFields := handler.fieldPolicy.Unwritable(companyInput.SetFields(), user.Scopes)
+	if len(unwritableFields) > 0 {
+		errOutput := fieldsNotWritableError(unwritableFields)
+		rowReport.ErrorCode = errOutput.ErrorCode
+		rowReport.Errors = errOutput.Errors
+		return rowReport, ErrFieldsNotWritable
+	}
+
+	// the rows are duplicates on the canonical name like the companies of the tenant, ex: "Acme" and "ACME"
+	nameCanonical := dedupe.CanonicalName(companyInput.Name)
+	if names[nameCanonical] {
+		rowReport.Status = models.ImportRowDuplicateName
+		rowReport.ErrorCode = ErrCodeDuplicateName
+		return rowReport, service.ErrDuplicateName
+	}
+
+	if !query.Force {
+		candidates, err := handler.findDuplicates(ctx, companyInput.Name, nil, user.Scopes)
+		if err != nil {
+			rowReport.ErrorCode = ErrCodeFindDuplicates
+			return rowReport, errors.Join(ErrFindDuplicates, err)
+		}
+		if len(candidates) > 0 {
+			rowReport.Status = models.ImportRowDuplicateName
+			rowReport.ErrorCode = ErrCodePossibleDuplicates
+			rowReport.Candidates = candidates
+			return rowReport, ErrPossibleDuplicates
+		}
+	}
+
+	companyOutput, err := handler.service.ImportCompany(ctx, companyInput, query.DryRun, user)
+	if err != nil {
+		if errors.Is(err, service.ErrDuplicateName) {
+			rowReport.Status = models.ImportRowDuplicateName
+			rowReport.ErrorCode = ErrCodeDuplicateName
+			return rowReport, err
+		}
+		_, errOutput := createCompanyError(err)
+		rowReport.ErrorCode = errOutput.ErrorCode
+		rowReport.Errors = errOutput.Errors
+		// another request created a company with the name since the row was checked
+		if errors.Is(err, repo.ErrNameTaken) {
+			rowReport.Status = models.ImportRowDuplicateName
+		}
+		return rowReport, errors.Join(ErrCouldNotCreateCompany, err)
+	}
+
+	names[nameCanonical] = true
+	rowReport.Status = models.ImportRowAccepted
+	if !query.DryRun {
+		rowReport.CompanyID = &companyOutput.ID
+	}
+	return rowReport, nil
+}
+
+// importReportContentType the content type of the import report
+const importReportContentType = "application/json; charset=utf-8"
+
+// importReport writes the report as the rows are imported:
+//
+//	{"dry_run": false, "rows": [{"row": 1, "status": "accepted", "company_id": "..."}], "summary": {...}}
+type importReport struct {
+	writer  io.Writer
+	summary models.ImportSummary
+	rows    int
+	// progress counts the rows of an import job, it is nil for a request
+	progress *jobs.Progress
+	// err the first write error, nothing is written after it
+	err error
+}
+
+func newImportReport(writer io.Writer, dryRun bool, progress *jobs.Progress) *importReport {
+	report := &importReport{
+		writer:   writer,
+		progress: progress,
+	}
+	report.write(`{"dry_run":`)
+	report.writeJSON(dryRun)
+	report.write(`,"rows":[`)
+	return report
+}
+
+func (report *importReport) writeRow(row models.ImportRow) {
+	if report.rows > 0 {
+		report.write(",")
+	}
+	report.rows++
+	report.summary.Add(row)
+	report.writeJSON(row)
+	if report.progress != nil {
+		report.progress.Add(1)
+	}
+}
+
+// close ends the report, the error code is set when the import could not be read to the end
+func (report *importReport) close(errorCode int) {
+	report.write(`],"summary":`)
+	report.writeJSON(report.summary)
+	if errorCode != 0 {
+		report.write(`,"error_code":`)
+		report.writeJSON(errorCode)
+	}
+	report.write("}")
+}
+
+func (report *importReport) writeJSON(value any) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		// the report values are plain structs, they can always be encoded
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("error while encoding the import report")
+		content = []byte("null")
+	}
+	report.write(string(content))
+}
+
+func (report *importReport) write(content string) {
+	if report.err != nil {
+		return
+	}
+	_, report.err = io.WriteString(report.writer, content)
+}
+
+// importJob runs the companies.import jobs, the report of the import is the job result
+type importJob struct {
+	handler *companyHandler
+}
+
+func NewImportJob(companyService service.CompanyService, fieldPolicy fieldpolicy.Policy) jobs.Handler {
+	return &importJob{
+		handler: &companyHandler{
+			service:     companyService,
+			fieldPolicy: fieldPolicy,
+		},
+	}
+}
+
+func (importJob *importJob) ContentType(job models.Job) string {
+	return importReportContentType
+}
+
+// Run an input that can not be read to the end is reported like in the response of a request, the job only
+// fails when the report can not be written or the rows can not be read at all
+func (importJob *importJob) Run(ctx context.Context, job models.Job, input io.Reader, output io.Writer, progress *jobs.Progress) error {
+	var params models.ImportJobParams
+	err := json.Unmarshal(job.Params, &params)
+	if err != nil {
+		return err
+	}
+	reader, err := importer.NewReader(params.Format, input, params.Mapping)
+	if err != nil {
+		return errors.Join(ErrInvalidImport, err)
+	}
+
+	report := newImportReport(output, params.DryRun, progress)
+	query := models.ImportQuery{
+		DryRun: params.DryRun,
+		Force:  params.Force,
+	}
+	err = importJob.handler.importRows(ctx, reader, query, job.Principal(), report)
+	if ctx.Err() != nil || report.err != nil {
+		return errors.Join(ctx.Err(), report.err)
+	}
+	errorCode := 0
+	if err != nil {
+		errorCode = ErrCodeImportAborted
+		log.Error().
+			Err(errors.Join(ErrImportAborted, err)).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errorCode).
+			Str(consts.LogKeyJobId, job.ID.String()).
+			Msg("error while trying to read the import")
+	}
+	report.close(errorCode)
+	return report.err
+}
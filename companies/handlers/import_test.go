@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCompanies(t *testing.T) {
+	companyId := uuid.New()
+
+	type importOutput struct {
+		DryRun    bool                 `json:"dry_run"`
+		Rows      []models.ImportRow   `json:"rows"`
+		Summary   models.ImportSummary `json:"summary"`
+		ErrorCode int                  `json:"error_code"`
+	}
+
+	testCases := []struct {
+		name               string
+		url                string
+		contentType        string
+		requestBody        string
+		expectedStatusCode int
+		expectedOutput     *importOutput
+		stubMocks          func(s *mocks.CompanyService)
+	}{
+		{
+			name:        "csv import",
+			url:         "/v1/companies/import?mapping[Company%20Name]=name",
+			contentType: "text/csv",
+			requestBody: "Company Name,number_of_employees,registered,type,description\n" +
+				"acme,10,true,Corporations,\n" +
+				"acme,20,true,Corporations,\n" +
+				"existing,5,false,NonProfit,\n" +
+				"invalid,5,false,Unknown,\n" +
+				"xss,5,false,NonProfit,<script>alert('secret')</script>\n",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput: &importOutput{
+				Rows: []models.ImportRow{
+					{Row: 1, Status: models.ImportRowAccepted, CompanyID: &companyId},
+					{Row: 2, Status: models.ImportRowDuplicateName, ErrorCode: ErrCodeDuplicateName},
+					{Row: 3, Status: models.ImportRowDuplicateName, ErrorCode: ErrCodeDuplicateName},
+					{Row: 4, Status: models.ImportRowRejected, ErrorCode: ErrCodeInvalidInput, Errors: []models.FieldError{{Field: "type", Rule: "oneof"}}},
+					{Row: 5, Status: models.ImportRowRejected, ErrorCode: ErrCodeInvalidInput},
+				},
+				Summary: models.ImportSummary{Accepted: 1, Rejected: 2, DuplicateName: 2},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, mock.AnythingOfType("string"), (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "acme" }), false, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{ID: companyId, Name: "acme"}, nil).Once()
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "existing" }), false, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, service.ErrDuplicateName).Once()
+			},
+		},
+		{
+			name:        "ndjson dry run",
+			url:         "/v1/companies/import?dry_run=true",
+			contentType: "application/x-ndjson",
+			requestBody: `{"name": "acme", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n" +
+				`{"name": "orphan", "number_of_employees": 10, "registered": true, "type": "Corporations", "parent_id": "` + uuid.NewString() + `"}` + "\n" +
+				`{"name": "acme"` + "\n",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput: &importOutput{
+				DryRun: true,
+				Rows: []models.ImportRow{
+					{Row: 1, Status: models.ImportRowAccepted},
+					{Row: 2, Status: models.ImportRowRejected, ErrorCode: ErrCodeParentNotFound},
+					{Row: 3, Status: models.ImportRowRejected, ErrorCode: ErrCodeInvalidInput},
+				},
+				Summary: models.ImportSummary{Accepted: 1, Rejected: 2},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, mock.AnythingOfType("string"), (*uuid.UUID)(nil)).
+					Return(nil, nil)
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "acme" }), true, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{Name: "acme"}, nil).Once()
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "orphan" }), true, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, service.ErrParentNotFound).Once()
+			},
+		},
+		{
+			name:        "the earlier rows are compared on the canonical name",
+			url:         "/v1/companies/import",
+			contentType: "application/x-ndjson",
+			requestBody: `{"name": "Acme", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n" +
+				`{"name": "ＡＣＭＥ", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput: &importOutput{
+				Rows: []models.ImportRow{
+					{Row: 1, Status: models.ImportRowAccepted, CompanyID: &companyId},
+					{Row: 2, Status: models.ImportRowDuplicateName, ErrorCode: ErrCodeDuplicateName},
+				},
+				Summary: models.ImportSummary{Accepted: 1, DuplicateName: 1},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "Acme", (*uuid.UUID)(nil)).
+					Return(nil, nil).Once()
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "Acme" }), false, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{ID: companyId, Name: "Acme"}, nil).Once()
+			},
+		},
+		{
+			name:               "name taken by another request since the row was checked",
+			url:                "/v1/companies/import",
+			contentType:        "application/x-ndjson",
+			requestBody:        `{"name": "acme", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput: &importOutput{
+				Rows: []models.ImportRow{
+					{Row: 1, Status: models.ImportRowDuplicateName, ErrorCode: ErrCodeNameTaken},
+				},
+				Summary: models.ImportSummary{DuplicateName: 1},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "acme", (*uuid.UUID)(nil)).
+					Return(nil, nil).Once()
+				s.On("ImportCompany", mock.Anything, mock.AnythingOfType("models.CompanyInput"), false, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, errors.Join(repo.ErrNameTaken, assert.AnError)).Once()
+			},
+		},
+		{
+			name:        "possible duplicates",
+			url:         "/v1/companies/import",
+			contentType: "application/x-ndjson",
+			requestBody: `{"name": "Acme Ltd", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n" +
+				`{"name": "Globex", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput: &importOutput{
+				Rows: []models.ImportRow{
+					{Row: 1, Status: models.ImportRowDuplicateName, ErrorCode: ErrCodePossibleDuplicates, Candidates: []models.DuplicateCandidate{{ID: companyId, Name: "ACME Limited", Score: 1, Exact: true}}},
+					{Row: 2, Status: models.ImportRowAccepted, CompanyID: &companyId},
+				},
+				Summary: models.ImportSummary{Accepted: 1, DuplicateName: 1},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("FindDuplicates", mock.Anything, "Acme Ltd", (*uuid.UUID)(nil)).
+					Return([]models.DuplicateCandidate{{ID: companyId, Name: "ACME Limited", Score: 1, Exact: true}}, nil).Once()
+				s.On("FindDuplicates", mock.Anything, "Globex", (*uuid.UUID)(nil)).
+					Return(nil, nil).Once()
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "Globex" }), false, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{ID: companyId, Name: "Globex"}, nil).Once()
+			},
+		},
+		{
+			name:               "force skips the possible duplicates",
+			url:                "/v1/companies/import?force=true",
+			contentType:        "application/x-ndjson",
+			requestBody:        `{"name": "Acme Ltd", "number_of_employees": 10, "registered": true, "type": "Corporations"}` + "\n",
+			expectedStatusCode: http.StatusOK,
+			expectedOutput: &importOutput{
+				Rows: []models.ImportRow{
+					{Row: 1, Status: models.ImportRowAccepted, CompanyID: &companyId},
+				},
+				Summary: models.ImportSummary{Accepted: 1},
+			},
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ImportCompany", mock.Anything, mock.MatchedBy(func(input models.CompanyInput) bool { return input.Name == "Acme Ltd" }), false, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{ID: companyId, Name: "Acme Ltd"}, nil).Once()
+			},
+		},
+		{
+			name:               "unknown csv field",
+			url:                "/v1/companies/import",
+			contentType:        "text/csv",
+			requestBody:        "name,revenue\nacme,100\n",
+			expectedStatusCode: http.StatusBadRequest,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "unsupported format",
+			url:                "/v1/companies/import",
+			contentType:        "application/json",
+			requestBody:        `[{"name": "acme"}]`,
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "invalid dry run",
+			url:                "/v1/companies/import?dry_run=maybe",
+			contentType:        "text/csv",
+			requestBody:        "name\nacme\n",
+			expectedStatusCode: http.StatusBadRequest,
+			stubMocks:          func(s *mocks.CompanyService) {},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.POST("/v1/companies/import", handler.ImportCompanies)
+
+			buf := bytes.NewBuffer([]byte(testCase.requestBody))
+
+			req, _ := http.NewRequest(http.MethodPost, testCase.url, buf)
+			req.Header.Set("content-type", testCase.contentType)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedOutput != nil {
+				var output importOutput
+				err := json.Unmarshal(rr.Body.Bytes(), &output)
+				require.NoError(t, err)
+				assert.Equal(t, *testCase.expectedOutput, output)
+			}
+		})
+	}
+}
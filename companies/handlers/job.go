@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type JobHandler interface {
+	GetJob(c *gin.Context)
+	CancelJob(c *gin.Context)
+	RetryJob(c *gin.Context)
+	GetJobResult(c *gin.Context)
+}
+
+type jobHandler struct {
+	service service.JobService
+}
+
+func NewJobHandler(jobService service.JobService) JobHandler {
+	return &jobHandler{
+		service: jobService,
+	}
+}
+
+func (handler *jobHandler) GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	jobId, ok := parseJobId(c)
+	if !ok {
+		return
+	}
+
+	job, err := handler.service.GetJob(ctx, jobId, principal(c))
+	if err != nil {
+		statusCode, errOutput := jobError(err, ErrCodeGetJob)
+		err = errors.Join(ErrGetJob, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyJobId, jobId.String()).
+			Msg("error while trying to get job")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyJobId, jobId.String()).
+		Msg("get job executed successfully")
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob cancels a queued job, a running job is returned with cancel_requested and is canceled by its worker
+func (handler *jobHandler) CancelJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	jobId, ok := parseJobId(c)
+	if !ok {
+		return
+	}
+
+	job, err := handler.service.CancelJob(ctx, jobId, principal(c))
+	if err != nil {
+		statusCode, errOutput := jobError(err, ErrCodeCancelJob)
+		err = errors.Join(ErrCancelJob, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyJobId, jobId.String()).
+			Msg("error while trying to cancel job")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if job.Status == models.JobStatusRunning {
+		statusCode = http.StatusAccepted
+	}
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, statusCode).
+		Str(consts.LogKeyJobId, jobId.String()).
+		Msg("cancel job executed successfully")
+	c.JSON(statusCode, job)
+}
+
+// RetryJob queues a failed or canceled job again
+func (handler *jobHandler) RetryJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	jobId, ok := parseJobId(c)
+	if !ok {
+		return
+	}
+
+	job, err := handler.service.RetryJob(ctx, jobId, principal(c))
+	if err != nil {
+		statusCode, errOutput := jobError(err, ErrCodeRetryJob)
+		err = errors.Join(ErrRetryJob, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyJobId, jobId.String()).
+			Msg("error while trying to retry job")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusAccepted).
+		Str(consts.LogKeyJobId, jobId.String()).
+		Msg("retry job executed successfully")
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJobResult streams the result of a succeeded job
+func (handler *jobHandler) GetJobResult(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	jobId, ok := parseJobId(c)
+	if !ok {
+		return
+	}
+
+	job, content, err := handler.service.GetJobResult(ctx, jobId, principal(c))
+	if err != nil {
+		statusCode, errOutput := jobError(err, ErrCodeGetJobResult)
+		err = errors.Join(ErrGetJobResult, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyJobId, jobId.String()).
+			Msg("error while trying to get job result")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+	defer content.Close()
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyJobId, jobId.String()).
+		Msg("get job result executed successfully")
+	headers := map[string]string{
+		"Content-Disposition":    fmt.Sprintf(`attachment; filename="%s"`, job.ID),
+		"X-Content-Type-Options": "nosniff",
+	}
+	c.DataFromReader(http.StatusOK, -1, job.ResultContentType, content, headers)
+}
+
+// createJob queues a job for the request and returns it with its location
+func (handler *companyHandler) createJob(c *gin.Context, jobType string, params any, input *models.JobInput) {
+	ctx := c.Request.Context()
+
+	job, err := handler.jobService.CreateJob(ctx, jobType, params, input, principal(c))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeCreateJob,
+		}
+		err = errors.Join(ErrCreateJob, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Str(consts.LogKeyJobType, jobType).
+			Msg("error while trying to create job")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusAccepted).
+		Str(consts.LogKeyJobId, job.ID.String()).
+		Str(consts.LogKeyJobType, jobType).
+		Msg("create job executed successfully")
+	c.Header("Location", "/v1/jobs/"+job.ID.String())
+	c.JSON(http.StatusAccepted, job)
+}
+
+// jobError maps the errors of the job service, the error code is the one of the endpoint for the other errors
+func jobError(err error, errorCode int) (int, models.ErrorOutput) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return http.StatusNotFound, models.ErrorOutput{ErrorCode: errorCode}
+	case errors.Is(err, service.ErrNotJobOwner):
+		return http.StatusForbidden, models.ErrorOutput{ErrorCode: ErrCodeNotOwner}
+	case errors.Is(err, repo.ErrJobNotCancelable):
+		return http.StatusConflict, models.ErrorOutput{ErrorCode: ErrCodeJobNotCancelable}
+	case errors.Is(err, repo.ErrJobNotRetryable):
+		return http.StatusConflict, models.ErrorOutput{ErrorCode: ErrCodeJobNotRetryable}
+	case errors.Is(err, service.ErrJobResultNotReady):
+		return http.StatusConflict, models.ErrorOutput{ErrorCode: ErrCodeJobResultNotReady}
+	}
+	return http.StatusInternalServerError, models.ErrorOutput{ErrorCode: errorCode}
+}
+
+func parseJobId(c *gin.Context) (uuid.UUID, bool) {
+	jobIdParam := c.Param("jobId")
+	jobId, err := uuid.Parse(jobIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyJobId, jobIdParam).
+			Msg("error while trying to parse jobId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return uuid.Nil, false
+	}
+	return jobId, true
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestGetJob(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name               string
+		jobId              string
+		expectedStatusCode int
+		expectedErrorCode  int
+		stubMocks          func(s *mocks.JobService)
+	}{
+		{
+			name:               "get job",
+			jobId:              jobId.String(),
+			expectedStatusCode: http.StatusOK,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("GetJob", mock.Anything, jobId, models.Principal{Username: "alice"}).
+					Return(models.Job{ID: jobId, Status: models.JobStatusRunning, Progress: 10}, nil)
+			},
+		},
+		{
+			name:               "invalid id",
+			jobId:              "invalid",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  ErrCodeInvalidId,
+			stubMocks:          func(s *mocks.JobService) {},
+		},
+		{
+			name:               "not found",
+			jobId:              jobId.String(),
+			expectedStatusCode: http.StatusNotFound,
+			expectedErrorCode:  ErrCodeGetJob,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("GetJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{}, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name:               "not the owner",
+			jobId:              jobId.String(),
+			expectedStatusCode: http.StatusForbidden,
+			expectedErrorCode:  ErrCodeNotOwner,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("GetJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{}, service.ErrNotJobOwner)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewJobService(t)
+
+			handler := NewJobHandler(s)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/jobs/:jobId", func(c *gin.Context) {
+				c.Set("username", "alice")
+				handler.GetJob(c)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/jobs/"+testCase.jobId, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedErrorCode != 0 {
+				var errOutput models.ErrorOutput
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errOutput))
+				assert.Equal(t, testCase.expectedErrorCode, errOutput.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestCancelAndRetryJob(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name               string
+		path               string
+		expectedStatusCode int
+		expectedErrorCode  int
+		stubMocks          func(s *mocks.JobService)
+	}{
+		{
+			name:               "cancel a queued job",
+			path:               "/cancel",
+			expectedStatusCode: http.StatusOK,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("CancelJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusCanceled}, nil)
+			},
+		},
+		{
+			name:               "cancel a running job",
+			path:               "/cancel",
+			expectedStatusCode: http.StatusAccepted,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("CancelJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusRunning, CancelRequested: true}, nil)
+			},
+		},
+		{
+			name:               "cancel a finished job",
+			path:               "/cancel",
+			expectedStatusCode: http.StatusConflict,
+			expectedErrorCode:  ErrCodeJobNotCancelable,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("CancelJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{}, repo.ErrJobNotCancelable)
+			},
+		},
+		{
+			name:               "retry a failed job",
+			path:               "/retry",
+			expectedStatusCode: http.StatusAccepted,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("RetryJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusQueued}, nil)
+			},
+		},
+		{
+			name:               "retry a succeeded job",
+			path:               "/retry",
+			expectedStatusCode: http.StatusConflict,
+			expectedErrorCode:  ErrCodeJobNotRetryable,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("RetryJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{}, repo.ErrJobNotRetryable)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewJobService(t)
+
+			handler := NewJobHandler(s)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.POST("/v1/jobs/:jobId/cancel", handler.CancelJob)
+			router.POST("/v1/jobs/:jobId/retry", handler.RetryJob)
+
+			req, _ := http.NewRequest(http.MethodPost, "/v1/jobs/"+jobId.String()+testCase.path, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedErrorCode != 0 {
+				var errOutput models.ErrorOutput
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errOutput))
+				assert.Equal(t, testCase.expectedErrorCode, errOutput.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestGetJobResult(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+		stubMocks            func(s *mocks.JobService)
+	}{
+		{
+			name:                 "result of a succeeded job",
+			expectedStatusCode:   http.StatusOK,
+			expectedContentType:  "text/csv",
+			expectedResponseBody: "name\nacme\n",
+			stubMocks: func(s *mocks.JobService) {
+				s.On("GetJobResult", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{ID: jobId, ResultContentType: "text/csv"}, io.NopCloser(strings.NewReader("name\nacme\n")), nil)
+			},
+		},
+		{
+			name:               "the job is still running",
+			expectedStatusCode: http.StatusConflict,
+			stubMocks: func(s *mocks.JobService) {
+				s.On("GetJobResult", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{}, nil, service.ErrJobResultNotReady)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewJobService(t)
+
+			handler := NewJobHandler(s)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/jobs/:jobId/result", handler.GetJobResult)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/jobs/"+jobId.String()+"/result", nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedContentType != "" {
+				assert.Equal(t, testCase.expectedContentType, rr.Header().Get("Content-Type"))
+			}
+			if testCase.expectedResponseBody != "" {
+				assert.Equal(t, testCase.expectedResponseBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAsyncImportAndExport(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name               string
+		method             string
+		path               string
+		contentType        string
+		expectedStatusCode int
+		stubMocks          func(j *mocks.JobService)
+	}{
+		{
+			name:               "import job",
+			method:             http.MethodPost,
+			path:               "/v1/companies/import?async=true&dry_run=true&mapping[Company]=name",
+			contentType:        "text/csv",
+			expectedStatusCode: http.StatusAccepted,
+			stubMocks: func(j *mocks.JobService) {
+				params := models.ImportJobParams{Format: "text/csv", Mapping: map[string]string{"Company": "name"}, DryRun: true}
+				j.On("CreateJob", mock.Anything, models.JobTypeCompaniesImport, params, mock.MatchedBy(func(input *models.JobInput) bool {
+					content, _ := io.ReadAll(input.Content)
+					return string(content) == "Company\nacme\n" && input.ContentType == "text/csv"
+				}), models.Principal{Username: "alice"}).
+					Return(models.Job{ID: jobId, Status: models.JobStatusQueued}, nil)
+			},
+		},
+		{
+			name:               "import job of an unsupported format",
+			method:             http.MethodPost,
+			path:               "/v1/companies/import?async=true",
+			contentType:        "application/json",
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+			stubMocks:          func(j *mocks.JobService) {},
+		},
+		{
+			name:               "export job in csv by default",
+			method:             http.MethodGet,
+			path:               "/v1/companies/export?async=true&type=NonProfit&columns=name",
+			expectedStatusCode: http.StatusAccepted,
+			stubMocks: func(j *mocks.JobService) {
+				params := models.ExportJobParams{Query: models.CompanyQuery{Type: "NonProfit"}, Format: "csv", Columns: "name"}
+				j.On("CreateJob", mock.Anything, models.JobTypeCompaniesExport, params, (*models.JobInput)(nil), models.Principal{Username: "alice"}).
+					Return(models.Job{ID: jobId, Status: models.JobStatusQueued}, nil)
+			},
+		},
+		{
+			name:               "the job can not be created",
+			method:             http.MethodGet,
+			path:               "/v1/companies/export?async=true",
+			expectedStatusCode: http.StatusInternalServerError,
+			stubMocks: func(j *mocks.JobService) {
+				j.On("CreateJob", mock.Anything, models.JobTypeCompaniesExport, mock.Anything, mock.Anything, mock.Anything).
+					Return(models.Job{}, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+			j := mocks.NewJobService(t)
+
+			handler := NewCompanyHandler(s, j, fieldpolicy.Policy{})
+
+			testCase.stubMocks(j)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "alice")
+			})
+			router.POST("/v1/companies/import", handler.ImportCompanies)
+			router.GET("/v1/companies/export", handler.ExportCompanies)
+
+			req, _ := http.NewRequest(testCase.method, testCase.path, strings.NewReader("Company\nacme\n"))
+			if testCase.contentType != "" {
+				req.Header.Set("Content-Type", testCase.contentType)
+			}
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedStatusCode == http.StatusAccepted {
+				assert.Equal(t, "/v1/jobs/"+jobId.String(), rr.Header().Get("Location"))
+			}
+		})
+	}
+}
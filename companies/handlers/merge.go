@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (handler *companyHandler) MergeCompanies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	var mergeInput models.MergeInput
+	err = c.ShouldBindJSON(&mergeInput)
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeInvalidInput,
+		Errors:    fieldErrors(err),
+	}
+	if err == nil {
+		errOutput.Errors = mergeInput.ConcatErrors()
+		if len(errOutput.Errors) > 0 {
+			err = ErrInvalidInput
+		}
+	}
+	if err != nil {
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to bind JSON input")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	user := principal(c)
+	unwritableFields := handler.fieldPolicy.Unwritable(mergeInput.ChangedFields(), user.Scopes)
+	if len(unwritableFields) > 0 {
+		errOutput := fieldsNotWritableError(unwritableFields)
+		log.Error().
+			Err(ErrFieldsNotWritable).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusForbidden).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Strs(consts.LogKeyFields, unwritableFields).
+			Msg("error while checking the writable fields")
+		c.JSON(http.StatusForbidden, errOutput)
+		return
+	}
+
+	companyOutput, err := handler.service.MergeCompanies(ctx, companyId, mergeInput, user)
+	if err != nil {
+		statusCode, errOutput := mergeCompaniesError(err)
+		err = errors.Join(ErrMergeCompanies, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Str(consts.LogKeySourceCompanyId, mergeInput.SourceID.String()).
+			Msg("error while trying to merge companies")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Str(consts.LogKeySourceCompanyId, mergeInput.SourceID.String()).
+		Msg("merge companies executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+// mergeCompaniesError maps the errors of merging companies to their status and error codes
+func mergeCompaniesError(err error) (int, models.ErrorOutput) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeMergeCompanies,
+	}
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrMergeSameCompany), errors.Is(err, service.ErrMergeTooLarge):
+		errOutput.ErrorCode = ErrCodeInvalidMerge
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrMergeSourceNotFound):
+		errOutput.ErrorCode = ErrCodeMergeSourceNotFound
+		statusCode = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrHierarchyCycle):
+		errOutput.ErrorCode = ErrCodeHierarchyCycle
+		statusCode = http.StatusConflict
+	case errors.Is(err, repo.ErrVersionConflict):
+		errOutput.ErrorCode = ErrCodeVersionConflict
+		statusCode = http.StatusConflict
+	case errors.Is(err, service.ErrNotOwner):
+		errOutput.ErrorCode = ErrCodeNotOwner
+		statusCode = http.StatusForbidden
+	case errors.Is(err, mongo.ErrNoDocuments):
+		statusCode = http.StatusNotFound
+	}
+	return statusCode, errOutput
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMergeCompanies(t *testing.T) {
+	targetId := uuid.New()
+	sourceId := uuid.New()
+	input := models.MergeInput{
+		SourceID: sourceId,
+		Fields:   map[string]string{"name": models.MergeStrategySource, "tags": models.MergeStrategyConcat},
+	}
+
+	testCases := []struct {
+		name                 string
+		requestBody          string
+		fieldPolicy          fieldpolicy.Policy
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "success test case",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s", "fields": {"name": "source", "tags": "concat"}}`, sourceId),
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name": "Acme Ltd",
+				"description": "",
+				"number_of_employees": 10,
+				"registered": true,
+				"type": "Corporations",
+				"tags": ["vip", "prospect"]
+			}`, targetId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("MergeCompanies", mock.Anything, targetId, input, models.Principal{Username: "alice"}).
+					Return(models.CompanyOutput{
+						ID:                targetId,
+						Name:              "Acme Ltd",
+						NumberOfEmployees: 10,
+						Registered:        true,
+						Type:              "Corporations",
+						Tags:              []string{"vip", "prospect"},
+					}, nil)
+			},
+		},
+		{
+			name:               "source_id is required",
+			requestBody:        `{"fields": {"name": "source"}}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "source_id", "rule": "required"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "unknown field",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s", "fields": {"version": "source"}}`, sourceId),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "fields[version]", "rule": "oneof"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "the field can not be concatenated",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s", "fields": {"website": "concat"}}`, sourceId),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "fields[website]", "rule": "concat"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:        "the user can not write a field taken from the source",
+			requestBody: fmt.Sprintf(`{"source_id": "%s", "fields": {"registered": "source", "name": "target"}}`, sourceId),
+			fieldPolicy: fieldpolicy.Policy{
+				Fields: map[string]fieldpolicy.Rule{
+					"registered": {Write: []string{"finance"}},
+				},
+			},
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "registered", "rule": "write_scope"}]
+			}`, ErrCodeFieldsNotWritable),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "a company can not be merged into itself",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s"}`, targetId),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeInvalidMerge),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("MergeCompanies", mock.Anything, targetId, models.MergeInput{SourceID: targetId}, mock.Anything).
+					Return(models.CompanyOutput{}, service.ErrMergeSameCompany)
+			},
+		},
+		{
+			name:               "source not found",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s"}`, sourceId),
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeMergeSourceNotFound),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("MergeCompanies", mock.Anything, targetId, mock.Anything, mock.Anything).
+					Return(models.CompanyOutput{}, errors.Join(service.ErrMergeSourceNotFound, mongo.ErrNoDocuments))
+			},
+		},
+		{
+			name:               "target not found",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s"}`, sourceId),
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeMergeCompanies),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("MergeCompanies", mock.Anything, targetId, mock.Anything, mock.Anything).
+					Return(models.CompanyOutput{}, errors.Join(assert.AnError, mongo.ErrNoDocuments))
+			},
+		},
+		{
+			name:               "version conflict",
+			requestBody:        fmt.Sprintf(`{"source_id": "%s"}`, sourceId),
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeVersionConflict),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("MergeCompanies", mock.Anything, targetId, mock.Anything, mock.Anything).
+					Return(models.CompanyOutput{}, repo.ErrVersionConflict)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, testCase.fieldPolicy)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "alice")
+			})
+			router.POST("/v1/company/:id/merge", handler.MergeCompanies)
+
+			buf := bytes.NewBuffer([]byte(testCase.requestBody))
+
+			url := fmt.Sprintf("/v1/company/%s/merge", targetId)
+			req, _ := http.NewRequest(http.MethodPost, url, buf)
+			req.Header.Set("content-type", "application/json")
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/middleware"
+	"companies/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type QuotaHandler interface {
+	GetQuota(c *gin.Context)
+}
+
+type quotaHandler struct {
+	reporter middleware.QuotaReporter
+}
+
+func NewQuotaHandler(reporter middleware.QuotaReporter) QuotaHandler {
+	return &quotaHandler{
+		reporter: reporter,
+	}
+}
+
+// GetQuota the requests the user has left today on every route group, the request itself is already counted
+func (handler *quotaHandler) GetQuota(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	quotas, err := handler.reporter.Quotas(ctx, middleware.Identity(c), principal(c).Scopes)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeGetQuota,
+		}
+		err = errors.Join(ErrGetQuota, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while reading the quotas")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("quotas read successfully")
+	c.JSON(http.StatusOK, models.QuotaOutput{Quotas: quotas})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"companies/mocks"
+	"companies/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetQuota(t *testing.T) {
+	reset := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name                 string
+		username             string
+		scopes               []string
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(r *mocks.QuotaReporter)
+	}{
+		{
+			name:               "success test case",
+			username:           "alice",
+			scopes:             []string{"companies:admin"},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: `{
+				"quotas": [
+					{"group": "api", "tier": "standard", "rate": 5, "burst": 10, "unlimited": false, "daily_limit": 10000, "daily_remaining": 9990, "daily_reset": "2025-01-02T00:00:00Z"},
+					{"group": "bulk", "tier": "premium", "rate": 1, "burst": 5, "unlimited": true, "daily_limit": 0, "daily_remaining": 0}
+				]
+			}`,
+			stubMocks: func(r *mocks.QuotaReporter) {
+				r.On("Quotas", mock.Anything, "user:alice", []string{"companies:admin"}).
+					Return([]models.Quota{
+						{Group: "api", Tier: "standard", Rate: 5, Burst: 10, DailyLimit: 10_000, DailyRemaining: 9_990, DailyReset: &reset},
+						{Group: "bulk", Tier: "premium", Rate: 1, Burst: 5, Unlimited: true},
+					}, nil)
+			},
+		},
+		{
+			name:               "reporter returns an error",
+			username:           "alice",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeGetQuota),
+			stubMocks: func(r *mocks.QuotaReporter) {
+				r.On("Quotas", mock.Anything, "user:alice", []string(nil)).
+					Return(nil, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewQuotaReporter(t)
+
+			handler := NewQuotaHandler(r)
+
+			testCase.stubMocks(r)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/quota", func(c *gin.Context) {
+				c.Set("username", testCase.username)
+				c.Set("scopes", testCase.scopes)
+			}, handler.GetQuota)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/quota", nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/search"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// searchHitOutput a search hit without the company fields the user can not read
+type searchHitOutput struct {
+	Company    any                 `json:"company"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+// SearchCompanies only the fields the user can read are searched, a hidden field could be probed otherwise
+func (handler *companyHandler) SearchCompanies(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.SearchQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Msg("error while trying to bind the query")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	scopes := principal(c).Scopes
+	fields := []string{}
+	for _, field := range search.Fields {
+		if handler.fieldPolicy.CanRead(field, scopes) {
+			fields = append(fields, field)
+		}
+	}
+
+	hits, err := handler.service.SearchCompanies(ctx, query, fields)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeSearchCompanies,
+		}
+		err = errors.Join(ErrSearchCompanies, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while trying to search companies")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	outputs := make([]searchHitOutput, 0, len(hits))
+	for _, hit := range hits {
+		company, err := handler.fieldPolicy.Filter(hit.Company, scopes)
+		if err != nil {
+			errOutput := models.ErrorOutput{
+				ErrorCode: ErrCodeFilterFields,
+			}
+			err = errors.Join(ErrFilterFields, err)
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+				Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+				Msg("error while filtering the unreadable fields")
+			c.JSON(http.StatusInternalServerError, errOutput)
+			return
+		}
+		outputs = append(outputs, searchHitOutput{
+			Company:    company,
+			Score:      hit.Score,
+			Highlights: hit.Highlights,
+		})
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("search companies executed successfully")
+	c.JSON(http.StatusOK, outputs)
+}
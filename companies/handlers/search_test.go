@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchCompanies(t *testing.T) {
+	companyId := uuid.New()
+	policy := fieldpolicy.Policy{
+		Fields: map[string]fieldpolicy.Rule{
+			"description": {Read: []string{"companies:internal"}},
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		url                  string
+		scopes               []string
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "search",
+			url:                "/v1/companies/search?q=acme&limit=5",
+			scopes:             []string{"companies:internal"},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`[{
+				"company": {"id": "%s", "name": "Acme", "description": "Rockets", "number_of_employees": 0, "registered": false, "type": ""},
+				"score": 1.5,
+				"highlights": {"name": ["<mark>Acme</mark>"]}
+			}]`, companyId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("SearchCompanies", mock.Anything, models.SearchQuery{Q: "acme", Limit: 5}, []string{"name", "description"}).
+					Return([]models.CompanySearchHit{{
+						Company:    models.CompanyOutput{ID: companyId, Name: "Acme", Description: "Rockets"},
+						Score:      1.5,
+						Highlights: map[string][]string{"name": {"<mark>Acme</mark>"}},
+					}}, nil)
+			},
+		},
+		{
+			name:               "the unreadable fields are not searched",
+			url:                "/v1/companies/search?q=acme",
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`[{
+				"company": {"id": "%s", "name": "Acme", "number_of_employees": 0, "registered": false, "type": ""},
+				"score": 1.5
+			}]`, companyId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("SearchCompanies", mock.Anything, models.SearchQuery{Q: "acme"}, []string{"name"}).
+					Return([]models.CompanySearchHit{{
+						Company: models.CompanyOutput{ID: companyId, Name: "Acme", Description: "Rockets"},
+						Score:   1.5,
+					}}, nil)
+			},
+		},
+		{
+			name:                 "missing text",
+			url:                  "/v1/companies/search",
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{"error_code": %d, "errors": [{"field": "q", "rule": "required"}]}`, ErrCodeInvalidInput),
+			stubMocks:            func(s *mocks.CompanyService) {},
+		},
+		{
+			name:                 "service error",
+			url:                  "/v1/companies/search?q=acme",
+			expectedStatusCode:   http.StatusInternalServerError,
+			expectedResponseBody: fmt.Sprintf(`{"error_code": %d}`, ErrCodeSearchCompanies),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("SearchCompanies", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, policy)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/companies/search", func(c *gin.Context) {
+				c.Set("scopes", testCase.scopes)
+			}, handler.SearchCompanies)
+
+			req, _ := http.NewRequest(http.MethodGet, testCase.url, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// CompanyStats the counts of the fields the user can not read are not returned
+func (handler *companyHandler) CompanyStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.CompanyQuery
+	if !bindStatsQuery(c, &query) || !handler.checkFilter(c, query) {
+		return
+	}
+
+	stats, err := handler.service.CompanyStats(ctx, query)
+	if err != nil {
+		companyStatsError(c, err)
+		return
+	}
+	scopes := principal(c).Scopes
+	if !handler.fieldPolicy.CanRead("type", scopes) {
+		stats.ByType = nil
+	}
+	if !handler.fieldPolicy.CanRead("registered", scopes) {
+		stats.ByRegistered = nil
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("company stats executed successfully")
+	c.JSON(http.StatusOK, stats)
+}
+
+// EmployeeStats responds 403 when the user can not read the number of employees
+func (handler *companyHandler) EmployeeStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var query models.CompanyQuery
+	var statsQuery models.EmployeeStatsQuery
+	if !bindStatsQuery(c, &query) || !bindStatsQuery(c, &statsQuery) || !handler.checkFilter(c, query) {
+		return
+	}
+	if !handler.fieldPolicy.CanRead("number_of_employees", principal(c).Scopes) {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeFieldsNotReadable,
+			Errors:    []models.FieldError{{Field: "number_of_employees", Rule: "read_scope"}},
+		}
+		log.Error().
+			Err(ErrFieldsNotReadable).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusForbidden).
+			Msg("error while trying to compute the employee stats")
+		c.JSON(http.StatusForbidden, errOutput)
+		return
+	}
+
+	stats, err := handler.service.EmployeeStats(ctx, query, statsQuery)
+	if err != nil {
+		companyStatsError(c, err)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("employee stats executed successfully")
+	c.JSON(http.StatusOK, stats)
+}
+
+func bindStatsQuery(c *gin.Context, query any) bool {
+	err := c.ShouldBindQuery(query)
+	if err == nil {
+		return true
+	}
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeInvalidInput,
+		Errors:    fieldErrors(err),
+	}
+	err = errors.Join(ErrInvalidInput, err)
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+		Msg("error while trying to bind the query")
+	c.JSON(http.StatusBadRequest, errOutput)
+	return false
+}
+
+func companyStatsError(c *gin.Context, err error) {
+	errOutput := models.ErrorOutput{
+		ErrorCode: ErrCodeCompanyStats,
+	}
+	err = errors.Join(ErrCompanyStats, err)
+	log.Error().
+		Err(err).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+		Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+		Msg("error while trying to compute the company stats")
+	c.JSON(http.StatusInternalServerError, errOutput)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCompanyStats(t *testing.T) {
+	stats := models.CompanyStats{
+		Total:        3,
+		ByType:       map[string]int{"Cooperative": 2, "NonProfit": 1},
+		ByRegistered: map[string]int{"true": 1, "false": 2},
+	}
+
+	testCases := []struct {
+		name                 string
+		query                string
+		fieldPolicy          fieldpolicy.Policy
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "success test case",
+			query:              "status=active",
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: `{
+				"total": 3,
+				"by_type": {"Cooperative": 2, "NonProfit": 1},
+				"by_registered": {"true": 1, "false": 2}
+			}`,
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("CompanyStats", mock.Anything, models.CompanyQuery{Status: models.CompanyStatusActive}).
+					Return(stats, nil)
+			},
+		},
+		{
+			name:  "the counts of the unreadable fields are dropped",
+			query: "",
+			fieldPolicy: fieldpolicy.Policy{
+				Fields: map[string]fieldpolicy.Rule{
+					"registered": {Read: []string{"finance"}},
+				},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: `{
+				"total": 3,
+				"by_type": {"Cooperative": 2, "NonProfit": 1}
+			}`,
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("CompanyStats", mock.Anything, models.CompanyQuery{}).
+					Return(stats, nil)
+			},
+		},
+		{
+			name:               "invalid filter expression",
+			query:              "filter=type==LLC",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "filter", "rule": "value", "message": "type must be one of Corporations, NonProfit, Cooperative, Sole Proprietorship, got \"LLC\"", "column": 7}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "service returns an error",
+			query:              "",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeCompanyStats),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("CompanyStats", mock.Anything, models.CompanyQuery{}).
+					Return(models.CompanyStats{}, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, testCase.fieldPolicy)
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/companies/stats", handler.CompanyStats)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/companies/stats?"+testCase.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestEmployeeStats(t *testing.T) {
+	to := 10
+
+	testCases := []struct {
+		name                 string
+		query                string
+		scopes               []string
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "success test case",
+			query:              "type=Cooperative&buckets=10&percentiles=50&percentiles=99.9",
+			scopes:             []string{"companies:headcount"},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: `{
+				"count": 3,
+				"buckets": [{"from": 0, "to": 10, "count": 1}, {"from": 10, "count": 2}],
+				"percentiles": {"p50": 12, "p99.9": 40}
+			}`,
+			stubMocks: func(s *mocks.CompanyService) {
+				query := models.CompanyQuery{Type: "Cooperative"}
+				statsQuery := models.EmployeeStatsQuery{Buckets: []int{10}, Percentiles: []float64{50, 99.9}}
+				s.On("EmployeeStats", mock.Anything, query, statsQuery).
+					Return(models.EmployeeStats{
+						Count: 3,
+						Buckets: []models.EmployeeBucket{
+							{From: 0, To: &to, Count: 1},
+							{From: 10, Count: 2},
+						},
+						Percentiles: map[string]int{"p50": 12, "p99.9": 40},
+					}, nil)
+			},
+		},
+		{
+			name:               "the user can not read the number of employees",
+			query:              "",
+			scopes:             []string{"companies:read"},
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "number_of_employees", "rule": "read_scope"}]
+			}`, ErrCodeFieldsNotReadable),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "invalid percentile",
+			query:              "percentiles=0",
+			scopes:             []string{"companies:headcount"},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "percentiles[0]", "rule": "gt"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "service returns an error",
+			query:              "",
+			scopes:             []string{"companies:headcount"},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeCompanyStats),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("EmployeeStats", mock.Anything, models.CompanyQuery{}, models.EmployeeStatsQuery{}).
+					Return(models.EmployeeStats{}, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, testFieldPolicy())
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/companies/stats/employees", func(c *gin.Context) {
+				c.Set("scopes", testCase.scopes)
+			}, handler.EmployeeStats)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/companies/stats/employees?"+testCase.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
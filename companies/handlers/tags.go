@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (handler *companyHandler) AddTags(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	var tagsInput models.TagsInput
+	err = c.ShouldBindJSON(&tagsInput)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to bind JSON input")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	companyOutput, err := handler.service.AddTags(ctx, companyId, tagsInput.Tags, principal(c))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeAddTags,
+		}
+		err = errors.Join(ErrAddTags, err)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repo.ErrTooManyTags):
+			errOutput.ErrorCode = ErrCodeTooManyTags
+			statusCode = http.StatusUnprocessableEntity
+		case errors.Is(err, service.ErrNotOwner):
+			errOutput.ErrorCode = ErrCodeNotOwner
+			statusCode = http.StatusForbidden
+		case errors.Is(err, mongo.ErrNoDocuments):
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to add tags")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Msg("add tags executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+func (handler *companyHandler) RemoveTag(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	companyOutput, err := handler.service.RemoveTag(ctx, companyId, c.Param("tag"), principal(c))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeRemoveTag,
+		}
+		err = errors.Join(ErrRemoveTag, err)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrNotOwner):
+			errOutput.ErrorCode = ErrCodeNotOwner
+			statusCode = http.StatusForbidden
+		case errors.Is(err, mongo.ErrNoDocuments):
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to remove tag")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Msg("remove tag executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+func (handler *companyHandler) CountTags(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tagCounts, err := handler.service.CountTags(ctx)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeCountTags,
+		}
+		err = errors.Join(ErrCountTags, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusInternalServerError).
+			Msg("error while trying to count tags")
+		c.JSON(http.StatusInternalServerError, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Msg("count tags executed successfully")
+	c.JSON(http.StatusOK, tagCounts)
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddTags(t *testing.T) {
+	companyId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		companyId            string
+		requestBody          string
+		companyOutput        models.CompanyOutput
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService, companyOutput models.CompanyOutput)
+	}{
+		{
+			name:        "success test case",
+			companyId:   companyId.String(),
+			requestBody: `{"tags": ["VIP", "prospect"]}`,
+			companyOutput: models.CompanyOutput{
+				ID:   companyId,
+				Name: "company-name",
+				Type: "Corporations",
+				Tags: []string{"vip", "prospect"},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"company-name",
+				"description":"",
+				"number_of_employees": 0,
+				"registered": false,
+				"type": "Corporations",
+				"tags": ["vip", "prospect"]
+			}`, companyId),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("AddTags", mock.Anything, companyId, []string{"VIP", "prospect"}, mock.AnythingOfType("models.Principal")).
+					Return(companyOutput, nil)
+			},
+		},
+		{
+			name:               "invalid tag",
+			companyId:          companyId.String(),
+			requestBody:        `{"tags": ["not/valid"]}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "tags[0]", "rule": "tag"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+
+			},
+		},
+		{
+			name:               "tag limit reached",
+			companyId:          companyId.String(),
+			requestBody:        `{"tags": ["vip"]}`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeTooManyTags),
+			stubMocks: func(s *mocks.CompanyService, companyOutput models.CompanyOutput) {
+				s.On("AddTags", mock.Anything, companyId, []string{"vip"}, mock.AnythingOfType("models.Principal")).
+					Return(models.CompanyOutput{}, repo.ErrTooManyTags)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := new(mocks.CompanyService)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s, testCase.companyOutput)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.POST("/v1/company/:id/tags", handler.AddTags)
+
+			buf := bytes.NewBuffer([]byte(testCase.requestBody))
+
+			url := fmt.Sprintf("/v1/company/%s/tags", testCase.companyId)
+			req, _ := http.NewRequest(http.MethodPost, url, buf)
+			req.Header.Set("content-type", "application/json")
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestListCompanies(t *testing.T) {
+	companyId := uuid.New()
+
+	testCases := []struct {
+		name                 string
+		query                string
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "success test case",
+			query:              "tags=vip&tags=prospect&tags_match=all&limit=10",
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`[{
+				"id": "%s",
+				"name":"company-name",
+				"description":"",
+				"number_of_employees": 0,
+				"registered": false,
+				"type": "Corporations",
+				"tags": ["prospect", "vip"]
+			}]`, companyId),
+			stubMocks: func(s *mocks.CompanyService) {
+				query := models.CompanyQuery{
+					Tags:      []string{"vip", "prospect"},
+					TagsMatch: models.TagsMatchAll,
+					Limit:     10,
+				}
+				s.On("ListCompanies", mock.Anything, query).
+					Return([]models.CompanyOutput{{
+						ID:   companyId,
+						Name: "company-name",
+						Type: "Corporations",
+						Tags: []string{"prospect", "vip"},
+					}}, nil)
+			},
+		},
+		{
+			name:               "invalid tags match",
+			query:              "tags=vip&tags_match=some",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "tags_match", "rule": "oneof"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:                 "filter expression",
+			query:                "filter=" + url.QueryEscape("type==Cooperative;number_of_employees=gt=50"),
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: `[]`,
+			stubMocks: func(s *mocks.CompanyService) {
+				query := models.CompanyQuery{Filter: "type==Cooperative;number_of_employees=gt=50"}
+				s.On("ListCompanies", mock.Anything, query).
+					Return([]models.CompanyOutput{}, nil)
+			},
+		},
+		{
+			name:               "invalid filter expression",
+			query:              "filter=" + url.QueryEscape("type==Cooperative;number_of_employees=gt="),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "filter", "rule": "syntax", "message": "expected a value", "column": 42}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "invalid filter value",
+			query:              "filter=" + url.QueryEscape("registered==yes"),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "filter", "rule": "value", "message": "registered expects true or false, got \"yes\"", "column": 13}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {
+
+			},
+		},
+		{
+			name:               "service returns an error",
+			query:              "",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeListCompanies),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("ListCompanies", mock.Anything, models.CompanyQuery{}).
+					Return(nil, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := new(mocks.CompanyService)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s)
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.Default()
+			router.GET("/v1/companies", handler.ListCompanies)
+
+			req, _ := http.NewRequest(http.MethodGet, "/v1/companies?"+testCase.query, nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
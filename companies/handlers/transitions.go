@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"companies/service"
+	"companies/xss"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (handler *companyHandler) TransitionCompany(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	companyIdParam := c.Param("id")
+	companyId, err := uuid.Parse(companyIdParam)
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidId,
+		}
+		err = errors.Join(ErrInvalidId, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyIdParam).
+			Msg("error while trying to parse companyId")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	var transitionInput models.TransitionInput
+	err = c.ShouldBindJSON(&transitionInput)
+	if err == nil {
+		err = xss.CheckForXSS(transitionInput.Reason)
+	}
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeInvalidInput,
+			Errors:    fieldErrors(err),
+		}
+		err = errors.Join(ErrInvalidInput, err)
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, http.StatusBadRequest).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Msg("error while trying to bind JSON input")
+		c.JSON(http.StatusBadRequest, errOutput)
+		return
+	}
+
+	companyOutput, err := handler.service.TransitionCompany(ctx, companyId, transitionInput, principal(c))
+	if err != nil {
+		errOutput := models.ErrorOutput{
+			ErrorCode: ErrCodeTransitionCompany,
+		}
+		err = errors.Join(ErrTransitionCompany, err)
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrReasonRequired):
+			errOutput.ErrorCode = ErrCodeReasonRequired
+			statusCode = http.StatusUnprocessableEntity
+		case errors.Is(err, service.ErrTransitionForbidden):
+			errOutput.ErrorCode = ErrCodeTransitionForbidden
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrNotOwner):
+			errOutput.ErrorCode = ErrCodeNotOwner
+			statusCode = http.StatusForbidden
+		case errors.Is(err, service.ErrTransitionNotAllowed), errors.Is(err, repo.ErrStatusChanged):
+			errOutput.ErrorCode = ErrCodeTransitionNotAllowed
+			statusCode = http.StatusConflict
+		case errors.Is(err, mongo.ErrNoDocuments):
+			statusCode = http.StatusNotFound
+		}
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Int(consts.LogKeyErrorCode, errOutput.ErrorCode).
+			Int(consts.LogKeyStatusCode, statusCode).
+			Str(consts.LogKeyCompanyId, companyId.String()).
+			Str(consts.LogKeyTransition, transitionInput.Transition).
+			Msg("error while trying to apply the company transition")
+		c.JSON(statusCode, errOutput)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Int(consts.LogKeyStatusCode, http.StatusOK).
+		Str(consts.LogKeyCompanyId, companyId.String()).
+		Str(consts.LogKeyTransition, transitionInput.Transition).
+		Msg("company transition executed successfully")
+	handler.writeCompanies(c, http.StatusOK, companyOutput)
+}
+
+// principal returns the user that ValidateJWTToken authenticated
+func principal(c *gin.Context) models.Principal {
+	return models.Principal{
+		Username: c.GetString("username"),
+		Scopes:   c.GetStringSlice("scopes"),
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/fieldpolicy"
+	"companies/mocks"
+	"companies/models"
+	"companies/service"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTransitionCompany(t *testing.T) {
+	companyId := uuid.New()
+	principal := models.Principal{Username: "alice", Scopes: []string{"companies:write"}}
+
+	testCases := []struct {
+		name                 string
+		requestBody          string
+		expectedStatusCode   int
+		expectedResponseBody string
+		stubMocks            func(s *mocks.CompanyService)
+	}{
+		{
+			name:               "success test case",
+			requestBody:        `{"transition": "activate"}`,
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: fmt.Sprintf(`{
+				"id": "%s",
+				"name":"company-name",
+				"description":"",
+				"number_of_employees": 0,
+				"registered": false,
+				"type": "Corporations",
+				"status": "active"
+			}`, companyId),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("TransitionCompany", mock.Anything, companyId, models.TransitionInput{Transition: "activate"}, principal).
+					Return(models.CompanyOutput{
+						ID:     companyId,
+						Name:   "company-name",
+						Type:   "Corporations",
+						Status: models.CompanyStatusActive,
+					}, nil)
+			},
+		},
+		{
+			name:               "unknown transition",
+			requestBody:        `{"transition": "archive"}`,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d,
+				"errors": [{"field": "transition", "rule": "oneof"}]
+			}`, ErrCodeInvalidInput),
+			stubMocks: func(s *mocks.CompanyService) {},
+		},
+		{
+			name:               "reason is required",
+			requestBody:        `{"transition": "suspend"}`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeReasonRequired),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("TransitionCompany", mock.Anything, companyId, models.TransitionInput{Transition: "suspend"}, principal).
+					Return(models.CompanyOutput{}, service.ErrReasonRequired)
+			},
+		},
+		{
+			name:               "missing scope",
+			requestBody:        `{"transition": "dissolve", "reason": "liquidated"}`,
+			expectedStatusCode: http.StatusForbidden,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeTransitionForbidden),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("TransitionCompany", mock.Anything, companyId, models.TransitionInput{Transition: "dissolve", Reason: "liquidated"}, principal).
+					Return(models.CompanyOutput{}, service.ErrTransitionForbidden)
+			},
+		},
+		{
+			name:               "not allowed from the current status",
+			requestBody:        `{"transition": "reinstate"}`,
+			expectedStatusCode: http.StatusConflict,
+			expectedResponseBody: fmt.Sprintf(`{
+				"error_code": %d
+			}`, ErrCodeTransitionNotAllowed),
+			stubMocks: func(s *mocks.CompanyService) {
+				s.On("TransitionCompany", mock.Anything, companyId, models.TransitionInput{Transition: "reinstate"}, principal).
+					Return(models.CompanyOutput{}, service.ErrTransitionNotAllowed)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := mocks.NewCompanyService(t)
+
+			handler := NewCompanyHandler(s, nil, fieldpolicy.Policy{})
+
+			testCase.stubMocks(s)
+
+			gin.SetMode(gin.TestMode)
+
+			router := gin.Default()
+			router.POST("/v1/company/:id/transitions", func(c *gin.Context) {
+				c.Set("username", principal.Username)
+				c.Set("scopes", principal.Scopes)
+			}, handler.TransitionCompany)
+
+			buf := bytes.NewBuffer([]byte(testCase.requestBody))
+
+			url := fmt.Sprintf("/v1/company/%s/transitions", companyId)
+			req, _ := http.NewRequest(http.MethodPost, url, buf)
+			req.Header.Set("content-type", "application/json")
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type csvColumn struct {
+	// path the JSON path of the company field, split on the dots
+	path []string
+	kind fieldKind
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns []*csvColumn
+	number  int
+}
+
+// NewCSVReader reads the header of the CSV. The mapping maps a header to the JSON path of a company field,
+// ex: "Company Name" -> "name", the headers without a mapping must be field paths. Empty headers are ignored
+func NewCSVReader(input io.Reader, mapping map[string]string) (Reader, error) {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrMissingHeader
+		}
+		return nil, err
+	}
+
+	columns := make([]*csvColumn, len(header))
+	mapped := map[string]bool{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if i == 0 {
+			// the byte order mark some spreadsheet tools add
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if name == "" {
+			continue
+		}
+		field, ok := mapping[name]
+		if !ok {
+			field = name
+		}
+		kind, ok := companyFields[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+		if mapped[field] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateField, field)
+		}
+		mapped[field] = true
+		columns[i] = &csvColumn{path: strings.Split(field, "."), kind: kind}
+	}
+
+	return &csvReader{
+		reader:  reader,
+		columns: columns,
+	}, nil
+}
+
+func (reader *csvReader) Next() (Row, error) {
+	record, err := reader.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return Row{}, err
+	}
+	reader.number++
+	row := Row{Number: reader.number}
+	var parseError *csv.ParseError
+	if errors.As(err, &parseError) {
+		// the reader continues on the next line after a malformed record
+		row.Err = err
+		return row, nil
+	}
+	if err != nil {
+		return Row{}, err
+	}
+
+	data := map[string]any{}
+	for i, value := range record {
+		if i >= len(reader.columns) || reader.columns[i] == nil {
+			continue
+		}
+		// empty cells are missing fields, the required ones fail the validation
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		column := reader.columns[i]
+		converted, err := column.kind.convert(strings.Join(column.path, "."), value)
+		if err != nil {
+			row.Err = err
+			return row, nil
+		}
+		setPath(data, column.path, converted)
+	}
+	row.Data, err = json.Marshal(data)
+	if err != nil {
+		row.Err = err
+	}
+	return row, nil
+}
+
+// setPath sets the value in the nested objects of the path, ex: registered_address.city
+func setPath(data map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := data[key].(map[string]any)
+		if !ok {
+			nested = map[string]any{}
+			data[key] = nested
+		}
+		data = nested
+	}
+	data[path[len(path)-1]] = value
+}
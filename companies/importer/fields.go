@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"companies/models"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ListSeparator separates the values of a list field in a CSV cell, ex: the tags vip;prospect
+const ListSeparator = ";"
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindList
+)
+
+// companyFields the kind of each CompanyInput field, keyed by its JSON path, ex: registered_address.city
+var companyFields = inputFields(reflect.TypeOf(models.CompanyInput{}), "")
+
+func inputFields(structType reflect.Type, prefix string) map[string]fieldKind {
+	fields := map[string]fieldKind{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			for nestedPath, kind := range inputFields(fieldType, path+".") {
+				fields[nestedPath] = kind
+			}
+		case reflect.Int:
+			fields[path] = kindInt
+		case reflect.Float64:
+			fields[path] = kindFloat
+		case reflect.Bool:
+			fields[path] = kindBool
+		case reflect.Slice:
+			fields[path] = kindList
+		default:
+			// strings and the text encoded values like the uuids
+			fields[path] = kindString
+		}
+	}
+	return fields
+}
+
+// convert the CSV value to the JSON type of the field, the validation of the value is left to the CompanyInput binding
+func (kind fieldKind) convert(field string, value string) (any, error) {
+	switch kind {
+	case kindInt:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ValueError{Field: field}
+		}
+		return number, nil
+	case kindFloat:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, ValueError{Field: field}
+		}
+		return number, nil
+	case kindBool:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, ValueError{Field: field}
+		}
+		return boolean, nil
+	case kindList:
+		values := strings.Split(value, ListSeparator)
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		return values, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// MaxNDJSONLineSize the maximum size of an NDJSON line, a company is far smaller
+const MaxNDJSONLineSize = 1 << 20
+
+var ErrInvalidJSON = errors.New("the line is not a JSON object")
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONReader reads one company JSON object per line, the empty lines are skipped
+func NewNDJSONReader(input io.Reader) Reader {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxNDJSONLineSize)
+	return &ndjsonReader{
+		scanner: scanner,
+	}
+}
+
+func (reader *ndjsonReader) Next() (Row, error) {
+	for reader.scanner.Scan() {
+		reader.line++
+		line := bytes.TrimSpace(reader.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		row := Row{Number: reader.line}
+		if line[0] != '{' || !json.Valid(line) {
+			row.Err = ErrInvalidJSON
+			return row, nil
+		}
+		// the scanner reuses its buffer for the next line
+		row.Data = bytes.Clone(line)
+		return row, nil
+	}
+	err := reader.scanner.Err()
+	if err != nil {
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
@@ -0,0 +1,59 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The supported import formats, the content types of the import request body
+const (
+	FormatCSV    = "text/csv"
+	FormatNDJSON = "application/x-ndjson"
+)
+
+var Formats = []string{FormatCSV, FormatNDJSON}
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	ErrUnknownField      = errors.New("unknown company field")
+	ErrMissingHeader     = errors.New("the CSV header is missing")
+	ErrDuplicateField    = errors.New("the company field is mapped to several columns")
+)
+
+// ValueError a CSV value that can not be converted to the type of its company field
+type ValueError struct {
+	Field string
+}
+
+func (err ValueError) Error() string {
+	return fmt.Sprintf("invalid value of the %s field", err.Field)
+}
+
+// Row a record of the import, Data is the JSON of a CompanyInput. Err is set when the record could not be read,
+// the next rows can still be read
+type Row struct {
+	// Number the CSV record, starting at 1 after the header, or the NDJSON line
+	Number int
+	Data   json.RawMessage
+	Err    error
+}
+
+// Reader reads the rows one at a time so a large import is never loaded in memory
+type Reader interface {
+	// Next returns io.EOF after the last row, any other error means the rest of the input can not be read
+	Next() (Row, error)
+}
+
+// NewReader returns the reader of the format, the mapping renames the CSV header columns to company fields
+func NewReader(format string, input io.Reader, mapping map[string]string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(input, mapping)
+	case FormatNDJSON:
+		return NewNDJSONReader(input), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, reader Reader) []Row {
+	rows := []Row{}
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        string
+		mapping      map[string]string
+		expectedErr  error
+		expectedRows []Row
+	}{
+		{
+			name: "header fields",
+			input: "name,number_of_employees,registered,type,tags,registered_address.city,registered_address.country\n" +
+				"acme,10,true,Corporations,vip; prospect,Paris,FR\n",
+			expectedRows: []Row{
+				{Number: 1, Data: []byte(`{"name":"acme","number_of_employees":10,"registered":true,"type":"Corporations","tags":["vip","prospect"],"registered_address":{"city":"Paris","country":"FR"}}`)},
+			},
+		},
+		{
+			name:    "mapped headers and ignored columns",
+			input:   "\ufeffCompany Name,,Employees\nacme,x,10\n",
+			mapping: map[string]string{"Company Name": "name", "Employees": "number_of_employees"},
+			expectedRows: []Row{
+				{Number: 1, Data: []byte(`{"name":"acme","number_of_employees":10}`)},
+			},
+		},
+		{
+			name:  "empty cells are missing fields",
+			input: "name,description,ownership_percentage\nacme,,\n",
+			expectedRows: []Row{
+				{Number: 1, Data: []byte(`{"name":"acme"}`)},
+			},
+		},
+		{
+			name:  "invalid value",
+			input: "name,number_of_employees\nacme,ten\nother,5\n",
+			expectedRows: []Row{
+				{Number: 1, Err: ValueError{Field: "number_of_employees"}},
+				{Number: 2, Data: []byte(`{"name":"other","number_of_employees":5}`)},
+			},
+		},
+		{
+			name:        "unknown field",
+			input:       "name,revenue\n",
+			expectedErr: ErrUnknownField,
+		},
+		{
+			name:        "field mapped twice",
+			input:       "name,Company Name\n",
+			mapping:     map[string]string{"Company Name": "name"},
+			expectedErr: ErrDuplicateField,
+		},
+		{
+			name:        "empty input",
+			input:       "",
+			expectedErr: ErrMissingHeader,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			reader, err := NewCSVReader(strings.NewReader(testCase.input), testCase.mapping)
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			rows := readAll(t, reader)
+			require.Len(t, rows, len(testCase.expectedRows))
+			for i, expectedRow := range testCase.expectedRows {
+				assert.Equal(t, expectedRow.Number, rows[i].Number)
+				assert.Equal(t, expectedRow.Err, rows[i].Err)
+				if expectedRow.Data != nil {
+					assert.JSONEq(t, string(expectedRow.Data), string(rows[i].Data))
+				}
+			}
+		})
+	}
+}
+
+func TestCSVReaderMalformedRecord(t *testing.T) {
+	reader, err := NewCSVReader(strings.NewReader("name,description\nacme,\"unclosed\nother,ok\n"), nil)
+	require.NoError(t, err)
+
+	rows := readAll(t, reader)
+	require.NotEmpty(t, rows)
+	assert.Error(t, rows[0].Err)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	input := `{"name":"acme"}` + "\n\n" + `not json` + "\n" + `{"name":"other"}`
+
+	rows := readAll(t, NewNDJSONReader(strings.NewReader(input)))
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, 1, rows[0].Number)
+	assert.JSONEq(t, `{"name":"acme"}`, string(rows[0].Data))
+	assert.Equal(t, 3, rows[1].Number)
+	assert.ErrorIs(t, rows[1].Err, ErrInvalidJSON)
+	assert.Equal(t, 4, rows[2].Number)
+	assert.JSONEq(t, `{"name":"other"}`, string(rows[2].Data))
+}
+
+func TestNDJSONReaderLineTooLong(t *testing.T) {
+	input := `{"name":"` + strings.Repeat("a", MaxNDJSONLineSize) + `"}`
+
+	_, err := NewNDJSONReader(strings.NewReader(input)).Next()
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,287 @@
+package jobs
+
+import (
+	"companies/blobstore"
+	"companies/consts"
+	"companies/eventpublisher"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrJobCanceled = errors.New("the job was canceled")
+
+// finishTimeout how long the final status of a job can take to be saved, the job context is already done then
+const finishTimeout = 10 * time.Second
+
+// Handler runs the jobs of a type. The input is the content uploaded with the job, nil when there is none,
+// and what is written to the output is stored as the job result
+type Handler interface {
+	// ContentType the content type of the result
+	ContentType(job models.Job) string
+	Run(ctx context.Context, job models.Job, input io.Reader, output io.Writer, progress *Progress) error
+}
+
+// Progress the number of items a job processed, the runner saves it each time the lease is renewed
+type Progress struct {
+	processed atomic.Int64
+}
+
+func (progress *Progress) Add(processed int) {
+	progress.processed.Add(int64(processed))
+}
+
+func (progress *Progress) Value() int {
+	return int(progress.processed.Load())
+}
+
+type Config struct {
+	// Workers the number of jobs run at the same time by the replica
+	Workers int
+	// Lease how long a job stays claimed by a worker without being renewed, it is renewed three times per lease
+	Lease time.Duration
+	// PollInterval how long an idle worker waits before looking for a job again
+	PollInterval time.Duration
+}
+
+// Runner claims the queued jobs and runs them with a bounded pool of workers. The jobs are claimed with a lease
+// so the replicas can share them, and the job of a replica that stopped is taken over when its lease expires
+type Runner struct {
+	repo           repo.JobRepo
+	blobStore      blobstore.BlobStore
+	eventPublisher eventpublisher.EventPublisher
+	config         Config
+	// owner identifies the runner in the leases
+	owner    string
+	handlers map[string]Handler
+	types    []string
+	wait     sync.WaitGroup
+}
+
+func NewRunner(jobRepo repo.JobRepo, blobStore blobstore.BlobStore, eventPublisher eventpublisher.EventPublisher, config Config) *Runner {
+	hostname, _ := os.Hostname()
+	return &Runner{
+		repo:           jobRepo,
+		blobStore:      blobStore,
+		eventPublisher: eventPublisher,
+		config:         config,
+		owner:          hostname + "/" + uuid.NewString(),
+		handlers:       map[string]Handler{},
+	}
+}
+
+// Register sets the handler of a job type, the runner only claims the jobs of the registered types
+func (runner *Runner) Register(jobType string, handler Handler) {
+	runner.handlers[jobType] = handler
+	runner.types = append(runner.types, jobType)
+}
+
+// Start starts the workers, they stop when the context is done. A running job is queued again for another worker
+func (runner *Runner) Start(ctx context.Context) {
+	for i := 0; i < runner.config.Workers; i++ {
+		runner.wait.Add(1)
+		go func() {
+			defer runner.wait.Done()
+			runner.work(ctx)
+		}()
+	}
+}
+
+// Wait waits for the workers to stop
+func (runner *Runner) Wait() {
+	runner.wait.Wait()
+}
+
+func (runner *Runner) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+		job, err := runner.repo.ClaimJob(ctx, runner.types, runner.owner, now.Add(runner.config.Lease), now)
+		if err == nil {
+			runner.run(ctx, job)
+			continue
+		}
+		if !errors.Is(err, repo.ErrNoJobQueued) && ctx.Err() == nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("error while trying to claim a job")
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(runner.config.PollInterval):
+		}
+	}
+}
+
+// run runs the job with the tenant it was created in, the heartbeat renews the lease until the handler returns
+// and cancels the job context when the job is canceled or the lease is lost
+func (runner *Runner) run(ctx context.Context, job models.Job) {
+	runner.publish(models.KafkaEventTypeJobStart, job)
+
+	jobCtx, cancel := context.WithCancelCause(tenancy.WithScope(ctx, tenancy.Scope{Tenant: job.Tenant}))
+	defer cancel(nil)
+	if job.CancelRequested {
+		cancel(ErrJobCanceled)
+	}
+	progress := &Progress{}
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		runner.heartbeat(jobCtx, job, progress, cancel)
+	}()
+
+	err := runner.execute(jobCtx, job, progress)
+	cause := context.Cause(jobCtx)
+	cancel(nil)
+	<-heartbeatDone
+
+	result := models.Job{
+		Status:   models.JobStatusSucceeded,
+		Progress: progress.Value(),
+	}
+	eventType := models.KafkaEventTypeJobSucceed
+	switch {
+	case err == nil:
+		result.ResultLocation = "/v1/jobs/" + job.ID.String() + "/result"
+		result.ResultContentType = runner.handlers[job.Type].ContentType(job)
+	case errors.Is(cause, repo.ErrLeaseLost):
+		// the job belongs to the worker that claimed it after the lease expired
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Str(consts.LogKeyJobId, job.ID.String()).
+			Msg("the job was stopped because its lease was lost")
+		return
+	case errors.Is(cause, ErrJobCanceled):
+		result.Status = models.JobStatusCanceled
+		eventType = models.KafkaEventTypeJobCancel
+	case ctx.Err() != nil:
+		runner.release(job)
+		return
+	default:
+		result.Status = models.JobStatusFailed
+		result.Error = err.Error()
+		eventType = models.KafkaEventTypeJobFail
+	}
+
+	finishCtx, finishCancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer finishCancel()
+	finished, err := runner.repo.FinishJob(finishCtx, job.ID, runner.owner, result, time.Now().UTC())
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Str(consts.LogKeyJobId, job.ID.String()).
+			Str(consts.LogKeyJobStatus, result.Status).
+			Msg("error while trying to finish a job")
+		return
+	}
+	runner.publish(eventType, finished)
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Str(consts.LogKeyJobId, finished.ID.String()).
+		Str(consts.LogKeyJobType, finished.Type).
+		Str(consts.LogKeyJobStatus, finished.Status).
+		Msg("job finished")
+}
+
+// execute streams the output of the handler to the result blob while the handler runs
+func (runner *Runner) execute(ctx context.Context, job models.Job, progress *Progress) error {
+	handler := runner.handlers[job.Type]
+
+	var input io.Reader
+	if job.Input != "" {
+		content, err := runner.blobStore.Get(ctx, job.Input)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		input = content
+	}
+
+	reader, writer := io.Pipe()
+	stored := make(chan error, 1)
+	go func() {
+		err := runner.blobStore.Put(ctx, models.JobResultKey(job.ID), reader, -1, handler.ContentType(job))
+		// unblocks the handler when the blob store stops reading
+		reader.CloseWithError(err)
+		stored <- err
+	}()
+
+	err := handler.Run(ctx, job, input, writer, progress)
+	// an error makes the blob store discard the partial result
+	writer.CloseWithError(err)
+	storeErr := <-stored
+	if err != nil {
+		return err
+	}
+	return storeErr
+}
+
+// heartbeat renews the lease until the job context is done
+func (runner *Runner) heartbeat(ctx context.Context, job models.Job, progress *Progress, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(runner.config.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now().UTC()
+		renewed, err := runner.repo.RenewLease(ctx, job.ID, runner.owner, progress.Value(), now.Add(runner.config.Lease), now)
+		if errors.Is(err, repo.ErrLeaseLost) {
+			cancel(err)
+			return
+		}
+		if err != nil {
+			// the lease is still valid until it expires, it is renewed again on the next tick
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyJobId, job.ID.String()).
+				Msg("error while trying to renew a job lease")
+			continue
+		}
+		if renewed.CancelRequested {
+			cancel(ErrJobCanceled)
+			return
+		}
+	}
+}
+
+// release queues a job the runner stopped before it finished
+func (runner *Runner) release(job models.Job) {
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer releaseCancel()
+	released, err := runner.repo.ReleaseJob(releaseCtx, job.ID, runner.owner, time.Now().UTC())
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Str(consts.LogKeyJobId, job.ID.String()).
+			Msg("error while trying to release a job")
+		return
+	}
+	runner.publish(models.KafkaEventTypeJobRelease, released)
+}
+
+func (runner *Runner) publish(eventType string, job models.Job) {
+	runner.eventPublisher.PublishEvent(models.KafkaEvent{
+		Type: eventType,
+		Data: models.JobEvent{
+			Job: job,
+		},
+	})
+}
@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testJobType = "test.job"
+
+type testHandler struct {
+	run func(ctx context.Context, output io.Writer, progress *Progress) error
+}
+
+func (handler testHandler) ContentType(job models.Job) string {
+	return "text/plain"
+}
+
+func (handler testHandler) Run(ctx context.Context, job models.Job, input io.Reader, output io.Writer, progress *Progress) error {
+	return handler.run(ctx, output, progress)
+}
+
+// memoryBlobStore keeps the blobs in memory, the pipe of the result can not be matched by the mock arguments
+type memoryBlobStore struct {
+	mutex sync.Mutex
+	blobs map[string]string
+}
+
+func (store *memoryBlobStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.blobs[key] = string(data)
+	return nil
+}
+
+func (store *memoryBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return io.NopCloser(strings.NewReader(store.blobs[key])), nil
+}
+
+func (store *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.blobs, key)
+	return nil
+}
+
+func TestRunnerRun(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name     string
+		job      models.Job
+		run      func(ctx context.Context, output io.Writer, progress *Progress) error
+		stopped  bool
+		stubMock func(r *mocks.JobRepo)
+		validate func(result string)
+	}{
+		{
+			name: "succeeded",
+			run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+				scope, err := tenancy.FromContext(ctx)
+				if err != nil || scope.Tenant != "tenant-a" {
+					return assert.AnError
+				}
+				progress.Add(2)
+				_, err = io.WriteString(output, "done")
+				return err
+			},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("FinishJob", mock.Anything, jobId, mock.Anything, models.Job{
+					Status:            models.JobStatusSucceeded,
+					Progress:          2,
+					ResultLocation:    "/v1/jobs/" + jobId.String() + "/result",
+					ResultContentType: "text/plain",
+				}, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusSucceeded}, nil)
+			},
+			validate: func(result string) {
+				assert.Equal(t, "done", result)
+			},
+		},
+		{
+			name: "failed",
+			run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+				return assert.AnError
+			},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("FinishJob", mock.Anything, jobId, mock.Anything, models.Job{
+					Status: models.JobStatusFailed,
+					Error:  assert.AnError.Error(),
+				}, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusFailed}, nil)
+			},
+		},
+		{
+			name: "canceled by the heartbeat",
+			run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("RenewLease", mock.Anything, jobId, mock.Anything, 0, mock.Anything, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusRunning, CancelRequested: true}, nil)
+				r.On("FinishJob", mock.Anything, jobId, mock.Anything, models.Job{Status: models.JobStatusCanceled}, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusCanceled}, nil)
+			},
+		},
+		{
+			name: "claimed after the cancellation was requested",
+			job:  models.Job{CancelRequested: true},
+			run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+				return ctx.Err()
+			},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("FinishJob", mock.Anything, jobId, mock.Anything, models.Job{Status: models.JobStatusCanceled}, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusCanceled}, nil)
+			},
+		},
+		{
+			name: "the lease was lost",
+			run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("RenewLease", mock.Anything, jobId, mock.Anything, 0, mock.Anything, mock.Anything).
+					Return(models.Job{}, repo.ErrLeaseLost)
+			},
+		},
+		{
+			name:    "the runner stopped",
+			stopped: true,
+			run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("RenewLease", mock.Anything, jobId, mock.Anything, 0, mock.Anything, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusRunning}, nil).Maybe()
+				r.On("ReleaseJob", mock.Anything, jobId, mock.Anything, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusQueued}, nil)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewJobRepo(t)
+			b := &memoryBlobStore{blobs: map[string]string{}}
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			runner := NewRunner(r, b, eventPublisher, Config{Workers: 1, Lease: 30 * time.Millisecond, PollInterval: time.Millisecond})
+			runner.Register(testJobType, testHandler{run: testCase.run})
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if testCase.stopped {
+				stopCtx, stop := context.WithCancel(ctx)
+				ctx = stopCtx
+				time.AfterFunc(20*time.Millisecond, stop)
+			}
+
+			job := testCase.job
+			job.ID = jobId
+			job.Type = testJobType
+			job.Tenant = "tenant-a"
+			job.Status = models.JobStatusRunning
+			runner.run(ctx, job)
+
+			if testCase.validate != nil {
+				testCase.validate(b.blobs[models.JobResultKey(jobId)])
+			}
+		})
+	}
+}
+
+func TestRunnerStart(t *testing.T) {
+	r := mocks.NewJobRepo(t)
+	b := &memoryBlobStore{blobs: map[string]string{}}
+
+	eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+	runner := NewRunner(r, b, eventPublisher, Config{Workers: 2, Lease: time.Minute, PollInterval: time.Millisecond})
+	var ran sync.WaitGroup
+	ran.Add(1)
+	runner.Register(testJobType, testHandler{run: func(ctx context.Context, output io.Writer, progress *Progress) error {
+		ran.Done()
+		return nil
+	}})
+
+	jobId := uuid.New()
+	r.On("ClaimJob", mock.Anything, []string{testJobType}, mock.Anything, mock.Anything, mock.Anything).
+		Return(models.Job{ID: jobId, Type: testJobType, Status: models.JobStatusRunning}, nil).Once()
+	r.On("ClaimJob", mock.Anything, []string{testJobType}, mock.Anything, mock.Anything, mock.Anything).
+		Return(models.Job{}, repo.ErrNoJobQueued)
+	r.On("FinishJob", mock.Anything, jobId, mock.Anything, mock.Anything, mock.Anything).
+		Return(models.Job{ID: jobId, Status: models.JobStatusSucceeded}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.Start(ctx)
+	ran.Wait()
+	cancel()
+	runner.Wait()
+
+	r.AssertNumberOfCalls(t, "FinishJob", 1)
+}
@@ -1,24 +1,39 @@
 package main
 
 import (
+	"companies/blobstore"
+	"companies/config"
 	"companies/consts"
+	"companies/eventconsumer"
 	"companies/eventpublisher"
+	"companies/fieldpolicy"
 	"companies/handlers"
+	"companies/jobs"
 	"companies/middleware"
+	"companies/models"
 	"companies/repo"
+	"companies/search"
 	"companies/service"
+	"companies/validators"
 	"context"
 	"errors"
+	"flag"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/time/rate"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
@@ -26,39 +41,60 @@ import (
 func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
 
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		err := errors.New("MONGO_URI env var not set")
+	cfg, rest, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
-			Msg("make sure to set the MONGO_URI env var")
+			Msg("make sure the config file, the env vars and the flags are valid, see companies -h")
 		return
 	}
 
-	jwtSecretKey := os.Getenv("JWT_SECRET_KEY")
-	if jwtSecretKey == "" {
-		err := errors.New("JWT_SECRET_KEY env var not set")
-		log.Error().
-			Err(err).
-			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
-			Msg("make sure to set the JWT_SECRET_KEY env var")
+	// companies print-config prints the effective config with the secrets redacted
+	if len(rest) > 0 && rest[0] == "print-config" {
+		err = cfg.WriteRedacted(os.Stdout)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("failed to print the config")
+		}
 		return
 	}
 
-	kafkaServers := os.Getenv("KAFKA_SERVERS")
-	if kafkaServers == "" {
-		err := errors.New("KAFKA_SERVERS env var not set. ex: KAFKA_SERVERS=localhost:9092,example.com:9092")
+	log.Info().
+		Interface(consts.LogKeyConfig, cfg.Redacted()).
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Msg("loaded the config")
+
+	// every user can read and write every field unless a field policy file is set
+	fieldPolicy := fieldpolicy.Policy{}
+	if cfg.FieldPolicyFile != "" {
+		fieldPolicy, err = fieldpolicy.Load(cfg.FieldPolicyFile)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msgf("failed to load the field policy file %s", cfg.FieldPolicyFile)
+			return
+		}
+	}
+
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
 		log.Error().
 			Err(err).
 			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
-			Msg("make sure to set the KAFKA_SERVERS env var")
+			Msg("failed to create the blob store")
 		return
 	}
 
 	// Set up a connection to MongoDB
-	clientOptions := options.Client().ApplyURI(mongoURI)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	clientOptions := options.Client().ApplyURI(cfg.Mongo.URI)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.ConnectTimeout)
 	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
@@ -84,10 +120,22 @@ func main() {
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Msg("connected to MongoDB")
 
+	// companies rebuild-search-index replaces the search index with one built from the database, the service must be stopped
+	if len(rest) > 0 && rest[0] == "rebuild-search-index" {
+		rebuildSearchIndex(client, cfg.Mongo.Database, cfg.SearchIndexDir)
+		return
+	}
+
+	// companies backfill-name-keys sets the canonical names and the name keys the possible duplicates are looked up with
+	if len(rest) > 0 && rest[0] == "backfill-name-keys" {
+		backfillNameKeys(client, cfg.Mongo.Database)
+		return
+	}
+
 	producer, err := kafka.NewProducer(&kafka.ConfigMap{
-		"bootstrap.servers": kafkaServers,
-		"client.id":         "companies-service",
-		"acks":              "all"})
+		"bootstrap.servers": strings.Join(cfg.Kafka.Servers, ","),
+		"client.id":         cfg.Kafka.ClientID,
+		"acks":              cfg.Kafka.Acks})
 
 	if err != nil {
 		log.Error().
@@ -101,38 +149,188 @@ func main() {
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Msg("created Kafka producer")
 
+	searchGroup := cfg.Kafka.SearchGroup
+	if searchGroup == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("failed to read the hostname of the search consumer group")
+			return
+		}
+		searchGroup = "companies-search-" + hostname
+	}
+	// a new group starts at the latest events, a new index is filled from the database
+	searchConsumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": strings.Join(cfg.Kafka.Servers, ","),
+		"client.id":         cfg.Kafka.ClientID,
+		"group.id":          searchGroup,
+		"auto.offset.reset": "latest"})
+	if err == nil {
+		err = searchConsumer.Subscribe(cfg.Kafka.Topic, nil)
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("failed to create the Kafka consumer of the search index")
+		return
+	}
+
 	// Setup the service
 
-	companyRepo := repo.NewMongoCompanyRepo(client)
-	eventPublisher := eventpublisher.NewEventPublisher(producer)
-	companyService := service.NewCompanyService(companyRepo, eventPublisher)
-	companyHandler := handlers.NewCompanyHandler(companyService)
+	searchIndex, searchIndexCreated, err := search.NewBleveIndex(cfg.SearchIndexDir)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msgf("failed to open the search index %s", cfg.SearchIndexDir)
+		return
+	}
+
+	companyRepo := repo.NewMongoCompanyRepo(client, cfg.Mongo.Database)
+	statsCache := service.NewStatsCache(cfg.StatsCacheTTL, service.DefaultStatsCacheEntries)
+	eventPublisher := eventpublisher.NewEventPublisher(producer, cfg.Kafka.Topic)
+	changeRequestRepo := repo.NewMongoChangeRequestRepo(client, cfg.Mongo.Database)
+	transactor := repo.NewMongoTransactor(client)
+	companyService := service.NewCompanyService(companyRepo, changeRequestRepo, eventPublisher, blobStore, cfg.ChangeRequestTTL, cfg.OwnerOnlyWrites, transactor, searchIndex, statsCache)
+	jobRepo := repo.NewMongoJobRepo(client, cfg.Mongo.Database)
+	jobService := service.NewJobService(jobRepo, blobStore, eventPublisher)
+	jobHandler := handlers.NewJobHandler(jobService)
+	companyHandler := handlers.NewCompanyHandler(companyService, jobService, fieldPolicy)
+	attachmentService := service.NewAttachmentService(companyRepo, blobStore, eventPublisher, cfg.OwnerOnlyWrites)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+
+	jobRunner := jobs.NewRunner(jobRepo, blobStore, eventPublisher, jobs.Config{
+		Workers:      cfg.JobWorkers,
+		Lease:        cfg.JobLease,
+		PollInterval: 2 * time.Second,
+	})
+	jobRunner.Register(models.JobTypeCompaniesImport, handlers.NewImportJob(companyService, fieldPolicy))
+	jobRunner.Register(models.JobTypeCompaniesExport, handlers.NewExportJob(companyService, fieldPolicy))
+	jobRunner.Register(models.JobTypeCompaniesDuplicates, handlers.NewDuplicatesJob(companyService, fieldPolicy))
 
 	// setup gin engine
 	gin.SetMode(gin.ReleaseMode)
 
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		err = validators.RegisterValidators(v)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("failed to register the custom validators")
+			return
+		}
+	}
+
 	engine := gin.New()
+	err = engine.SetTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("failed to set the trusted proxies")
+		return
+	}
+
 	engine.Use(gin.Recovery())
-	engine.Use(middleware.TimeoutMiddleware(5 * time.Second))
 
-	// rate limit 5 req/s with burst of 10
-	limiter := middleware.NewClientLimiter(5, 10)
-	engine.Use(middleware.RateLimitMiddleware(limiter))
+	// the user limits are checked after the token is validated so the users behind the same IP do not share them
+	clientLimiter := middleware.NewClientLimiter(cfg.RateLimit.MaxClients, cfg.RateLimit.ClientTTL)
+	var limiter middleware.RateLimiter = clientLimiter
+	if cfg.RedisURL != "" {
+		redisClient, err := newRedisClient(cfg.RedisURL)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msg("failed to parse the Redis URL")
+			return
+		}
+		defer redisClient.Close()
+		// the replica limits each user by itself while the Redis server is unreachable, it is retried every 5s
+		redisLimiter := middleware.NewRedisLimiter(redisClient, "ratelimit:companies:")
+		limiter = middleware.NewFallbackLimiter(redisLimiter, clientLimiter, 5*time.Second)
+	}
+
+	apiQuota := quotaGroup("api", cfg.RateLimit.API)
+	// the imports, exports, batches, duplicates reports and merges have their own stricter quota
+	bulkQuota := quotaGroup("bulk", cfg.RateLimit.Bulk)
+	quotaHandler := handlers.NewQuotaHandler(middleware.NewQuotaReporter(limiter, apiQuota, bulkQuota))
 
-	v1Group := engine.Group("/v1", middleware.ValidateJWTToken([]byte(jwtSecretKey)))
+	// the streamed responses have no timeout, the uploads have longer ones. The synchronous imports stream their
+	// report, they stop through the deadline of their context and end the report instead
+	engine.Use(middleware.TimeoutMiddleware(cfg.Timeouts.Request, middleware.RouteTimeouts{
+		"GET /v1/companies/export":                      0,
+		"GET /v1/jobs/:jobId/result":                    0,
+		"GET /v1/company/:id/attachments/:attachmentId": 0,
+		"POST /v1/companies/import":                     0,
+		"POST /v1/company/:id/attachments":              cfg.Timeouts.Long,
+		"POST /v1/companies:method":                     cfg.Timeouts.Long,
+		"POST /v1/company/:id/merge":                    cfg.Timeouts.Long,
+	}))
+
+	// each client IP is limited before the token is validated, so the requests without a valid token are limited too
+	ipQuota := middleware.QuotaGroup{
+		Name: "ip",
+		Tiers: []middleware.QuotaTier{
+			{Name: "ip", Limit: middleware.Limit{Rate: rate.Limit(cfg.RateLimit.IP.Rate), Burst: cfg.RateLimit.IP.Burst}, Daily: cfg.RateLimit.IP.Daily},
+		},
+	}
+	v1Group := engine.Group("/v1", middleware.QuotaMiddleware(limiter, ipQuota), middleware.ValidateJWTToken([]byte(cfg.JWTSecretKey)))
+	// the bulk routes are only counted in the bulk quota, the group is created before the api quota is added
+	bulkGroup := v1Group.Group("", middleware.QuotaMiddleware(limiter, bulkQuota))
+	v1Group.Use(middleware.QuotaMiddleware(limiter, apiQuota))
 
 	v1Group.POST("/company", companyHandler.CreateCompany)
 	v1Group.PATCH("/company/:id", companyHandler.PatchCompany)
 	v1Group.GET("/company/:id", companyHandler.GetCompany)
+	v1Group.GET("/company/by-identifier/:scheme/:value", companyHandler.GetCompanyByIdentifier)
 	v1Group.DELETE("/company/:id", companyHandler.DeleteCompany)
+	v1Group.GET("/company/:id/ancestors", companyHandler.GetAncestors)
+	v1Group.GET("/company/:id/children", companyHandler.GetChildren)
+	v1Group.GET("/company/:id/subtree", companyHandler.GetSubtree)
+	v1Group.DELETE("/company/:id/parent", companyHandler.RemoveParent)
+	v1Group.POST("/company/:id/tags", companyHandler.AddTags)
+	v1Group.DELETE("/company/:id/tags/:tag", companyHandler.RemoveTag)
+	v1Group.POST("/company/:id/transitions", companyHandler.TransitionCompany)
+	bulkGroup.POST("/company/:id/merge", companyHandler.MergeCompanies)
+	v1Group.POST("/company/:id/attachments", attachmentHandler.UploadAttachment)
+	v1Group.GET("/company/:id/attachments", attachmentHandler.ListAttachments)
+	v1Group.GET("/company/:id/attachments/:attachmentId", attachmentHandler.GetAttachment)
+	v1Group.DELETE("/company/:id/attachments/:attachmentId", attachmentHandler.DeleteAttachment)
+
+	v1Group.GET("/companies", companyHandler.ListCompanies)
+	v1Group.GET("/companies/tags", companyHandler.CountTags)
+	bulkGroup.POST("/companies/import", middleware.DeadlineMiddleware(cfg.Timeouts.Import), companyHandler.ImportCompanies)
+	bulkGroup.GET("/companies/export", companyHandler.ExportCompanies)
+	v1Group.GET("/companies/search", companyHandler.SearchCompanies)
+	v1Group.GET("/companies/stats", companyHandler.CompanyStats)
+	v1Group.GET("/companies/stats/employees", companyHandler.EmployeeStats)
+	bulkGroup.POST("/companies/duplicates/report", companyHandler.ReportDuplicates)
+	// POST /v1/companies:batch, gin routes can not have a literal colon so :method matches the rest of the segment
+	bulkGroup.POST("/companies:method", companyHandler.BatchCompanies)
+
+	v1Group.GET("/change-requests", companyHandler.ListChangeRequests)
+	v1Group.GET("/change-requests/:requestId", companyHandler.GetChangeRequest)
+	v1Group.POST("/change-requests/:requestId/approve", companyHandler.ApproveChangeRequest)
+	v1Group.POST("/change-requests/:requestId/reject", companyHandler.RejectChangeRequest)
+
+	v1Group.GET("/quota", quotaHandler.GetQuota)
+
+	v1Group.GET("/jobs/:jobId", jobHandler.GetJob)
+	v1Group.POST("/jobs/:jobId/cancel", jobHandler.CancelJob)
+	v1Group.POST("/jobs/:jobId/retry", jobHandler.RetryJob)
+	v1Group.GET("/jobs/:jobId/result", jobHandler.GetJobResult)
 
 	// graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// run the service on port 8080
 	go func() {
-		port := "8080"
+		port := strconv.Itoa(cfg.Port)
 		err := engine.Run(":" + port)
 		if err != nil {
 			log.Error().
@@ -142,9 +340,56 @@ func main() {
 		}
 	}()
 
+	// a new index is filled in the background, the search results are incomplete until it is done
+	if searchIndexCreated {
+		go func() {
+			count, err := search.Fill(ctx, searchIndex, companyRepo)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+					Msg("error while filling the search index")
+				return
+			}
+			log.Info().
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Msgf("filled the search index with %d companies", count)
+		}()
+	}
+
+	// the changes made through the other replicas are indexed from their events
+	searchIndexer := eventconsumer.NewSearchIndexer(searchConsumer, searchIndex, companyRepo)
+	searchIndexer.Start(ctx)
+
+	// run the jobs until the shutdown, the running jobs are queued again for another replica
+	jobRunner.Start(ctx)
+
 	// Wait until context is canceled
 	<-ctx.Done()
 
+	jobRunner.Wait()
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Msg("stopped the job workers")
+
+	searchIndexer.Wait()
+	err = searchConsumer.Close()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("error while closing the Kafka consumer of the search index")
+	}
+
+	err = searchIndex.Close()
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("error while closing the search index")
+	}
+
 	// close mongodb connection
 	disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer disconnectCancel()
@@ -168,3 +413,92 @@ func main() {
 		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
 		Msg("closed the Kafka producer")
 }
+
+// rebuildSearchIndex rebuilds the search index from the companies of every tenant
+func rebuildSearchIndex(client *mongo.Client, database string, searchIndexDir string) {
+	defer func() {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer disconnectCancel()
+		_ = client.Disconnect(disconnectCtx)
+	}()
+
+	count, err := search.Rebuild(context.Background(), searchIndexDir, repo.NewMongoCompanyRepo(client, database))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msg("failed to rebuild the search index")
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Msgf("rebuilt the search index with %d companies", count)
+}
+
+func backfillNameKeys(client *mongo.Client, database string) {
+	defer func() {
+		disconnectCtx, disconnectCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer disconnectCancel()
+		_ = client.Disconnect(disconnectCtx)
+	}()
+
+	count, conflicts, err := service.BackfillNameKeys(context.Background(), repo.NewMongoCompanyRepo(client, database))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Msgf("failed to backfill the name keys after %d companies", count)
+		return
+	}
+
+	log.Info().
+		Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+		Msgf("backfilled the name keys of %d companies, %d companies with a conflicting canonical name were skipped", count, conflicts)
+}
+
+// newRedisClient creates the client of the rate limits, the requests do not wait more than 100ms for the server
+// unless the URL sets other timeouts
+func newRedisClient(redisURL string) (*redis.Client, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	if options.DialTimeout == 0 {
+		options.DialTimeout = 100 * time.Millisecond
+	}
+	if options.ReadTimeout == 0 {
+		options.ReadTimeout = 100 * time.Millisecond
+	}
+	if options.WriteTimeout == 0 {
+		options.WriteTimeout = 100 * time.Millisecond
+	}
+	return redis.NewClient(options), nil
+}
+
+// quotaGroup the route group with the premium tier for the users with the companies:quota:premium scope and the
+// standard tier for the others
+func quotaGroup(name string, quota config.QuotaConfig) middleware.QuotaGroup {
+	return middleware.QuotaGroup{
+		Name: name,
+		Tiers: []middleware.QuotaTier{
+			{Name: "premium", Scope: models.ScopeCompaniesQuotaPremium, Limit: middleware.Limit{Rate: rate.Limit(quota.Premium.Rate), Burst: quota.Premium.Burst}, Daily: quota.Premium.Daily},
+			{Name: "standard", Limit: middleware.Limit{Rate: rate.Limit(quota.Standard.Rate), Burst: quota.Standard.Burst}, Daily: quota.Standard.Daily},
+		},
+	}
+}
+
+// newBlobStore creates the attachments blob store of the config, local or s3
+func newBlobStore(cfg config.Config) (blobstore.BlobStore, error) {
+	if cfg.BlobStore.Type == "s3" {
+		return blobstore.NewS3BlobStore(blobstore.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+		})
+	}
+	return blobstore.NewLocalBlobStore(cfg.BlobStore.Dir)
+}
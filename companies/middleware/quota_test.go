@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"companies/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var testQuotaGroup = QuotaGroup{
+	Name: "api",
+	Tiers: []QuotaTier{
+		{Name: "premium", Scope: models.ScopeCompaniesQuotaPremium, Limit: Limit{Rate: 1, Burst: 3}},
+		{Name: "standard", Limit: Limit{Rate: 100, Burst: 100}, Daily: 2},
+	},
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimit, error) {
+	return RateLimit{}, assert.AnError
+}
+
+func (failingLimiter) Count(ctx context.Context, key string, day time.Time, n int) (int, error) {
+	return 0, assert.AnError
+}
+
+func TestQuotaTier(t *testing.T) {
+	assert.Equal(t, "premium", testQuotaGroup.Tier([]string{"companies:admin", models.ScopeCompaniesQuotaPremium}).Name)
+	assert.Equal(t, "standard", testQuotaGroup.Tier([]string{"companies:admin"}).Name)
+	assert.Equal(t, "standard", testQuotaGroup.Tier(nil).Name)
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	testCases := []struct {
+		name               string
+		limiter            RateLimiter
+		scopes             []string
+		usernames          []string
+		expectedStatusCode int
+		expectedError      string
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "standard tier",
+			usernames:          []string{"alice"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "100",
+				"RateLimit-Remaining": "99",
+				"X-Quota-Limit":       "2",
+				"X-Quota-Remaining":   "1",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:               "daily quota exceeded",
+			usernames:          []string{"alice", "alice", "alice"},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedError:      "daily quota exceeded",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":   "100",
+				"X-Quota-Limit":     "2",
+				"X-Quota-Remaining": "0",
+			},
+		},
+		{
+			name:               "the users behind the same IP have their own quotas",
+			usernames:          []string{"alice", "alice", "bob"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Remaining": "99",
+				"X-Quota-Remaining":   "1",
+			},
+		},
+		{
+			name:               "the requests without a token are counted by IP",
+			usernames:          []string{"", "", "alice"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Quota-Remaining": "1",
+			},
+		},
+		{
+			name:               "rate limit exceeded",
+			scopes:             []string{models.ScopeCompaniesQuotaPremium},
+			usernames:          []string{"alice", "alice", "alice", "alice"},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedError:      "rate limit exceeded",
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "3",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "3",
+				"Retry-After":         "1",
+				"X-Quota-Limit":       "",
+			},
+		},
+		{
+			name:               "the requests are allowed when the limiter fails",
+			limiter:            failingLimiter{},
+			usernames:          []string{"alice"},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+				"X-Quota-Limit":   "",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			limiter := testCase.limiter
+			if limiter == nil {
+				limiter = NewClientLimiter(100, time.Minute)
+			}
+
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			var username string
+			router := gin.New()
+			router.GET("/test", func(c *gin.Context) {
+				if username != "" {
+					c.Set("username", username)
+				}
+				c.Set("scopes", testCase.scopes)
+			}, QuotaMiddleware(limiter, testQuotaGroup), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			var rr *httptest.ResponseRecorder
+			for _, username = range testCase.usernames {
+				req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+				req.RemoteAddr = "10.0.0.1:1234"
+				rr = httptest.NewRecorder()
+
+				// Perform the request
+				router.ServeHTTP(rr, req)
+			}
+
+			// Assertions
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedError != "" {
+				assert.JSONEq(t, `{"error": "`+testCase.expectedError+`"}`, rr.Body.String())
+			}
+			for header, value := range testCase.expectedHeaders {
+				assert.Equal(t, value, rr.Header().Get(header), header)
+			}
+			// the daily quotas are reset at midnight UTC
+			if rr.Header().Get("X-Quota-Limit") != "" {
+				reset, err := strconv.Atoi(rr.Header().Get("X-Quota-Reset"))
+				assert.NoError(t, err)
+				assert.InDelta(t, 12*time.Hour.Seconds(), reset, 12*time.Hour.Seconds())
+			}
+			if testCase.expectedError == "daily quota exceeded" {
+				assert.Equal(t, rr.Header().Get("X-Quota-Reset"), rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	testCases := []struct {
+		name             string
+		username         string
+		trustedProxies   []string
+		expectedIdentity string
+	}{
+		{
+			name:             "the user of the token",
+			username:         "alice",
+			expectedIdentity: "user:alice",
+		},
+		{
+			name:             "the forwarded address of a trusted proxy",
+			trustedProxies:   []string{"192.168.0.0/16"},
+			expectedIdentity: "ip:203.0.113.7",
+		},
+		{
+			name:             "the forwarded address of an untrusted proxy is ignored",
+			trustedProxies:   nil,
+			expectedIdentity: "ip:192.168.1.1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			var identity string
+			router := gin.New()
+			assert.NoError(t, router.SetTrustedProxies(testCase.trustedProxies))
+			router.GET("/test", func(c *gin.Context) {
+				if testCase.username != "" {
+					c.Set("username", testCase.username)
+				}
+				identity = Identity(c)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = "192.168.1.1:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, testCase.expectedIdentity, identity)
+		})
+	}
+}
+
+func TestQuotaReporter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC)
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	reset := day.AddDate(0, 0, 1)
+	bulkQuotaGroup := QuotaGroup{
+		Name: "bulk",
+		Tiers: []QuotaTier{
+			{Name: "standard", Limit: Limit{Rate: 0.5, Burst: 1}, Daily: 10},
+		},
+	}
+
+	cl := NewClientLimiter(100, time.Minute)
+	count(t, cl, "api:user:alice", day, 1)
+	count(t, cl, "bulk:user:alice", day, 3)
+	reporter := NewQuotaReporter(cl, testQuotaGroup, bulkQuotaGroup).(*quotaReporter)
+	reporter.now = func() time.Time { return now }
+
+	quotas, err := reporter.Quotas(context.Background(), "user:alice", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Quota{
+		{Group: "api", Tier: "standard", Rate: 100, Burst: 100, DailyLimit: 2, DailyRemaining: 1, DailyReset: &reset},
+		{Group: "bulk", Tier: "standard", Rate: 0.5, Burst: 1, DailyLimit: 10, DailyRemaining: 7, DailyReset: &reset},
+	}, quotas)
+
+	// the reporter does not count a request
+	assert.Equal(t, 1, count(t, cl, "api:user:alice", day, 0))
+
+	quotas, err = reporter.Quotas(context.Background(), "user:alice", []string{models.ScopeCompaniesQuotaPremium})
+	assert.NoError(t, err)
+	assert.Equal(t, models.Quota{Group: "api", Tier: "premium", Rate: 1, Burst: 3, Unlimited: true}, quotas[0])
+
+	_, err = NewQuotaReporter(failingLimiter{}, testQuotaGroup).Quotas(context.Background(), "user:alice", nil)
+	assert.ErrorIs(t, err, assert.AnError)
+}
@@ -1,53 +1,212 @@
 package middleware
 
 import (
-	"net/http"
+	"container/list"
+	"context"
+	"hash/fnv"
+	"math"
 	"sync"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
-// Limiter middleware config
+// limiterShards the keys are split across shards with their own lock, so the requests of different clients
+// rarely wait for each other
+const limiterShards = 16
+
+// Limit the token bucket of a key, Rate tokens per second come back up to Burst tokens
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// RateLimit the state of the bucket of a key after a request, it is sent in the RateLimit headers
+type RateLimit struct {
+	Allowed bool
+	// Limit the number of requests a client can send at once
+	Limit int
+	// Remaining the number of requests the client can still send at once
+	Remaining int
+	// Reset the time until the client can send Limit requests again
+	Reset time.Duration
+	// RetryAfter the time until the client can send a request again, zero when the request is allowed
+	RetryAfter time.Duration
+}
+
+// RateLimiter a token bucket and a daily request count per key, shared by the replicas or not
+type RateLimiter interface {
+	// Allow takes a token from the bucket of the key when it has one
+	Allow(ctx context.Context, key string, limit Limit) (RateLimit, error)
+	// Count adds n requests to the count of the key on the UTC day and returns it, n is 0 to only read it
+	Count(ctx context.Context, key string, day time.Time, n int) (int, error)
+}
+
+// ClientLimiter a token bucket and a daily request count per key in the memory of the replica, it keeps at most
+// maxClients buckets: the least recently used ones are evicted first and the ones idle for longer than the ttl are
+// removed as the other clients send requests. At most maxClients keys are counted each day, the least recently
+// counted ones are evicted first and their count starts again
 type ClientLimiter struct {
-	clients map[string]*rate.Limiter
+	shards [limiterShards]limiterShard
+	ttl    time.Duration
+	// maxShardClients the number of buckets and counts a shard keeps
+	maxShardClients int
+	now             func() time.Time
+}
+
+type limiterShard struct {
 	mu      sync.Mutex
-	r       rate.Limit
-	b       int
+	clients map[string]*list.Element
+	// lru the entries of the clients, the most recently used first
+	lru list.List
+	// day the day of the counts, they are cleared by the first request of the next day
+	day    time.Time
+	counts map[string]*list.Element
+	// countLRU the counts of the keys, the most recently counted first
+	countLRU list.List
+}
+
+type limiterEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
 }
 
-func NewClientLimiter(r rate.Limit, b int) *ClientLimiter {
-	return &ClientLimiter{
-		clients: make(map[string]*rate.Limiter),
-		r:       r,
-		b:       b,
+type countEntry struct {
+	key   string
+	count int
+}
+
+// NewClientLimiter keeps the buckets of up to maxClients keys. A bucket idle for the time it takes to fill,
+// Burst/Rate, is full again, so a ttl at least that long only evicts buckets that did not limit anything
+func NewClientLimiter(maxClients int, ttl time.Duration) *ClientLimiter {
+	cl := &ClientLimiter{
+		ttl:             ttl,
+		maxShardClients: max(1, (maxClients+limiterShards-1)/limiterShards),
+		now:             time.Now,
+	}
+	for i := range cl.shards {
+		cl.shards[i].clients = make(map[string]*list.Element)
+		cl.shards[i].counts = make(map[string]*list.Element)
+	}
+	return cl
+}
+
+// Allow takes a token from the bucket of the key when it has one, it never fails
+func (cl *ClientLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimit, error) {
+	now := cl.now()
+	shard := cl.shard(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.evictIdle(now.Add(-cl.ttl))
+
+	var entry *limiterEntry
+	if element, exists := shard.clients[key]; exists {
+		entry = element.Value.(*limiterEntry)
+		shard.lru.MoveToFront(element)
+		// the tier of the user changed since the last request
+		if entry.limiter.Limit() != limit.Rate || entry.limiter.Burst() != limit.Burst {
+			entry.limiter.SetLimitAt(now, limit.Rate)
+			entry.limiter.SetBurstAt(now, limit.Burst)
+		}
+	} else {
+		entry = &limiterEntry{
+			key:     key,
+			limiter: rate.NewLimiter(limit.Rate, limit.Burst),
+		}
+		shard.clients[key] = shard.lru.PushFront(entry)
+		for shard.lru.Len() > cl.maxShardClients {
+			shard.remove(shard.lru.Back())
+		}
+	}
+	entry.lastSeen = now
+
+	allowed := entry.limiter.AllowN(now, 1)
+	tokens := entry.limiter.TokensAt(now)
+	rateLimit := RateLimit{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     fillTime(limit, float64(limit.Burst)-tokens),
+	}
+	if !allowed {
+		rateLimit.RetryAfter = fillTime(limit, 1-tokens)
 	}
+	return rateLimit, nil
 }
 
-func (cl *ClientLimiter) getLimiter(ip string) *rate.Limiter {
-	cl.mu.Lock()
-	defer cl.mu.Unlock()
+// Count adds n requests to the count of the key on the day, it never fails
+func (cl *ClientLimiter) Count(ctx context.Context, key string, day time.Time, n int) (int, error) {
+	shard := cl.shard(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	limiter, exists := cl.clients[ip]
-	if !exists {
-		limiter = rate.NewLimiter(cl.r, cl.b)
-		cl.clients[ip] = limiter
+	if day.After(shard.day) {
+		shard.day = day
+		clear(shard.counts)
+		shard.countLRU.Init()
 	}
-	return limiter
+	// the requests of an older day were sent before midnight, they are no longer counted
+	if day.Before(shard.day) {
+		return 0, nil
+	}
+
+	element, exists := shard.counts[key]
+	if n == 0 {
+		if !exists {
+			return 0, nil
+		}
+		return element.Value.(*countEntry).count, nil
+	}
+	if exists {
+		shard.countLRU.MoveToFront(element)
+	} else {
+		element = shard.countLRU.PushFront(&countEntry{key: key})
+		shard.counts[key] = element
+		for shard.countLRU.Len() > cl.maxShardClients {
+			oldest := shard.countLRU.Back()
+			shard.countLRU.Remove(oldest)
+			delete(shard.counts, oldest.Value.(*countEntry).key)
+		}
+	}
+	entry := element.Value.(*countEntry)
+	entry.count += n
+	return entry.count, nil
 }
 
-func RateLimitMiddleware(cl *ClientLimiter) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		limiter := cl.getLimiter(ip)
+// fillTime returns the time the bucket takes to get the tokens back
+func fillTime(limit Limit, tokens float64) time.Duration {
+	if tokens <= 0 || limit.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(limit.Rate) * float64(time.Second))
+}
+
+func (cl *ClientLimiter) shard(key string) *limiterShard {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return &cl.shards[hash.Sum32()%limiterShards]
+}
 
-		if !limiter.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "rate limit exceeded",
-			})
+// evictIdle removes the buckets last used before the time, they are at the back of the lru list
+func (shard *limiterShard) evictIdle(before time.Time) {
+	for element := shard.lru.Back(); element != nil; element = shard.lru.Back() {
+		if !element.Value.(*limiterEntry).lastSeen.Before(before) {
 			return
 		}
-
-		c.Next()
+		shard.remove(element)
 	}
 }
+
+func (shard *limiterShard) remove(element *list.Element) {
+	shard.lru.Remove(element)
+	delete(shard.clients, element.Value.(*limiterEntry).key)
+}
+
+// ceilSeconds rounds the duration up to whole seconds, the precision of the headers
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		routeTimeouts        RouteTimeouts
+		handler              func(c *gin.Context)
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedHeader       string
+		expectedFlushed      bool
+	}{
+		{
+			name: "the handler finishes before the timeout",
+			handler: func(c *gin.Context) {
+				c.Header("X-Test", "value")
+				c.JSON(http.StatusCreated, gin.H{"id": 1})
+			},
+			expectedStatusCode:   http.StatusCreated,
+			expectedResponseBody: `{"id": 1}`,
+			expectedHeader:       "value",
+		},
+		{
+			name: "the handler stops when the context is canceled",
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": c.Request.Context().Err().Error()})
+			},
+			expectedStatusCode:   http.StatusGatewayTimeout,
+			expectedResponseBody: `{"error": "request timed out"}`,
+			expectedFlushed:      true,
+		},
+		{
+			name: "the writes of the handler after the timeout are discarded",
+			handler: func(c *gin.Context) {
+				c.Header("X-Test", "value")
+				time.Sleep(100 * time.Millisecond)
+				c.JSON(http.StatusOK, gin.H{"id": 1})
+			},
+			expectedStatusCode:   http.StatusGatewayTimeout,
+			expectedResponseBody: `{"error": "request timed out"}`,
+			expectedFlushed:      true,
+		},
+		{
+			name:          "the route has a longer timeout",
+			routeTimeouts: RouteTimeouts{"GET /test": time.Second},
+			handler: func(c *gin.Context) {
+				time.Sleep(100 * time.Millisecond)
+				c.JSON(http.StatusOK, gin.H{"id": 1})
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: `{"id": 1}`,
+		},
+		{
+			name:          "the route has no timeout",
+			routeTimeouts: RouteTimeouts{"GET /test": 0},
+			handler: func(c *gin.Context) {
+				time.Sleep(100 * time.Millisecond)
+				c.JSON(http.StatusOK, gin.H{"id": 1})
+				c.Writer.Flush()
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: `{"id": 1}`,
+			expectedFlushed:      true,
+		},
+		{
+			name: "the panics reach the recovery middleware",
+			handler: func(c *gin.Context) {
+				panic("handler panic")
+			},
+			expectedStatusCode:   http.StatusInternalServerError,
+			expectedResponseBody: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			returned := atomic.Bool{}
+			router := gin.New()
+			router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}))
+			router.Use(TimeoutMiddleware(20*time.Millisecond, testCase.routeTimeouts))
+			router.GET("/test", func(c *gin.Context) {
+				defer returned.Store(true)
+				testCase.handler(c)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.True(t, returned.Load())
+			assert.Equal(t, testCase.expectedStatusCode, rr.Code)
+			if testCase.expectedResponseBody == "" {
+				assert.Empty(t, rr.Body.String())
+			} else {
+				assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+			}
+			assert.Equal(t, testCase.expectedHeader, rr.Header().Get("X-Test"))
+			assert.Equal(t, testCase.expectedFlushed, rr.Flushed)
+		})
+	}
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		timeout              time.Duration
+		expectedResponseBody string
+	}{
+		{
+			name:                 "the handler ends its response after the deadline",
+			timeout:              20 * time.Millisecond,
+			expectedResponseBody: `{"rows": [1], "error": "context deadline exceeded"}`,
+		},
+		{
+			name:                 "no deadline",
+			expectedResponseBody: `{"rows": [1], "error": ""}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Set Gin to test mode
+			gin.SetMode(gin.TestMode)
+
+			// Create a test context and response recorder
+			router := gin.New()
+			router.GET("/test", DeadlineMiddleware(testCase.timeout), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+				_, _ = c.Writer.WriteString(`{"rows": [1]`)
+				c.Writer.Flush()
+				select {
+				case <-c.Request.Context().Done():
+				case <-time.After(100 * time.Millisecond):
+				}
+				errMessage := ""
+				if err := c.Request.Context().Err(); err != nil {
+					errMessage = err.Error()
+				}
+				_, _ = c.Writer.WriteString(`, "error": "` + errMessage + `"}`)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			rr := httptest.NewRecorder()
+
+			// Perform the request
+			router.ServeHTTP(rr, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, rr.Body.String())
+			assert.True(t, rr.Flushed)
+		})
+	}
+}
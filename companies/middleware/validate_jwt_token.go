@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"companies/models"
+	"companies/tenancy"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +13,9 @@ import (
 
 const issuer = "auth"
 
+// TenantHeader the header a super admin sets to query another tenant
+const TenantHeader = "X-Tenant"
+
 func ValidateJWTToken(jwtSecretKey []byte) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -41,8 +46,33 @@ func ValidateJWTToken(jwtSecretKey []byte) gin.HandlerFunc {
 			return
 		}
 
+		if claims.Tenant == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		// a super admin can explicitly switch to another tenant, or read across every tenant
+		scope := tenancy.Scope{Tenant: claims.Tenant}
+		requestedTenant := c.GetHeader(TenantHeader)
+		if requestedTenant != "" && requestedTenant != claims.Tenant {
+			if !slices.Contains(claims.Scopes, tenancy.ScopeCompaniesSuperAdmin) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Tenant not allowed"})
+				return
+			}
+			if requestedTenant == tenancy.AllTenants {
+				if c.Request.Method != http.MethodGet {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "All tenants can only be read"})
+					return
+				}
+				scope = tenancy.Scope{AllTenants: true}
+			} else {
+				scope = tenancy.Scope{Tenant: requestedTenant}
+			}
+		}
+
 		c.Set("username", claims.Username)
 		c.Set("scopes", claims.Scopes)
+		c.Request = c.Request.WithContext(tenancy.WithScope(c.Request.Context(), scope))
 
 		c.Next()
 	}
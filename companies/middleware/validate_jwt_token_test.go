@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"companies/models"
+	"companies/tenancy"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJWTTokenTenant(t *testing.T) {
+	jwtSecretKey := []byte("secret")
+
+	testCases := []struct {
+		name           string
+		method         string
+		tenant         string
+		scopes         []string
+		tenantHeader   string
+		expectedStatus int
+		expectedScope  tenancy.Scope
+	}{
+		{
+			name:           "tenant of the token",
+			method:         http.MethodGet,
+			tenant:         "tenant-a",
+			expectedStatus: http.StatusOK,
+			expectedScope:  tenancy.Scope{Tenant: "tenant-a"},
+		},
+		{
+			name:           "token without tenant",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "same tenant header",
+			method:         http.MethodPost,
+			tenant:         "tenant-a",
+			tenantHeader:   "tenant-a",
+			expectedStatus: http.StatusOK,
+			expectedScope:  tenancy.Scope{Tenant: "tenant-a"},
+		},
+		{
+			name:           "other tenant without the super admin scope",
+			method:         http.MethodGet,
+			tenant:         "tenant-a",
+			tenantHeader:   "tenant-b",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "other tenant with the super admin scope",
+			method:         http.MethodPost,
+			tenant:         "tenant-a",
+			scopes:         []string{tenancy.ScopeCompaniesSuperAdmin},
+			tenantHeader:   "tenant-b",
+			expectedStatus: http.StatusOK,
+			expectedScope:  tenancy.Scope{Tenant: "tenant-b"},
+		},
+		{
+			name:           "all tenants read with the super admin scope",
+			method:         http.MethodGet,
+			tenant:         "tenant-a",
+			scopes:         []string{tenancy.ScopeCompaniesSuperAdmin},
+			tenantHeader:   tenancy.AllTenants,
+			expectedStatus: http.StatusOK,
+			expectedScope:  tenancy.Scope{AllTenants: true},
+		},
+		{
+			name:           "all tenants write with the super admin scope",
+			method:         http.MethodPatch,
+			tenant:         "tenant-a",
+			scopes:         []string{tenancy.ScopeCompaniesSuperAdmin},
+			tenantHeader:   tenancy.AllTenants,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "all tenants without the super admin scope",
+			method:         http.MethodGet,
+			tenant:         "tenant-a",
+			tenantHeader:   tenancy.AllTenants,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			claims := &models.JWTClaims{
+				Username: "user",
+				Tenant:   testCase.tenant,
+				Scopes:   testCase.scopes,
+				RegisteredClaims: jwt.RegisteredClaims{
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+					Issuer:    issuer,
+				},
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecretKey)
+			require.NoError(t, err)
+
+			var scope tenancy.Scope
+			engine := gin.New()
+			engine.Use(ValidateJWTToken(jwtSecretKey))
+			engine.Handle(testCase.method, "/", func(c *gin.Context) {
+				scope, err = tenancy.FromContext(c.Request.Context())
+				require.NoError(t, err)
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(testCase.method, "/", nil)
+			request.Header.Set("Authorization", "Bearer "+token)
+			if testCase.tenantHeader != "" {
+				request.Header.Set(TenantHeader, testCase.tenantHeader)
+			}
+			recorder := httptest.NewRecorder()
+			engine.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.expectedStatus, recorder.Code)
+			assert.Equal(t, testCase.expectedScope, scope)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AttachmentHandler is an autogenerated mock type for the AttachmentHandler type
+type AttachmentHandler struct {
+	mock.Mock
+}
+
+// DeleteAttachment provides a mock function with given fields: c
+func (_m *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetAttachment provides a mock function with given fields: c
+func (_m *AttachmentHandler) GetAttachment(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ListAttachments provides a mock function with given fields: c
+func (_m *AttachmentHandler) ListAttachments(c *gin.Context) {
+	_m.Called(c)
+}
+
+// UploadAttachment provides a mock function with given fields: c
+func (_m *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	_m.Called(c)
+}
+
+// NewAttachmentHandler creates a new instance of AttachmentHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAttachmentHandler(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AttachmentHandler {
+	mock := &AttachmentHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
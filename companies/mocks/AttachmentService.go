@@ -0,0 +1,153 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	io "io"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "companies/models"
+
+	uuid "github.com/google/uuid"
+)
+
+// AttachmentService is an autogenerated mock type for the AttachmentService type
+type AttachmentService struct {
+	mock.Mock
+}
+
+// DeleteAttachment provides a mock function with given fields: ctx, companyId, attachmentId, principal
+func (_m *AttachmentService) DeleteAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID, principal models.Principal) error {
+	ret := _m.Called(ctx, companyId, attachmentId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAttachment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, models.Principal) error); ok {
+		r0 = rf(ctx, companyId, attachmentId, principal)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAttachment provides a mock function with given fields: ctx, companyId, attachmentId
+func (_m *AttachmentService) GetAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID) (models.Attachment, io.ReadCloser, error) {
+	ret := _m.Called(ctx, companyId, attachmentId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAttachment")
+	}
+
+	var r0 models.Attachment
+	var r1 io.ReadCloser
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) (models.Attachment, io.ReadCloser, error)); ok {
+		return rf(ctx, companyId, attachmentId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) models.Attachment); ok {
+		r0 = rf(ctx, companyId, attachmentId)
+	} else {
+		r0 = ret.Get(0).(models.Attachment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) io.ReadCloser); ok {
+		r1 = rf(ctx, companyId, attachmentId)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadCloser)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r2 = rf(ctx, companyId, attachmentId)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListAttachments provides a mock function with given fields: ctx, companyId
+func (_m *AttachmentService) ListAttachments(ctx context.Context, companyId uuid.UUID) ([]models.Attachment, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAttachments")
+	}
+
+	var r0 []models.Attachment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.Attachment, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.Attachment); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Attachment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UploadAttachment provides a mock function with given fields: ctx, companyId, kind, fileName, content, principal
+func (_m *AttachmentService) UploadAttachment(ctx context.Context, companyId uuid.UUID, kind string, fileName string, content []byte, principal models.Principal) (models.Attachment, bool, error) {
+	ret := _m.Called(ctx, companyId, kind, fileName, content, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadAttachment")
+	}
+
+	var r0 models.Attachment
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string, []byte, models.Principal) (models.Attachment, bool, error)); ok {
+		return rf(ctx, companyId, kind, fileName, content, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string, []byte, models.Principal) models.Attachment); ok {
+		r0 = rf(ctx, companyId, kind, fileName, content, principal)
+	} else {
+		r0 = ret.Get(0).(models.Attachment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, string, []byte, models.Principal) bool); ok {
+		r1 = rf(ctx, companyId, kind, fileName, content, principal)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, string, string, []byte, models.Principal) error); ok {
+		r2 = rf(ctx, companyId, kind, fileName, content, principal)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// NewAttachmentService creates a new instance of AttachmentService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAttachmentService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AttachmentService {
+	mock := &AttachmentService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
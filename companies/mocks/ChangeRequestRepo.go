@@ -0,0 +1,155 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "companies/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// ChangeRequestRepo is an autogenerated mock type for the ChangeRequestRepo type
+type ChangeRequestRepo struct {
+	mock.Mock
+}
+
+// CreateChangeRequest provides a mock function with given fields: ctx, changeRequest
+func (_m *ChangeRequestRepo) CreateChangeRequest(ctx context.Context, changeRequest models.ChangeRequest) error {
+	ret := _m.Called(ctx, changeRequest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateChangeRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.ChangeRequest) error); ok {
+		r0 = rf(ctx, changeRequest)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DecideChangeRequest provides a mock function with given fields: ctx, changeRequestId, decision, now
+func (_m *ChangeRequestRepo) DecideChangeRequest(ctx context.Context, changeRequestId uuid.UUID, decision models.ChangeRequest, now time.Time) (models.ChangeRequest, error) {
+	ret := _m.Called(ctx, changeRequestId, decision, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DecideChangeRequest")
+	}
+
+	var r0 models.ChangeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.ChangeRequest, time.Time) (models.ChangeRequest, error)); ok {
+		return rf(ctx, changeRequestId, decision, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.ChangeRequest, time.Time) models.ChangeRequest); ok {
+		r0 = rf(ctx, changeRequestId, decision, now)
+	} else {
+		r0 = ret.Get(0).(models.ChangeRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.ChangeRequest, time.Time) error); ok {
+		r1 = rf(ctx, changeRequestId, decision, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChangeRequest provides a mock function with given fields: ctx, changeRequestId
+func (_m *ChangeRequestRepo) GetChangeRequest(ctx context.Context, changeRequestId uuid.UUID) (models.ChangeRequest, error) {
+	ret := _m.Called(ctx, changeRequestId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChangeRequest")
+	}
+
+	var r0 models.ChangeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (models.ChangeRequest, error)); ok {
+		return rf(ctx, changeRequestId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.ChangeRequest); ok {
+		r0 = rf(ctx, changeRequestId)
+	} else {
+		r0 = ret.Get(0).(models.ChangeRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, changeRequestId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPendingChangeRequests provides a mock function with given fields: ctx, companyId, now
+func (_m *ChangeRequestRepo) ListPendingChangeRequests(ctx context.Context, companyId *uuid.UUID, now time.Time) ([]models.ChangeRequest, error) {
+	ret := _m.Called(ctx, companyId, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPendingChangeRequests")
+	}
+
+	var r0 []models.ChangeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *uuid.UUID, time.Time) ([]models.ChangeRequest, error)); ok {
+		return rf(ctx, companyId, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *uuid.UUID, time.Time) []models.ChangeRequest); ok {
+		r0 = rf(ctx, companyId, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ChangeRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, companyId, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetChangeRequestStatus provides a mock function with given fields: ctx, changeRequestId, status
+func (_m *ChangeRequestRepo) SetChangeRequestStatus(ctx context.Context, changeRequestId uuid.UUID, status string) error {
+	ret := _m.Called(ctx, changeRequestId, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetChangeRequestStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, changeRequestId, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewChangeRequestRepo creates a new instance of ChangeRequestRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewChangeRequestRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ChangeRequestRepo {
+	mock := &ChangeRequestRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
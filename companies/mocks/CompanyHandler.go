@@ -13,6 +13,31 @@ type CompanyHandler struct {
 	mock.Mock
 }
 
+// AddTags provides a mock function with given fields: c
+func (_m *CompanyHandler) AddTags(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ApproveChangeRequest provides a mock function with given fields: c
+func (_m *CompanyHandler) ApproveChangeRequest(c *gin.Context) {
+	_m.Called(c)
+}
+
+// BatchCompanies provides a mock function with given fields: c
+func (_m *CompanyHandler) BatchCompanies(c *gin.Context) {
+	_m.Called(c)
+}
+
+// CompanyStats provides a mock function with given fields: c
+func (_m *CompanyHandler) CompanyStats(c *gin.Context) {
+	_m.Called(c)
+}
+
+// CountTags provides a mock function with given fields: c
+func (_m *CompanyHandler) CountTags(c *gin.Context) {
+	_m.Called(c)
+}
+
 // CreateCompany provides a mock function with given fields: c
 func (_m *CompanyHandler) CreateCompany(c *gin.Context) {
 	_m.Called(c)
@@ -23,16 +48,101 @@ func (_m *CompanyHandler) DeleteCompany(c *gin.Context) {
 	_m.Called(c)
 }
 
+// EmployeeStats provides a mock function with given fields: c
+func (_m *CompanyHandler) EmployeeStats(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ExportCompanies provides a mock function with given fields: c
+func (_m *CompanyHandler) ExportCompanies(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetAncestors provides a mock function with given fields: c
+func (_m *CompanyHandler) GetAncestors(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetChangeRequest provides a mock function with given fields: c
+func (_m *CompanyHandler) GetChangeRequest(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetChildren provides a mock function with given fields: c
+func (_m *CompanyHandler) GetChildren(c *gin.Context) {
+	_m.Called(c)
+}
+
 // GetCompany provides a mock function with given fields: c
 func (_m *CompanyHandler) GetCompany(c *gin.Context) {
 	_m.Called(c)
 }
 
+// GetCompanyByIdentifier provides a mock function with given fields: c
+func (_m *CompanyHandler) GetCompanyByIdentifier(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetSubtree provides a mock function with given fields: c
+func (_m *CompanyHandler) GetSubtree(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ImportCompanies provides a mock function with given fields: c
+func (_m *CompanyHandler) ImportCompanies(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ListChangeRequests provides a mock function with given fields: c
+func (_m *CompanyHandler) ListChangeRequests(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ListCompanies provides a mock function with given fields: c
+func (_m *CompanyHandler) ListCompanies(c *gin.Context) {
+	_m.Called(c)
+}
+
+// MergeCompanies provides a mock function with given fields: c
+func (_m *CompanyHandler) MergeCompanies(c *gin.Context) {
+	_m.Called(c)
+}
+
 // PatchCompany provides a mock function with given fields: c
 func (_m *CompanyHandler) PatchCompany(c *gin.Context) {
 	_m.Called(c)
 }
 
+// RejectChangeRequest provides a mock function with given fields: c
+func (_m *CompanyHandler) RejectChangeRequest(c *gin.Context) {
+	_m.Called(c)
+}
+
+// RemoveParent provides a mock function with given fields: c
+func (_m *CompanyHandler) RemoveParent(c *gin.Context) {
+	_m.Called(c)
+}
+
+// RemoveTag provides a mock function with given fields: c
+func (_m *CompanyHandler) RemoveTag(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ReportDuplicates provides a mock function with given fields: c
+func (_m *CompanyHandler) ReportDuplicates(c *gin.Context) {
+	_m.Called(c)
+}
+
+// SearchCompanies provides a mock function with given fields: c
+func (_m *CompanyHandler) SearchCompanies(c *gin.Context) {
+	_m.Called(c)
+}
+
+// TransitionCompany provides a mock function with given fields: c
+func (_m *CompanyHandler) TransitionCompany(c *gin.Context) {
+	_m.Called(c)
+}
+
 // NewCompanyHandler creates a new instance of CompanyHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCompanyHandler(t interface {
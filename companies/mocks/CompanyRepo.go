@@ -16,6 +16,148 @@ type CompanyRepo struct {
 	mock.Mock
 }
 
+// AddAttachment provides a mock function with given fields: ctx, companyId, attachment, stamp
+func (_m *CompanyRepo) AddAttachment(ctx context.Context, companyId uuid.UUID, attachment models.Attachment, stamp models.AuditStamp) (models.Company, error) {
+	ret := _m.Called(ctx, companyId, attachment, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddAttachment")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Attachment, models.AuditStamp) (models.Company, error)); ok {
+		return rf(ctx, companyId, attachment, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Attachment, models.AuditStamp) models.Company); ok {
+		r0 = rf(ctx, companyId, attachment, stamp)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Attachment, models.AuditStamp) error); ok {
+		r1 = rf(ctx, companyId, attachment, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddTags provides a mock function with given fields: ctx, companyId, tags, stamp
+func (_m *CompanyRepo) AddTags(ctx context.Context, companyId uuid.UUID, tags []string, stamp models.AuditStamp) (models.Company, error) {
+	ret := _m.Called(ctx, companyId, tags, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTags")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string, models.AuditStamp) (models.Company, error)); ok {
+		return rf(ctx, companyId, tags, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string, models.AuditStamp) models.Company); ok {
+		r0 = rf(ctx, companyId, tags, stamp)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, []string, models.AuditStamp) error); ok {
+		r1 = rf(ctx, companyId, tags, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompanyNameExists provides a mock function with given fields: ctx, name
+func (_m *CompanyRepo) CompanyNameExists(ctx context.Context, name string) (bool, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompanyNameExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompanyStats provides a mock function with given fields: ctx, query
+func (_m *CompanyRepo) CompanyStats(ctx context.Context, query models.CompanyQuery) (models.CompanyStats, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompanyStats")
+	}
+
+	var r0 models.CompanyStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) (models.CompanyStats, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) models.CompanyStats); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(models.CompanyStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountTags provides a mock function with given fields: ctx
+func (_m *CompanyRepo) CountTags(ctx context.Context) ([]models.TagCount, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountTags")
+	}
+
+	var r0 []models.TagCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.TagCount, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.TagCount); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TagCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateCompany provides a mock function with given fields: ctx, company
 func (_m *CompanyRepo) CreateCompany(ctx context.Context, company models.Company) (uuid.UUID, error) {
 	ret := _m.Called(ctx, company)
@@ -46,6 +188,24 @@ func (_m *CompanyRepo) CreateCompany(ctx context.Context, company models.Company
 	return r0, r1
 }
 
+// CreateTombstone provides a mock function with given fields: ctx, tombstone
+func (_m *CompanyRepo) CreateTombstone(ctx context.Context, tombstone models.CompanyTombstone) error {
+	ret := _m.Called(ctx, tombstone)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTombstone")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyTombstone) error); ok {
+		r0 = rf(ctx, tombstone)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteCompany provides a mock function with given fields: ctx, companyId
 func (_m *CompanyRepo) DeleteCompany(ctx context.Context, companyId uuid.UUID) error {
 	ret := _m.Called(ctx, companyId)
@@ -64,6 +224,172 @@ func (_m *CompanyRepo) DeleteCompany(ctx context.Context, companyId uuid.UUID) e
 	return r0
 }
 
+// DetachChildren provides a mock function with given fields: ctx, parentId, stamp
+func (_m *CompanyRepo) DetachChildren(ctx context.Context, parentId uuid.UUID, stamp models.AuditStamp) ([]uuid.UUID, error) {
+	ret := _m.Called(ctx, parentId, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DetachChildren")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.AuditStamp) ([]uuid.UUID, error)); ok {
+		return rf(ctx, parentId, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.AuditStamp) []uuid.UUID); ok {
+		r0 = rf(ctx, parentId, stamp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.AuditStamp) error); ok {
+		r1 = rf(ctx, parentId, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EmployeeStats provides a mock function with given fields: ctx, query, buckets, percentiles
+func (_m *CompanyRepo) EmployeeStats(ctx context.Context, query models.CompanyQuery, buckets []int, percentiles []float64) (models.EmployeeStats, error) {
+	ret := _m.Called(ctx, query, buckets, percentiles)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EmployeeStats")
+	}
+
+	var r0 models.EmployeeStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery, []int, []float64) (models.EmployeeStats, error)); ok {
+		return rf(ctx, query, buckets, percentiles)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery, []int, []float64) models.EmployeeStats); ok {
+		r0 = rf(ctx, query, buckets, percentiles)
+	} else {
+		r0 = ret.Get(0).(models.EmployeeStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyQuery, []int, []float64) error); ok {
+		r1 = rf(ctx, query, buckets, percentiles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportCompanies provides a mock function with given fields: ctx, query, fn
+func (_m *CompanyRepo) ExportCompanies(ctx context.Context, query models.CompanyQuery, fn func(models.Company) error) error {
+	ret := _m.Called(ctx, query, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportCompanies")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery, func(models.Company) error) error); ok {
+		r0 = rf(ctx, query, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindNameCandidates provides a mock function with given fields: ctx, trigrams, limit
+func (_m *CompanyRepo) FindNameCandidates(ctx context.Context, trigrams []string, limit int) ([]models.Company, error) {
+	ret := _m.Called(ctx, trigrams, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindNameCandidates")
+	}
+
+	var r0 []models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int) ([]models.Company, error)); ok {
+		return rf(ctx, trigrams, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int) []models.Company); ok {
+		r0 = rf(ctx, trigrams, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Company)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, int) error); ok {
+		r1 = rf(ctx, trigrams, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAncestors provides a mock function with given fields: ctx, companyId
+func (_m *CompanyRepo) GetAncestors(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNode, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAncestors")
+	}
+
+	var r0 []models.CompanyNode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.CompanyNode, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.CompanyNode); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanyNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCompanies provides a mock function with given fields: ctx, companyIds
+func (_m *CompanyRepo) GetCompanies(ctx context.Context, companyIds []uuid.UUID) ([]models.Company, error) {
+	ret := _m.Called(ctx, companyIds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCompanies")
+	}
+
+	var r0 []models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) ([]models.Company, error)); ok {
+		return rf(ctx, companyIds)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []uuid.UUID) []models.Company); ok {
+		r0 = rf(ctx, companyIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Company)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []uuid.UUID) error); ok {
+		r1 = rf(ctx, companyIds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCompany provides a mock function with given fields: ctx, companyId
 func (_m *CompanyRepo) GetCompany(ctx context.Context, companyId uuid.UUID) (models.Company, error) {
 	ret := _m.Called(ctx, companyId)
@@ -92,9 +418,155 @@ func (_m *CompanyRepo) GetCompany(ctx context.Context, companyId uuid.UUID) (mod
 	return r0, r1
 }
 
-// PatchCompany provides a mock function with given fields: ctx, companyId, company
-func (_m *CompanyRepo) PatchCompany(ctx context.Context, companyId uuid.UUID, company models.UpdateCompanyInput) (models.Company, error) {
-	ret := _m.Called(ctx, companyId, company)
+// GetCompanyByIdentifier provides a mock function with given fields: ctx, scheme, value
+func (_m *CompanyRepo) GetCompanyByIdentifier(ctx context.Context, scheme string, value string) (models.Company, error) {
+	ret := _m.Called(ctx, scheme, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCompanyByIdentifier")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (models.Company, error)); ok {
+		return rf(ctx, scheme, value)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.Company); ok {
+		r0 = rf(ctx, scheme, value)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, scheme, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDescendants provides a mock function with given fields: ctx, companyId, maxDepth
+func (_m *CompanyRepo) GetDescendants(ctx context.Context, companyId uuid.UUID, maxDepth *int) ([]models.CompanyNode, error) {
+	ret := _m.Called(ctx, companyId, maxDepth)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDescendants")
+	}
+
+	var r0 []models.CompanyNode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *int) ([]models.CompanyNode, error)); ok {
+		return rf(ctx, companyId, maxDepth)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *int) []models.CompanyNode); ok {
+		r0 = rf(ctx, companyId, maxDepth)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanyNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, *int) error); ok {
+		r1 = rf(ctx, companyId, maxDepth)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTombstone provides a mock function with given fields: ctx, companyId
+func (_m *CompanyRepo) GetTombstone(ctx context.Context, companyId uuid.UUID) (models.CompanyTombstone, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTombstone")
+	}
+
+	var r0 models.CompanyTombstone
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (models.CompanyTombstone, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.CompanyTombstone); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		r0 = ret.Get(0).(models.CompanyTombstone)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCompanies provides a mock function with given fields: ctx, query
+func (_m *CompanyRepo) ListCompanies(ctx context.Context, query models.CompanyQuery) ([]models.Company, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCompanies")
+	}
+
+	var r0 []models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) ([]models.Company, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) []models.Company); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Company)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MoveChildren provides a mock function with given fields: ctx, fromParentId, toParentId, stamp
+func (_m *CompanyRepo) MoveChildren(ctx context.Context, fromParentId uuid.UUID, toParentId uuid.UUID, stamp models.AuditStamp) ([]models.Company, error) {
+	ret := _m.Called(ctx, fromParentId, toParentId, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MoveChildren")
+	}
+
+	var r0 []models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, models.AuditStamp) ([]models.Company, error)); ok {
+		return rf(ctx, fromParentId, toParentId, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, models.AuditStamp) []models.Company); ok {
+		r0 = rf(ctx, fromParentId, toParentId, stamp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Company)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, models.AuditStamp) error); ok {
+		r1 = rf(ctx, fromParentId, toParentId, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchCompany provides a mock function with given fields: ctx, companyId, company, expectedVersion, stamp
+func (_m *CompanyRepo) PatchCompany(ctx context.Context, companyId uuid.UUID, company models.UpdateCompanyInput, expectedVersion *int, stamp models.AuditStamp) (models.Company, error) {
+	ret := _m.Called(ctx, companyId, company, expectedVersion, stamp)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PatchCompany")
@@ -102,17 +574,193 @@ func (_m *CompanyRepo) PatchCompany(ctx context.Context, companyId uuid.UUID, co
 
 	var r0 models.Company
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput) (models.Company, error)); ok {
-		return rf(ctx, companyId, company)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, *int, models.AuditStamp) (models.Company, error)); ok {
+		return rf(ctx, companyId, company, expectedVersion, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, *int, models.AuditStamp) models.Company); ok {
+		r0 = rf(ctx, companyId, company, expectedVersion, stamp)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, *int, models.AuditStamp) error); ok {
+		r1 = rf(ctx, companyId, company, expectedVersion, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveAttachment provides a mock function with given fields: ctx, companyId, attachmentId, stamp
+func (_m *CompanyRepo) RemoveAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID, stamp models.AuditStamp) (models.Attachment, error) {
+	ret := _m.Called(ctx, companyId, attachmentId, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveAttachment")
+	}
+
+	var r0 models.Attachment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, models.AuditStamp) (models.Attachment, error)); ok {
+		return rf(ctx, companyId, attachmentId, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, models.AuditStamp) models.Attachment); ok {
+		r0 = rf(ctx, companyId, attachmentId, stamp)
+	} else {
+		r0 = ret.Get(0).(models.Attachment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, models.AuditStamp) error); ok {
+		r1 = rf(ctx, companyId, attachmentId, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveTag provides a mock function with given fields: ctx, companyId, tag, stamp
+func (_m *CompanyRepo) RemoveTag(ctx context.Context, companyId uuid.UUID, tag string, stamp models.AuditStamp) (models.Company, error) {
+	ret := _m.Called(ctx, companyId, tag, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveTag")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.AuditStamp) (models.Company, error)); ok {
+		return rf(ctx, companyId, tag, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.AuditStamp) models.Company); ok {
+		r0 = rf(ctx, companyId, tag, stamp)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, models.AuditStamp) error); ok {
+		r1 = rf(ctx, companyId, tag, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceCompany provides a mock function with given fields: ctx, company, expectedVersion, stamp
+func (_m *CompanyRepo) ReplaceCompany(ctx context.Context, company models.Company, expectedVersion int, stamp models.AuditStamp) (models.Company, error) {
+	ret := _m.Called(ctx, company, expectedVersion, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceCompany")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Company, int, models.AuditStamp) (models.Company, error)); ok {
+		return rf(ctx, company, expectedVersion, stamp)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Company, int, models.AuditStamp) models.Company); ok {
+		r0 = rf(ctx, company, expectedVersion, stamp)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Company, int, models.AuditStamp) error); ok {
+		r1 = rf(ctx, company, expectedVersion, stamp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetNameCanonical provides a mock function with given fields: ctx, companyId, nameCanonical
+func (_m *CompanyRepo) SetNameCanonical(ctx context.Context, companyId uuid.UUID, nameCanonical string) error {
+	ret := _m.Called(ctx, companyId, nameCanonical)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetNameCanonical")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, companyId, nameCanonical)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNameKeys provides a mock function with given fields: ctx, companyId, nameKey, nameTrigrams
+func (_m *CompanyRepo) SetNameKeys(ctx context.Context, companyId uuid.UUID, nameKey string, nameTrigrams []string) error {
+	ret := _m.Called(ctx, companyId, nameKey, nameTrigrams)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetNameKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, []string) error); ok {
+		r0 = rf(ctx, companyId, nameKey, nameTrigrams)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetStatus provides a mock function with given fields: ctx, companyId, from, change
+func (_m *CompanyRepo) SetStatus(ctx context.Context, companyId uuid.UUID, from string, change models.StatusChange) (models.Company, error) {
+	ret := _m.Called(ctx, companyId, from, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetStatus")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.StatusChange) (models.Company, error)); ok {
+		return rf(ctx, companyId, from, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.StatusChange) models.Company); ok {
+		r0 = rf(ctx, companyId, from, change)
+	} else {
+		r0 = ret.Get(0).(models.Company)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, models.StatusChange) error); ok {
+		r1 = rf(ctx, companyId, from, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnsetParent provides a mock function with given fields: ctx, companyId, stamp
+func (_m *CompanyRepo) UnsetParent(ctx context.Context, companyId uuid.UUID, stamp models.AuditStamp) (models.Company, error) {
+	ret := _m.Called(ctx, companyId, stamp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnsetParent")
+	}
+
+	var r0 models.Company
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.AuditStamp) (models.Company, error)); ok {
+		return rf(ctx, companyId, stamp)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput) models.Company); ok {
-		r0 = rf(ctx, companyId, company)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.AuditStamp) models.Company); ok {
+		r0 = rf(ctx, companyId, stamp)
 	} else {
 		r0 = ret.Get(0).(models.Company)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.UpdateCompanyInput) error); ok {
-		r1 = rf(ctx, companyId, company)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.AuditStamp) error); ok {
+		r1 = rf(ctx, companyId, stamp)
 	} else {
 		r1 = ret.Error(1)
 	}
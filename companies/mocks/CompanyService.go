@@ -16,9 +16,153 @@ type CompanyService struct {
 	mock.Mock
 }
 
-// CreateCompany provides a mock function with given fields: ctx, companyInput
-func (_m *CompanyService) CreateCompany(ctx context.Context, companyInput models.CompanyInput) (models.CompanyOutput, error) {
-	ret := _m.Called(ctx, companyInput)
+// AddTags provides a mock function with given fields: ctx, companyId, tags, principal
+func (_m *CompanyService) AddTags(ctx context.Context, companyId uuid.UUID, tags []string, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, companyId, tags, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddTags")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, companyId, tags, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyId, tags, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, []string, models.Principal) error); ok {
+		r1 = rf(ctx, companyId, tags, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ApproveChangeRequest provides a mock function with given fields: ctx, changeRequestId, principal
+func (_m *CompanyService) ApproveChangeRequest(ctx context.Context, changeRequestId uuid.UUID, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, changeRequestId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApproveChangeRequest")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, changeRequestId, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, changeRequestId, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r1 = rf(ctx, changeRequestId, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BatchCompanies provides a mock function with given fields: ctx, operations, allOrNothing, principal
+func (_m *CompanyService) BatchCompanies(ctx context.Context, operations []models.BatchOperation, allOrNothing bool, principal models.Principal) ([]models.BatchOperationResult, error) {
+	ret := _m.Called(ctx, operations, allOrNothing, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BatchCompanies")
+	}
+
+	var r0 []models.BatchOperationResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.BatchOperation, bool, models.Principal) ([]models.BatchOperationResult, error)); ok {
+		return rf(ctx, operations, allOrNothing, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []models.BatchOperation, bool, models.Principal) []models.BatchOperationResult); ok {
+		r0 = rf(ctx, operations, allOrNothing, principal)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BatchOperationResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []models.BatchOperation, bool, models.Principal) error); ok {
+		r1 = rf(ctx, operations, allOrNothing, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompanyStats provides a mock function with given fields: ctx, query
+func (_m *CompanyService) CompanyStats(ctx context.Context, query models.CompanyQuery) (models.CompanyStats, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompanyStats")
+	}
+
+	var r0 models.CompanyStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) (models.CompanyStats, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) models.CompanyStats); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(models.CompanyStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountTags provides a mock function with given fields: ctx
+func (_m *CompanyService) CountTags(ctx context.Context) ([]models.TagCount, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountTags")
+	}
+
+	var r0 []models.TagCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.TagCount, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.TagCount); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TagCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCompany provides a mock function with given fields: ctx, companyInput, principal
+func (_m *CompanyService) CreateCompany(ctx context.Context, companyInput models.CompanyInput, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, companyInput, principal)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateCompany")
@@ -26,17 +170,17 @@ func (_m *CompanyService) CreateCompany(ctx context.Context, companyInput models
 
 	var r0 models.CompanyOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyInput) (models.CompanyOutput, error)); ok {
-		return rf(ctx, companyInput)
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyInput, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, companyInput, principal)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyInput) models.CompanyOutput); ok {
-		r0 = rf(ctx, companyInput)
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyInput, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyInput, principal)
 	} else {
 		r0 = ret.Get(0).(models.CompanyOutput)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyInput) error); ok {
-		r1 = rf(ctx, companyInput)
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyInput, models.Principal) error); ok {
+		r1 = rf(ctx, companyInput, principal)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,17 +188,17 @@ func (_m *CompanyService) CreateCompany(ctx context.Context, companyInput models
 	return r0, r1
 }
 
-// DeleteCompany provides a mock function with given fields: ctx, companyId
-func (_m *CompanyService) DeleteCompany(ctx context.Context, companyId uuid.UUID) error {
-	ret := _m.Called(ctx, companyId)
+// DeleteCompany provides a mock function with given fields: ctx, companyId, principal
+func (_m *CompanyService) DeleteCompany(ctx context.Context, companyId uuid.UUID, principal models.Principal) error {
+	ret := _m.Called(ctx, companyId, principal)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteCompany")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, companyId)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r0 = rf(ctx, companyId, principal)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -62,6 +206,170 @@ func (_m *CompanyService) DeleteCompany(ctx context.Context, companyId uuid.UUID
 	return r0
 }
 
+// EmployeeStats provides a mock function with given fields: ctx, query, statsQuery
+func (_m *CompanyService) EmployeeStats(ctx context.Context, query models.CompanyQuery, statsQuery models.EmployeeStatsQuery) (models.EmployeeStats, error) {
+	ret := _m.Called(ctx, query, statsQuery)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EmployeeStats")
+	}
+
+	var r0 models.EmployeeStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery, models.EmployeeStatsQuery) (models.EmployeeStats, error)); ok {
+		return rf(ctx, query, statsQuery)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery, models.EmployeeStatsQuery) models.EmployeeStats); ok {
+		r0 = rf(ctx, query, statsQuery)
+	} else {
+		r0 = ret.Get(0).(models.EmployeeStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyQuery, models.EmployeeStatsQuery) error); ok {
+		r1 = rf(ctx, query, statsQuery)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportCompanies provides a mock function with given fields: ctx, query, fn
+func (_m *CompanyService) ExportCompanies(ctx context.Context, query models.CompanyQuery, fn func(models.CompanyOutput) error) error {
+	ret := _m.Called(ctx, query, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportCompanies")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery, func(models.CompanyOutput) error) error); ok {
+		r0 = rf(ctx, query, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindDuplicates provides a mock function with given fields: ctx, name, excludeId
+func (_m *CompanyService) FindDuplicates(ctx context.Context, name string, excludeId *uuid.UUID) ([]models.DuplicateCandidate, error) {
+	ret := _m.Called(ctx, name, excludeId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindDuplicates")
+	}
+
+	var r0 []models.DuplicateCandidate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *uuid.UUID) ([]models.DuplicateCandidate, error)); ok {
+		return rf(ctx, name, excludeId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *uuid.UUID) []models.DuplicateCandidate); ok {
+		r0 = rf(ctx, name, excludeId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DuplicateCandidate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *uuid.UUID) error); ok {
+		r1 = rf(ctx, name, excludeId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAncestors provides a mock function with given fields: ctx, companyId
+func (_m *CompanyService) GetAncestors(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAncestors")
+	}
+
+	var r0 []models.CompanyNodeOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.CompanyNodeOutput, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.CompanyNodeOutput); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanyNodeOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChangeRequest provides a mock function with given fields: ctx, changeRequestId
+func (_m *CompanyService) GetChangeRequest(ctx context.Context, changeRequestId uuid.UUID) (models.ChangeRequest, error) {
+	ret := _m.Called(ctx, changeRequestId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChangeRequest")
+	}
+
+	var r0 models.ChangeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (models.ChangeRequest, error)); ok {
+		return rf(ctx, changeRequestId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.ChangeRequest); ok {
+		r0 = rf(ctx, changeRequestId)
+	} else {
+		r0 = ret.Get(0).(models.ChangeRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, changeRequestId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetChildren provides a mock function with given fields: ctx, companyId
+func (_m *CompanyService) GetChildren(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChildren")
+	}
+
+	var r0 []models.CompanyNodeOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.CompanyNodeOutput, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.CompanyNodeOutput); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanyNodeOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCompany provides a mock function with given fields: ctx, companyId
 func (_m *CompanyService) GetCompany(ctx context.Context, companyId uuid.UUID) (models.CompanyOutput, error) {
 	ret := _m.Called(ctx, companyId)
@@ -90,27 +398,380 @@ func (_m *CompanyService) GetCompany(ctx context.Context, companyId uuid.UUID) (
 	return r0, r1
 }
 
-// PatchCompany provides a mock function with given fields: ctx, companyId, updateCompanyInput
-func (_m *CompanyService) PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput) (models.CompanyOutput, error) {
-	ret := _m.Called(ctx, companyId, updateCompanyInput)
+// GetCompanyByIdentifier provides a mock function with given fields: ctx, scheme, value
+func (_m *CompanyService) GetCompanyByIdentifier(ctx context.Context, scheme string, value string) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, scheme, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCompanyByIdentifier")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (models.CompanyOutput, error)); ok {
+		return rf(ctx, scheme, value)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) models.CompanyOutput); ok {
+		r0 = rf(ctx, scheme, value)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, scheme, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubtree provides a mock function with given fields: ctx, companyId
+func (_m *CompanyService) GetSubtree(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubtree")
+	}
+
+	var r0 []models.CompanyNodeOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]models.CompanyNodeOutput, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []models.CompanyNodeOutput); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanyNodeOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportCompany provides a mock function with given fields: ctx, companyInput, dryRun, principal
+func (_m *CompanyService) ImportCompany(ctx context.Context, companyInput models.CompanyInput, dryRun bool, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, companyInput, dryRun, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportCompany")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyInput, bool, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, companyInput, dryRun, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyInput, bool, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyInput, dryRun, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyInput, bool, models.Principal) error); ok {
+		r1 = rf(ctx, companyInput, dryRun, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListChangeRequests provides a mock function with given fields: ctx, companyId
+func (_m *CompanyService) ListChangeRequests(ctx context.Context, companyId *uuid.UUID) ([]models.ChangeRequest, error) {
+	ret := _m.Called(ctx, companyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListChangeRequests")
+	}
+
+	var r0 []models.ChangeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *uuid.UUID) ([]models.ChangeRequest, error)); ok {
+		return rf(ctx, companyId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *uuid.UUID) []models.ChangeRequest); ok {
+		r0 = rf(ctx, companyId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ChangeRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *uuid.UUID) error); ok {
+		r1 = rf(ctx, companyId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCompanies provides a mock function with given fields: ctx, query
+func (_m *CompanyService) ListCompanies(ctx context.Context, query models.CompanyQuery) ([]models.CompanyOutput, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCompanies")
+	}
+
+	var r0 []models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) ([]models.CompanyOutput, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CompanyQuery) []models.CompanyOutput); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanyOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CompanyQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeCompanies provides a mock function with given fields: ctx, targetId, input, principal
+func (_m *CompanyService) MergeCompanies(ctx context.Context, targetId uuid.UUID, input models.MergeInput, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, targetId, input, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeCompanies")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.MergeInput, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, targetId, input, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.MergeInput, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, targetId, input, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.MergeInput, models.Principal) error); ok {
+		r1 = rf(ctx, targetId, input, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchCompany provides a mock function with given fields: ctx, companyId, updateCompanyInput, principal
+func (_m *CompanyService) PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput, principal models.Principal) (models.CompanyOutput, *models.ChangeRequest, error) {
+	ret := _m.Called(ctx, companyId, updateCompanyInput, principal)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PatchCompany")
 	}
 
+	var r0 models.CompanyOutput
+	var r1 *models.ChangeRequest
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, models.Principal) (models.CompanyOutput, *models.ChangeRequest, error)); ok {
+		return rf(ctx, companyId, updateCompanyInput, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyId, updateCompanyInput, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, models.Principal) *models.ChangeRequest); ok {
+		r1 = rf(ctx, companyId, updateCompanyInput, principal)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.ChangeRequest)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, models.UpdateCompanyInput, models.Principal) error); ok {
+		r2 = rf(ctx, companyId, updateCompanyInput, principal)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RejectChangeRequest provides a mock function with given fields: ctx, changeRequestId, input, principal
+func (_m *CompanyService) RejectChangeRequest(ctx context.Context, changeRequestId uuid.UUID, input models.RejectChangeRequestInput, principal models.Principal) (models.ChangeRequest, error) {
+	ret := _m.Called(ctx, changeRequestId, input, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RejectChangeRequest")
+	}
+
+	var r0 models.ChangeRequest
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.RejectChangeRequestInput, models.Principal) (models.ChangeRequest, error)); ok {
+		return rf(ctx, changeRequestId, input, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.RejectChangeRequestInput, models.Principal) models.ChangeRequest); ok {
+		r0 = rf(ctx, changeRequestId, input, principal)
+	} else {
+		r0 = ret.Get(0).(models.ChangeRequest)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.RejectChangeRequestInput, models.Principal) error); ok {
+		r1 = rf(ctx, changeRequestId, input, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveParent provides a mock function with given fields: ctx, companyId, principal
+func (_m *CompanyService) RemoveParent(ctx context.Context, companyId uuid.UUID, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, companyId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveParent")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, companyId, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyId, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r1 = rf(ctx, companyId, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveTag provides a mock function with given fields: ctx, companyId, tag, principal
+func (_m *CompanyService) RemoveTag(ctx context.Context, companyId uuid.UUID, tag string, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, companyId, tag, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveTag")
+	}
+
+	var r0 models.CompanyOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, companyId, tag, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyId, tag, principal)
+	} else {
+		r0 = ret.Get(0).(models.CompanyOutput)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, models.Principal) error); ok {
+		r1 = rf(ctx, companyId, tag, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReportDuplicates provides a mock function with given fields: ctx, threshold, progress
+func (_m *CompanyService) ReportDuplicates(ctx context.Context, threshold float64, progress func()) (models.DuplicatesReport, error) {
+	ret := _m.Called(ctx, threshold, progress)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReportDuplicates")
+	}
+
+	var r0 models.DuplicatesReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, float64, func()) (models.DuplicatesReport, error)); ok {
+		return rf(ctx, threshold, progress)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, float64, func()) models.DuplicatesReport); ok {
+		r0 = rf(ctx, threshold, progress)
+	} else {
+		r0 = ret.Get(0).(models.DuplicatesReport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, float64, func()) error); ok {
+		r1 = rf(ctx, threshold, progress)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchCompanies provides a mock function with given fields: ctx, query, fields
+func (_m *CompanyService) SearchCompanies(ctx context.Context, query models.SearchQuery, fields []string) ([]models.CompanySearchHit, error) {
+	ret := _m.Called(ctx, query, fields)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchCompanies")
+	}
+
+	var r0 []models.CompanySearchHit
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SearchQuery, []string) ([]models.CompanySearchHit, error)); ok {
+		return rf(ctx, query, fields)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SearchQuery, []string) []models.CompanySearchHit); ok {
+		r0 = rf(ctx, query, fields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CompanySearchHit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SearchQuery, []string) error); ok {
+		r1 = rf(ctx, query, fields)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionCompany provides a mock function with given fields: ctx, companyId, input, principal
+func (_m *CompanyService) TransitionCompany(ctx context.Context, companyId uuid.UUID, input models.TransitionInput, principal models.Principal) (models.CompanyOutput, error) {
+	ret := _m.Called(ctx, companyId, input, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionCompany")
+	}
+
 	var r0 models.CompanyOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput) (models.CompanyOutput, error)); ok {
-		return rf(ctx, companyId, updateCompanyInput)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.TransitionInput, models.Principal) (models.CompanyOutput, error)); ok {
+		return rf(ctx, companyId, input, principal)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.UpdateCompanyInput) models.CompanyOutput); ok {
-		r0 = rf(ctx, companyId, updateCompanyInput)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.TransitionInput, models.Principal) models.CompanyOutput); ok {
+		r0 = rf(ctx, companyId, input, principal)
 	} else {
 		r0 = ret.Get(0).(models.CompanyOutput)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.UpdateCompanyInput) error); ok {
-		r1 = rf(ctx, companyId, updateCompanyInput)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.TransitionInput, models.Principal) error); ok {
+		r1 = rf(ctx, companyId, input, principal)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,48 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// JobHandler is an autogenerated mock type for the JobHandler type
+type JobHandler struct {
+	mock.Mock
+}
+
+// CancelJob provides a mock function with given fields: c
+func (_m *JobHandler) CancelJob(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetJob provides a mock function with given fields: c
+func (_m *JobHandler) GetJob(c *gin.Context) {
+	_m.Called(c)
+}
+
+// GetJobResult provides a mock function with given fields: c
+func (_m *JobHandler) GetJobResult(c *gin.Context) {
+	_m.Called(c)
+}
+
+// RetryJob provides a mock function with given fields: c
+func (_m *JobHandler) RetryJob(c *gin.Context) {
+	_m.Called(c)
+}
+
+// NewJobHandler creates a new instance of JobHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewJobHandler(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *JobHandler {
+	mock := &JobHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
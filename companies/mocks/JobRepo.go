@@ -0,0 +1,247 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "companies/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// JobRepo is an autogenerated mock type for the JobRepo type
+type JobRepo struct {
+	mock.Mock
+}
+
+// CancelJob provides a mock function with given fields: ctx, jobId, now
+func (_m *JobRepo) CancelJob(ctx context.Context, jobId uuid.UUID, now time.Time) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) (models.Job, error)); ok {
+		return rf(ctx, jobId, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) models.Job); ok {
+		r0 = rf(ctx, jobId, now)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, jobId, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimJob provides a mock function with given fields: ctx, jobTypes, owner, leaseExpiresAt, now
+func (_m *JobRepo) ClaimJob(ctx context.Context, jobTypes []string, owner string, leaseExpiresAt time.Time, now time.Time) (models.Job, error) {
+	ret := _m.Called(ctx, jobTypes, owner, leaseExpiresAt, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, time.Time, time.Time) (models.Job, error)); ok {
+		return rf(ctx, jobTypes, owner, leaseExpiresAt, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, time.Time, time.Time) models.Job); ok {
+		r0 = rf(ctx, jobTypes, owner, leaseExpiresAt, now)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, jobTypes, owner, leaseExpiresAt, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateJob provides a mock function with given fields: ctx, job
+func (_m *JobRepo) CreateJob(ctx context.Context, job models.Job) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishJob provides a mock function with given fields: ctx, jobId, owner, result, now
+func (_m *JobRepo) FinishJob(ctx context.Context, jobId uuid.UUID, owner string, result models.Job, now time.Time) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, owner, result, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.Job, time.Time) (models.Job, error)); ok {
+		return rf(ctx, jobId, owner, result, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, models.Job, time.Time) models.Job); ok {
+		r0 = rf(ctx, jobId, owner, result, now)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, models.Job, time.Time) error); ok {
+		r1 = rf(ctx, jobId, owner, result, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJob provides a mock function with given fields: ctx, jobId
+func (_m *JobRepo) GetJob(ctx context.Context, jobId uuid.UUID) (models.Job, error) {
+	ret := _m.Called(ctx, jobId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (models.Job, error)); ok {
+		return rf(ctx, jobId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.Job); ok {
+		r0 = rf(ctx, jobId)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, jobId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseJob provides a mock function with given fields: ctx, jobId, owner, now
+func (_m *JobRepo) ReleaseJob(ctx context.Context, jobId uuid.UUID, owner string, now time.Time) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, owner, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, time.Time) (models.Job, error)); ok {
+		return rf(ctx, jobId, owner, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, time.Time) models.Job); ok {
+		r0 = rf(ctx, jobId, owner, now)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, time.Time) error); ok {
+		r1 = rf(ctx, jobId, owner, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RenewLease provides a mock function with given fields: ctx, jobId, owner, progress, leaseExpiresAt, now
+func (_m *JobRepo) RenewLease(ctx context.Context, jobId uuid.UUID, owner string, progress int, leaseExpiresAt time.Time, now time.Time) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, owner, progress, leaseExpiresAt, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenewLease")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, int, time.Time, time.Time) (models.Job, error)); ok {
+		return rf(ctx, jobId, owner, progress, leaseExpiresAt, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, int, time.Time, time.Time) models.Job); ok {
+		r0 = rf(ctx, jobId, owner, progress, leaseExpiresAt, now)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, int, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, jobId, owner, progress, leaseExpiresAt, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetryJob provides a mock function with given fields: ctx, jobId, now
+func (_m *JobRepo) RetryJob(ctx context.Context, jobId uuid.UUID, now time.Time) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) (models.Job, error)); ok {
+		return rf(ctx, jobId, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) models.Job); ok {
+		r0 = rf(ctx, jobId, now)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, jobId, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewJobRepo creates a new instance of JobRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewJobRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *JobRepo {
+	mock := &JobRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
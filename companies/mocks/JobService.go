@@ -0,0 +1,182 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	io "io"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "companies/models"
+
+	uuid "github.com/google/uuid"
+)
+
+// JobService is an autogenerated mock type for the JobService type
+type JobService struct {
+	mock.Mock
+}
+
+// CancelJob provides a mock function with given fields: ctx, jobId, principal
+func (_m *JobService) CancelJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) (models.Job, error)); ok {
+		return rf(ctx, jobId, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) models.Job); ok {
+		r0 = rf(ctx, jobId, principal)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r1 = rf(ctx, jobId, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateJob provides a mock function with given fields: ctx, jobType, params, input, principal
+func (_m *JobService) CreateJob(ctx context.Context, jobType string, params interface{}, input *models.JobInput, principal models.Principal) (models.Job, error) {
+	ret := _m.Called(ctx, jobType, params, input, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, *models.JobInput, models.Principal) (models.Job, error)); ok {
+		return rf(ctx, jobType, params, input, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, *models.JobInput, models.Principal) models.Job); ok {
+		r0 = rf(ctx, jobType, params, input, principal)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, interface{}, *models.JobInput, models.Principal) error); ok {
+		r1 = rf(ctx, jobType, params, input, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJob provides a mock function with given fields: ctx, jobId, principal
+func (_m *JobService) GetJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) (models.Job, error)); ok {
+		return rf(ctx, jobId, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) models.Job); ok {
+		r0 = rf(ctx, jobId, principal)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r1 = rf(ctx, jobId, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobResult provides a mock function with given fields: ctx, jobId, principal
+func (_m *JobService) GetJobResult(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, io.ReadCloser, error) {
+	ret := _m.Called(ctx, jobId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJobResult")
+	}
+
+	var r0 models.Job
+	var r1 io.ReadCloser
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) (models.Job, io.ReadCloser, error)); ok {
+		return rf(ctx, jobId, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) models.Job); ok {
+		r0 = rf(ctx, jobId, principal)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Principal) io.ReadCloser); ok {
+		r1 = rf(ctx, jobId, principal)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadCloser)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r2 = rf(ctx, jobId, principal)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RetryJob provides a mock function with given fields: ctx, jobId, principal
+func (_m *JobService) RetryJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error) {
+	ret := _m.Called(ctx, jobId, principal)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryJob")
+	}
+
+	var r0 models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) (models.Job, error)); ok {
+		return rf(ctx, jobId, principal)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, models.Principal) models.Job); ok {
+		r0 = rf(ctx, jobId, principal)
+	} else {
+		r0 = ret.Get(0).(models.Job)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, models.Principal) error); ok {
+		r1 = rf(ctx, jobId, principal)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewJobService creates a new instance of JobService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewJobService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *JobService {
+	mock := &JobService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,33 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	gin "github.com/gin-gonic/gin"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// QuotaHandler is an autogenerated mock type for the QuotaHandler type
+type QuotaHandler struct {
+	mock.Mock
+}
+
+// GetQuota provides a mock function with given fields: c
+func (_m *QuotaHandler) GetQuota(c *gin.Context) {
+	_m.Called(c)
+}
+
+// NewQuotaHandler creates a new instance of QuotaHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewQuotaHandler(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *QuotaHandler {
+	mock := &QuotaHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.53.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	models "companies/models"
+)
+
+// QuotaReporter is an autogenerated mock type for the QuotaReporter type
+type QuotaReporter struct {
+	mock.Mock
+}
+
+// Quotas provides a mock function with given fields: ctx, identity, scopes
+func (_m *QuotaReporter) Quotas(ctx context.Context, identity string, scopes []string) ([]models.Quota, error) {
+	ret := _m.Called(ctx, identity, scopes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Quotas")
+	}
+
+	var r0 []models.Quota
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) ([]models.Quota, error)); ok {
+		return rf(ctx, identity, scopes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) []models.Quota); ok {
+		r0 = rf(ctx, identity, scopes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Quota)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, identity, scopes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewQuotaReporter creates a new instance of QuotaReporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewQuotaReporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *QuotaReporter {
+	mock := &QuotaReporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
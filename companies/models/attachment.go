@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AttachmentKindLogo     = "logo"
+	AttachmentKindDocument = "document"
+)
+
+// MaxAttachmentsPerCompany the maximum number of attachments a company can have
+const MaxAttachmentsPerCompany = 20
+
+// AttachmentRules the accepted sniffed content types and the maximum size of each attachment kind
+var AttachmentRules = map[string]struct {
+	ContentTypes []string
+	MaxSize      int64
+}{
+	AttachmentKindLogo: {
+		ContentTypes: []string{"image/png", "image/jpeg", "image/webp"},
+		MaxSize:      1 << 20, // 1 MiB
+	},
+	AttachmentKindDocument: {
+		ContentTypes: []string{"application/pdf"},
+		MaxSize:      10 << 20, // 10 MiB
+	},
+}
+
+// AttachmentInput the form fields of the multipart upload, the file is read separately
+type AttachmentInput struct {
+	Kind string `form:"kind" binding:"required,oneof=logo document"`
+}
+
+// Attachment the metadata of a file attached to a company, the content is in the blob store
+type Attachment struct {
+	ID          uuid.UUID `json:"id" bson:"id"`
+	Kind        string    `json:"kind" bson:"kind"`
+	FileName    string    `json:"file_name" bson:"file_name"`
+	ContentType string    `json:"content_type" bson:"content_type"`
+	Size        int64     `json:"size" bson:"size"`
+	SHA256      string    `json:"sha256" bson:"sha256"`
+	UploadedAt  time.Time `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+// BlobKey the blob store key of the attachment content, the same content of a company has the same key
+func (attachment Attachment) BlobKey(companyId uuid.UUID) string {
+	return "companies/" + companyId.String() + "/" + attachment.SHA256
+}
+
+// AttachmentEvent the data of the company.attachment.* events
+type AttachmentEvent struct {
+	CompanyID  uuid.UUID  `json:"company_id"`
+	Attachment Attachment `json:"attachment"`
+}
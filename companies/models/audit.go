@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ScopeCompaniesAdmin the jwt scope of the users that can modify and delete the companies created by other users
+// when the owner rule is enabled
+const ScopeCompaniesAdmin = "companies:admin"
+
+// AuditStamp who changed a company and when
+type AuditStamp struct {
+	By string
+	At time.Time
+}
+
+func NewAuditStamp(principal Principal) AuditStamp {
+	return AuditStamp{
+		By: principal.Username,
+		At: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// MaxBatchOperations the maximum number of operations of a batch request, also set in the BatchInput binding
+const MaxBatchOperations = 100
+
+const (
+	BatchModeAllOrNothing = "all_or_nothing"
+	BatchModeBestEffort   = "best_effort"
+)
+
+const (
+	BatchOperationCreate = "create"
+	BatchOperationPatch  = "patch"
+	BatchOperationDelete = "delete"
+)
+
+// BatchQuery the query string of the batch endpoint, force skips the possible duplicates check of the creates and
+// the renames
+type BatchQuery struct {
+	Force bool `form:"force"`
+}
+
+// BatchInput the body of the batch endpoint, the data of each operation is validated like on the single company endpoints
+type BatchInput struct {
+	Mode       string                `json:"mode" binding:"required,oneof=all_or_nothing best_effort"`
+	Operations []BatchOperationInput `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+type BatchOperationInput struct {
+	Operation string     `json:"operation" binding:"required,oneof=create patch delete"`
+	ID        *uuid.UUID `json:"id" binding:"required_unless=Operation create,excluded_if=Operation create"`
+	// Data the CompanyInput of a create or the UpdateCompanyInput of a patch
+	Data json.RawMessage `json:"data"`
+}
+
+// BatchOperation a validated batch operation
+type BatchOperation struct {
+	Operation   string
+	CompanyID   uuid.UUID
+	CompanyData CompanyInput
+	PatchData   UpdateCompanyInput
+}
+
+// BatchOperationResult the result of a batch operation in the service, Err is set when it failed
+type BatchOperationResult struct {
+	Company       *CompanyOutput
+	ChangeRequest *ChangeRequest
+	Err           error
+}
+
+// BatchResult the result of a batch operation, the status and error codes are the ones of the single company endpoint
+type BatchResult struct {
+	StatusCode int          `json:"status_code"`
+	ErrorCode  int          `json:"error_code,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	// Company the CompanyOutput without the fields the user can not read
+	Company any `json:"company,omitempty"`
+	// ChangeRequest the ChangeRequest without the changes of the fields the user can not read
+	ChangeRequest any `json:"change_request,omitempty"`
+	// Candidates the possible duplicates of the name of a create or a rename, with the 409 status
+	Candidates []DuplicateCandidate `json:"candidates,omitempty"`
+}
+
+type BatchOutput struct {
+	Results []BatchResult `json:"results"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScopeCompaniesApprove the jwt scope of the users that approve the changes of sensitive fields,
+// their own patches are applied directly
+const ScopeCompaniesApprove = "companies:approve"
+
+// The statuses of a change request, the expired status is only reported, it is not stored
+const (
+	ChangeRequestStatusPending    = "pending"
+	ChangeRequestStatusApproved   = "approved"
+	ChangeRequestStatusRejected   = "rejected"
+	ChangeRequestStatusSuperseded = "superseded"
+	ChangeRequestStatusExpired    = "expired"
+)
+
+// ChangeRequest a patch of sensitive company fields waiting for the approval of a second user
+type ChangeRequest struct {
+	ID        uuid.UUID          `json:"id" bson:"_id"`
+	CompanyID uuid.UUID          `json:"company_id" bson:"company_id"`
+	Tenant    string             `json:"tenant,omitempty" bson:"tenant"`
+	Changes   UpdateCompanyInput `json:"changes" bson:"changes"`
+	// BaseVersion the company version the changes were requested on, they are only applied to that version
+	BaseVersion     int        `json:"base_version" bson:"base_version"`
+	Status          string     `json:"status" bson:"status"`
+	RequestedBy     string     `json:"requested_by" bson:"requested_by"`
+	RequestedAt     time.Time  `json:"requested_at" bson:"requested_at"`
+	ExpiresAt       time.Time  `json:"expires_at" bson:"expires_at"`
+	DecidedBy       string     `json:"decided_by,omitempty" bson:"decided_by,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty" bson:"decided_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty" bson:"rejection_reason,omitempty"`
+}
+
+// IsExpired reports whether the change request is still pending after its expiration
+func (changeRequest ChangeRequest) IsExpired(now time.Time) bool {
+	return changeRequest.Status == ChangeRequestStatusPending && !now.Before(changeRequest.ExpiresAt)
+}
+
+// RejectChangeRequestInput the JSON request body of the reject endpoint
+type RejectChangeRequestInput struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ChangeRequestEvent the data of the company.change_request.* events
+type ChangeRequestEvent struct {
+	ChangeRequest
+}
@@ -1,27 +1,110 @@
 package models
 
 import (
+	"companies/dedupe"
+	"encoding/json"
+	"sort"
+	"time"
+
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 // CompanyInput the struct from the JSON request body
 type CompanyInput struct {
-	Name              string `json:"name" binding:"required,max=15"` // must be unique
-	Description       string `json:"description" binding:"max=3000"`
-	NumberOfEmployees *int   `json:"number_of_employees" binding:"required"`
-	Registered        *bool  `json:"registered" binding:"required"`
-	Type              string `json:"type" binding:"required,oneof='Corporations' 'NonProfit' 'Cooperative' 'Sole Proprietorship'"`
+	Name                string         `json:"name" binding:"required,max=15"` // must be unique
+	Description         string         `json:"description" binding:"max=3000"`
+	NumberOfEmployees   *int           `json:"number_of_employees" binding:"required"`
+	Registered          *bool          `json:"registered" binding:"required"`
+	Type                string         `json:"type" binding:"required,oneof='Corporations' 'NonProfit' 'Cooperative' 'Sole Proprietorship'"`
+	RegisteredAddress   *Address       `json:"registered_address" binding:"omitempty"`
+	OperatingAddress    *Address       `json:"operating_address" binding:"omitempty"`
+	Website             string         `json:"website" binding:"omitempty,http_url,max=2048"`
+	IndustryCodes       *IndustryCodes `json:"industry_codes" binding:"omitempty"`
+	FoundedOn           string         `json:"founded_on" binding:"omitempty,datetime=2006-01-02,pastdate"`
+	ContactEmail        string         `json:"contact_email" binding:"omitempty,email,max=254"`
+	Identifiers         *Identifiers   `json:"identifiers" binding:"omitempty"`
+	ParentID            *uuid.UUID     `json:"parent_id"`
+	OwnershipPercentage *float64       `json:"ownership_percentage" binding:"omitempty,gt=0,lte=100,excluded_without=ParentID"`
+	Tags                []string       `json:"tags" binding:"omitempty,max=20,dive,tag"`
+	InternalNotes       string         `json:"internal_notes" binding:"max=3000"`
+}
+
+// FreeTextFields returns the user supplied text that has to be checked for XSS content
+func (input CompanyInput) FreeTextFields() []string {
+	fields := []string{input.Description, input.InternalNotes}
+	fields = append(fields, input.RegisteredAddress.freeTextFields()...)
+	fields = append(fields, input.OperatingAddress.freeTextFields()...)
+	return fields
+}
+
+// SetFields returns the sorted JSON names of the fields the input sets to something else than their zero value, ex:
+// registered is only set when it is true
+func (input CompanyInput) SetFields() []string {
+	content, err := json.Marshal(input)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	err = json.Unmarshal(content, &fields)
+	if err != nil {
+		return nil
+	}
+	setFields := make([]string, 0, len(fields))
+	for field, value := range fields {
+		switch value := value.(type) {
+		case nil:
+			continue
+		case bool:
+			if !value {
+				continue
+			}
+		case float64:
+			if value == 0 {
+				continue
+			}
+		case string:
+			if value == "" {
+				continue
+			}
+		case []any:
+			if len(value) == 0 {
+				continue
+			}
+		}
+		setFields = append(setFields, field)
+	}
+	sort.Strings(setFields)
+	return setFields
 }
 
 // CompanyOutput the JSON response struct
 type CompanyOutput struct {
-	ID                uuid.UUID `json:"id"`
-	Name              string    `json:"name"`
-	Description       string    `json:"description"`
-	NumberOfEmployees int       `json:"number_of_employees"`
-	Registered        bool      `json:"registered"`
-	Type              string    `json:"type"`
+	ID                  uuid.UUID      `json:"id"`
+	Name                string         `json:"name"`
+	Description         string         `json:"description"`
+	NumberOfEmployees   int            `json:"number_of_employees"`
+	Registered          bool           `json:"registered"`
+	Type                string         `json:"type"`
+	RegisteredAddress   *Address       `json:"registered_address,omitempty"`
+	OperatingAddress    *Address       `json:"operating_address,omitempty"`
+	Website             string         `json:"website,omitempty"`
+	IndustryCodes       *IndustryCodes `json:"industry_codes,omitempty"`
+	FoundedOn           string         `json:"founded_on,omitempty"`
+	ContactEmail        string         `json:"contact_email,omitempty"`
+	Identifiers         *Identifiers   `json:"identifiers,omitempty"`
+	ParentID            *uuid.UUID     `json:"parent_id,omitempty"`
+	OwnershipPercentage *float64       `json:"ownership_percentage,omitempty"`
+	Tags                []string       `json:"tags,omitempty"`
+	Attachments         []Attachment   `json:"attachments,omitempty"`
+	Status              string         `json:"status,omitempty"`
+	LastStatusChange    *StatusChange  `json:"last_status_change,omitempty"`
+	InternalNotes       string         `json:"internal_notes,omitempty"`
+	Tenant              string         `json:"tenant,omitempty"`
+	CreatedAt           *time.Time     `json:"created_at,omitempty"`
+	CreatedBy           string         `json:"created_by,omitempty"`
+	UpdatedAt           *time.Time     `json:"updated_at,omitempty"`
+	UpdatedBy           string         `json:"updated_by,omitempty"`
 }
 
 func (output *CompanyOutput) FromCompany(input Company) {
@@ -33,20 +116,78 @@ func (output *CompanyOutput) FromCompany(input Company) {
 	output.NumberOfEmployees = input.NumberOfEmployees
 	output.Registered = input.Registered
 	output.Type = input.Type
+	output.RegisteredAddress = input.RegisteredAddress
+	output.OperatingAddress = input.OperatingAddress
+	output.Website = input.Website
+	output.IndustryCodes = input.IndustryCodes
+	if input.FoundedOn != nil {
+		output.FoundedOn = input.FoundedOn.UTC().Format(DateLayout)
+	}
+	output.ContactEmail = input.ContactEmail
+	output.Identifiers = input.Identifiers
+	output.ParentID = input.ParentID
+	output.OwnershipPercentage = input.OwnershipPercentage
+	output.Tags = input.Tags
+	output.Attachments = input.Attachments
+	output.Status = input.Status
+	output.LastStatusChange = input.LastStatusChange
+	output.InternalNotes = input.InternalNotes
+	output.Tenant = input.Tenant
+	if !input.CreatedAt.IsZero() {
+		createdAt := input.CreatedAt
+		output.CreatedAt = &createdAt
+	}
+	output.CreatedBy = input.CreatedBy
+	if !input.UpdatedAt.IsZero() {
+		updatedAt := input.UpdatedAt
+		output.UpdatedAt = &updatedAt
+	}
+	output.UpdatedBy = input.UpdatedBy
 }
 
 // The Database entry
 type Company struct {
-	ID                uuid.UUID `bson:"_id"`
-	Name              string    `bson:"name"`
-	Description       string    `bson:"description"`
-	NumberOfEmployees int       `bson:"number_of_employees"`
-	Registered        bool      `bson:"registered"`
-	Type              string    `bson:"type"`
+	ID   uuid.UUID `bson:"_id"`
+	Name string    `bson:"name"`
+	// NameCanonical the case folded name the names of the companies of a tenant are unique on, see dedupe.CanonicalName
+	NameCanonical string `bson:"name_canonical,omitempty"`
+	// NameKey and NameTrigrams the normalized name the possible duplicates are looked up with, see dedupe.Key
+	NameKey             string         `bson:"name_key,omitempty"`
+	NameTrigrams        []string       `bson:"name_trigrams,omitempty"`
+	Description         string         `bson:"description"`
+	NumberOfEmployees   int            `bson:"number_of_employees"`
+	Registered          bool           `bson:"registered"`
+	Type                string         `bson:"type"`
+	RegisteredAddress   *Address       `bson:"registered_address,omitempty"`
+	OperatingAddress    *Address       `bson:"operating_address,omitempty"`
+	Website             string         `bson:"website,omitempty"`
+	IndustryCodes       *IndustryCodes `bson:"industry_codes,omitempty"`
+	FoundedOn           *time.Time     `bson:"founded_on,omitempty"`
+	ContactEmail        string         `bson:"contact_email,omitempty"`
+	Identifiers         *Identifiers   `bson:"identifiers,omitempty"`
+	ParentID            *uuid.UUID     `bson:"parent_id,omitempty"`
+	OwnershipPercentage *float64       `bson:"ownership_percentage,omitempty"`
+	Tags                []string       `bson:"tags,omitempty"`
+	Attachments         []Attachment   `bson:"attachments,omitempty"`
+	Status              string         `bson:"status"`
+	LastStatusChange    *StatusChange  `bson:"last_status_change,omitempty"`
+	InternalNotes       string         `bson:"internal_notes,omitempty"`
+	// Tenant the names and identifiers are unique per tenant, a company is only visible to its tenant
+	Tenant string `bson:"tenant"`
+	// Version is incremented by every patch
+	Version int `bson:"version"`
+	// the audit stamps are set by the service, they are not part of the inputs
+	CreatedAt time.Time `bson:"created_at,omitempty"`
+	CreatedBy string    `bson:"created_by,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at,omitempty"`
+	UpdatedBy string    `bson:"updated_by,omitempty"`
 }
 
 func (company *Company) FromCompanyInput(input CompanyInput) {
-	company.Name = input.Name
+	company.Name = dedupe.NormalizeName(input.Name)
+	company.NameCanonical = dedupe.CanonicalName(input.Name)
+	company.NameKey = dedupe.Key(input.Name)
+	company.NameTrigrams = dedupe.Trigrams(company.NameKey)
 	company.Description = input.Description
 	if input.NumberOfEmployees != nil {
 		company.NumberOfEmployees = *input.NumberOfEmployees
@@ -55,20 +196,88 @@ func (company *Company) FromCompanyInput(input CompanyInput) {
 		company.Registered = *input.Registered
 	}
 	company.Type = input.Type
+	company.RegisteredAddress = input.RegisteredAddress
+	company.OperatingAddress = input.OperatingAddress
+	company.Website = input.Website
+	company.IndustryCodes = input.IndustryCodes
+	if input.FoundedOn != "" {
+		// the layout is checked by the binding validation
+		foundedOn, err := time.Parse(DateLayout, input.FoundedOn)
+		if err == nil {
+			company.FoundedOn = &foundedOn
+		}
+	}
+	company.ContactEmail = input.ContactEmail
+	company.Identifiers = input.Identifiers
+	company.ParentID = input.ParentID
+	company.OwnershipPercentage = input.OwnershipPercentage
+	company.Tags = NormalizeTags(input.Tags)
+	company.Status = CompanyStatusDraft
+	company.InternalNotes = input.InternalNotes
 }
 
 type UpdateCompanyInput struct {
-	Name              *string `json:"name" binding:"omitempty,max=15"` // must be unique
-	Description       *string `json:"description" binding:"omitempty,max=3000"`
-	NumberOfEmployees *int    `json:"number_of_employees" binding:"omitempty"`
-	Registered        *bool   `json:"registered" binding:"omitempty"`
-	Type              *string `json:"type" binding:"omitempty,oneof='Corporations' 'NonProfit' 'Cooperative' 'Sole Proprietorship'"`
+	Name                *string                   `json:"name,omitempty" bson:"name,omitempty" binding:"omitempty,max=15"` // must be unique
+	Description         *string                   `json:"description,omitempty" bson:"description,omitempty" binding:"omitempty,max=3000"`
+	NumberOfEmployees   *int                      `json:"number_of_employees,omitempty" bson:"number_of_employees,omitempty" binding:"omitempty"`
+	Registered          *bool                     `json:"registered,omitempty" bson:"registered,omitempty" binding:"omitempty"`
+	Type                *string                   `json:"type,omitempty" bson:"type,omitempty" binding:"omitempty,oneof='Corporations' 'NonProfit' 'Cooperative' 'Sole Proprietorship'"`
+	RegisteredAddress   *UpdateAddressInput       `json:"registered_address,omitempty" bson:"registered_address,omitempty" binding:"omitempty"`
+	OperatingAddress    *UpdateAddressInput       `json:"operating_address,omitempty" bson:"operating_address,omitempty" binding:"omitempty"`
+	Website             *string                   `json:"website,omitempty" bson:"website,omitempty" binding:"omitempty,http_url,max=2048"`
+	IndustryCodes       *UpdateIndustryCodesInput `json:"industry_codes,omitempty" bson:"industry_codes,omitempty" binding:"omitempty"`
+	FoundedOn           *string                   `json:"founded_on,omitempty" bson:"founded_on,omitempty" binding:"omitempty,datetime=2006-01-02,pastdate"`
+	ContactEmail        *string                   `json:"contact_email,omitempty" bson:"contact_email,omitempty" binding:"omitempty,email,max=254"`
+	Identifiers         *UpdateIdentifiersInput   `json:"identifiers,omitempty" bson:"identifiers,omitempty" binding:"omitempty"`
+	ParentID            *uuid.UUID                `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	OwnershipPercentage *float64                  `json:"ownership_percentage,omitempty" bson:"ownership_percentage,omitempty" binding:"omitempty,gt=0,lte=100"`
+	InternalNotes       *string                   `json:"internal_notes,omitempty" bson:"internal_notes,omitempty" binding:"omitempty,max=3000"`
+	// Status is only changed by the lifecycle transitions
+	Status *string `json:"status,omitempty" bson:"status,omitempty" binding:"isdefault"`
+}
+
+// HasSensitiveChanges reports whether the patch changes a field that needs a second user approval
+func (updateCompanyInput UpdateCompanyInput) HasSensitiveChanges() bool {
+	return updateCompanyInput.Name != nil ||
+		updateCompanyInput.Type != nil ||
+		updateCompanyInput.Registered != nil
+}
+
+// PartialAddresses returns the BSON names of the addresses the patch only partially sets, ex: registered_address, the
+// company must already have them
+func (updateCompanyInput UpdateCompanyInput) PartialAddresses() []string {
+	addresses := []string{}
+	if updateCompanyInput.RegisteredAddress.isPartial() {
+		addresses = append(addresses, "registered_address")
+	}
+	if updateCompanyInput.OperatingAddress.isPartial() {
+		addresses = append(addresses, "operating_address")
+	}
+	return addresses
+}
+
+// FreeTextFields returns the user supplied text that has to be checked for XSS content
+func (updateCompanyInput UpdateCompanyInput) FreeTextFields() []string {
+	fields := []string{}
+	if updateCompanyInput.Description != nil {
+		fields = append(fields, *updateCompanyInput.Description)
+	}
+	if updateCompanyInput.InternalNotes != nil {
+		fields = append(fields, *updateCompanyInput.InternalNotes)
+	}
+	fields = append(fields, updateCompanyInput.RegisteredAddress.freeTextFields()...)
+	fields = append(fields, updateCompanyInput.OperatingAddress.freeTextFields()...)
+	return fields
 }
 
 func (updateCompanyInput UpdateCompanyInput) ToBsonM() bson.M {
 	output := bson.M{}
 	if updateCompanyInput.Name != nil {
-		output["name"] = updateCompanyInput.Name
+		output["name"] = dedupe.NormalizeName(*updateCompanyInput.Name)
+		output["name_canonical"] = dedupe.CanonicalName(*updateCompanyInput.Name)
+		nameKey := dedupe.Key(*updateCompanyInput.Name)
+		output["name_key"] = nameKey
+		output["name_trigrams"] = dedupe.Trigrams(nameKey)
 	}
 	if updateCompanyInput.Description != nil {
 		output["description"] = updateCompanyInput.Description
@@ -82,5 +291,51 @@ func (updateCompanyInput UpdateCompanyInput) ToBsonM() bson.M {
 	if updateCompanyInput.Type != nil {
 		output["type"] = updateCompanyInput.Type
 	}
+	updateCompanyInput.RegisteredAddress.addToBsonM("registered_address", output)
+	updateCompanyInput.OperatingAddress.addToBsonM("operating_address", output)
+	if updateCompanyInput.Website != nil {
+		output["website"] = updateCompanyInput.Website
+	}
+	updateCompanyInput.IndustryCodes.addToBsonM("industry_codes", output)
+	if updateCompanyInput.FoundedOn != nil {
+		// the layout is checked by the binding validation
+		foundedOn, err := time.Parse(DateLayout, *updateCompanyInput.FoundedOn)
+		if err == nil {
+			output["founded_on"] = foundedOn
+		}
+	}
+	if updateCompanyInput.ContactEmail != nil {
+		output["contact_email"] = updateCompanyInput.ContactEmail
+	}
+	updateCompanyInput.Identifiers.addToBsonM("identifiers", output)
+	if updateCompanyInput.ParentID != nil {
+		output["parent_id"] = updateCompanyInput.ParentID
+	}
+	if updateCompanyInput.OwnershipPercentage != nil {
+		output["ownership_percentage"] = updateCompanyInput.OwnershipPercentage
+	}
+	if updateCompanyInput.InternalNotes != nil {
+		output["internal_notes"] = updateCompanyInput.InternalNotes
+	}
 	return output
 }
+
+// ChangedFields returns the sorted JSON names of the top level fields set by the patch
+func (updateCompanyInput UpdateCompanyInput) ChangedFields() []string {
+	// the JSON fields are omitted when not set
+	content, err := json.Marshal(updateCompanyInput)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(content, &fields)
+	if err != nil {
+		return nil
+	}
+	changedFields := make([]string, 0, len(fields))
+	for field := range fields {
+		changedFields = append(changedFields, field)
+	}
+	sort.Strings(changedFields)
+	return changedFields
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpdateCompanyInputToBsonM(t *testing.T) {
+	name := "company-name"
+	city := "Dublin"
+	country := "IE"
+	nace := []string{"62.01"}
+	foundedOn := "2001-02-03"
+	fullWidthName := " ＡＣＭＥ "
+
+	testCases := []struct {
+		name     string
+		input    UpdateCompanyInput
+		expected bson.M
+	}{
+		{
+			name:     "empty input",
+			input:    UpdateCompanyInput{},
+			expected: bson.M{},
+		},
+		{
+			name: "nested paths",
+			input: UpdateCompanyInput{
+				Name: &name,
+				RegisteredAddress: &UpdateAddressInput{
+					City:    &city,
+					Country: &country,
+				},
+				IndustryCodes: &UpdateIndustryCodesInput{
+					NACE: &nace,
+				},
+				FoundedOn: &foundedOn,
+			},
+			expected: bson.M{
+				"name":                       name,
+				"name_canonical":             name,
+				"name_key":                   "company name",
+				"name_trigrams":              []string{"  c", "  n", " co", " na", "ame", "any", "com", "me ", "mpa", "nam", "ny ", "omp", "pan"},
+				"registered_address.city":    &city,
+				"registered_address.country": &country,
+				"industry_codes.nace":        &nace,
+				"founded_on":                 time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "the name is normalized",
+			input: UpdateCompanyInput{
+				Name: &fullWidthName,
+			},
+			expected: bson.M{
+				"name":           "ACME",
+				"name_canonical": "acme",
+				"name_key":       "acme",
+				"name_trigrams":  []string{"  a", " ac", "acm", "cme", "me "},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, testCase.input.ToBsonM())
+		})
+	}
+}
+
+func TestUpdateCompanyInputChangedFields(t *testing.T) {
+	registered := true
+	city := "Dublin"
+	notes := ""
+
+	input := UpdateCompanyInput{
+		Registered:        &registered,
+		RegisteredAddress: &UpdateAddressInput{City: &city},
+		InternalNotes:     &notes,
+	}
+
+	assert.Equal(t, []string{"internal_notes", "registered", "registered_address"}, input.ChangedFields())
+	assert.Empty(t, UpdateCompanyInput{}.ChangedFields())
+}
+
+func TestCompanyInputSetFields(t *testing.T) {
+	employees := 0
+	registered := false
+
+	input := CompanyInput{
+		Name:              "acme",
+		NumberOfEmployees: &employees,
+		Registered:        &registered,
+		Type:              "Corporations",
+		InternalNotes:     "notes",
+	}
+
+	assert.Equal(t, []string{"internal_notes", "name", "type"}, input.SetFields())
+}
+
+func TestUpdateCompanyInputPartialAddresses(t *testing.T) {
+	street := "1 Main Street"
+	city := "Dublin"
+	country := "IE"
+
+	input := UpdateCompanyInput{
+		RegisteredAddress: &UpdateAddressInput{Street: &street, City: &city, Country: &country},
+		OperatingAddress:  &UpdateAddressInput{City: &city},
+	}
+
+	assert.Equal(t, []string{"operating_address"}, input.PartialAddresses())
+	assert.Empty(t, UpdateCompanyInput{}.PartialAddresses())
+}
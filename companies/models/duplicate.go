@@ -0,0 +1,48 @@
+package models
+
+import "github.com/google/uuid"
+
+// JobTypeCompaniesDuplicates the job that reports the clusters of possible duplicates of a tenant
+const JobTypeCompaniesDuplicates = "companies.duplicates"
+
+// MaxDuplicateCandidates the number of possible duplicates returned when a company is created or renamed
+const MaxDuplicateCandidates = 10
+
+// DuplicateCandidate an existing company whose name is similar to the name of a company being created or renamed
+type DuplicateCandidate struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name,omitempty"`
+	// Score the similarity of the normalized names, from 0 to 1
+	Score float64 `json:"score"`
+	// Exact the normalized names are the same, ex: "Acme Ltd" and "ACME Limited"
+	Exact bool `json:"exact"`
+}
+
+// DuplicatesOutput the 409 response of the create and patch endpoints when the name has possible duplicates
+type DuplicatesOutput struct {
+	ErrorCode  int                  `json:"error_code"`
+	Candidates []DuplicateCandidate `json:"candidates"`
+}
+
+// ForceQuery the query string of the endpoints that check the possible duplicates, force skips the check
+type ForceQuery struct {
+	Force bool `form:"force"`
+}
+
+// DuplicatesJobParams the parameters of a companies.duplicates job, the threshold is dedupe.DefaultThreshold when 0
+type DuplicatesJobParams struct {
+	Threshold float64 `json:"threshold,omitempty" form:"threshold" binding:"omitempty,gte=0.5,lte=1"`
+}
+
+// DuplicateCluster companies linked by similar names, each company is similar to at least another one
+type DuplicateCluster struct {
+	Companies []DuplicateCandidate `json:"companies"`
+	// MinScore the lowest similarity of the links of the cluster
+	MinScore float64 `json:"min_score"`
+}
+
+// DuplicatesReport the result of a companies.duplicates job, the largest clusters first
+type DuplicatesReport struct {
+	Companies int                `json:"companies"`
+	Clusters  []DuplicateCluster `json:"clusters"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// ExportQuery the export parameters, the companies are filtered with the CompanyQuery parameters
+type ExportQuery struct {
+	// Format overrides the Accept header
+	Format string `form:"format" binding:"omitempty,oneof=csv ndjson xlsx"`
+	// Columns the comma separated JSON paths of the exported fields, ex: name,registered_address.city
+	Columns string `form:"columns" binding:"max=2000"`
+}
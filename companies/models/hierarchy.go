@@ -0,0 +1,27 @@
+package models
+
+import "github.com/google/uuid"
+
+// CompanyNode a company found by a hierarchy query, Depth is 0 for the closest relatives
+type CompanyNode struct {
+	Company `bson:",inline"`
+	Depth   int `bson:"depth"`
+}
+
+// CompanyNodeOutput the JSON response struct of a hierarchy query entry
+type CompanyNodeOutput struct {
+	CompanyOutput
+	Depth int `json:"depth"`
+}
+
+func (output *CompanyNodeOutput) FromCompanyNode(input CompanyNode) {
+	output.FromCompany(input.Company)
+	output.Depth = input.Depth
+}
+
+// ParentRelationEvent the data of the company.parent.* events
+type ParentRelationEvent struct {
+	CompanyID           uuid.UUID  `json:"company_id"`
+	ParentID            *uuid.UUID `json:"parent_id,omitempty"`
+	OwnershipPercentage *float64   `json:"ownership_percentage,omitempty"`
+}
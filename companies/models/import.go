@@ -0,0 +1,46 @@
+package models
+
+import "github.com/google/uuid"
+
+// The statuses of an import row
+const (
+	ImportRowAccepted      = "accepted"
+	ImportRowRejected      = "rejected"
+	ImportRowDuplicateName = "duplicate_name"
+)
+
+// ImportQuery the query parameters of the import, the CSV header mapping is read from the mapping[<header>]=<field> parameters.
+// Force imports the rows whose name has possible duplicates
+type ImportQuery struct {
+	DryRun bool `form:"dry_run"`
+	Force  bool `form:"force"`
+}
+
+// ImportRow the report of a row of the import, the row is the CSV record or the NDJSON line
+type ImportRow struct {
+	Row       int          `json:"row"`
+	Status    string       `json:"status"`
+	CompanyID *uuid.UUID   `json:"company_id,omitempty"`
+	ErrorCode int          `json:"error_code,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Candidates the possible duplicates of the name of a duplicate_name row
+	Candidates []DuplicateCandidate `json:"candidates,omitempty"`
+}
+
+// ImportSummary the number of rows of each status
+type ImportSummary struct {
+	Accepted      int `json:"accepted"`
+	Rejected      int `json:"rejected"`
+	DuplicateName int `json:"duplicate_name"`
+}
+
+func (summary *ImportSummary) Add(row ImportRow) {
+	switch row.Status {
+	case ImportRowAccepted:
+		summary.Accepted++
+	case ImportRowRejected:
+		summary.Rejected++
+	case ImportRowDuplicateName:
+		summary.DuplicateName++
+	}
+}
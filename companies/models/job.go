@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The statuses of a job, a job runs again after a failure or a cancellation only when it is retried
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+)
+
+// The types of the jobs run by the companies service
+const (
+	JobTypeCompaniesImport = "companies.import"
+	JobTypeCompaniesExport = "companies.export"
+)
+
+// Job a long running task, like an import or an export, that is run by the workers of any replica
+type Job struct {
+	ID     uuid.UUID `json:"id" bson:"_id"`
+	Tenant string    `json:"tenant,omitempty" bson:"tenant"`
+	Type   string    `json:"type" bson:"type"`
+	Status string    `json:"status" bson:"status"`
+	// Params the JSON parameters of the job type
+	Params json.RawMessage `json:"params,omitempty" bson:"params,omitempty"`
+	// Input the blob key of the content uploaded with the job
+	Input string `json:"-" bson:"input,omitempty"`
+	// Progress the number of items processed, saved each time the lease is renewed
+	Progress int `json:"progress" bson:"progress"`
+	// ResultLocation the path the result of a succeeded job is downloaded from
+	ResultLocation    string `json:"result_location,omitempty" bson:"result_location,omitempty"`
+	ResultContentType string `json:"result_content_type,omitempty" bson:"result_content_type,omitempty"`
+	Error             string `json:"error,omitempty" bson:"error,omitempty"`
+	// Attempts the number of times a worker claimed the job
+	Attempts        int  `json:"attempts" bson:"attempts"`
+	CancelRequested bool `json:"cancel_requested,omitempty" bson:"cancel_requested,omitempty"`
+	// LeaseOwner the worker running the job until LeaseExpiresAt, another worker claims it when the lease expires
+	LeaseOwner     string     `json:"-" bson:"lease_owner,omitempty"`
+	LeaseExpiresAt *time.Time `json:"-" bson:"lease_expires_at,omitempty"`
+	CreatedBy      string     `json:"created_by" bson:"created_by"`
+	// Scopes the scopes of the user that created the job, the job runs with them
+	Scopes     []string   `json:"-" bson:"scopes,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// Principal returns the user that created the job
+func (job Job) Principal() Principal {
+	return Principal{
+		Username: job.CreatedBy,
+		Scopes:   job.Scopes,
+	}
+}
+
+// JobInputKey the blob key of the content uploaded with a job
+func JobInputKey(jobId uuid.UUID) string {
+	return "jobs/" + jobId.String() + "/input"
+}
+
+// JobResultKey the blob key of the result of a job
+func JobResultKey(jobId uuid.UUID) string {
+	return "jobs/" + jobId.String() + "/result"
+}
+
+// JobInput the content uploaded with a job, the size is -1 when it is unknown
+type JobInput struct {
+	Content     io.Reader
+	Size        int64
+	ContentType string
+}
+
+// JobQuery the query parameters of the endpoints that can run as a job
+type JobQuery struct {
+	Async bool `form:"async"`
+}
+
+// ImportJobParams the parameters of a companies.import job, the format is the content type of the input
+type ImportJobParams struct {
+	Format  string            `json:"format"`
+	Mapping map[string]string `json:"mapping,omitempty"`
+	DryRun  bool              `json:"dry_run"`
+	Force   bool              `json:"force,omitempty"`
+}
+
+// ExportJobParams the parameters of a companies.export job
+type ExportJobParams struct {
+	Query   CompanyQuery `json:"query"`
+	Format  string       `json:"format"`
+	Columns string       `json:"columns,omitempty"`
+}
+
+// JobEvent the data of the job.* events
+type JobEvent struct {
+	Job
+}
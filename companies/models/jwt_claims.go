@@ -9,6 +9,7 @@ import (
 
 type JWTClaims struct {
 	Username string   `json:"username"`
+	Tenant   string   `json:"tenant"`
 	Scopes   []string `json:"scopes"`
 	jwt.RegisteredClaims
 }
@@ -1,13 +1,40 @@
 package models
 
+import "github.com/google/uuid"
+
 type KafkaEventType string
 
 const KafkaEventTypeCompanyCreate = "company.create"
 const KafkaEventTypeCompanyGet = "company.get"
 const KafkaEventTypeCompanyPatch = "company.patch"
 const KafkaEventTypeCompanyDelete = "company.delete"
+const KafkaEventTypeCompanyMerge = "company.merge"
+const KafkaEventTypeCompanyParentSet = "company.parent.set"
+const KafkaEventTypeCompanyParentRemove = "company.parent.remove"
+const KafkaEventTypeCompanyAttachmentAdd = "company.attachment.add"
+const KafkaEventTypeCompanyAttachmentRemove = "company.attachment.remove"
+const KafkaEventTypeCompanyActivate = "company.activate"
+const KafkaEventTypeCompanySuspend = "company.suspend"
+const KafkaEventTypeCompanyReinstate = "company.reinstate"
+const KafkaEventTypeCompanyDissolve = "company.dissolve"
+const KafkaEventTypeCompanyChangeRequestCreate = "company.change_request.create"
+const KafkaEventTypeCompanyChangeRequestApprove = "company.change_request.approve"
+const KafkaEventTypeCompanyChangeRequestReject = "company.change_request.reject"
+const KafkaEventTypeJobCreate = "job.create"
+const KafkaEventTypeJobStart = "job.start"
+const KafkaEventTypeJobSucceed = "job.succeed"
+const KafkaEventTypeJobFail = "job.fail"
+const KafkaEventTypeJobCancel = "job.cancel"
+const KafkaEventTypeJobCancelRequest = "job.cancel_request"
+const KafkaEventTypeJobRetry = "job.retry"
+const KafkaEventTypeJobRelease = "job.release"
 
 type KafkaEvent struct {
 	Type string
 	Data interface{}
 }
+
+// CompanyDeleteEvent the data of the company.delete event
+type CompanyDeleteEvent struct {
+	CompanyID uuid.UUID `json:"company_id"`
+}
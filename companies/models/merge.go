@@ -0,0 +1,129 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The strategies of a merged field
+const (
+	MergeStrategyTarget = "target"
+	MergeStrategySource = "source"
+	MergeStrategyConcat = "concat"
+)
+
+// MaxMergedTextLength the maximum length of a concatenated description or internal notes, same as their inputs
+const MaxMergedTextLength = 3000
+
+// MergeConcatFields the fields that can be concatenated, the texts are joined by a new line and the tags are united
+var MergeConcatFields = []string{"description", "internal_notes", "tags"}
+
+// MergeInput the JSON request body of the merge endpoint, the source company is merged into the company of the path
+type MergeInput struct {
+	SourceID uuid.UUID `json:"source_id" binding:"required"`
+	// Fields the strategy of each field, the fields that are not listed keep the value of the target
+	Fields map[string]string `json:"fields" binding:"omitempty,dive,keys,oneof=name description number_of_employees registered type registered_address operating_address website industry_codes founded_on contact_email identifiers tags internal_notes,endkeys,oneof=target source concat"`
+}
+
+// ConcatErrors returns the errors of the fields that are concatenated but can not be, sorted by field
+func (input MergeInput) ConcatErrors() []FieldError {
+	fieldErrors := []FieldError{}
+	for field, strategy := range input.Fields {
+		if strategy == MergeStrategyConcat && !slices.Contains(MergeConcatFields, field) {
+			fieldErrors = append(fieldErrors, FieldError{Field: "fields[" + field + "]", Rule: "concat"})
+		}
+	}
+	slices.SortFunc(fieldErrors, func(a, b FieldError) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return fieldErrors
+}
+
+// ChangedFields returns the sorted fields of the target that take a value from the source
+func (input MergeInput) ChangedFields() []string {
+	fields := []string{}
+	for field, strategy := range input.Fields {
+		if strategy != MergeStrategyTarget {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// MergeCompany returns the target with the fields resolved from the source. The value of the source is taken
+// as is, an empty value unsets the field of the target
+func MergeCompany(target Company, source Company, fields map[string]string) Company {
+	merged := target
+	for field, strategy := range fields {
+		if strategy == MergeStrategyTarget {
+			continue
+		}
+		concat := strategy == MergeStrategyConcat
+		switch field {
+		case "name":
+			merged.Name, merged.NameCanonical = source.Name, source.NameCanonical
+			merged.NameKey, merged.NameTrigrams = source.NameKey, source.NameTrigrams
+		case "description":
+			merged.Description = mergeText(target.Description, source.Description, concat)
+		case "number_of_employees":
+			merged.NumberOfEmployees = source.NumberOfEmployees
+		case "registered":
+			merged.Registered = source.Registered
+		case "type":
+			merged.Type = source.Type
+		case "registered_address":
+			merged.RegisteredAddress = source.RegisteredAddress
+		case "operating_address":
+			merged.OperatingAddress = source.OperatingAddress
+		case "website":
+			merged.Website = source.Website
+		case "industry_codes":
+			merged.IndustryCodes = source.IndustryCodes
+		case "founded_on":
+			merged.FoundedOn = source.FoundedOn
+		case "contact_email":
+			merged.ContactEmail = source.ContactEmail
+		case "identifiers":
+			merged.Identifiers = source.Identifiers
+		case "tags":
+			merged.Tags = source.Tags
+			if concat {
+				merged.Tags = NormalizeTags(append(slices.Clone(target.Tags), source.Tags...))
+			}
+		case "internal_notes":
+			merged.InternalNotes = mergeText(target.InternalNotes, source.InternalNotes, concat)
+		}
+	}
+	return merged
+}
+
+func mergeText(target string, source string, concat bool) string {
+	if !concat {
+		return source
+	}
+	if target == "" || source == "" {
+		return target + source
+	}
+	return target + "\n" + source
+}
+
+// CompanyTombstone the redirect left by a company merged into another one, the survivor
+type CompanyTombstone struct {
+	ID         uuid.UUID `json:"id" bson:"_id"`
+	Tenant     string    `json:"-" bson:"tenant"`
+	MergedInto uuid.UUID `json:"merged_into" bson:"merged_into"`
+	MergedAt   time.Time `json:"merged_at" bson:"merged_at"`
+	MergedBy   string    `json:"merged_by" bson:"merged_by"`
+}
+
+// MergeEvent the data of the company.merge event, the source id is a redirect to the target id from now on
+type MergeEvent struct {
+	SourceID uuid.UUID     `json:"source_id"`
+	TargetID uuid.UUID     `json:"target_id"`
+	Fields   []string      `json:"fields"`
+	Company  CompanyOutput `json:"company"`
+}
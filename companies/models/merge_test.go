@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeCompany(t *testing.T) {
+	target := Company{
+		ID:            uuid.New(),
+		Name:          "Acme",
+		NameKey:       "acme",
+		Description:   "Rockets",
+		Website:       "https://acme.example",
+		Tags:          []string{"vip", "emea"},
+		InternalNotes: "",
+		Version:       3,
+	}
+	source := Company{
+		ID:            uuid.New(),
+		Name:          "Acme Ltd",
+		NameKey:       "acme",
+		Description:   "Anvils",
+		Website:       "",
+		Tags:          []string{"emea", "prospect"},
+		InternalNotes: "Call first",
+		Version:       1,
+	}
+
+	testCases := []struct {
+		name     string
+		fields   map[string]string
+		expected func(company *Company)
+	}{
+		{
+			name:     "the target is kept by default",
+			fields:   nil,
+			expected: func(company *Company) {},
+		},
+		{
+			name:   "take the source",
+			fields: map[string]string{"name": MergeStrategySource, "description": MergeStrategyTarget, "website": MergeStrategySource},
+			expected: func(company *Company) {
+				company.Name = "Acme Ltd"
+				company.Website = ""
+			},
+		},
+		{
+			name:   "concatenate",
+			fields: map[string]string{"description": MergeStrategyConcat, "tags": MergeStrategyConcat, "internal_notes": MergeStrategyConcat},
+			expected: func(company *Company) {
+				company.Description = "Rockets\nAnvils"
+				company.Tags = []string{"vip", "emea", "prospect"}
+				company.InternalNotes = "Call first"
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			expected := target
+			testCase.expected(&expected)
+			assert.Equal(t, expected, MergeCompany(target, source, testCase.fields))
+		})
+	}
+}
+
+func TestMergeInput(t *testing.T) {
+	input := MergeInput{
+		Fields: map[string]string{
+			"website":     MergeStrategyConcat,
+			"tags":        MergeStrategyConcat,
+			"name":        MergeStrategyConcat,
+			"description": MergeStrategyTarget,
+			"type":        MergeStrategySource,
+		},
+	}
+
+	assert.Equal(t, []FieldError{
+		{Field: "fields[name]", Rule: "concat"},
+		{Field: "fields[website]", Rule: "concat"},
+	}, input.ConcatErrors())
+	assert.Equal(t, []string{"name", "tags", "type", "website"}, input.ChangedFields())
+}
@@ -1,5 +1,15 @@
 package models
 
 type ErrorOutput struct {
-	ErrorCode int `json:"error_code"`
+	ErrorCode int          `json:"error_code"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError a failed validation rule of an input field
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	// Message and Column locate the error in an expression, ex: the filter of the list companies endpoint
+	Message string `json:"message,omitempty"`
+	Column  int    `json:"column,omitempty"`
 }
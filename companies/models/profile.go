@@ -0,0 +1,142 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// DateLayout the layout used for calendar dates like the founding date
+const DateLayout = "2006-01-02"
+
+// Address a postal address, the country is an ISO 3166-1 alpha-2 code
+type Address struct {
+	Street     string `json:"street" bson:"street" binding:"required,max=200"`
+	City       string `json:"city" bson:"city" binding:"required,max=100"`
+	PostalCode string `json:"postal_code,omitempty" bson:"postal_code,omitempty" binding:"max=20"`
+	Region     string `json:"region,omitempty" bson:"region,omitempty" binding:"max=100"`
+	Country    string `json:"country" bson:"country" binding:"required,iso3166_1_alpha2"`
+}
+
+func (address *Address) freeTextFields() []string {
+	if address == nil {
+		return nil
+	}
+	return []string{address.Street, address.City, address.PostalCode, address.Region}
+}
+
+// UpdateAddressInput a partial update of an Address
+type UpdateAddressInput struct {
+	Street     *string `json:"street,omitempty" bson:"street,omitempty" binding:"omitempty,min=1,max=200"`
+	City       *string `json:"city,omitempty" bson:"city,omitempty" binding:"omitempty,min=1,max=100"`
+	PostalCode *string `json:"postal_code,omitempty" bson:"postal_code,omitempty" binding:"omitempty,max=20"`
+	Region     *string `json:"region,omitempty" bson:"region,omitempty" binding:"omitempty,max=100"`
+	Country    *string `json:"country,omitempty" bson:"country,omitempty" binding:"omitempty,iso3166_1_alpha2"`
+}
+
+func (input *UpdateAddressInput) freeTextFields() []string {
+	if input == nil {
+		return nil
+	}
+	fields := []string{}
+	for _, field := range []*string{input.Street, input.City, input.PostalCode, input.Region} {
+		if field != nil {
+			fields = append(fields, *field)
+		}
+	}
+	return fields
+}
+
+// isPartial reports whether the patch leaves out a required field of the Address, it can then only update an address
+// the company already has
+func (input *UpdateAddressInput) isPartial() bool {
+	return input != nil && (input.Street == nil || input.City == nil || input.Country == nil)
+}
+
+// addToBsonM adds the set fields as nested paths under prefix, ex: registered_address.city
+func (input *UpdateAddressInput) addToBsonM(prefix string, output bson.M) {
+	if input == nil {
+		return
+	}
+	if input.Street != nil {
+		output[prefix+".street"] = input.Street
+	}
+	if input.City != nil {
+		output[prefix+".city"] = input.City
+	}
+	if input.PostalCode != nil {
+		output[prefix+".postal_code"] = input.PostalCode
+	}
+	if input.Region != nil {
+		output[prefix+".region"] = input.Region
+	}
+	if input.Country != nil {
+		output[prefix+".country"] = input.Country
+	}
+}
+
+// IndustryCodes the NACE Rev. 2 and SIC codes of a company
+type IndustryCodes struct {
+	NACE []string `json:"nace,omitempty" bson:"nace,omitempty" binding:"max=10,dive,nace"`
+	SIC  []string `json:"sic,omitempty" bson:"sic,omitempty" binding:"max=10,dive,sic"`
+}
+
+// UpdateIndustryCodesInput replaces the NACE and/or SIC code lists
+type UpdateIndustryCodesInput struct {
+	NACE *[]string `json:"nace,omitempty" bson:"nace,omitempty" binding:"omitempty,max=10,dive,nace"`
+	SIC  *[]string `json:"sic,omitempty" bson:"sic,omitempty" binding:"omitempty,max=10,dive,sic"`
+}
+
+func (input *UpdateIndustryCodesInput) addToBsonM(prefix string, output bson.M) {
+	if input == nil {
+		return
+	}
+	if input.NACE != nil {
+		output[prefix+".nace"] = input.NACE
+	}
+	if input.SIC != nil {
+		output[prefix+".sic"] = input.SIC
+	}
+}
+
+// The supported legal identifier schemes, also the field names of Identifiers
+const (
+	IdentifierSchemeLEI  = "lei"
+	IdentifierSchemeVAT  = "vat"
+	IdentifierSchemeDUNS = "duns"
+)
+
+// IsIdentifierScheme reports whether scheme is one of the supported legal identifier schemes
+func IsIdentifierScheme(scheme string) bool {
+	switch scheme {
+	case IdentifierSchemeLEI, IdentifierSchemeVAT, IdentifierSchemeDUNS:
+		return true
+	}
+	return false
+}
+
+// Identifiers the legal identifiers of a company, each one is unique across companies
+type Identifiers struct {
+	LEI  string `json:"lei,omitempty" bson:"lei,omitempty" binding:"omitempty,lei"`
+	VAT  string `json:"vat,omitempty" bson:"vat,omitempty" binding:"omitempty,eu_vat"`
+	DUNS string `json:"duns,omitempty" bson:"duns,omitempty" binding:"omitempty,duns"`
+}
+
+// UpdateIdentifiersInput a partial update of the Identifiers, an empty identifier is invalid since it would be unique
+// across companies
+type UpdateIdentifiersInput struct {
+	LEI  *string `json:"lei,omitempty" bson:"lei,omitempty" binding:"omitnil,lei"`
+	VAT  *string `json:"vat,omitempty" bson:"vat,omitempty" binding:"omitnil,eu_vat"`
+	DUNS *string `json:"duns,omitempty" bson:"duns,omitempty" binding:"omitnil,duns"`
+}
+
+func (input *UpdateIdentifiersInput) addToBsonM(prefix string, output bson.M) {
+	if input == nil {
+		return
+	}
+	if input.LEI != nil {
+		output[prefix+"."+IdentifierSchemeLEI] = input.LEI
+	}
+	if input.VAT != nil {
+		output[prefix+"."+IdentifierSchemeVAT] = input.VAT
+	}
+	if input.DUNS != nil {
+		output[prefix+"."+IdentifierSchemeDUNS] = input.DUNS
+	}
+}
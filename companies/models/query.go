@@ -0,0 +1,106 @@
+package models
+
+import (
+	"companies/rsql"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	TagsMatchAny = "any"
+	TagsMatchAll = "all"
+)
+
+const (
+	DefaultQueryLimit = 50
+	MaxQueryLimit     = 200
+)
+
+// CompanyQuery the query string of the list companies endpoint
+type CompanyQuery struct {
+	Type       string   `json:"type,omitempty" form:"type" binding:"omitempty,oneof='Corporations' 'NonProfit' 'Cooperative' 'Sole Proprietorship'"`
+	Registered *bool    `json:"registered,omitempty" form:"registered"`
+	Status     string   `json:"status,omitempty" form:"status" binding:"omitempty,oneof=draft active suspended dissolved"`
+	Tags       []string `json:"tags,omitempty" form:"tags" binding:"omitempty,max=20,dive,tag"`
+	TagsMatch  string   `json:"tags_match,omitempty" form:"tags_match" binding:"omitempty,oneof=any all"`
+	Limit      int      `json:"limit,omitempty" form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset     int      `json:"offset,omitempty" form:"offset" binding:"omitempty,min=0"`
+	// Filter an RSQL expression on the CompanyFilterFields, ex: type==Cooperative;(number_of_employees=gt=50,registered==false)
+	Filter string `json:"filter,omitempty" form:"filter" binding:"omitempty,max=2000"`
+}
+
+// CompanyFilterFields the fields of the filter expression, the nested fields are selected with dots
+var CompanyFilterFields = rsql.Schema{
+	"id":                         {Path: "_id", Type: rsql.UUID},
+	"name":                       {Type: rsql.String},
+	"type":                       {Type: rsql.String, Values: []string{"Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"}},
+	"status":                     {Type: rsql.String, Values: []string{CompanyStatusDraft, CompanyStatusActive, CompanyStatusSuspended, CompanyStatusDissolved}},
+	"registered":                 {Type: rsql.Bool},
+	"number_of_employees":        {Type: rsql.Int},
+	"parent_id":                  {Type: rsql.UUID},
+	"ownership_percentage":       {Type: rsql.Float},
+	"tags":                       {Type: rsql.String, Normalize: NormalizeTag},
+	"founded_on":                 {Type: rsql.Date},
+	"website":                    {Type: rsql.String},
+	"contact_email":              {Type: rsql.String},
+	"registered_address.city":    {Type: rsql.String},
+	"registered_address.country": {Type: rsql.String, Normalize: strings.ToUpper},
+	"operating_address.city":     {Type: rsql.String},
+	"operating_address.country":  {Type: rsql.String, Normalize: strings.ToUpper},
+	"industry_codes.nace":        {Type: rsql.String},
+	"industry_codes.sic":         {Type: rsql.String},
+	"created_at":                 {Type: rsql.Date},
+	"created_by":                 {Type: rsql.String},
+	"updated_at":                 {Type: rsql.Date},
+	"updated_by":                 {Type: rsql.String},
+}
+
+// CompanyFilterLimits the filter expressions run on every request so they are kept small
+var CompanyFilterLimits = rsql.Limits{
+	MaxDepth:     3,
+	MaxClauses:   20,
+	MaxArguments: 50,
+}
+
+// ToFilter returns the MongoDB filter of the query, tags match any of the given tags by default.
+// The filter expression has to match too, its *rsql.Error is returned when it is invalid
+func (query CompanyQuery) ToFilter() (bson.M, error) {
+	filter := bson.M{}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.Registered != nil {
+		filter["registered"] = *query.Registered
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	tags := NormalizeTags(query.Tags)
+	if len(tags) > 0 {
+		operator := "$in"
+		if query.TagsMatch == TagsMatchAll {
+			operator = "$all"
+		}
+		filter["tags"] = bson.M{operator: tags}
+	}
+	if query.Filter != "" {
+		expression, err := rsql.Compile(query.Filter, CompanyFilterFields, CompanyFilterLimits)
+		if err != nil {
+			return nil, err
+		}
+		filter["$and"] = bson.A{expression}
+	}
+	return filter, nil
+}
+
+// GetLimit returns the page size, DefaultQueryLimit when not set
+func (query CompanyQuery) GetLimit() int {
+	if query.Limit <= 0 {
+		return DefaultQueryLimit
+	}
+	if query.Limit > MaxQueryLimit {
+		return MaxQueryLimit
+	}
+	return query.Limit
+}
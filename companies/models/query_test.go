@@ -0,0 +1,80 @@
+package models
+
+import (
+	"companies/rsql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCompanyQueryToFilter(t *testing.T) {
+	registered := false
+
+	testCases := []struct {
+		name     string
+		query    CompanyQuery
+		expected bson.M
+		err      error
+	}{
+		{
+			name:     "empty query",
+			query:    CompanyQuery{},
+			expected: bson.M{},
+		},
+		{
+			name: "tags match any by default",
+			query: CompanyQuery{
+				Tags: []string{"VIP", " under review ", "vip"},
+			},
+			expected: bson.M{
+				"tags": bson.M{"$in": []string{"vip", "under-review"}},
+			},
+		},
+		{
+			name: "tags match all",
+			query: CompanyQuery{
+				Type:       "Cooperative",
+				Registered: &registered,
+				Status:     CompanyStatusActive,
+				Tags:       []string{"prospect", "vip"},
+				TagsMatch:  TagsMatchAll,
+			},
+			expected: bson.M{
+				"type":       "Cooperative",
+				"registered": false,
+				"status":     "active",
+				"tags":       bson.M{"$all": []string{"prospect", "vip"}},
+			},
+		},
+		{
+			name: "the filter expression is combined with the parameters",
+			query: CompanyQuery{
+				Status: CompanyStatusActive,
+				Filter: "tags==VIP,registered_address.country==de",
+			},
+			expected: bson.M{
+				"status": "active",
+				"$and": bson.A{bson.D{{Key: "$or", Value: bson.A{
+					bson.D{{Key: "tags", Value: "vip"}},
+					bson.D{{Key: "registered_address.country", Value: "DE"}},
+				}}}},
+			},
+		},
+		{
+			name: "invalid filter expression",
+			query: CompanyQuery{
+				Filter: "internal_notes==secret",
+			},
+			err: &rsql.Error{Kind: rsql.KindField, Column: 1, Message: "unknown field internal_notes"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filter, err := testCase.query.ToFilter()
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.expected, filter)
+		})
+	}
+}
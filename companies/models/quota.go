@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ScopeCompaniesQuotaPremium the scope of the premium quota tier, higher rate limits and no daily quota
+const ScopeCompaniesQuotaPremium = "companies:quota:premium"
+
+// Quota the limits of a user on a route group and the requests left for the current UTC day
+type Quota struct {
+	// Group the route group, ex: api or bulk
+	Group string `json:"group"`
+	// Tier the tier the scopes of the user give, ex: standard or premium
+	Tier string `json:"tier"`
+	// Rate the requests per second and Burst the requests the user can send at once
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	// Unlimited the tier has no daily quota, DailyLimit and DailyRemaining are then 0 and DailyReset is not set
+	Unlimited      bool       `json:"unlimited"`
+	DailyLimit     int        `json:"daily_limit"`
+	DailyRemaining int        `json:"daily_remaining"`
+	DailyReset     *time.Time `json:"daily_reset,omitempty"`
+}
+
+// QuotaOutput the quotas of the user on every route group
+type QuotaOutput struct {
+	Quotas []Quota `json:"quotas"`
+}
@@ -0,0 +1,33 @@
+package models
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchQuery the query string of the search companies endpoint
+type SearchQuery struct {
+	Q      string `form:"q" binding:"required,max=200"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// GetLimit returns the page size, DefaultSearchLimit when not set
+func (query SearchQuery) GetLimit() int {
+	if query.Limit <= 0 {
+		return DefaultSearchLimit
+	}
+	if query.Limit > MaxSearchLimit {
+		return MaxSearchLimit
+	}
+	return query.Limit
+}
+
+// CompanySearchHit a company matching the search text, best first
+type CompanySearchHit struct {
+	Company CompanyOutput `json:"company"`
+	Score   float64       `json:"score"`
+	// Highlights the matching fragments of each field, the text is HTML escaped and the matched terms
+	// are wrapped in <mark> tags
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"slices"
+	"strconv"
+)
+
+// DefaultEmployeeBuckets the lower bounds of the micro, small, medium and large companies
+var DefaultEmployeeBuckets = []int{0, 10, 50, 250}
+
+// DefaultEmployeePercentiles the median and the tail of the number of employees
+var DefaultEmployeePercentiles = []float64{50, 90, 99}
+
+// CompanyStats the number of companies of the query by type and by registration, the keys of ByRegistered
+// are true and false
+type CompanyStats struct {
+	Total        int            `json:"total"`
+	ByType       map[string]int `json:"by_type,omitempty"`
+	ByRegistered map[string]int `json:"by_registered,omitempty"`
+}
+
+// EmployeeStatsQuery the query string of the employees statistics endpoint, the companies are filtered by a CompanyQuery
+type EmployeeStatsQuery struct {
+	// Buckets the lower bounds of the buckets of the histogram, ex: buckets=10&buckets=50
+	Buckets []int `json:"buckets,omitempty" form:"buckets" binding:"omitempty,max=20,dive,min=0"`
+	// Percentiles ex: percentiles=50&percentiles=99.9
+	Percentiles []float64 `json:"percentiles,omitempty" form:"percentiles" binding:"omitempty,max=10,dive,gt=0,lte=100"`
+}
+
+// GetBuckets returns the sorted bounds of the buckets, the first one is always 0 so every company is counted
+func (query EmployeeStatsQuery) GetBuckets() []int {
+	if len(query.Buckets) == 0 {
+		return DefaultEmployeeBuckets
+	}
+	buckets := append([]int{0}, query.Buckets...)
+	slices.Sort(buckets)
+	return slices.Compact(buckets)
+}
+
+// GetPercentiles returns the sorted percentiles, DefaultEmployeePercentiles when not set
+func (query EmployeeStatsQuery) GetPercentiles() []float64 {
+	if len(query.Percentiles) == 0 {
+		return DefaultEmployeePercentiles
+	}
+	percentiles := slices.Clone(query.Percentiles)
+	slices.Sort(percentiles)
+	return slices.Compact(percentiles)
+}
+
+// EmployeeStats the distribution of the number of employees of the companies of the query
+type EmployeeStats struct {
+	Count   int              `json:"count"`
+	Buckets []EmployeeBucket `json:"buckets"`
+	// Percentiles keyed by PercentileKey, the nearest rank values, missing when there are no companies
+	Percentiles map[string]int `json:"percentiles"`
+}
+
+// EmployeeBucket the number of companies with From employees or more and less than To, the last bucket has no To
+type EmployeeBucket struct {
+	From  int  `json:"from"`
+	To    *int `json:"to,omitempty"`
+	Count int  `json:"count"`
+}
+
+// PercentileKey ex: p50, p99.9
+func PercentileKey(percentile float64) string {
+	return "p" + strconv.FormatFloat(percentile, 'f', -1, 64)
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The lifecycle statuses of a company, a new company is a draft
+const (
+	CompanyStatusDraft     = "draft"
+	CompanyStatusActive    = "active"
+	CompanyStatusSuspended = "suspended"
+	CompanyStatusDissolved = "dissolved"
+)
+
+// ScopeCompaniesDissolve the jwt scope needed to dissolve a company
+const ScopeCompaniesDissolve = "companies:dissolve"
+
+// CompanyTransition a move between lifecycle statuses
+type CompanyTransition struct {
+	From           []string
+	To             string
+	RequiresReason bool
+	// Scope the jwt scope needed to apply the transition, empty when every user can
+	Scope     string
+	EventType string
+}
+
+// IsAllowedFrom reports whether the transition can be applied to a company in the status
+func (transition CompanyTransition) IsAllowedFrom(status string) bool {
+	return slices.Contains(transition.From, status)
+}
+
+// CompanyTransitions the transition table of the company lifecycle, keyed by the transition name
+var CompanyTransitions = map[string]CompanyTransition{
+	"activate": {
+		From:      []string{CompanyStatusDraft},
+		To:        CompanyStatusActive,
+		EventType: KafkaEventTypeCompanyActivate,
+	},
+	"suspend": {
+		From:           []string{CompanyStatusActive},
+		To:             CompanyStatusSuspended,
+		RequiresReason: true,
+		EventType:      KafkaEventTypeCompanySuspend,
+	},
+	"reinstate": {
+		From:      []string{CompanyStatusSuspended},
+		To:        CompanyStatusActive,
+		EventType: KafkaEventTypeCompanyReinstate,
+	},
+	"dissolve": {
+		From:           []string{CompanyStatusActive, CompanyStatusSuspended},
+		To:             CompanyStatusDissolved,
+		RequiresReason: true,
+		Scope:          ScopeCompaniesDissolve,
+		EventType:      KafkaEventTypeCompanyDissolve,
+	},
+}
+
+// TransitionInput the JSON request body of the transitions endpoint
+type TransitionInput struct {
+	Transition string `json:"transition" binding:"required,oneof=activate suspend reinstate dissolve"`
+	Reason     string `json:"reason" binding:"max=500"`
+}
+
+// StatusChange the last lifecycle transition of a company
+type StatusChange struct {
+	Status    string    `json:"status" bson:"status"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	ChangedBy string    `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+}
+
+// StatusChangeEvent the data of the company lifecycle events
+type StatusChangeEvent struct {
+	CompanyID  uuid.UUID `json:"company_id"`
+	Transition string    `json:"transition"`
+	From       string    `json:"from"`
+	StatusChange
+}
+
+// Principal the authenticated user of a request, from the jwt claims
+type Principal struct {
+	Username string
+	Scopes   []string
+}
+
+// HasScope reports whether the principal was granted the scope
+func (principal Principal) HasScope(scope string) bool {
+	return slices.Contains(principal.Scopes, scope)
+}
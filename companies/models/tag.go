@@ -0,0 +1,41 @@
+package models
+
+import "strings"
+
+// MaxTagsPerCompany the maximum number of tags a company can have
+const MaxTagsPerCompany = 20
+
+// TagsInput the JSON request body of the add tags endpoint
+type TagsInput struct {
+	Tags []string `json:"tags" binding:"required,min=1,max=20,dive,tag"`
+}
+
+// TagCount the number of companies that have a tag
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// NormalizeTag trims, lower cases and replaces the inner white space of a tag with dashes,
+// ex: " Under Review " -> "under-review"
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// NormalizeTags normalizes the tags and removes the duplicates, keeping the first occurrence order
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	output := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		output = append(output, tag)
+	}
+	return output
+}
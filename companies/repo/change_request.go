@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"companies/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChangeRequestRepo interface {
+	CreateChangeRequest(ctx context.Context, changeRequest models.ChangeRequest) error
+	GetChangeRequest(ctx context.Context, changeRequestId uuid.UUID) (models.ChangeRequest, error)
+	ListPendingChangeRequests(ctx context.Context, companyId *uuid.UUID, now time.Time) ([]models.ChangeRequest, error)
+	DecideChangeRequest(ctx context.Context, changeRequestId uuid.UUID, decision models.ChangeRequest, now time.Time) (models.ChangeRequest, error)
+	SetChangeRequestStatus(ctx context.Context, changeRequestId uuid.UUID, status string) error
+}
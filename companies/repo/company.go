@@ -9,7 +9,45 @@ import (
 
 type CompanyRepo interface {
 	CreateCompany(ctx context.Context, company models.Company) (uuid.UUID, error)
-	PatchCompany(ctx context.Context, companyId uuid.UUID, company models.UpdateCompanyInput) (models.Company, error)
+	PatchCompany(ctx context.Context, companyId uuid.UUID, company models.UpdateCompanyInput, expectedVersion *int, stamp models.AuditStamp) (models.Company, error)
 	GetCompany(ctx context.Context, companyId uuid.UUID) (models.Company, error)
+	// GetCompanies returns the companies of the ids that exist, in no particular order
+	GetCompanies(ctx context.Context, companyIds []uuid.UUID) ([]models.Company, error)
+	GetCompanyByIdentifier(ctx context.Context, scheme string, value string) (models.Company, error)
+	CompanyNameExists(ctx context.Context, name string) (bool, error)
+	// FindNameCandidates returns the companies of the tenant that share the most name trigrams with the given ones,
+	// only their id, name and name key are set
+	FindNameCandidates(ctx context.Context, trigrams []string, limit int) ([]models.Company, error)
+	// SetNameKeys sets the name key and trigrams of a company, ex: of the companies created before they existed
+	SetNameKeys(ctx context.Context, companyId uuid.UUID, nameKey string, nameTrigrams []string) error
+	// SetNameCanonical sets the canonical name of a company, ErrNameTaken is returned when another company
+	// of the tenant has it
+	SetNameCanonical(ctx context.Context, companyId uuid.UUID, nameCanonical string) error
 	DeleteCompany(ctx context.Context, companyId uuid.UUID) error
+	GetAncestors(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNode, error)
+	GetDescendants(ctx context.Context, companyId uuid.UUID, maxDepth *int) ([]models.CompanyNode, error)
+	UnsetParent(ctx context.Context, companyId uuid.UUID, stamp models.AuditStamp) (models.Company, error)
+	DetachChildren(ctx context.Context, parentId uuid.UUID, stamp models.AuditStamp) ([]uuid.UUID, error)
+	// MoveChildren moves the direct children of fromParentId under toParentId and returns them
+	MoveChildren(ctx context.Context, fromParentId uuid.UUID, toParentId uuid.UUID, stamp models.AuditStamp) ([]models.Company, error)
+	// ReplaceCompany replaces the expected version of the company and increments its version,
+	// ErrVersionConflict is returned when the company was changed since
+	ReplaceCompany(ctx context.Context, company models.Company, expectedVersion int, stamp models.AuditStamp) (models.Company, error)
+	// CreateTombstone saves the redirect of a merged company, the redirects to the merged company
+	// are moved to the survivor
+	CreateTombstone(ctx context.Context, tombstone models.CompanyTombstone) error
+	GetTombstone(ctx context.Context, companyId uuid.UUID) (models.CompanyTombstone, error)
+	AddTags(ctx context.Context, companyId uuid.UUID, tags []string, stamp models.AuditStamp) (models.Company, error)
+	RemoveTag(ctx context.Context, companyId uuid.UUID, tag string, stamp models.AuditStamp) (models.Company, error)
+	ListCompanies(ctx context.Context, query models.CompanyQuery) ([]models.Company, error)
+	ExportCompanies(ctx context.Context, query models.CompanyQuery, fn func(models.Company) error) error
+	CountTags(ctx context.Context) ([]models.TagCount, error)
+	// CompanyStats returns the number of companies of the query by type and by registration
+	CompanyStats(ctx context.Context, query models.CompanyQuery) (models.CompanyStats, error)
+	// EmployeeStats returns the histogram of the number of employees, buckets are the sorted lower bounds
+	// of the histogram starting at 0, and the nearest rank percentiles
+	EmployeeStats(ctx context.Context, query models.CompanyQuery, buckets []int, percentiles []float64) (models.EmployeeStats, error)
+	AddAttachment(ctx context.Context, companyId uuid.UUID, attachment models.Attachment, stamp models.AuditStamp) (models.Company, error)
+	RemoveAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID, stamp models.AuditStamp) (models.Attachment, error)
+	SetStatus(ctx context.Context, companyId uuid.UUID, from string, change models.StatusChange) (models.Company, error)
 }
@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"companies/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobRepo stores the jobs. The worker methods are not restricted to the tenant of the context, a worker runs
+// the jobs of every tenant and a job is only changed by the worker that holds its lease
+type JobRepo interface {
+	CreateJob(ctx context.Context, job models.Job) error
+	GetJob(ctx context.Context, jobId uuid.UUID) (models.Job, error)
+	CancelJob(ctx context.Context, jobId uuid.UUID, now time.Time) (models.Job, error)
+	RetryJob(ctx context.Context, jobId uuid.UUID, now time.Time) (models.Job, error)
+	ClaimJob(ctx context.Context, jobTypes []string, owner string, leaseExpiresAt time.Time, now time.Time) (models.Job, error)
+	RenewLease(ctx context.Context, jobId uuid.UUID, owner string, progress int, leaseExpiresAt time.Time, now time.Time) (models.Job, error)
+	FinishJob(ctx context.Context, jobId uuid.UUID, owner string, result models.Job, now time.Time) (models.Job, error)
+	ReleaseJob(ctx context.Context, jobId uuid.UUID, owner string, now time.Time) (models.Job, error)
+}
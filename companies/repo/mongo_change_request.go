@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ChangeRequestsCollection string = "company_change_requests"
+
+var (
+	ErrInsertOne = errors.New("insertOne returned an error")
+	ErrUpdateOne = errors.New("updateOne returned an error")
+	// ErrChangeRequestNotPending the change request was already decided or it expired
+	ErrChangeRequestNotPending = errors.New("the change request is not pending")
+)
+
+type mongoChangeRequestRepo struct {
+	client *mongo.Client
+	// database the name of the database of the service, ex: mx-auth
+	database string
+}
+
+func NewMongoChangeRequestRepo(mongoClient *mongo.Client, database string) ChangeRequestRepo {
+	return &mongoChangeRequestRepo{
+		client:   mongoClient,
+		database: database,
+	}
+}
+
+func (r *mongoChangeRequestRepo) CreateChangeRequest(ctx context.Context, changeRequest models.ChangeRequest) error {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if scope.AllTenants || changeRequest.Tenant != scope.Tenant {
+		return ErrTenantMismatch
+	}
+	_, err = r.client.
+		Database(r.database).
+		Collection(ChangeRequestsCollection).
+		InsertOne(ctx, changeRequest)
+	if err != nil {
+		return errors.Join(ErrInsertOne, err)
+	}
+	return nil
+}
+
+func (r *mongoChangeRequestRepo) GetChangeRequest(ctx context.Context, changeRequestId uuid.UUID) (models.ChangeRequest, error) {
+	filter, err := tenantFilter(ctx, bson.M{"_id": changeRequestId})
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+	result := r.client.
+		Database(r.database).
+		Collection(ChangeRequestsCollection).
+		FindOne(ctx, filter)
+	err = result.Err()
+	if err != nil {
+		return models.ChangeRequest{}, errors.Join(ErrFindOne, err)
+	}
+	var changeRequest models.ChangeRequest
+	err = result.Decode(&changeRequest)
+	if err != nil {
+		return models.ChangeRequest{}, errors.Join(ErrFindOneDecode, err)
+	}
+	return changeRequest, nil
+}
+
+// ListPendingChangeRequests returns the pending change requests that did not expire, the oldest first,
+// of every company when companyId is nil
+func (r *mongoChangeRequestRepo) ListPendingChangeRequests(ctx context.Context, companyId *uuid.UUID, now time.Time) ([]models.ChangeRequest, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"status":     models.ChangeRequestStatusPending,
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if companyId != nil {
+		filter["company_id"] = *companyId
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "requested_at", Value: 1}})
+
+	cursor, err := r.client.
+		Database(r.database).
+		Collection(ChangeRequestsCollection).
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Join(ErrFind, err)
+	}
+	defer cursor.Close(ctx)
+
+	changeRequests := []models.ChangeRequest{}
+	err = cursor.All(ctx, &changeRequests)
+	if err != nil {
+		return nil, errors.Join(ErrFindDecode, err)
+	}
+	return changeRequests, nil
+}
+
+// DecideChangeRequest sets the status and the decision fields of a pending change request that did not expire,
+// ErrChangeRequestNotPending is returned when another request decided it first
+func (r *mongoChangeRequestRepo) DecideChangeRequest(ctx context.Context, changeRequestId uuid.UUID, decision models.ChangeRequest, now time.Time) (models.ChangeRequest, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id":        changeRequestId,
+		"status":     models.ChangeRequestStatusPending,
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+	set := bson.M{
+		"status":     decision.Status,
+		"decided_by": decision.DecidedBy,
+		"decided_at": decision.DecidedAt,
+	}
+	if decision.RejectionReason != "" {
+		set["rejection_reason"] = decision.RejectionReason
+	}
+	update := bson.M{"$set": set}
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(false)
+
+	result := r.client.
+		Database(r.database).
+		Collection(ChangeRequestsCollection).
+		FindOneAndUpdate(ctx, filter, update, opts)
+	err = result.Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.ChangeRequest{}, ErrChangeRequestNotPending
+		}
+		return models.ChangeRequest{}, errors.Join(ErrFindOneAndUpdate, err)
+	}
+	var changeRequest models.ChangeRequest
+	err = result.Decode(&changeRequest)
+	if err != nil {
+		return models.ChangeRequest{}, errors.Join(ErrFindOneAndUpdateDecode, err)
+	}
+	return changeRequest, nil
+}
+
+// SetChangeRequestStatus sets the status of a change request whatever its current status,
+// the decision fields are removed when it goes back to pending
+func (r *mongoChangeRequestRepo) SetChangeRequestStatus(ctx context.Context, changeRequestId uuid.UUID, status string) error {
+	filter, err := tenantFilter(ctx, bson.M{"_id": changeRequestId})
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"status": status}}
+	if status == models.ChangeRequestStatusPending {
+		update["$unset"] = bson.M{"decided_by": "", "decided_at": ""}
+	}
+	_, err = r.client.
+		Database(r.database).
+		Collection(ChangeRequestsCollection).
+		UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Join(ErrUpdateOne, err)
+	}
+	return nil
+}
@@ -1,9 +1,16 @@
 package repo
 
 import (
+	"companies/dedupe"
 	"companies/models"
+	"companies/tenancy"
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,36 +19,97 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-const (
-	DatabaseName        string = "mx-auth"
-	CompaniesCollection string = "companies"
-)
+const CompaniesCollection string = "companies"
 
 var (
-	ErrFindOne                = errors.New("findOne returned an error")
-	ErrFindOneDecode          = errors.New("findOne returned an error while decode ")
-	ErrFindOneAndUpdate       = errors.New("findOneAndUpdate returned an error")
-	ErrFindOneAndUpdateDecode = errors.New("findOneAndUpdate returned an error while decoding")
-	ErrDeleteOne              = errors.New("deleteOne returned an error")
-	ErrDocumentNotFound       = errors.New("document not found")
-	ErrDeleteOneNotOne        = errors.New("deleteOne result returned a count different than one")
+	ErrFindOne                 = errors.New("findOne returned an error")
+	ErrFindOneDecode           = errors.New("findOne returned an error while decode ")
+	ErrFindOneAndUpdate        = errors.New("findOneAndUpdate returned an error")
+	ErrFindOneAndUpdateDecode  = errors.New("findOneAndUpdate returned an error while decoding")
+	ErrFindOneAndReplace       = errors.New("findOneAndReplace returned an error")
+	ErrFindOneAndReplaceDecode = errors.New("findOneAndReplace returned an error while decoding")
+	ErrDeleteOne               = errors.New("deleteOne returned an error")
+	ErrDocumentNotFound        = errors.New("document not found")
+	ErrDeleteOneNotOne         = errors.New("deleteOne result returned a count different than one")
+	ErrAddressIncomplete       = errors.New("the company has no address to update, the street, city and country are required")
+	ErrAggregate               = errors.New("aggregate returned an error")
+	ErrAggregateDecode         = errors.New("aggregate returned an error while decoding")
+	ErrFind                    = errors.New("find returned an error")
+	ErrFindDecode              = errors.New("find returned an error while decoding")
+	ErrUpdateMany              = errors.New("updateMany returned an error")
+	ErrTooManyTags             = errors.New("the company would have more tags than allowed")
+	ErrAttachmentNotAdded      = errors.New("the company already has the attachment content or too many attachments")
+	ErrAttachmentNotFound      = errors.New("attachment not found")
+	ErrStatusChanged           = errors.New("the company status was changed by another request")
+	ErrVersionConflict         = errors.New("the company was changed since the expected version")
+	ErrTenantMismatch          = errors.New("the company does not belong to the tenant of the request")
+	ErrCountDocuments          = errors.New("countDocuments returned an error")
+	ErrCursor                  = errors.New("the cursor returned an error")
+	ErrNameTaken               = errors.New("another company of the tenant has the same canonical name")
+	ErrIdentifierTaken         = errors.New("another company of the tenant has the same identifier")
+	ErrOwnershipWithoutParent  = errors.New("the company has no parent to set the ownership percentage of")
 )
 
+// identifierFields the identifiers with a unique index per tenant
+var identifierFields = []string{"identifiers.lei", "identifiers.vat", "identifiers.duns"}
+
+// IdentifierTakenError is returned when another company of the tenant has the identifier, Field names it, ex:
+// identifiers.lei
+type IdentifierTakenError struct {
+	Field string
+}
+
+func (e IdentifierTakenError) Error() string {
+	return "another company of the tenant has the same " + e.Field
+}
+
+func (e IdentifierTakenError) Unwrap() error {
+	return ErrIdentifierTaken
+}
+
+// duplicateKeyError maps a duplicate key error of a unique index of the companies to the field it was raised on, the
+// index is only named by the message of the error, ex: index: tenant_1_identifiers.lei_1 dup key: ...
+func duplicateKeyError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	for _, field := range identifierFields {
+		if strings.Contains(err.Error(), field+"_1") {
+			return errors.Join(IdentifierTakenError{Field: field}, err)
+		}
+	}
+	// the names are unique per tenant on their exact and their canonical form
+	if strings.Contains(err.Error(), "name_1 ") || strings.Contains(err.Error(), "name_canonical_1 ") {
+		return errors.Join(ErrNameTaken, err)
+	}
+	return err
+}
+
 type mongoCompanyRepo struct {
 	client *mongo.Client
+	// database the name of the database of the service, ex: mx-auth
+	database string
 }
 
-func NewMongoCompanyRepo(mongoClient *mongo.Client) CompanyRepo {
+func NewMongoCompanyRepo(mongoClient *mongo.Client, database string) CompanyRepo {
 	return &mongoCompanyRepo{
-		client: mongoClient,
+		client:   mongoClient,
+		database: database,
 	}
 }
 
 func (r *mongoCompanyRepo) CreateCompany(ctx context.Context, company models.Company) (uuid.UUID, error) {
-	result, err := r.client.Database(DatabaseName).Collection(CompaniesCollection).InsertOne(ctx, company)
+	scope, err := tenancy.FromContext(ctx)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	if scope.AllTenants || company.Tenant != scope.Tenant {
+		return uuid.Nil, ErrTenantMismatch
+	}
+	result, err := r.client.Database(r.database).Collection(CompaniesCollection).InsertOne(ctx, company)
+	if err != nil {
+		return uuid.Nil, duplicateKeyError(err)
+	}
 	insertedId, err := uuid.FromBytes(result.InsertedID.(primitive.Binary).Data)
 	if err != nil {
 		return uuid.Nil, err
@@ -49,37 +117,190 @@ func (r *mongoCompanyRepo) CreateCompany(ctx context.Context, company models.Com
 	return insertedId, nil
 }
 
-func (r *mongoCompanyRepo) PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput) (models.Company, error) {
-	filter := bson.M{"_id": companyId}
-	update := bson.M{"$set": updateCompanyInput.ToBsonM()}
+// CompanyNameExists reports whether a company of the tenant already has the name, ignoring its case and normal form
+func (r *mongoCompanyRepo) CompanyNameExists(ctx context.Context, name string) (bool, error) {
+	// the companies created before the canonical names only have their exact name until the backfill
+	filter, err := tenantFilter(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"name_canonical": dedupe.CanonicalName(name)},
+			bson.M{"name": dedupe.NormalizeName(name)},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	count, err := r.client.Database(r.database).Collection(CompaniesCollection).
+		CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, errors.Join(ErrCountDocuments, err)
+	}
+	return count > 0, nil
+}
 
-	opts := options.FindOneAndUpdate().
-		SetReturnDocument(options.After).
-		SetUpsert(false)
+func (r *mongoCompanyRepo) FindNameCandidates(ctx context.Context, trigrams []string, limit int) ([]models.Company, error) {
+	match, err := tenantFilter(ctx, bson.M{
+		"name_trigrams": bson.M{"$in": trigrams},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$project", Value: bson.M{
+			"name":     1,
+			"name_key": 1,
+			"common":   bson.M{"$size": bson.M{"$setIntersection": bson.A{"$name_trigrams", trigrams}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "common", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	companies := []models.Company{}
+	err = r.aggregate(ctx, pipeline, &companies)
+	if err != nil {
+		return nil, err
+	}
+	return companies, nil
+}
 
-	var updatedCompany models.Company
-	result := r.client.
-		Database(DatabaseName).
+func (r *mongoCompanyRepo) SetNameKeys(ctx context.Context, companyId uuid.UUID, nameKey string, nameTrigrams []string) error {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id": companyId,
+	})
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{
+		"name_key":      nameKey,
+		"name_trigrams": nameTrigrams,
+	}}
+	_, err = r.client.
+		Database(r.database).
 		Collection(CompaniesCollection).
-		FindOneAndUpdate(ctx, filter, update, opts)
-	err := result.Err()
+		UpdateOne(ctx, filter, update)
 	if err != nil {
-		return models.Company{}, errors.Join(ErrFindOneAndUpdate, err)
+		return errors.Join(ErrUpdateOne, err)
 	}
-	err = result.Decode(&updatedCompany)
+	return nil
+}
+
+func (r *mongoCompanyRepo) SetNameCanonical(ctx context.Context, companyId uuid.UUID, nameCanonical string) error {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id": companyId,
+	})
 	if err != nil {
-		return models.Company{}, errors.Join(ErrFindOneAndUpdateDecode, err)
+		return err
 	}
+	update := bson.M{"$set": bson.M{
+		"name_canonical": nameCanonical,
+	}}
+	_, err = r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.Join(ErrNameTaken, err)
+	}
+	if err != nil {
+		return errors.Join(ErrUpdateOne, err)
+	}
+	return nil
+}
 
-	return updatedCompany, nil
+// PatchCompany applies the patch and increments the company version. When expectedVersion is set the patch is
+// only applied to that version of the company, ErrVersionConflict is returned otherwise
+func (r *mongoCompanyRepo) PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput, expectedVersion *int, stamp models.AuditStamp) (models.Company, error) {
+	filter := bson.M{"_id": companyId}
+	if expectedVersion != nil {
+		filter["version"] = *expectedVersion
+	}
+	// a partial address only updates an existing one, setting registered_address.city alone would store an address
+	// without its street and country
+	partialAddresses := updateCompanyInput.PartialAddresses()
+	for _, address := range partialAddresses {
+		filter[address] = bson.M{"$type": "object"}
+	}
+	// the ownership percentage is the share the parent holds, a company without a parent can not have one
+	requiresParent := updateCompanyInput.OwnershipPercentage != nil && updateCompanyInput.ParentID == nil
+	if requiresParent {
+		filter["parent_id"] = bson.M{"$exists": true}
+	}
+	update := bson.M{
+		"$inc": bson.M{"version": 1},
+		"$set": updateCompanyInput.ToBsonM(),
+	}
+	update = withAuditStamp(update, stamp)
+
+	company, err := r.findOneAndUpdate(ctx, filter, update)
+	if err != nil && (expectedVersion != nil || len(partialAddresses) > 0 || requiresParent) && errors.Is(err, mongo.ErrNoDocuments) {
+		current, getErr := r.GetCompany(ctx, companyId)
+		if getErr == nil {
+			if expectedVersion != nil && current.Version != *expectedVersion {
+				return models.Company{}, ErrVersionConflict
+			}
+			if requiresParent && current.ParentID == nil {
+				return models.Company{}, ErrOwnershipWithoutParent
+			}
+			if len(partialAddresses) > 0 {
+				return models.Company{}, ErrAddressIncomplete
+			}
+		}
+	}
+	return company, err
 }
 
 func (r *mongoCompanyRepo) GetCompany(ctx context.Context, companyId uuid.UUID) (models.Company, error) {
-	filter := bson.M{
+	filter, err := tenantFilter(ctx, bson.M{
 		"_id": companyId,
+	})
+	if err != nil {
+		return models.Company{}, err
 	}
-	result := r.client.Database(DatabaseName).Collection(CompaniesCollection).FindOne(ctx, filter)
-	err := result.Err()
+	result := r.client.Database(r.database).Collection(CompaniesCollection).FindOne(ctx, filter)
+	err = result.Err()
+	if err != nil {
+		return models.Company{}, errors.Join(ErrFindOne, err)
+	}
+	var company models.Company
+	err = result.Decode(&company)
+	if err != nil {
+		return models.Company{}, errors.Join(ErrFindOneDecode, err)
+	}
+	return company, nil
+}
+
+func (r *mongoCompanyRepo) GetCompanies(ctx context.Context, companyIds []uuid.UUID) ([]models.Company, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id": bson.M{"$in": companyIds},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		Find(ctx, filter)
+	if err != nil {
+		return nil, errors.Join(ErrFind, err)
+	}
+	defer cursor.Close(ctx)
+
+	companies := []models.Company{}
+	err = cursor.All(ctx, &companies)
+	if err != nil {
+		return nil, errors.Join(ErrFindDecode, err)
+	}
+	return companies, nil
+}
+
+func (r *mongoCompanyRepo) GetCompanyByIdentifier(ctx context.Context, scheme string, value string) (models.Company, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"identifiers." + scheme: value,
+	})
+	if err != nil {
+		return models.Company{}, err
+	}
+	result := r.client.Database(r.database).Collection(CompaniesCollection).FindOne(ctx, filter)
+	err = result.Err()
 	if err != nil {
 		return models.Company{}, errors.Join(ErrFindOne, err)
 	}
@@ -92,11 +313,14 @@ func (r *mongoCompanyRepo) GetCompany(ctx context.Context, companyId uuid.UUID)
 }
 
 func (r *mongoCompanyRepo) DeleteCompany(ctx context.Context, companyId uuid.UUID) error {
-	filter := bson.M{
+	filter, err := tenantFilter(ctx, bson.M{
 		"_id": companyId,
+	})
+	if err != nil {
+		return err
 	}
 	result, err := r.client.
-		Database(DatabaseName).
+		Database(r.database).
 		Collection(CompaniesCollection).
 		DeleteOne(ctx, filter)
 	if err != nil {
@@ -110,3 +334,631 @@ func (r *mongoCompanyRepo) DeleteCompany(ctx context.Context, companyId uuid.UUI
 	}
 	return nil
 }
+
+// GetAncestors returns the parent chain of a company, the direct parent has depth 0
+func (r *mongoCompanyRepo) GetAncestors(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNode, error) {
+	graphLookup := bson.M{
+		"from":             CompaniesCollection,
+		"startWith":        "$parent_id",
+		"connectFromField": "parent_id",
+		"connectToField":   "_id",
+		"as":               "nodes",
+		"depthField":       "depth",
+	}
+	return r.graphLookup(ctx, companyId, graphLookup)
+}
+
+// GetDescendants returns the subtree of a company, the direct children have depth 0.
+// A nil maxDepth returns the full subtree
+func (r *mongoCompanyRepo) GetDescendants(ctx context.Context, companyId uuid.UUID, maxDepth *int) ([]models.CompanyNode, error) {
+	graphLookup := bson.M{
+		"from":             CompaniesCollection,
+		"startWith":        "$_id",
+		"connectFromField": "_id",
+		"connectToField":   "parent_id",
+		"as":               "nodes",
+		"depthField":       "depth",
+	}
+	if maxDepth != nil {
+		graphLookup["maxDepth"] = *maxDepth
+	}
+	return r.graphLookup(ctx, companyId, graphLookup)
+}
+
+// graphLookup runs a $graphLookup that starts from companyId and returns the found nodes sorted by depth.
+// mongo.ErrNoDocuments is returned when companyId does not exist. The walk does not leave the tenant of the request
+func (r *mongoCompanyRepo) graphLookup(ctx context.Context, companyId uuid.UUID, graphLookup bson.M) ([]models.CompanyNode, error) {
+	match, err := tenantFilter(ctx, bson.M{"_id": companyId})
+	if err != nil {
+		return nil, err
+	}
+	if tenant, ok := match["tenant"]; ok {
+		graphLookup["restrictSearchWithMatch"] = bson.M{"tenant": tenant}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$graphLookup", Value: graphLookup}},
+		{{Key: "$project", Value: bson.M{"nodes": 1}}},
+	}
+	cursor, err := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Join(ErrAggregate, err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Nodes []models.CompanyNode `bson:"nodes"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, errors.Join(ErrAggregateDecode, err)
+	}
+	if len(results) == 0 {
+		return nil, errors.Join(ErrDocumentNotFound, mongo.ErrNoDocuments)
+	}
+
+	nodes := results[0].Nodes
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Depth < nodes[j].Depth
+	})
+	return nodes, nil
+}
+
+func (r *mongoCompanyRepo) UnsetParent(ctx context.Context, companyId uuid.UUID, stamp models.AuditStamp) (models.Company, error) {
+	filter := bson.M{"_id": companyId}
+	update := bson.M{"$unset": bson.M{"parent_id": "", "ownership_percentage": ""}}
+	return r.findOneAndUpdate(ctx, filter, withAuditStamp(update, stamp))
+}
+
+// DetachChildren turns the direct children of parentId into top level companies and returns their ids
+func (r *mongoCompanyRepo) DetachChildren(ctx context.Context, parentId uuid.UUID, stamp models.AuditStamp) ([]uuid.UUID, error) {
+	collection := r.client.Database(r.database).Collection(CompaniesCollection)
+	filter, err := tenantFilter(ctx, bson.M{"parent_id": parentId})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Join(ErrFind, err)
+	}
+	defer cursor.Close(ctx)
+
+	var children []struct {
+		ID uuid.UUID `bson:"_id"`
+	}
+	err = cursor.All(ctx, &children)
+	if err != nil {
+		return nil, errors.Join(ErrFindDecode, err)
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+
+	update := bson.M{"$unset": bson.M{"parent_id": "", "ownership_percentage": ""}}
+	_, err = collection.UpdateMany(ctx, filter, withAuditStamp(update, stamp))
+	if err != nil {
+		return nil, errors.Join(ErrUpdateMany, err)
+	}
+
+	childIds := make([]uuid.UUID, 0, len(children))
+	for _, child := range children {
+		childIds = append(childIds, child.ID)
+	}
+	return childIds, nil
+}
+
+func (r *mongoCompanyRepo) MoveChildren(ctx context.Context, fromParentId uuid.UUID, toParentId uuid.UUID, stamp models.AuditStamp) ([]models.Company, error) {
+	collection := r.client.Database(r.database).Collection(CompaniesCollection)
+	filter, err := tenantFilter(ctx, bson.M{"parent_id": fromParentId})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "ownership_percentage": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Join(ErrFind, err)
+	}
+	defer cursor.Close(ctx)
+
+	children := []models.Company{}
+	err = cursor.All(ctx, &children)
+	if err != nil {
+		return nil, errors.Join(ErrFindDecode, err)
+	}
+	if len(children) == 0 {
+		return children, nil
+	}
+
+	update := bson.M{"$set": bson.M{"parent_id": toParentId}}
+	_, err = collection.UpdateMany(ctx, filter, withAuditStamp(update, stamp))
+	if err != nil {
+		return nil, errors.Join(ErrUpdateMany, err)
+	}
+
+	for i := range children {
+		children[i].ParentID = &toParentId
+	}
+	return children, nil
+}
+
+func (r *mongoCompanyRepo) ReplaceCompany(ctx context.Context, company models.Company, expectedVersion int, stamp models.AuditStamp) (models.Company, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id":     company.ID,
+		"version": expectedVersion,
+	})
+	if err != nil {
+		return models.Company{}, err
+	}
+	company.Version = expectedVersion + 1
+	company.UpdatedBy, company.UpdatedAt = stamp.By, stamp.At
+	opts := options.FindOneAndReplace().
+		SetReturnDocument(options.After).
+		SetUpsert(false)
+
+	var replacedCompany models.Company
+	result := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		FindOneAndReplace(ctx, filter, company, opts)
+	err = result.Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			_, getErr := r.GetCompany(ctx, company.ID)
+			if getErr == nil {
+				return models.Company{}, ErrVersionConflict
+			}
+		}
+		return models.Company{}, errors.Join(ErrFindOneAndReplace, duplicateKeyError(err))
+	}
+	err = result.Decode(&replacedCompany)
+	if err != nil {
+		return models.Company{}, errors.Join(ErrFindOneAndReplaceDecode, err)
+	}
+	return replacedCompany, nil
+}
+
+// AddTags adds the tags that the company does not have yet. The tag limit is checked in the update filter
+// so concurrent requests can not go over it
+func (r *mongoCompanyRepo) AddTags(ctx context.Context, companyId uuid.UUID, tags []string, stamp models.AuditStamp) (models.Company, error) {
+	filter := bson.M{
+		"_id": companyId,
+		"$expr": bson.M{
+			"$lte": bson.A{
+				bson.M{"$size": bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}, tags}}},
+				models.MaxTagsPerCompany,
+			},
+		},
+	}
+	update := bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": tags}}}
+
+	company, err := r.findOneAndUpdate(ctx, filter, withAuditStamp(update, stamp))
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		// the filter did not match, either the company does not exist or the limit was reached
+		_, getErr := r.GetCompany(ctx, companyId)
+		if getErr == nil {
+			return models.Company{}, ErrTooManyTags
+		}
+	}
+	return company, err
+}
+
+func (r *mongoCompanyRepo) RemoveTag(ctx context.Context, companyId uuid.UUID, tag string, stamp models.AuditStamp) (models.Company, error) {
+	filter := bson.M{"_id": companyId}
+	update := bson.M{"$pull": bson.M{"tags": tag}}
+	return r.findOneAndUpdate(ctx, filter, withAuditStamp(update, stamp))
+}
+
+// SetStatus moves the company from the status to the status of the change, ErrStatusChanged is returned
+// when the company is no longer in the from status
+func (r *mongoCompanyRepo) SetStatus(ctx context.Context, companyId uuid.UUID, from string, change models.StatusChange) (models.Company, error) {
+	filter := bson.M{"_id": companyId, "status": from}
+	update := bson.M{"$set": bson.M{
+		"status":             change.Status,
+		"last_status_change": change,
+	}}
+	update = withAuditStamp(update, models.AuditStamp{By: change.ChangedBy, At: change.ChangedAt})
+
+	company, err := r.findOneAndUpdate(ctx, filter, update)
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		_, getErr := r.GetCompany(ctx, companyId)
+		if getErr == nil {
+			return models.Company{}, ErrStatusChanged
+		}
+	}
+	return company, err
+}
+
+func (r *mongoCompanyRepo) findOneAndUpdate(ctx context.Context, filter bson.M, update bson.M) (models.Company, error) {
+	filter, err := tenantFilter(ctx, filter)
+	if err != nil {
+		return models.Company{}, err
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetUpsert(false)
+
+	var updatedCompany models.Company
+	result := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		FindOneAndUpdate(ctx, filter, update, opts)
+	err = result.Err()
+	if err != nil {
+		return models.Company{}, errors.Join(ErrFindOneAndUpdate, duplicateKeyError(err))
+	}
+	err = result.Decode(&updatedCompany)
+	if err != nil {
+		return models.Company{}, errors.Join(ErrFindOneAndUpdateDecode, err)
+	}
+
+	return updatedCompany, nil
+}
+
+func (r *mongoCompanyRepo) ListCompanies(ctx context.Context, query models.CompanyQuery) ([]models.Company, error) {
+	queryFilter, err := query.ToFilter()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := tenantFilter(ctx, queryFilter)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.GetLimit()))
+
+	cursor, err := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Join(ErrFind, err)
+	}
+	defer cursor.Close(ctx)
+
+	companies := []models.Company{}
+	err = cursor.All(ctx, &companies)
+	if err != nil {
+		return nil, errors.Join(ErrFindDecode, err)
+	}
+	return companies, nil
+}
+
+// ExportCompanies calls fn with each company of the query filter, sorted by name, the pagination is ignored.
+// The companies are read from a snapshot so the export is consistent, and decoded one at a time
+func (r *mongoCompanyRepo) ExportCompanies(ctx context.Context, query models.CompanyQuery, fn func(models.Company) error) error {
+	queryFilter, err := query.ToFilter()
+	if err != nil {
+		return err
+	}
+	filter, err := tenantFilter(ctx, queryFilter)
+	if err != nil {
+		return err
+	}
+	session, err := r.client.StartSession(options.Session().SetSnapshot(true))
+	if err != nil {
+		return errors.Join(ErrStartSession, err)
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sessionCtx mongo.SessionContext) error {
+		opts := options.Find().
+			SetSort(bson.D{{Key: "name", Value: 1}})
+		cursor, err := r.client.
+			Database(r.database).
+			Collection(CompaniesCollection).
+			Find(sessionCtx, filter, opts)
+		if err != nil {
+			return errors.Join(ErrFind, err)
+		}
+		defer cursor.Close(sessionCtx)
+
+		for cursor.Next(sessionCtx) {
+			var company models.Company
+			err = cursor.Decode(&company)
+			if err != nil {
+				return errors.Join(ErrFindDecode, err)
+			}
+			err = fn(company)
+			if err != nil {
+				return err
+			}
+		}
+		err = cursor.Err()
+		if err != nil {
+			return errors.Join(ErrCursor, err)
+		}
+		return nil
+	})
+}
+
+// CountTags returns the number of companies of each tag, the most used tags first
+func (r *mongoCompanyRepo) CountTags(ctx context.Context) ([]models.TagCount, error) {
+	match, err := tenantFilter(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Join(ErrAggregate, err)
+	}
+	defer cursor.Close(ctx)
+
+	tagCounts := []models.TagCount{}
+	err = cursor.All(ctx, &tagCounts)
+	if err != nil {
+		return nil, errors.Join(ErrAggregateDecode, err)
+	}
+	return tagCounts, nil
+}
+
+// groupCount a group of an aggregation, the value of the grouped field and its number of companies
+type groupCount struct {
+	Value any `bson:"_id"`
+	Count int `bson:"count"`
+}
+
+// groupInt returns the integer value of a group, the numbers are decoded as int32 or int64 depending on their size
+func groupInt(group groupCount) (int, bool) {
+	switch value := group.Value.(type) {
+	case int32:
+		return int(value), true
+	case int64:
+		return int(value), true
+	}
+	return 0, false
+}
+
+func (r *mongoCompanyRepo) CompanyStats(ctx context.Context, query models.CompanyQuery) (models.CompanyStats, error) {
+	queryFilter, err := query.ToFilter()
+	if err != nil {
+		return models.CompanyStats{}, err
+	}
+	filter, err := tenantFilter(ctx, queryFilter)
+	if err != nil {
+		return models.CompanyStats{}, err
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"by_type":       bson.A{bson.M{"$group": bson.M{"_id": "$type", "count": bson.M{"$sum": 1}}}},
+			"by_registered": bson.A{bson.M{"$group": bson.M{"_id": "$registered", "count": bson.M{"$sum": 1}}}},
+		}}},
+	}
+	var results []struct {
+		ByType       []groupCount `bson:"by_type"`
+		ByRegistered []groupCount `bson:"by_registered"`
+	}
+	err = r.aggregate(ctx, pipeline, &results)
+	if err != nil {
+		return models.CompanyStats{}, err
+	}
+
+	stats := models.CompanyStats{
+		ByType:       map[string]int{},
+		ByRegistered: map[string]int{"true": 0, "false": 0},
+	}
+	if len(results) == 0 {
+		return stats, nil
+	}
+	for _, group := range results[0].ByType {
+		stats.Total += group.Count
+		stats.ByType[fmt.Sprint(group.Value)] = group.Count
+	}
+	for _, group := range results[0].ByRegistered {
+		registered, _ := group.Value.(bool)
+		stats.ByRegistered[strconv.FormatBool(registered)] += group.Count
+	}
+	return stats, nil
+}
+
+// EmployeeStats counts the companies in the buckets with one aggregation, each percentile is then read
+// with a sorted find that skips to its rank, MongoDB 6 has no $percentile
+func (r *mongoCompanyRepo) EmployeeStats(ctx context.Context, query models.CompanyQuery, buckets []int, percentiles []float64) (models.EmployeeStats, error) {
+	queryFilter, err := query.ToFilter()
+	if err != nil {
+		return models.EmployeeStats{}, err
+	}
+	filter, err := tenantFilter(ctx, queryFilter)
+	if err != nil {
+		return models.EmployeeStats{}, err
+	}
+	boundaries := make(bson.A, 0, len(buckets)+1)
+	for _, bound := range buckets {
+		boundaries = append(boundaries, int64(bound))
+	}
+	boundaries = append(boundaries, int64(math.MaxInt64))
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"count": bson.A{bson.M{"$count": "count"}},
+			"buckets": bson.A{bson.M{"$bucket": bson.M{
+				"groupBy":    "$number_of_employees",
+				"boundaries": boundaries,
+				// a negative number of employees is counted in the other group, it is not in any bucket
+				"default": "other",
+				"output":  bson.M{"count": bson.M{"$sum": 1}},
+			}}},
+		}}},
+	}
+	var results []struct {
+		Count []struct {
+			Count int `bson:"count"`
+		} `bson:"count"`
+		Buckets []groupCount `bson:"buckets"`
+	}
+	err = r.aggregate(ctx, pipeline, &results)
+	if err != nil {
+		return models.EmployeeStats{}, err
+	}
+
+	stats := models.EmployeeStats{
+		Buckets:     make([]models.EmployeeBucket, 0, len(buckets)),
+		Percentiles: map[string]int{},
+	}
+	counts := map[int]int{}
+	if len(results) > 0 {
+		if len(results[0].Count) > 0 {
+			stats.Count = results[0].Count[0].Count
+		}
+		for _, group := range results[0].Buckets {
+			bound, ok := groupInt(group)
+			if ok {
+				counts[bound] = group.Count
+			}
+		}
+	}
+	for i, bound := range buckets {
+		bucket := models.EmployeeBucket{From: bound, Count: counts[bound]}
+		if i+1 < len(buckets) {
+			bucket.To = &buckets[i+1]
+		}
+		stats.Buckets = append(stats.Buckets, bucket)
+	}
+
+	collection := r.client.Database(r.database).Collection(CompaniesCollection)
+	for _, percentile := range percentiles {
+		if stats.Count == 0 {
+			break
+		}
+		// the nearest rank, the smallest number of employees that percentile % of the companies have or are under
+		rank := int64(math.Ceil(percentile / 100 * float64(stats.Count)))
+		opts := options.FindOne().
+			SetSort(bson.D{{Key: "number_of_employees", Value: 1}}).
+			SetSkip(rank - 1).
+			SetProjection(bson.M{"number_of_employees": 1})
+		result := collection.FindOne(ctx, filter, opts)
+		err = result.Err()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// companies were deleted since they were counted
+			break
+		}
+		if err != nil {
+			return models.EmployeeStats{}, errors.Join(ErrFindOne, err)
+		}
+		var company models.Company
+		err = result.Decode(&company)
+		if err != nil {
+			return models.EmployeeStats{}, errors.Join(ErrFindOneDecode, err)
+		}
+		stats.Percentiles[models.PercentileKey(percentile)] = company.NumberOfEmployees
+	}
+	return stats, nil
+}
+
+// aggregate runs the pipeline on the companies and decodes all its results
+func (r *mongoCompanyRepo) aggregate(ctx context.Context, pipeline mongo.Pipeline, results any) error {
+	cursor, err := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		Aggregate(ctx, pipeline)
+	if err != nil {
+		return errors.Join(ErrAggregate, err)
+	}
+	defer cursor.Close(ctx)
+
+	err = cursor.All(ctx, results)
+	if err != nil {
+		return errors.Join(ErrAggregateDecode, err)
+	}
+	return nil
+}
+
+// AddAttachment adds the attachment metadata unless the company already has the same content
+// or the maximum number of attachments, ErrAttachmentNotAdded is returned in that case
+func (r *mongoCompanyRepo) AddAttachment(ctx context.Context, companyId uuid.UUID, attachment models.Attachment, stamp models.AuditStamp) (models.Company, error) {
+	filter := bson.M{
+		"_id":                companyId,
+		"attachments.sha256": bson.M{"$ne": attachment.SHA256},
+		// the array index of the last allowed attachment must be free
+		fmt.Sprintf("attachments.%d", models.MaxAttachmentsPerCompany-1): bson.M{"$exists": false},
+	}
+	update := bson.M{"$push": bson.M{"attachments": attachment}}
+
+	company, err := r.findOneAndUpdate(ctx, filter, withAuditStamp(update, stamp))
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		_, getErr := r.GetCompany(ctx, companyId)
+		if getErr == nil {
+			return models.Company{}, ErrAttachmentNotAdded
+		}
+	}
+	return company, err
+}
+
+// RemoveAttachment removes the attachment metadata and returns it
+func (r *mongoCompanyRepo) RemoveAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID, stamp models.AuditStamp) (models.Attachment, error) {
+	filter, err := tenantFilter(ctx, bson.M{"_id": companyId, "attachments.id": attachmentId})
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	update := withAuditStamp(bson.M{"$pull": bson.M{"attachments": bson.M{"id": attachmentId}}}, stamp)
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetUpsert(false)
+
+	result := r.client.
+		Database(r.database).
+		Collection(CompaniesCollection).
+		FindOneAndUpdate(ctx, filter, update, opts)
+	err = result.Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Attachment{}, errors.Join(ErrAttachmentNotFound, err)
+		}
+		return models.Attachment{}, errors.Join(ErrFindOneAndUpdate, err)
+	}
+	var company models.Company
+	err = result.Decode(&company)
+	if err != nil {
+		return models.Attachment{}, errors.Join(ErrFindOneAndUpdateDecode, err)
+	}
+
+	for _, attachment := range company.Attachments {
+		if attachment.ID == attachmentId {
+			return attachment, nil
+		}
+	}
+	return models.Attachment{}, ErrAttachmentNotFound
+}
+
+// tenantFilter restricts the filter to the tenant of the request, every query of the repo goes through it
+// so the companies of another tenant are not found
+func tenantFilter(ctx context.Context, filter bson.M) (bson.M, error) {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.AllTenants {
+		filter["tenant"] = scope.Tenant
+	}
+	return filter, nil
+}
+
+// withAuditStamp sets who updated the company and when in the update
+func withAuditStamp(update bson.M, stamp models.AuditStamp) bson.M {
+	set, ok := update["$set"].(bson.M)
+	if !ok {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_by"] = stamp.By
+	set["updated_at"] = stamp.At
+	return update
+}
@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const JobsCollection string = "jobs"
+
+var (
+	ErrNoJobQueued = errors.New("no job is waiting for a worker")
+	// ErrLeaseLost the lease of the job expired and another worker claimed it, or the job was retried
+	ErrLeaseLost = errors.New("the worker does not hold the job lease anymore")
+	// ErrJobNotCancelable the job already finished
+	ErrJobNotCancelable = errors.New("only a queued or running job can be canceled")
+	ErrJobNotRetryable  = errors.New("only a failed or canceled job can be retried")
+)
+
+type mongoJobRepo struct {
+	client *mongo.Client
+	// database the name of the database of the service, ex: mx-auth
+	database string
+}
+
+func NewMongoJobRepo(mongoClient *mongo.Client, database string) JobRepo {
+	return &mongoJobRepo{
+		client:   mongoClient,
+		database: database,
+	}
+}
+
+func (r *mongoJobRepo) CreateJob(ctx context.Context, job models.Job) error {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if scope.AllTenants || job.Tenant != scope.Tenant {
+		return ErrTenantMismatch
+	}
+	_, err = r.client.
+		Database(r.database).
+		Collection(JobsCollection).
+		InsertOne(ctx, job)
+	if err != nil {
+		return errors.Join(ErrInsertOne, err)
+	}
+	return nil
+}
+
+func (r *mongoJobRepo) GetJob(ctx context.Context, jobId uuid.UUID) (models.Job, error) {
+	filter, err := tenantFilter(ctx, bson.M{"_id": jobId})
+	if err != nil {
+		return models.Job{}, err
+	}
+	result := r.client.
+		Database(r.database).
+		Collection(JobsCollection).
+		FindOne(ctx, filter)
+	err = result.Err()
+	if err != nil {
+		return models.Job{}, errors.Join(ErrFindOne, err)
+	}
+	var job models.Job
+	err = result.Decode(&job)
+	if err != nil {
+		return models.Job{}, errors.Join(ErrFindOneDecode, err)
+	}
+	return job, nil
+}
+
+// CancelJob cancels a queued job directly, a running job is flagged and its worker stops it
+func (r *mongoJobRepo) CancelJob(ctx context.Context, jobId uuid.UUID, now time.Time) (models.Job, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id":    jobId,
+		"status": bson.M{"$in": bson.A{models.JobStatusQueued, models.JobStatusRunning}},
+	})
+	if err != nil {
+		return models.Job{}, err
+	}
+	queued := bson.M{"$eq": bson.A{"$status", models.JobStatusQueued}}
+	// a pipeline so the update depends on the status it is applied to
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"status":           bson.M{"$cond": bson.A{queued, models.JobStatusCanceled, "$status"}},
+		"cancel_requested": bson.M{"$not": bson.A{queued}},
+		"finished_at":      bson.M{"$cond": bson.A{queued, now, "$$REMOVE"}},
+		"updated_at":       now,
+	}}}}
+	return r.findOneAndUpdate(ctx, filter, update, ErrJobNotCancelable)
+}
+
+// RetryJob queues a failed or canceled job again, the number of attempts is kept
+func (r *mongoJobRepo) RetryJob(ctx context.Context, jobId uuid.UUID, now time.Time) (models.Job, error) {
+	filter, err := tenantFilter(ctx, bson.M{
+		"_id":    jobId,
+		"status": bson.M{"$in": bson.A{models.JobStatusFailed, models.JobStatusCanceled}},
+	})
+	if err != nil {
+		return models.Job{}, err
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.JobStatusQueued,
+			"progress":   0,
+			"updated_at": now,
+		},
+		"$unset": bson.M{
+			"error":               "",
+			"result_location":     "",
+			"result_content_type": "",
+			"cancel_requested":    "",
+			"started_at":          "",
+			"finished_at":         "",
+		},
+	}
+	return r.findOneAndUpdate(ctx, filter, update, ErrJobNotRetryable)
+}
+
+// ClaimJob starts the oldest queued job of the types, or takes over a running job whose worker did not renew
+// the lease in time. ErrNoJobQueued is returned when there is none
+func (r *mongoJobRepo) ClaimJob(ctx context.Context, jobTypes []string, owner string, leaseExpiresAt time.Time, now time.Time) (models.Job, error) {
+	filter := bson.M{
+		"type": bson.M{"$in": jobTypes},
+		"$or": bson.A{
+			bson.M{"status": models.JobStatusQueued},
+			bson.M{"status": models.JobStatusRunning, "lease_expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":           models.JobStatusRunning,
+			"lease_owner":      owner,
+			"lease_expires_at": leaseExpiresAt,
+			"started_at":       now,
+			"updated_at":       now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+	return r.findOneAndUpdate(ctx, filter, update, ErrNoJobQueued, opts)
+}
+
+// RenewLease extends the lease of a running job and saves its progress, the returned job tells the worker
+// whether the job was canceled in the meantime
+func (r *mongoJobRepo) RenewLease(ctx context.Context, jobId uuid.UUID, owner string, progress int, leaseExpiresAt time.Time, now time.Time) (models.Job, error) {
+	filter := bson.M{
+		"_id":         jobId,
+		"status":      models.JobStatusRunning,
+		"lease_owner": owner,
+	}
+	update := bson.M{"$set": bson.M{
+		"progress":         progress,
+		"lease_expires_at": leaseExpiresAt,
+		"updated_at":       now,
+	}}
+	return r.findOneAndUpdate(ctx, filter, update, ErrLeaseLost)
+}
+
+// FinishJob sets the final status, progress, result and error of a running job and releases its lease
+func (r *mongoJobRepo) FinishJob(ctx context.Context, jobId uuid.UUID, owner string, result models.Job, now time.Time) (models.Job, error) {
+	filter := bson.M{
+		"_id":         jobId,
+		"status":      models.JobStatusRunning,
+		"lease_owner": owner,
+	}
+	set := bson.M{
+		"status":      result.Status,
+		"progress":    result.Progress,
+		"finished_at": now,
+		"updated_at":  now,
+	}
+	if result.ResultLocation != "" {
+		set["result_location"] = result.ResultLocation
+		set["result_content_type"] = result.ResultContentType
+	}
+	if result.Error != "" {
+		set["error"] = result.Error
+	}
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease_owner": "", "lease_expires_at": ""},
+	}
+	return r.findOneAndUpdate(ctx, filter, update, ErrLeaseLost)
+}
+
+// ReleaseJob queues a running job again without waiting for its lease to expire, ex: when the worker stops
+func (r *mongoJobRepo) ReleaseJob(ctx context.Context, jobId uuid.UUID, owner string, now time.Time) (models.Job, error) {
+	filter := bson.M{
+		"_id":         jobId,
+		"status":      models.JobStatusRunning,
+		"lease_owner": owner,
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": models.JobStatusQueued, "updated_at": now},
+		"$unset": bson.M{"lease_owner": "", "lease_expires_at": "", "started_at": ""},
+	}
+	return r.findOneAndUpdate(ctx, filter, update, ErrLeaseLost)
+}
+
+// findOneAndUpdate returns the updated job, notMatched is returned when no job matches the filter
+func (r *mongoJobRepo) findOneAndUpdate(ctx context.Context, filter bson.M, update any, notMatched error, opts ...*options.FindOneAndUpdateOptions) (models.Job, error) {
+	opts = append(opts, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	result := r.client.
+		Database(r.database).
+		Collection(JobsCollection).
+		FindOneAndUpdate(ctx, filter, update, opts...)
+	err := result.Err()
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Job{}, notMatched
+		}
+		return models.Job{}, errors.Join(ErrFindOneAndUpdate, err)
+	}
+	var job models.Job
+	err = result.Decode(&job)
+	if err != nil {
+		return models.Job{}, errors.Join(ErrFindOneAndUpdateDecode, err)
+	}
+	return job, nil
+}
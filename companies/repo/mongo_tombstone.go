@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const CompanyTombstonesCollection string = "company_tombstones"
+
+func (r *mongoCompanyRepo) CreateTombstone(ctx context.Context, tombstone models.CompanyTombstone) error {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if scope.AllTenants || tombstone.Tenant != scope.Tenant {
+		return ErrTenantMismatch
+	}
+	collection := r.client.Database(r.database).Collection(CompanyTombstonesCollection)
+
+	// the redirects always point to a company that exists, a merged survivor passes its redirects on
+	filter := bson.M{"tenant": tombstone.Tenant, "merged_into": tombstone.ID}
+	update := bson.M{"$set": bson.M{"merged_into": tombstone.MergedInto}}
+	_, err = collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return errors.Join(ErrUpdateMany, err)
+	}
+
+	_, err = collection.InsertOne(ctx, tombstone)
+	if err != nil {
+		return errors.Join(ErrInsertOne, err)
+	}
+	return nil
+}
+
+func (r *mongoCompanyRepo) GetTombstone(ctx context.Context, companyId uuid.UUID) (models.CompanyTombstone, error) {
+	filter, err := tenantFilter(ctx, bson.M{"_id": companyId})
+	if err != nil {
+		return models.CompanyTombstone{}, err
+	}
+	result := r.client.
+		Database(r.database).
+		Collection(CompanyTombstonesCollection).
+		FindOne(ctx, filter)
+	err = result.Err()
+	if err != nil {
+		return models.CompanyTombstone{}, errors.Join(ErrFindOne, err)
+	}
+	var tombstone models.CompanyTombstone
+	err = result.Decode(&tombstone)
+	if err != nil {
+		return models.CompanyTombstone{}, errors.Join(ErrFindOneDecode, err)
+	}
+	return tombstone, nil
+}
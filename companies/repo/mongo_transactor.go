@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrStartSession = errors.New("startSession returned an error")
+
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+// NewMongoTransactor the transactions need MongoDB to run as a replica set
+func NewMongoTransactor(mongoClient *mongo.Client) Transactor {
+	return &mongoTransactor{
+		client: mongoClient,
+	}
+}
+
+// WithTransaction the function can be called again when the transaction has a transient error
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return errors.Join(ErrStartSession, err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (any, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
@@ -0,0 +1,9 @@
+package repo
+
+import "context"
+
+// Transactor runs a function in a database transaction, the repo calls made with the ctx of the function
+// are committed together or not at all
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
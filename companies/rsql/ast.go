@@ -0,0 +1,126 @@
+package rsql
+
+import "strings"
+
+const (
+	OpEqual          = "=="
+	OpNotEqual       = "!="
+	OpGreater        = "=gt="
+	OpGreaterOrEqual = "=ge="
+	OpLess           = "=lt="
+	OpLessOrEqual    = "=le="
+	OpIn             = "=in="
+	OpOut            = "=out="
+)
+
+// operators the supported comparison operators, the <, <=, > and >= aliases are parsed as their FIQL form
+var operators = []string{OpEqual, OpNotEqual, OpGreater, OpGreaterOrEqual, OpLess, OpLessOrEqual, OpIn, OpOut}
+
+// Node a parsed expression, an *Or, an *And or a *Comparison.
+// String formats it back with the groups parenthesized and the values quoted
+type Node interface {
+	node()
+	String() string
+}
+
+// Or matches when one of its children matches, it has at least 2 children
+type Or struct {
+	Children []Node
+}
+
+// And matches when all of its children match, it has at least 2 children
+type And struct {
+	Children []Node
+}
+
+// Comparison a selector compared to one value, or to a list of values for =in= and =out=
+type Comparison struct {
+	Selector string
+	Operator string
+	Values   []Value
+	// SelectorColumn and OperatorColumn the 1-based positions in the expression, for the errors
+	SelectorColumn int
+	OperatorColumn int
+}
+
+// Value an argument of a comparison
+type Value struct {
+	// Text the value with the escapes of a quoted value resolved
+	Text string
+	// Parts the value split on its unescaped * wildcards, a value without wildcards has a single part
+	Parts []string
+	// Column the 1-based position of the value in the expression
+	Column int
+}
+
+func (*Or) node()         {}
+func (*And) node()        {}
+func (*Comparison) node() {}
+
+// HasWildcard reports whether the value has an unescaped *
+func (value Value) HasWildcard() bool {
+	return len(value.Parts) > 1
+}
+
+// Selectors returns the selectors of the expression in the order they appear, with their duplicates
+func Selectors(node Node) []string {
+	switch node := node.(type) {
+	case *Or:
+		return childSelectors(node.Children)
+	case *And:
+		return childSelectors(node.Children)
+	case *Comparison:
+		return []string{node.Selector}
+	}
+	return nil
+}
+
+func childSelectors(children []Node) []string {
+	selectors := []string{}
+	for _, child := range children {
+		selectors = append(selectors, Selectors(child)...)
+	}
+	return selectors
+}
+
+func (or *Or) String() string {
+	return joinNodes(or.Children, ",")
+}
+
+func (and *And) String() string {
+	return joinNodes(and.Children, ";")
+}
+
+func (comparison *Comparison) String() string {
+	values := make([]string, 0, len(comparison.Values))
+	for _, value := range comparison.Values {
+		values = append(values, value.quoted())
+	}
+	if comparison.Operator == OpIn || comparison.Operator == OpOut {
+		return comparison.Selector + comparison.Operator + "(" + strings.Join(values, ",") + ")"
+	}
+	return comparison.Selector + comparison.Operator + values[0]
+}
+
+func joinNodes(children []Node, separator string) string {
+	parts := make([]string, 0, len(children))
+	for _, child := range children {
+		part := child.String()
+		if _, ok := child.(*Comparison); !ok {
+			part = "(" + part + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, separator)
+}
+
+func (value Value) quoted() string {
+	escaped := make([]string, 0, len(value.Parts))
+	for _, part := range value.Parts {
+		part = strings.ReplaceAll(part, `\`, `\\`)
+		part = strings.ReplaceAll(part, `"`, `\"`)
+		part = strings.ReplaceAll(part, `*`, `\*`)
+		escaped = append(escaped, part)
+	}
+	return `"` + strings.Join(escaped, "*") + `"`
+}
@@ -0,0 +1,112 @@
+package rsql
+
+import (
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// comparisonOperators the MongoDB operators of the comparisons, == is an implicit equality
+var comparisonOperators = map[string]string{
+	OpNotEqual:       "$ne",
+	OpGreater:        "$gt",
+	OpGreaterOrEqual: "$gte",
+	OpLess:           "$lt",
+	OpLessOrEqual:    "$lte",
+	OpIn:             "$in",
+	OpOut:            "$nin",
+}
+
+// Compile parses the expression and compiles it to a MongoDB filter, the fields are checked against the schema
+// and the values are coerced to the type of their field
+func Compile(input string, schema Schema, limits Limits) (bson.D, error) {
+	node, err := Parse(input, limits)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Compile(node)
+}
+
+// Compile compiles a parsed expression to a MongoDB filter
+func (schema Schema) Compile(node Node) (bson.D, error) {
+	switch node := node.(type) {
+	case *Or:
+		return schema.compileChildren("$or", node.Children)
+	case *And:
+		return schema.compileChildren("$and", node.Children)
+	case *Comparison:
+		return schema.compileComparison(node)
+	}
+	return nil, nil
+}
+
+func (schema Schema) compileChildren(operator string, children []Node) (bson.D, error) {
+	filters := make(bson.A, 0, len(children))
+	for _, child := range children {
+		filter, err := schema.Compile(child)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return bson.D{{Key: operator, Value: filters}}, nil
+}
+
+func (schema Schema) compileComparison(comparison *Comparison) (bson.D, error) {
+	field, ok := schema[comparison.Selector]
+	if !ok {
+		return nil, newError(KindField, comparison.SelectorColumn, "unknown field %s", comparison.Selector)
+	}
+	if !field.allows(comparison.Operator) {
+		return nil, newError(KindOperator, comparison.OperatorColumn, "the operator %s can not be used on %s", comparison.Operator, comparison.Selector)
+	}
+	path := field.path(comparison.Selector)
+
+	values := make(bson.A, 0, len(comparison.Values))
+	for _, value := range comparison.Values {
+		if value.HasWildcard() {
+			if field.Type != String || len(field.Values) > 0 {
+				return nil, newError(KindValue, value.Column, "%s does not support wildcards", comparison.Selector)
+			}
+			if comparison.Operator != OpEqual && comparison.Operator != OpNotEqual {
+				return nil, newError(KindValue, value.Column, "the wildcards can only be used with == and !=")
+			}
+			return bson.D{{Key: path, Value: field.wildcardCondition(comparison.Operator, value)}}, nil
+		}
+		coerced, err := field.coerce(comparison.Selector, value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, coerced)
+	}
+
+	switch comparison.Operator {
+	case OpEqual:
+		return bson.D{{Key: path, Value: values[0]}}, nil
+	case OpIn, OpOut:
+		return bson.D{{Key: path, Value: bson.D{{Key: comparisonOperators[comparison.Operator], Value: values}}}}, nil
+	}
+	return bson.D{{Key: path, Value: bson.D{{Key: comparisonOperators[comparison.Operator], Value: values[0]}}}}, nil
+}
+
+// wildcardCondition matches the whole value with a regular expression, a value that only ends with a wildcard
+// is a prefix match that can use an index
+func (field Field) wildcardCondition(operator string, value Value) any {
+	parts := make([]string, 0, len(value.Parts))
+	for _, part := range value.Parts {
+		parts = append(parts, regexp.QuoteMeta(field.normalize(part)))
+	}
+	pattern := "^" + strings.Join(parts, ".*")
+	if strings.HasSuffix(pattern, ".*") {
+		pattern = strings.TrimSuffix(pattern, ".*")
+	} else {
+		pattern += "$"
+	}
+	regex := primitive.Regex{Pattern: pattern}
+	if operator == OpNotEqual {
+		return bson.D{{Key: "$not", Value: regex}}
+	}
+	return regex
+}
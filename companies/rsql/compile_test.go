@@ -0,0 +1,146 @@
+package rsql
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// go test ./rsql -update writes the golden files of the compiled filters
+var update = flag.Bool("update", false, "update the golden files")
+
+var testSchema = Schema{
+	"id":                  {Path: "_id", Type: UUID},
+	"name":                {Type: String},
+	"type":                {Type: String, Values: []string{"Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"}},
+	"number_of_employees": {Type: Int},
+	"ownership":           {Path: "ownership_percentage", Type: Float},
+	"registered":          {Type: Bool},
+	"founded_on":          {Type: Date},
+	"tags":                {Type: String, Normalize: strings.ToLower},
+	"country":             {Path: "registered_address.country", Type: String, Operators: []string{OpEqual}},
+}
+
+func TestCompileGolden(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+	}{
+		{name: "equal", input: "name==Acme"},
+		{name: "and_or", input: "type==Cooperative;(number_of_employees=gt=50,registered==false)"},
+		{name: "comparisons", input: "number_of_employees=ge=10;number_of_employees<100;ownership>=50.5;ownership=le=75"},
+		{name: "in_out", input: `type=in=(Cooperative,"Sole Proprietorship");number_of_employees=out=(0,1)`},
+		{name: "not_equal", input: "registered!=true,name!=Acme"},
+		{name: "dates", input: "founded_on=ge=2020-01-01;founded_on<2024-06-30T12:00:00+02:00"},
+		{name: "uuid", input: "id==5d0c8a4e-1f6b-4b7e-9a1d-2c3e4f5a6b7c"},
+		{name: "path", input: "country==DE"},
+		{name: "normalize", input: "tags==VIP"},
+		{name: "wildcard_prefix", input: "name==Ac*"},
+		{name: "wildcard_escaped", input: `name=="a.c*m\*e";name!=*corp`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filter, err := Compile(testCase.input, testSchema, Limits{})
+			require.NoError(t, err)
+			actual, err := bson.MarshalExtJSONIndent(filter, false, false, "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", testCase.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, append(actual, '\n'), 0o644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual)+"\n")
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected *Error
+	}{
+		{
+			name:     "unknown field",
+			input:    "name==Acme;secret==1",
+			expected: &Error{Kind: KindField, Column: 12, Message: "unknown field secret"},
+		},
+		{
+			name:     "operator of the type",
+			input:    "registered=gt=true",
+			expected: &Error{Kind: KindOperator, Column: 11, Message: "the operator =gt= can not be used on registered"},
+		},
+		{
+			name:     "operator of the field",
+			input:    "country!=DE",
+			expected: &Error{Kind: KindOperator, Column: 8, Message: "the operator != can not be used on country"},
+		},
+		{
+			name:     "integer",
+			input:    "number_of_employees=gt=5.5",
+			expected: &Error{Kind: KindValue, Column: 24, Message: `number_of_employees expects an integer, got "5.5"`},
+		},
+		{
+			name:     "number",
+			input:    "ownership=gt=NaN",
+			expected: &Error{Kind: KindValue, Column: 14, Message: `ownership expects a number, got "NaN"`},
+		},
+		{
+			name:     "boolean",
+			input:    "registered==yes",
+			expected: &Error{Kind: KindValue, Column: 13, Message: `registered expects true or false, got "yes"`},
+		},
+		{
+			name:     "date",
+			input:    "founded_on=ge=2020-13-01",
+			expected: &Error{Kind: KindValue, Column: 15, Message: `founded_on expects a date like 2024-01-31 or 2024-01-31T10:00:00Z, got "2020-13-01"`},
+		},
+		{
+			name:     "uuid",
+			input:    "id==abc",
+			expected: &Error{Kind: KindValue, Column: 5, Message: `id expects a UUID, got "abc"`},
+		},
+		{
+			name:     "enum",
+			input:    "type=in=(Cooperative,LLC)",
+			expected: &Error{Kind: KindValue, Column: 22, Message: `type must be one of Corporations, NonProfit, Cooperative, Sole Proprietorship, got "LLC"`},
+		},
+		{
+			name:     "wildcard on an enum",
+			input:    "type==Coop*",
+			expected: &Error{Kind: KindValue, Column: 7, Message: "type does not support wildcards"},
+		},
+		{
+			name:     "wildcard in a list",
+			input:    "name=in=(Acme,Glob*)",
+			expected: &Error{Kind: KindValue, Column: 15, Message: "the wildcards can only be used with == and !="},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Compile(testCase.input, testSchema, Limits{})
+			assert.Equal(t, testCase.expected, err)
+		})
+	}
+}
+
+func TestSchemaRestrict(t *testing.T) {
+	restricted := testSchema.Restrict(func(selector string) bool {
+		return selector != "number_of_employees"
+	})
+
+	_, err := Compile("number_of_employees=gt=5", restricted, Limits{})
+	assert.Equal(t, &Error{Kind: KindField, Column: 1, Message: "unknown field number_of_employees"}, err)
+	_, err = Compile("number_of_employees=gt=5", testSchema, Limits{})
+	assert.NoError(t, err)
+}
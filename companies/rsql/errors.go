@@ -0,0 +1,31 @@
+package rsql
+
+import "fmt"
+
+// the kinds of errors, a syntax error is an invalid expression, the others are valid expressions the schema rejects
+const (
+	KindSyntax   = "syntax"
+	KindField    = "field"
+	KindOperator = "operator"
+	KindValue    = "value"
+	KindLimit    = "limit"
+)
+
+// Error an invalid expression, Column is the 1-based position, in characters, of what is invalid
+type Error struct {
+	Kind    string
+	Column  int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s at column %d", err.Message, err.Column)
+}
+
+func newError(kind string, column int, format string, args ...any) *Error {
+	return &Error{
+		Kind:    kind,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,358 @@
+package rsql
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Limits bound the cost of an expression, a zero limit is not checked
+type Limits struct {
+	// MaxDepth the number of nested groups
+	MaxDepth int
+	// MaxClauses the number of comparisons
+	MaxClauses int
+	// MaxArguments the number of values of an =in= or =out= comparison
+	MaxArguments int
+}
+
+// reserved the characters an unquoted selector or value can not have, besides the spaces
+const reserved = `"'();,=!~<>`
+
+type parser struct {
+	input   []rune
+	pos     int
+	limits  Limits
+	depth   int
+	clauses int
+}
+
+// Parse parses an RSQL expression, ex: type==Cooperative;(number_of_employees=gt=50,registered==false)
+//
+//	or         = and { "," and }
+//	and        = constraint { ";" constraint }
+//	constraint = "(" or ")" | selector operator arguments
+//	operator   = "==" | "!=" | "=gt=" | "=ge=" | "=lt=" | "=le=" | "=in=" | "=out=" | ">" | ">=" | "<" | "<="
+//	arguments  = "(" value { "," value } ")" | value
+//	value      = unreserved | '"' text '"' | "'" text "'"
+//
+// The spaces between the tokens are ignored, a quoted value escapes its quote, \ and * with a \.
+// An unescaped * in a value is a wildcard
+func Parse(input string, limits Limits) (Node, error) {
+	p := &parser{input: []rune(input), limits: limits}
+	p.skipSpaces()
+	if p.eof() {
+		return nil, newError(KindSyntax, p.column(), "the expression is empty")
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.eof() {
+		return nil, p.unexpected()
+	}
+	return node, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	children, err := p.parseList(',', p.parseAnd)
+	if err != nil {
+		return nil, err
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &Or{Children: flatten[*Or](children, func(or *Or) []Node { return or.Children })}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	children, err := p.parseList(';', p.parseConstraint)
+	if err != nil {
+		return nil, err
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &And{Children: flatten[*And](children, func(and *And) []Node { return and.Children })}, nil
+}
+
+// parseList parses the nodes separated by the separator
+func (p *parser) parseList(separator rune, parseNode func() (Node, error)) ([]Node, error) {
+	nodes := []Node{}
+	for {
+		node, err := parseNode()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		p.skipSpaces()
+		if !p.consume(separator) {
+			return nodes, nil
+		}
+	}
+}
+
+// flatten merges the groups of the same logical operator, (a;b);c is a;b;c
+func flatten[T Node](nodes []Node, children func(T) []Node) []Node {
+	flattened := []Node{}
+	for _, node := range nodes {
+		group, ok := node.(T)
+		if ok {
+			flattened = append(flattened, children(group)...)
+		} else {
+			flattened = append(flattened, node)
+		}
+	}
+	return flattened
+}
+
+func (p *parser) parseConstraint() (Node, error) {
+	p.skipSpaces()
+	if p.peek() != '(' {
+		return p.parseComparison()
+	}
+
+	openColumn := p.column()
+	p.pos++
+	p.depth++
+	if p.limits.MaxDepth > 0 && p.depth > p.limits.MaxDepth {
+		return nil, newError(KindLimit, openColumn, "the groups can not be nested more than %d levels deep", p.limits.MaxDepth)
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.consume(')') {
+		if p.eof() {
+			return nil, newError(KindSyntax, openColumn, `the "(" is not closed`)
+		}
+		return nil, p.unexpected()
+	}
+	p.depth--
+	return node, nil
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	selectorColumn := p.column()
+	selector := p.readUnreserved()
+	if selector == "" {
+		if p.eof() {
+			return nil, newError(KindSyntax, p.column(), "expected a field name")
+		}
+		return nil, p.unexpected()
+	}
+	p.clauses++
+	if p.limits.MaxClauses > 0 && p.clauses > p.limits.MaxClauses {
+		return nil, newError(KindLimit, selectorColumn, "the expression can not have more than %d comparisons", p.limits.MaxClauses)
+	}
+
+	p.skipSpaces()
+	operatorColumn := p.column()
+	operator, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	values, err := p.parseArguments(operator, operatorColumn)
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{
+		Selector:       selector,
+		Operator:       operator,
+		Values:         values,
+		SelectorColumn: selectorColumn,
+		OperatorColumn: operatorColumn,
+	}, nil
+}
+
+func (p *parser) parseOperator() (string, error) {
+	column := p.column()
+	switch p.peek() {
+	case '<', '>':
+		alias := string(p.input[p.pos])
+		p.pos++
+		if p.consume('=') {
+			alias += "="
+		}
+		return map[string]string{"<": OpLess, "<=": OpLessOrEqual, ">": OpGreater, ">=": OpGreaterOrEqual}[alias], nil
+	case '!':
+		p.pos++
+		if !p.consume('=') {
+			return "", newError(KindSyntax, column, `expected "!="`)
+		}
+		return OpNotEqual, nil
+	case '=':
+		p.pos++
+		start := p.pos
+		for !p.eof() && unicode.IsLetter(p.peek()) {
+			p.pos++
+		}
+		name := string(p.input[start:p.pos])
+		if !p.consume('=') {
+			return "", newError(KindSyntax, column, "expected an operator like ==, =gt= or =in=")
+		}
+		operator := "=" + name + "="
+		if name == "" {
+			operator = OpEqual
+		}
+		if !slices.Contains(operators, operator) {
+			return "", newError(KindOperator, column, "unknown operator %s", operator)
+		}
+		return operator, nil
+	}
+	if p.eof() {
+		return "", newError(KindSyntax, column, "expected an operator")
+	}
+	return "", newError(KindSyntax, column, "expected an operator, got %q", p.peek())
+}
+
+// parseArguments only =in= and =out= take a list of values
+func (p *parser) parseArguments(operator string, operatorColumn int) ([]Value, error) {
+	isList := operator == OpIn || operator == OpOut
+	if p.peek() != '(' {
+		if isList {
+			return nil, newError(KindSyntax, p.column(), `expected the "(" of the list of values`)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return []Value{value}, nil
+	}
+	if !isList {
+		return nil, newError(KindOperator, operatorColumn, "the operator %s takes a single value", operator)
+	}
+
+	openColumn := p.column()
+	p.pos++
+	values := []Value{}
+	for {
+		p.skipSpaces()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if p.limits.MaxArguments > 0 && len(values) > p.limits.MaxArguments {
+			return nil, newError(KindLimit, value.Column, "the operator %s can not have more than %d values", operator, p.limits.MaxArguments)
+		}
+		p.skipSpaces()
+		if p.consume(')') {
+			return values, nil
+		}
+		if p.eof() {
+			return nil, newError(KindSyntax, openColumn, `the "(" is not closed`)
+		}
+		if !p.consume(',') {
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	column := p.column()
+	quote := p.peek()
+	if quote == '"' || quote == '\'' {
+		return p.parseQuotedValue()
+	}
+	text := p.readUnreserved()
+	if text == "" {
+		if p.eof() {
+			return Value{}, newError(KindSyntax, column, "expected a value")
+		}
+		return Value{}, p.unexpected()
+	}
+	return Value{
+		Text:   text,
+		Parts:  strings.Split(text, "*"),
+		Column: column,
+	}, nil
+}
+
+func (p *parser) parseQuotedValue() (Value, error) {
+	column := p.column()
+	quote := p.input[p.pos]
+	p.pos++
+	var text, part strings.Builder
+	parts := []string{}
+	for {
+		if p.eof() {
+			return Value{}, newError(KindSyntax, column, "the quoted value is not closed")
+		}
+		char := p.input[p.pos]
+		p.pos++
+		switch {
+		case char == quote:
+			return Value{
+				Text:   text.String(),
+				Parts:  append(parts, part.String()),
+				Column: column,
+			}, nil
+		case char == '\\':
+			if p.eof() {
+				return Value{}, newError(KindSyntax, column, "the quoted value is not closed")
+			}
+			char = p.input[p.pos]
+			p.pos++
+			text.WriteRune(char)
+			part.WriteRune(char)
+		case char == '*':
+			text.WriteRune(char)
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			text.WriteRune(char)
+			part.WriteRune(char)
+		}
+	}
+}
+
+func (p *parser) readUnreserved() string {
+	start := p.pos
+	for !p.eof() {
+		char := p.input[p.pos]
+		if unicode.IsSpace(char) || strings.ContainsRune(reserved, char) {
+			break
+		}
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+func (p *parser) unexpected() *Error {
+	return newError(KindSyntax, p.column(), "unexpected %q", p.peek())
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(char rune) bool {
+	if p.peek() != char {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+// peek returns the current character, 0 at the end of the input
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) column() int {
+	return p.pos + 1
+}
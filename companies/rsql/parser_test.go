@@ -0,0 +1,168 @@
+package rsql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "comparison",
+			input:    "name==Acme",
+			expected: `name=="Acme"`,
+		},
+		{
+			name:     "and binds tighter than or",
+			input:    "a==1,b==2;c==3",
+			expected: `a=="1",(b=="2";c=="3")`,
+		},
+		{
+			name:     "groups",
+			input:    "type==Cooperative;(number_of_employees=gt=50,registered==false)",
+			expected: `type=="Cooperative";(number_of_employees=gt="50",registered=="false")`,
+		},
+		{
+			name:     "nested groups of the same operator are flattened",
+			input:    "(a==1;b==2);(c==3;d==4)",
+			expected: `a=="1";b=="2";c=="3";d=="4"`,
+		},
+		{
+			name:     "spaces between the tokens",
+			input:    " ( a == 1 , b =in= ( 2 , 3 ) ) ",
+			expected: `a=="1",b=in=("2","3")`,
+		},
+		{
+			name:     "comparison aliases",
+			input:    "a<1;b<=2;c>3;d>=4",
+			expected: `a=lt="1";b=le="2";c=gt="3";d=ge="4"`,
+		},
+		{
+			name:     "quoted values",
+			input:    `type=="Sole Proprietorship",name=='it"s',name=="say \"hi\" \\ \*"`,
+			expected: `type=="Sole Proprietorship",name=="it\"s",name=="say \"hi\" \\ \*"`,
+		},
+		{
+			name:     "wildcards",
+			input:    `name==Ac*me*;name=="lit\*eral*"`,
+			expected: `name=="Ac*me*";name=="lit\*eral*"`,
+		},
+		{
+			name:     "unicode",
+			input:    "name==Société",
+			expected: `name=="Société"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			node, err := Parse(testCase.input, Limits{})
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.expected, node.String())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	limits := Limits{MaxDepth: 2, MaxClauses: 3, MaxArguments: 2}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected *Error
+	}{
+		{
+			name:     "empty",
+			input:    "  ",
+			expected: &Error{Kind: KindSyntax, Column: 3, Message: "the expression is empty"},
+		},
+		{
+			name:     "missing operator",
+			input:    "name",
+			expected: &Error{Kind: KindSyntax, Column: 5, Message: "expected an operator"},
+		},
+		{
+			name:     "unknown operator",
+			input:    "name=like=Acme",
+			expected: &Error{Kind: KindOperator, Column: 5, Message: "unknown operator =like="},
+		},
+		{
+			name:     "unterminated operator",
+			input:    "name=gt",
+			expected: &Error{Kind: KindSyntax, Column: 5, Message: "expected an operator like ==, =gt= or =in="},
+		},
+		{
+			name:     "missing value",
+			input:    "a==1;b==",
+			expected: &Error{Kind: KindSyntax, Column: 9, Message: "expected a value"},
+		},
+		{
+			name:     "reserved character in a value",
+			input:    "a==1;b==!x",
+			expected: &Error{Kind: KindSyntax, Column: 9, Message: `unexpected '!'`},
+		},
+		{
+			name:     "unclosed group",
+			input:    "a==1;(b==2,c==3",
+			expected: &Error{Kind: KindSyntax, Column: 6, Message: `the "(" is not closed`},
+		},
+		{
+			name:     "unclosed quote",
+			input:    `a=="abc`,
+			expected: &Error{Kind: KindSyntax, Column: 4, Message: "the quoted value is not closed"},
+		},
+		{
+			name:     "trailing separator",
+			input:    "a==1;",
+			expected: &Error{Kind: KindSyntax, Column: 6, Message: "expected a field name"},
+		},
+		{
+			name:     "trailing characters",
+			input:    "a==1)",
+			expected: &Error{Kind: KindSyntax, Column: 5, Message: `unexpected ')'`},
+		},
+		{
+			name:     "list of values without =in=",
+			input:    "a==(1,2)",
+			expected: &Error{Kind: KindOperator, Column: 2, Message: "the operator == takes a single value"},
+		},
+		{
+			name:     "=in= without a list",
+			input:    "a=in=1",
+			expected: &Error{Kind: KindSyntax, Column: 6, Message: `expected the "(" of the list of values`},
+		},
+		{
+			name:     "the columns count characters",
+			input:    "name==Société;",
+			expected: &Error{Kind: KindSyntax, Column: 15, Message: "expected a field name"},
+		},
+		{
+			name:     "too deep",
+			input:    "a==1;(b==2,(c==3;(d==4)))",
+			expected: &Error{Kind: KindLimit, Column: 18, Message: "the groups can not be nested more than 2 levels deep"},
+		},
+		{
+			name:     "too many comparisons",
+			input:    "a==1;b==2;c==3;d==4",
+			expected: &Error{Kind: KindLimit, Column: 16, Message: "the expression can not have more than 3 comparisons"},
+		},
+		{
+			name:     "too many values",
+			input:    "a=in=(1,2,3)",
+			expected: &Error{Kind: KindLimit, Column: 11, Message: "the operator =in= can not have more than 2 values"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Parse(testCase.input, limits)
+			assert.Equal(t, testCase.expected, err)
+		})
+	}
+}
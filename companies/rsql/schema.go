@@ -0,0 +1,130 @@
+package rsql
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type the type the values of a field are coerced to
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	// Date a date, ex: 2024-01-31, or a date and time in RFC 3339, ex: 2024-01-31T10:00:00Z, in UTC
+	Date
+	UUID
+)
+
+// typeOperators the operators of each type, the text and the identifiers are only compared for equality
+var typeOperators = map[Type][]string{
+	String: {OpEqual, OpNotEqual, OpIn, OpOut},
+	Int:    operators,
+	Float:  operators,
+	Bool:   {OpEqual, OpNotEqual},
+	Date:   operators,
+	UUID:   {OpEqual, OpNotEqual, OpIn, OpOut},
+}
+
+// Field a queryable field
+type Field struct {
+	// Path the path of the field in the documents, the selector when empty
+	Path string
+	Type Type
+	// Values the allowed values of an enumerated String field
+	Values []string
+	// Operators restricts the operators of the field, the operators of its type are allowed when nil
+	Operators []string
+	// Normalize is applied to the String values before they are checked, ex: to lower case them
+	Normalize func(string) string
+}
+
+// Schema the whitelist of the queryable fields, keyed by their selector
+type Schema map[string]Field
+
+// Restrict returns the fields of the schema the allowed function accepts
+func (schema Schema) Restrict(allowed func(selector string) bool) Schema {
+	restricted := Schema{}
+	for selector, field := range schema {
+		if allowed(selector) {
+			restricted[selector] = field
+		}
+	}
+	return restricted
+}
+
+func (field Field) allows(operator string) bool {
+	allowed := field.Operators
+	if allowed == nil {
+		allowed = typeOperators[field.Type]
+	}
+	return slices.Contains(allowed, operator)
+}
+
+// coerce converts a value without wildcards to the type of the field
+func (field Field) coerce(selector string, value Value) (any, error) {
+	text := value.Text
+	switch field.Type {
+	case String:
+		text = field.normalize(text)
+		if len(field.Values) > 0 && !slices.Contains(field.Values, text) {
+			return nil, newError(KindValue, value.Column, "%s must be one of %s, got %q", selector, strings.Join(field.Values, ", "), text)
+		}
+		return text, nil
+	case Int:
+		number, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, newError(KindValue, value.Column, "%s expects an integer, got %q", selector, text)
+		}
+		return number, nil
+	case Float:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, newError(KindValue, value.Column, "%s expects a number, got %q", selector, text)
+		}
+		return number, nil
+	case Bool:
+		if text != "true" && text != "false" {
+			return nil, newError(KindValue, value.Column, "%s expects true or false, got %q", selector, text)
+		}
+		return text == "true", nil
+	case Date:
+		date, err := time.Parse(time.DateOnly, text)
+		if err != nil {
+			date, err = time.Parse(time.RFC3339, text)
+		}
+		if err != nil {
+			return nil, newError(KindValue, value.Column, "%s expects a date like 2024-01-31 or 2024-01-31T10:00:00Z, got %q", selector, text)
+		}
+		return date.UTC(), nil
+	case UUID:
+		id, err := uuid.Parse(text)
+		if err != nil {
+			return nil, newError(KindValue, value.Column, "%s expects a UUID, got %q", selector, text)
+		}
+		return id, nil
+	}
+	return nil, fmt.Errorf("unknown type %d of %s", field.Type, selector)
+}
+
+func (field Field) normalize(text string) string {
+	if field.Normalize == nil {
+		return text
+	}
+	return field.Normalize(text)
+}
+
+func (field Field) path(selector string) string {
+	if field.Path == "" {
+		return selector
+	}
+	return field.Path
+}
@@ -0,0 +1,222 @@
+package search
+
+import (
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/google/uuid"
+)
+
+// analyzerName splits on the unicode word boundaries and lower cases, the stop words are kept
+// since a prefix being typed, ex: "an" for "analytics", must not be dropped
+const analyzerName = "company"
+
+// maxTerms bounds the cost of a query, the terms after it are ignored
+const maxTerms = 10
+
+// fieldBoosts a match on the name ranks above the same match on the description
+var fieldBoosts = map[string]float64{
+	"name":        3,
+	"description": 1,
+}
+
+// document the indexed fields of a company, the id of the document is the id of the company
+type document struct {
+	Tenant      string `json:"tenant"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type bleveIndex struct {
+	index bleve.Index
+}
+
+// NewBleveIndex opens the index at path, it is created when it does not exist and created is true,
+// the caller has to fill it then
+func NewBleveIndex(path string) (index Index, created bool, err error) {
+	bleveIdx, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		indexMapping, err := newMapping()
+		if err != nil {
+			return nil, false, err
+		}
+		bleveIdx, err = bleve.New(path, indexMapping)
+		if err != nil {
+			return nil, false, err
+		}
+		return &bleveIndex{index: bleveIdx}, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &bleveIndex{index: bleveIdx}, false, nil
+}
+
+// NewMemoryIndex creates an index that is not persisted
+func NewMemoryIndex() (Index, error) {
+	indexMapping, err := newMapping()
+	if err != nil {
+		return nil, err
+	}
+	bleveIdx, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		return nil, err
+	}
+	return &bleveIndex{index: bleveIdx}, nil
+}
+
+func newMapping() (mapping.IndexMapping, error) {
+	indexMapping := bleve.NewIndexMapping()
+	err := indexMapping.AddCustomAnalyzer(analyzerName, map[string]any{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	indexMapping.DefaultAnalyzer = analyzerName
+
+	// the text is stored with its term vectors so the matches can be highlighted
+	textField := bleve.NewTextFieldMapping()
+	textField.Analyzer = analyzerName
+	textField.IncludeInAll = false
+	tenantField := bleve.NewKeywordFieldMapping()
+	tenantField.Store = false
+	tenantField.IncludeInAll = false
+
+	companyMapping := bleve.NewDocumentStaticMapping()
+	companyMapping.AddFieldMappingsAt("tenant", tenantField)
+	for _, field := range Fields {
+		companyMapping.AddFieldMappingsAt(field, textField)
+	}
+	indexMapping.DefaultMapping = companyMapping
+	return indexMapping, nil
+}
+
+func (index *bleveIndex) IndexCompanies(companies ...models.Company) error {
+	batch := index.index.NewBatch()
+	for _, company := range companies {
+		err := batch.Index(company.ID.String(), document{
+			Tenant:      company.Tenant,
+			Name:        company.Name,
+			Description: company.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return index.index.Batch(batch)
+}
+
+func (index *bleveIndex) DeleteCompany(companyId uuid.UUID) error {
+	return index.index.Delete(companyId.String())
+}
+
+// Search every term of the text has to match one of the fields, exactly, as a prefix or with a typo
+func (index *bleveIndex) Search(ctx context.Context, text string, fields []string, limit int, offset int) ([]Hit, error) {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	terms := index.terms(text)
+	if len(terms) == 0 || len(fields) == 0 {
+		return []Hit{}, nil
+	}
+
+	conjuncts := []query.Query{}
+	if !scope.AllTenants {
+		tenantQuery := bleve.NewTermQuery(scope.Tenant)
+		tenantQuery.SetField("tenant")
+		conjuncts = append(conjuncts, tenantQuery)
+	}
+	for _, term := range terms {
+		disjuncts := []query.Query{}
+		for _, field := range fields {
+			disjuncts = append(disjuncts, termQueries(term, field, fieldBoosts[field])...)
+		}
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(disjuncts...))
+	}
+
+	request := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(conjuncts...), limit, offset, false)
+	request.Highlight = bleve.NewHighlightWithStyle(html.Name)
+	request.Highlight.Fields = fields
+	result, err := index.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		companyId, err := uuid.Parse(match.ID)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, Hit{
+			ID:         companyId,
+			Score:      match.Score,
+			Highlights: match.Fragments,
+		})
+	}
+	return hits, nil
+}
+
+func (index *bleveIndex) Close() error {
+	return index.index.Close()
+}
+
+// terms analyzes the text like the indexed fields
+func (index *bleveIndex) terms(text string) []string {
+	analyzer := index.index.Mapping().AnalyzerNamed(analyzerName)
+	terms := []string{}
+	for _, token := range analyzer.Analyze([]byte(text)) {
+		if len(terms) == maxTerms {
+			break
+		}
+		terms = append(terms, string(token.Term))
+	}
+	return terms
+}
+
+// termQueries an exact match ranks above a prefix match, which ranks above a match with a typo
+func termQueries(term string, field string, boost float64) []query.Query {
+	exactQuery := bleve.NewTermQuery(term)
+	exactQuery.SetField(field)
+	exactQuery.SetBoost(3 * boost)
+
+	prefixQuery := bleve.NewPrefixQuery(term)
+	prefixQuery.SetField(field)
+	prefixQuery.SetBoost(2 * boost)
+
+	queries := []query.Query{exactQuery, prefixQuery}
+	if fuzziness := fuzziness(term); fuzziness > 0 {
+		fuzzyQuery := bleve.NewFuzzyQuery(term)
+		fuzzyQuery.SetField(field)
+		fuzzyQuery.SetFuzziness(fuzziness)
+		fuzzyQuery.SetBoost(boost)
+		queries = append(queries, fuzzyQuery)
+	}
+	return queries
+}
+
+// fuzziness the number of typos allowed grows with the length of the term, short terms would match too much
+func fuzziness(term string) int {
+	switch length := utf8.RuneCountInString(term); {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
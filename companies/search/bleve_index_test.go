@@ -0,0 +1,195 @@
+package search
+
+import (
+	"companies/mocks"
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBleveIndexSearch(t *testing.T) {
+	acme := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme", Description: "Rockets and anvils"}
+	anvil := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Anvil Works", Description: "Supplier of acme"}
+	globex := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Globex", Description: "<b>Analytics</b> & consulting"}
+	otherTenant := models.Company{ID: uuid.New(), Tenant: "tenant-b", Name: "Acme", Description: "Rockets"}
+
+	testCases := []struct {
+		name     string
+		text     string
+		fields   []string
+		scope    tenancy.Scope
+		validate func(hits []Hit, err error)
+	}{
+		{
+			name:   "the name ranks above the description",
+			text:   "acme",
+			fields: Fields,
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				if assert.Len(t, hits, 2) {
+					assert.Equal(t, acme.ID, hits[0].ID)
+					assert.Equal(t, anvil.ID, hits[1].ID)
+					assert.Greater(t, hits[0].Score, hits[1].Score)
+				}
+			},
+		},
+		{
+			name:   "prefix",
+			text:   "glo",
+			fields: Fields,
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				if assert.Len(t, hits, 1) {
+					assert.Equal(t, globex.ID, hits[0].ID)
+				}
+			},
+		},
+		{
+			name:   "typo",
+			text:   "globx",
+			fields: Fields,
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				if assert.Len(t, hits, 1) {
+					assert.Equal(t, globex.ID, hits[0].ID)
+				}
+			},
+		},
+		{
+			name:   "every term has to match",
+			text:   "anvil works",
+			fields: Fields,
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				if assert.Len(t, hits, 1) {
+					assert.Equal(t, anvil.ID, hits[0].ID)
+				}
+			},
+		},
+		{
+			name:   "the highlights are escaped",
+			text:   "analytics",
+			fields: Fields,
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				if assert.Len(t, hits, 1) {
+					assert.Equal(t, []string{"&lt;b&gt;<mark>Analytics</mark>&lt;/b&gt; &amp; consulting"}, hits[0].Highlights["description"])
+				}
+			},
+		},
+		{
+			name:   "only the given fields are searched",
+			text:   "rockets",
+			fields: []string{"name"},
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, hits)
+			},
+		},
+		{
+			name:   "every tenant",
+			text:   "acme",
+			fields: []string{"name"},
+			scope:  tenancy.Scope{AllTenants: true},
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, hits, 2)
+			},
+		},
+		{
+			name:   "no terms",
+			text:   "  ,. ",
+			fields: Fields,
+			validate: func(hits []Hit, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, hits)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			index, err := NewMemoryIndex()
+			require.NoError(t, err)
+			defer index.Close()
+			require.NoError(t, index.IndexCompanies(acme, anvil, globex, otherTenant))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			scope := testCase.scope
+			if !scope.AllTenants {
+				scope.Tenant = "tenant-a"
+			}
+			ctx = tenancy.WithScope(ctx, scope)
+
+			hits, err := index.Search(ctx, testCase.text, testCase.fields, 20, 0)
+			testCase.validate(hits, err)
+		})
+	}
+}
+
+func TestBleveIndexDeleteCompany(t *testing.T) {
+	index, err := NewMemoryIndex()
+	require.NoError(t, err)
+	defer index.Close()
+
+	company := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme"}
+	require.NoError(t, index.IndexCompanies(company))
+	require.NoError(t, index.DeleteCompany(company.ID))
+
+	ctx := tenancy.WithScope(context.Background(), tenancy.Scope{Tenant: "tenant-a"})
+	hits, err := index.Search(ctx, "acme", Fields, 20, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestRebuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search")
+	companies := []models.Company{
+		{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme"},
+		{ID: uuid.New(), Tenant: "tenant-b", Name: "Globex"},
+	}
+
+	// the index has a company that does not exist anymore
+	index, _, err := NewBleveIndex(path)
+	require.NoError(t, err)
+	require.NoError(t, index.IndexCompanies(models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme Deleted"}))
+	require.NoError(t, index.Close())
+
+	r := mocks.NewCompanyRepo(t)
+	r.On("ExportCompanies", mock.MatchedBy(func(ctx context.Context) bool {
+		scope, err := tenancy.FromContext(ctx)
+		return err == nil && scope.AllTenants
+	}), models.CompanyQuery{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(models.Company) error)
+			for _, company := range companies {
+				_ = fn(company)
+			}
+		}).
+		Return(nil)
+
+	count, err := Rebuild(context.Background(), path, r)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	index, created, err := NewBleveIndex(path)
+	require.NoError(t, err)
+	defer index.Close()
+	assert.False(t, created)
+
+	ctx := tenancy.WithScope(context.Background(), tenancy.Scope{Tenant: "tenant-a"})
+	hits, err := index.Search(ctx, "acme", Fields, 20, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, companies[0].ID, hits[0].ID)
+	}
+}
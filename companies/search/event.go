@@ -0,0 +1,67 @@
+package search
+
+import (
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// event a message of the companies events topic, the data is decoded by type
+type event struct {
+	Type string
+	Data json.RawMessage
+}
+
+// EventCompanyIds returns the companies whose indexed fields an event of the companies events topic may have changed,
+// the other events return none
+func EventCompanyIds(value []byte) ([]uuid.UUID, error) {
+	var message event
+	err := json.Unmarshal(value, &message)
+	if err != nil {
+		return nil, err
+	}
+	switch message.Type {
+	case models.KafkaEventTypeCompanyCreate, models.KafkaEventTypeCompanyPatch:
+		var company models.CompanyOutput
+		err = json.Unmarshal(message.Data, &company)
+		return []uuid.UUID{company.ID}, err
+	case models.KafkaEventTypeCompanyDelete:
+		var deleteEvent models.CompanyDeleteEvent
+		err = json.Unmarshal(message.Data, &deleteEvent)
+		return []uuid.UUID{deleteEvent.CompanyID}, err
+	case models.KafkaEventTypeCompanyMerge:
+		var mergeEvent models.MergeEvent
+		err = json.Unmarshal(message.Data, &mergeEvent)
+		return []uuid.UUID{mergeEvent.SourceID, mergeEvent.TargetID}, err
+	}
+	return nil, nil
+}
+
+// Refresh indexes the companies as they are in the database and removes the ones that are not there anymore. The
+// events only say which companies changed, the events of a company are not ordered across the partitions of the topic
+func Refresh(ctx context.Context, index Index, companyRepo repo.CompanyRepo, companyIds []uuid.UUID) error {
+	ctx = tenancy.WithScope(ctx, tenancy.Scope{AllTenants: true})
+	companies, err := companyRepo.GetCompanies(ctx, companyIds)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if len(companies) > 0 {
+		errs = append(errs, index.IndexCompanies(companies...))
+	}
+	for _, companyId := range companyIds {
+		found := slices.ContainsFunc(companies, func(company models.Company) bool {
+			return company.ID == companyId
+		})
+		if !found {
+			errs = append(errs, index.DeleteCompany(companyId))
+		}
+	}
+	return errors.Join(errs...)
+}
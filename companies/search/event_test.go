@@ -0,0 +1,92 @@
+package search
+
+import (
+	"companies/mocks"
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCompanyIds(t *testing.T) {
+	companyId := uuid.New()
+	targetId := uuid.New()
+
+	testCases := []struct {
+		name     string
+		event    models.KafkaEvent
+		expected []uuid.UUID
+	}{
+		{
+			name:     "create",
+			event:    models.KafkaEvent{Type: models.KafkaEventTypeCompanyCreate, Data: models.CompanyOutput{ID: companyId, Name: "Acme"}},
+			expected: []uuid.UUID{companyId},
+		},
+		{
+			name:     "patch",
+			event:    models.KafkaEvent{Type: models.KafkaEventTypeCompanyPatch, Data: models.CompanyOutput{ID: companyId, Name: "Acme"}},
+			expected: []uuid.UUID{companyId},
+		},
+		{
+			name:     "delete",
+			event:    models.KafkaEvent{Type: models.KafkaEventTypeCompanyDelete, Data: models.CompanyDeleteEvent{CompanyID: companyId}},
+			expected: []uuid.UUID{companyId},
+		},
+		{
+			name:     "merge",
+			event:    models.KafkaEvent{Type: models.KafkaEventTypeCompanyMerge, Data: models.MergeEvent{SourceID: companyId, TargetID: targetId}},
+			expected: []uuid.UUID{companyId, targetId},
+		},
+		{
+			name:  "the other events do not change the indexed fields",
+			event: models.KafkaEvent{Type: models.KafkaEventTypeCompanySuspend, Data: models.StatusChangeEvent{CompanyID: companyId}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			value, err := json.Marshal(testCase.event)
+			require.NoError(t, err)
+
+			companyIds, err := EventCompanyIds(value)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, companyIds)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	acme := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme", Description: "Rockets and anvils"}
+	deleted := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme Deleted"}
+	ctx := tenancy.WithScope(context.Background(), tenancy.Scope{Tenant: "tenant-a"})
+
+	index, err := NewMemoryIndex()
+	require.NoError(t, err)
+	defer index.Close()
+	require.NoError(t, index.IndexCompanies(models.Company{ID: acme.ID, Tenant: "tenant-a", Name: "Old Name"}, deleted))
+
+	companyRepo := new(mocks.CompanyRepo)
+	companyRepo.On("GetCompanies", mock.Anything, []uuid.UUID{acme.ID, deleted.ID}).
+		Return([]models.Company{acme}, nil)
+
+	// the companies are read as they are in the database, the deleted one is removed from the index
+	err = Refresh(context.Background(), index, companyRepo, []uuid.UUID{acme.ID, deleted.ID})
+	require.NoError(t, err)
+
+	hits, err := index.Search(ctx, "acme", Fields, 10, 0)
+	require.NoError(t, err)
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, acme.ID, hits[0].ID)
+	}
+	hits, err = index.Search(ctx, "old", Fields, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	companyRepo.AssertExpectations(t)
+}
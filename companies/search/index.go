@@ -0,0 +1,30 @@
+package search
+
+import (
+	"companies/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Fields the company fields that are indexed, keyed by their JSON name
+var Fields = []string{"name", "description"}
+
+// Hit a company matching the search text
+type Hit struct {
+	ID    uuid.UUID
+	Score float64
+	// Highlights the matching fragments of each field, the matched terms are wrapped in <mark> tags
+	Highlights map[string][]string
+}
+
+// Index the full-text index of the companies, it only holds the searchable fields so the companies
+// are read from the database and a company that is not there anymore is skipped
+type Index interface {
+	// IndexCompanies adds or replaces the companies in a single batch
+	IndexCompanies(companies ...models.Company) error
+	DeleteCompany(companyId uuid.UUID) error
+	// Search matches the text against the fields, the companies of the tenant of the context are returned best first
+	Search(ctx context.Context, text string, fields []string, limit int, offset int) ([]Hit, error)
+	Close() error
+}
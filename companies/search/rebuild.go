@@ -0,0 +1,69 @@
+package search
+
+import (
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"errors"
+	"os"
+)
+
+// batchSize the number of companies indexed at once while filling the index
+const batchSize = 500
+
+// Fill indexes the companies of every tenant, it returns the number of indexed companies
+func Fill(ctx context.Context, index Index, companyRepo repo.CompanyRepo) (int, error) {
+	ctx = tenancy.WithScope(ctx, tenancy.Scope{AllTenants: true})
+	count := 0
+	batch := make([]models.Company, 0, batchSize)
+	flush := func() error {
+		err := index.IndexCompanies(batch...)
+		if err != nil {
+			return err
+		}
+		count += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := companyRepo.ExportCompanies(ctx, models.CompanyQuery{}, func(company models.Company) error {
+		batch = append(batch, company)
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return count, err
+	}
+	if len(batch) > 0 {
+		err = flush()
+	}
+	return count, err
+}
+
+// Rebuild builds a new index next to the one at path and replaces it, the current index is kept when it fails.
+// The index is locked by the service that opened it so the service has to be stopped
+func Rebuild(ctx context.Context, path string, companyRepo repo.CompanyRepo) (int, error) {
+	rebuildPath := path + ".rebuild"
+	err := os.RemoveAll(rebuildPath)
+	if err != nil {
+		return 0, err
+	}
+	index, _, err := NewBleveIndex(rebuildPath)
+	if err != nil {
+		return 0, err
+	}
+	count, err := Fill(ctx, index, companyRepo)
+	err = errors.Join(err, index.Close())
+	if err != nil {
+		return count, errors.Join(err, os.RemoveAll(rebuildPath))
+	}
+
+	err = os.RemoveAll(path)
+	if err != nil {
+		return count, err
+	}
+	return count, os.Rename(rebuildPath, path)
+}
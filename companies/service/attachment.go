@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"companies/blobstore"
+	"companies/consts"
+	"companies/eventpublisher"
+	"companies/models"
+	"companies/repo"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrUnsupportedContentType = errors.New("unsupported attachment content type")
+	ErrAttachmentTooLarge     = errors.New("attachment is larger than allowed")
+	ErrTooManyAttachments     = errors.New("the company has the maximum number of attachments")
+)
+
+type AttachmentService interface {
+	// UploadAttachment stores the content, created is false when the company already had the same content
+	UploadAttachment(ctx context.Context, companyId uuid.UUID, kind string, fileName string, content []byte, principal models.Principal) (attachment models.Attachment, created bool, err error)
+	ListAttachments(ctx context.Context, companyId uuid.UUID) ([]models.Attachment, error)
+	GetAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID) (models.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID, principal models.Principal) error
+}
+
+type attachmentService struct {
+	repo           repo.CompanyRepo
+	blobStore      blobstore.BlobStore
+	eventPublisher eventpublisher.EventPublisher
+	ownerRule      ownerRule
+}
+
+func NewAttachmentService(repo repo.CompanyRepo, blobStore blobstore.BlobStore, eventPublisher eventpublisher.EventPublisher, ownerOnlyWrites bool) AttachmentService {
+	return &attachmentService{
+		repo:           repo,
+		blobStore:      blobStore,
+		eventPublisher: eventPublisher,
+		ownerRule:      ownerRule{enabled: ownerOnlyWrites},
+	}
+}
+
+func (service *attachmentService) UploadAttachment(ctx context.Context, companyId uuid.UUID, kind string, fileName string, content []byte, principal models.Principal) (models.Attachment, bool, error) {
+	rule := models.AttachmentRules[kind]
+	if int64(len(content)) > rule.MaxSize {
+		return models.Attachment{}, false, ErrAttachmentTooLarge
+	}
+	// the content type sent by the client is not trusted
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil || !slices.Contains(rule.ContentTypes, contentType) {
+		return models.Attachment{}, false, errors.Join(ErrUnsupportedContentType, err)
+	}
+	checksum := sha256.Sum256(content)
+
+	attachment := models.Attachment{
+		ID:          uuid.New(),
+		Kind:        kind,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(checksum[:]),
+		UploadedAt:  time.Now().UTC(),
+	}
+
+	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return models.Attachment{}, false, err
+	}
+	err = service.ownerRule.check(company, principal)
+	if err != nil {
+		return models.Attachment{}, false, err
+	}
+	existing, found := findAttachmentBySHA256(company.Attachments, attachment.SHA256)
+	if found {
+		return existing, false, nil
+	}
+
+	// the key only depends on the content so concurrent uploads of the same file write the same blob
+	key := attachment.BlobKey(companyId)
+	err = service.blobStore.Put(ctx, key, bytes.NewReader(content), attachment.Size, contentType)
+	if err != nil {
+		return models.Attachment{}, false, err
+	}
+
+	company, err = service.repo.AddAttachment(ctx, companyId, attachment, models.NewAuditStamp(principal))
+	if errors.Is(err, repo.ErrAttachmentNotAdded) {
+		company, err = service.repo.GetCompany(ctx, companyId)
+		if err != nil {
+			return models.Attachment{}, false, err
+		}
+		existing, found := findAttachmentBySHA256(company.Attachments, attachment.SHA256)
+		if found {
+			// a concurrent upload added the same content first
+			return existing, false, nil
+		}
+		service.deleteBlob(ctx, key)
+		return models.Attachment{}, false, ErrTooManyAttachments
+	}
+	if err != nil {
+		// the blob is not referenced by the company, it would never be deleted
+		service.deleteBlob(ctx, key)
+		return models.Attachment{}, false, err
+	}
+
+	service.publishAttachmentEvent(models.KafkaEventTypeCompanyAttachmentAdd, companyId, attachment)
+
+	// a company has a single logo, the new one replaces the previous ones
+	if kind == models.AttachmentKindLogo {
+		for _, previous := range company.Attachments {
+			if previous.Kind != models.AttachmentKindLogo || previous.ID == attachment.ID {
+				continue
+			}
+			err = service.DeleteAttachment(ctx, companyId, previous.ID, principal)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+					Str(consts.LogKeyCompanyId, companyId.String()).
+					Str(consts.LogKeyAttachmentId, previous.ID.String()).
+					Msg("error while deleting the replaced logo")
+			}
+		}
+	}
+
+	return attachment, true, nil
+}
+
+func (service *attachmentService) ListAttachments(ctx context.Context, companyId uuid.UUID) ([]models.Attachment, error) {
+	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return nil, err
+	}
+	if company.Attachments == nil {
+		return []models.Attachment{}, nil
+	}
+	return company.Attachments, nil
+}
+
+func (service *attachmentService) GetAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID) (models.Attachment, io.ReadCloser, error) {
+	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	for _, attachment := range company.Attachments {
+		if attachment.ID != attachmentId {
+			continue
+		}
+		content, err := service.blobStore.Get(ctx, attachment.BlobKey(companyId))
+		if err != nil {
+			return models.Attachment{}, nil, err
+		}
+		return attachment, content, nil
+	}
+	return models.Attachment{}, nil, repo.ErrAttachmentNotFound
+}
+
+func (service *attachmentService) DeleteAttachment(ctx context.Context, companyId uuid.UUID, attachmentId uuid.UUID, principal models.Principal) error {
+	err := service.ownerRule.authorize(ctx, service.repo, companyId, principal)
+	if err != nil {
+		return err
+	}
+	attachment, err := service.repo.RemoveAttachment(ctx, companyId, attachmentId, models.NewAuditStamp(principal))
+	if err != nil {
+		return err
+	}
+	service.deleteBlob(ctx, attachment.BlobKey(companyId))
+
+	service.publishAttachmentEvent(models.KafkaEventTypeCompanyAttachmentRemove, companyId, attachment)
+
+	return nil
+}
+
+// deleteBlob only logs the errors, the metadata is the source of truth and an orphan blob is harmless
+func (service *attachmentService) deleteBlob(ctx context.Context, key string) {
+	err := service.blobStore.Delete(ctx, key)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Str(consts.LogKeyBlobKey, key).
+			Msg("error while deleting blob")
+	}
+}
+
+func (service *attachmentService) publishAttachmentEvent(eventType string, companyId uuid.UUID, attachment models.Attachment) {
+	event := models.KafkaEvent{
+		Type: eventType,
+		Data: models.AttachmentEvent{
+			CompanyID:  companyId,
+			Attachment: attachment,
+		},
+	}
+
+	go service.eventPublisher.PublishEvent(event)
+}
+
+func findAttachmentBySHA256(attachments []models.Attachment, checksum string) (models.Attachment, bool) {
+	for _, attachment := range attachments {
+		if attachment.SHA256 == checksum {
+			return attachment, true
+		}
+	}
+	return models.Attachment{}, false
+}
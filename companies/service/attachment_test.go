@@ -0,0 +1,240 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var pngContent = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestUploadAttachment(t *testing.T) {
+	companyId := uuid.New()
+	checksum := sha256.Sum256(pngContent)
+	pngSHA256 := hex.EncodeToString(checksum[:])
+	previousLogo := models.Attachment{ID: uuid.New(), Kind: models.AttachmentKindLogo, SHA256: "aa"}
+
+	testCases := []struct {
+		name     string
+		kind     string
+		content  []byte
+		stubMock func(r *mocks.CompanyRepo, b *mocks.BlobStore)
+		validate func(attachment models.Attachment, created bool, err error)
+	}{
+		{
+			name:    "success test case",
+			kind:    models.AttachmentKindLogo,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{}, nil)
+				b.On("Put", mock.Anything, "companies/"+companyId.String()+"/"+pngSHA256, mock.Anything, int64(len(pngContent)), "image/png").
+					Return(nil)
+				r.On("AddAttachment", mock.Anything, companyId, mock.AnythingOfType("models.Attachment"), mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{}, nil)
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, created)
+				assert.Equal(t, "image/png", attachment.ContentType)
+				assert.Equal(t, pngSHA256, attachment.SHA256)
+			},
+		},
+		{
+			name:    "the same content is deduplicated",
+			kind:    models.AttachmentKindLogo,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{
+						Attachments: []models.Attachment{{ID: previousLogo.ID, SHA256: pngSHA256}},
+					}, nil)
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.NoError(t, err)
+				assert.False(t, created)
+				assert.Equal(t, previousLogo.ID, attachment.ID)
+			},
+		},
+		{
+			name:    "a new logo replaces the previous one",
+			kind:    models.AttachmentKindLogo,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{Attachments: []models.Attachment{previousLogo}}, nil)
+				b.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil)
+				r.On("AddAttachment", mock.Anything, companyId, mock.AnythingOfType("models.Attachment"), mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{Attachments: []models.Attachment{previousLogo}}, nil)
+				r.On("RemoveAttachment", mock.Anything, companyId, previousLogo.ID, mock.AnythingOfType("models.AuditStamp")).
+					Return(previousLogo, nil).Once()
+				b.On("Delete", mock.Anything, previousLogo.BlobKey(companyId)).
+					Return(nil).Once()
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.NoError(t, err)
+				assert.True(t, created)
+			},
+		},
+		{
+			name:    "unsupported content type",
+			kind:    models.AttachmentKindDocument,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.ErrorIs(t, err, ErrUnsupportedContentType)
+			},
+		},
+		{
+			name:    "too large",
+			kind:    models.AttachmentKindLogo,
+			content: append(pngContent, make([]byte, models.AttachmentRules[models.AttachmentKindLogo].MaxSize)...),
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.ErrorIs(t, err, ErrAttachmentTooLarge)
+			},
+		},
+		{
+			name:    "too many attachments, the blob is deleted",
+			kind:    models.AttachmentKindLogo,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{}, nil)
+				b.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil)
+				r.On("AddAttachment", mock.Anything, companyId, mock.AnythingOfType("models.Attachment"), mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{}, repo.ErrAttachmentNotAdded)
+				b.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, pngSHA256) })).
+					Return(nil).Once()
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.ErrorIs(t, err, ErrTooManyAttachments)
+			},
+		},
+		{
+			name:    "the attachment is not added, the blob is deleted",
+			kind:    models.AttachmentKindLogo,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{}, nil)
+				b.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil)
+				r.On("AddAttachment", mock.Anything, companyId, mock.AnythingOfType("models.Attachment"), mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{}, assert.AnError)
+				b.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, pngSHA256) })).
+					Return(nil).Once()
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.ErrorIs(t, err, assert.AnError)
+			},
+		},
+		{
+			name:    "blob store returned an error",
+			kind:    models.AttachmentKindLogo,
+			content: pngContent,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{}, nil)
+				b.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(assert.AnError)
+			},
+			validate: func(attachment models.Attachment, created bool, err error) {
+				assert.ErrorIs(t, err, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+			b := mocks.NewBlobStore(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			attachmentService := NewAttachmentService(r, b, eventPublisher, false)
+
+			testCase.stubMock(r, b)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			attachment, created, err := attachmentService.UploadAttachment(ctx, companyId, testCase.kind, "logo.png", testCase.content, models.Principal{Username: "alice"})
+			testCase.validate(attachment, created, err)
+		})
+	}
+}
+
+func TestGetAttachment(t *testing.T) {
+	companyId := uuid.New()
+	attachment := models.Attachment{ID: uuid.New(), SHA256: "aa"}
+
+	testCases := []struct {
+		name         string
+		attachmentId uuid.UUID
+		stubMock     func(r *mocks.CompanyRepo, b *mocks.BlobStore)
+		validate     func(content io.ReadCloser, err error)
+	}{
+		{
+			name:         "success test case",
+			attachmentId: attachment.ID,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{Attachments: []models.Attachment{attachment}}, nil)
+				b.On("Get", mock.Anything, attachment.BlobKey(companyId)).
+					Return(io.NopCloser(strings.NewReader("content")), nil)
+			},
+			validate: func(content io.ReadCloser, err error) {
+				assert.NoError(t, err)
+				data, _ := io.ReadAll(content)
+				assert.Equal(t, "content", string(data))
+			},
+		},
+		{
+			name:         "attachment not found",
+			attachmentId: uuid.New(),
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{Attachments: []models.Attachment{attachment}}, nil)
+			},
+			validate: func(content io.ReadCloser, err error) {
+				assert.ErrorIs(t, err, repo.ErrAttachmentNotFound)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+			b := mocks.NewBlobStore(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			attachmentService := NewAttachmentService(r, b, eventPublisher, false)
+
+			testCase.stubMock(r, b)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, content, err := attachmentService.GetAttachment(ctx, companyId, testCase.attachmentId)
+			testCase.validate(content, err)
+		})
+	}
+}
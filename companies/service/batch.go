@@ -0,0 +1,89 @@
+package service
+
+import (
+	"companies/models"
+	"context"
+	"errors"
+)
+
+var (
+	// ErrBatchAborted the operation was not applied, or was rolled back, because another operation of the batch failed
+	ErrBatchAborted          = errors.New("another operation of the batch failed")
+	ErrUnknownBatchOperation = errors.New("unknown batch operation")
+)
+
+// BatchCompanies runs the operations in order with the same rules and events as the single company methods.
+// In all or nothing mode they run in a transaction that the first failed operation aborts, the other operations
+// fail with ErrBatchAborted. The events and blob deletions of the transaction only happen once it is committed
+func (service *companyService) BatchCompanies(ctx context.Context, operations []models.BatchOperation, allOrNothing bool, principal models.Principal) ([]models.BatchOperationResult, error) {
+	if !allOrNothing {
+		results := make([]models.BatchOperationResult, len(operations))
+		for i, operation := range operations {
+			results[i] = service.runBatchOperation(ctx, operation, principal)
+		}
+		return results, nil
+	}
+
+	var results []models.BatchOperationResult
+	var pending []func()
+	failed := false
+	err := service.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		// the function runs again after a transient error, nothing of the previous attempt is kept
+		results = make([]models.BatchOperationResult, len(operations))
+		pending = nil
+		failed = false
+		transactionService := *service
+		transactionService.pending = &pending
+		for i, operation := range operations {
+			result := transactionService.runBatchOperation(ctx, operation, principal)
+			if result.Err != nil {
+				failed = true
+				for j := range results {
+					results[j] = models.BatchOperationResult{Err: ErrBatchAborted}
+				}
+				results[i] = result
+				return result.Err
+			}
+			results[i] = result
+		}
+		return nil
+	})
+	if failed {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fn := range pending {
+		fn()
+	}
+	return results, nil
+}
+
+func (service *companyService) runBatchOperation(ctx context.Context, operation models.BatchOperation, principal models.Principal) models.BatchOperationResult {
+	switch operation.Operation {
+	case models.BatchOperationCreate:
+		output, err := service.CreateCompany(ctx, operation.CompanyData, principal)
+		if err != nil {
+			return models.BatchOperationResult{Err: err}
+		}
+		return models.BatchOperationResult{Company: &output}
+	case models.BatchOperationPatch:
+		output, changeRequest, err := service.PatchCompany(ctx, operation.CompanyID, operation.PatchData, principal)
+		if err != nil {
+			return models.BatchOperationResult{Err: err}
+		}
+		if changeRequest != nil {
+			return models.BatchOperationResult{ChangeRequest: changeRequest}
+		}
+		return models.BatchOperationResult{Company: &output}
+	case models.BatchOperationDelete:
+		err := service.DeleteCompany(ctx, operation.CompanyID, principal)
+		if err != nil {
+			return models.BatchOperationResult{Err: err}
+		}
+		return models.BatchOperationResult{}
+	}
+	return models.BatchOperationResult{Err: ErrUnknownBatchOperation}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBatchCompanies(t *testing.T) {
+	companyId := uuid.New()
+	attachments := []models.Attachment{{ID: uuid.New(), SHA256: "aa"}}
+
+	operations := []models.BatchOperation{
+		{
+			Operation:   models.BatchOperationCreate,
+			CompanyData: models.CompanyInput{Name: "company-name", Type: "Corporations"},
+		},
+		{
+			Operation: models.BatchOperationDelete,
+			CompanyID: companyId,
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		allOrNothing bool
+		stubMock     func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool)
+		validate     func(results []models.BatchOperationResult, err error)
+	}{
+		{
+			name:         "best effort keeps the successful operations",
+			allOrNothing: false,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+					Return(uuid.New(), nil)
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+			},
+			validate: func(results []models.BatchOperationResult, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, results, 2)
+				assert.NoError(t, results[0].Err)
+				assert.Equal(t, "company-name", results[0].Company.Name)
+				assert.ErrorIs(t, results[1].Err, repo.ErrDocumentNotFound)
+			},
+		},
+		{
+			name:         "all or nothing deletes the blobs after the commit",
+			allOrNothing: true,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						err := fn(ctx)
+						*committed = err == nil
+						return err
+					})
+				r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+					Return(uuid.New(), nil)
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, Attachments: attachments}, nil)
+				r.On("DetachChildren", mock.Anything, companyId, mock.AnythingOfType("models.AuditStamp")).
+					Return(nil, nil)
+				r.On("DeleteCompany", mock.Anything, companyId).
+					Return(nil)
+				b.On("Delete", mock.Anything, attachments[0].BlobKey(companyId)).
+					Run(func(args mock.Arguments) {
+						assert.True(t, *committed)
+					}).
+					Return(nil).Once()
+			},
+			validate: func(results []models.BatchOperationResult, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, results, 2)
+				assert.NoError(t, results[0].Err)
+				assert.NoError(t, results[1].Err)
+			},
+		},
+		{
+			name:         "all or nothing aborts the other operations",
+			allOrNothing: true,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+				r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+					Return(uuid.New(), nil)
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+			},
+			validate: func(results []models.BatchOperationResult, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, results, 2)
+				assert.ErrorIs(t, results[0].Err, ErrBatchAborted)
+				assert.Nil(t, results[0].Company)
+				assert.ErrorIs(t, results[1].Err, repo.ErrDocumentNotFound)
+			},
+		},
+		{
+			name:         "all or nothing commit failed",
+			allOrNothing: true,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(assert.AnError)
+			},
+			validate: func(results []models.BatchOperationResult, err error) {
+				assert.ErrorIs(t, err, assert.AnError)
+				assert.Nil(t, results)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+			b := mocks.NewBlobStore(t)
+			tx := mocks.NewTransactor(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, b, time.Hour, false, tx, newSearchIndex(t), nil)
+
+			committed := false
+			testCase.stubMock(r, b, tx, &committed)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+
+			results, err := companyService.BatchCompanies(ctx, operations, testCase.allOrNothing, models.Principal{Username: "alice"})
+			testCase.validate(results, err)
+		})
+	}
+}
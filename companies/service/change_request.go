@@ -0,0 +1,150 @@
+package service
+
+import (
+	"companies/consts"
+	"companies/models"
+	"companies/repo"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrApproverScopeRequired the user does not have the scope to decide change requests
+	ErrApproverScopeRequired = errors.New("the user can not decide change requests")
+	ErrSelfApproval          = errors.New("a change request can not be decided by the user that requested it")
+)
+
+func (service *companyService) createChangeRequest(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput, principal models.Principal) (models.ChangeRequest, error) {
+	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+
+	now := time.Now().UTC()
+	changeRequest := models.ChangeRequest{
+		ID:          uuid.New(),
+		CompanyID:   companyId,
+		Tenant:      company.Tenant,
+		Changes:     updateCompanyInput,
+		BaseVersion: company.Version,
+		Status:      models.ChangeRequestStatusPending,
+		RequestedBy: principal.Username,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(service.changeRequestTTL),
+	}
+	err = service.changeRequestRepo.CreateChangeRequest(ctx, changeRequest)
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+
+	service.publishChangeRequest(models.KafkaEventTypeCompanyChangeRequestCreate, changeRequest)
+
+	return changeRequest, nil
+}
+
+// ListChangeRequests returns the pending change requests of a company, or of every company when companyId is nil
+func (service *companyService) ListChangeRequests(ctx context.Context, companyId *uuid.UUID) ([]models.ChangeRequest, error) {
+	return service.changeRequestRepo.ListPendingChangeRequests(ctx, companyId, time.Now().UTC())
+}
+
+func (service *companyService) GetChangeRequest(ctx context.Context, changeRequestId uuid.UUID) (models.ChangeRequest, error) {
+	changeRequest, err := service.changeRequestRepo.GetChangeRequest(ctx, changeRequestId)
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+	if changeRequest.IsExpired(time.Now().UTC()) {
+		changeRequest.Status = models.ChangeRequestStatusExpired
+	}
+	return changeRequest, nil
+}
+
+// ApproveChangeRequest applies the changes to the company version they were requested on. The change request is
+// claimed first so it is applied at most once, it is superseded when the company changed in the meantime
+func (service *companyService) ApproveChangeRequest(ctx context.Context, changeRequestId uuid.UUID, principal models.Principal) (models.CompanyOutput, error) {
+	changeRequest, err := service.claimChangeRequest(ctx, changeRequestId, models.ChangeRequest{Status: models.ChangeRequestStatusApproved}, principal)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+
+	if changeRequest.Changes.ParentID != nil {
+		err = service.checkParent(ctx, changeRequest.CompanyID, *changeRequest.Changes.ParentID)
+	}
+	var output models.CompanyOutput
+	if err == nil {
+		output, err = service.applyPatch(ctx, changeRequest.CompanyID, changeRequest.Changes, &changeRequest.BaseVersion, principal)
+	}
+	if err != nil {
+		// a request that can not be applied anymore is superseded, the others can be approved again
+		status := models.ChangeRequestStatusPending
+		switch {
+		case errors.Is(err, repo.ErrVersionConflict), errors.Is(err, mongo.ErrNoDocuments),
+			errors.Is(err, ErrParentNotFound), errors.Is(err, ErrHierarchyCycle), errors.Is(err, repo.ErrAddressIncomplete):
+			status = models.ChangeRequestStatusSuperseded
+		}
+		setErr := service.changeRequestRepo.SetChangeRequestStatus(ctx, changeRequestId, status)
+		if setErr != nil {
+			log.Error().
+				Err(setErr).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyChangeRequestId, changeRequestId.String()).
+				Msg("error while setting the status of a change request that was not applied")
+		}
+		return models.CompanyOutput{}, err
+	}
+
+	service.publishChangeRequest(models.KafkaEventTypeCompanyChangeRequestApprove, changeRequest)
+
+	return output, nil
+}
+
+func (service *companyService) RejectChangeRequest(ctx context.Context, changeRequestId uuid.UUID, input models.RejectChangeRequestInput, principal models.Principal) (models.ChangeRequest, error) {
+	decision := models.ChangeRequest{
+		Status:          models.ChangeRequestStatusRejected,
+		RejectionReason: strings.TrimSpace(input.Reason),
+	}
+	changeRequest, err := service.claimChangeRequest(ctx, changeRequestId, decision, principal)
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+
+	service.publishChangeRequest(models.KafkaEventTypeCompanyChangeRequestReject, changeRequest)
+
+	return changeRequest, nil
+}
+
+// claimChangeRequest checks that the principal can decide the change request and atomically moves it
+// from pending to the decision status, only one decision can succeed
+func (service *companyService) claimChangeRequest(ctx context.Context, changeRequestId uuid.UUID, decision models.ChangeRequest, principal models.Principal) (models.ChangeRequest, error) {
+	if !principal.HasScope(models.ScopeCompaniesApprove) {
+		return models.ChangeRequest{}, ErrApproverScopeRequired
+	}
+	changeRequest, err := service.changeRequestRepo.GetChangeRequest(ctx, changeRequestId)
+	if err != nil {
+		return models.ChangeRequest{}, err
+	}
+	if changeRequest.RequestedBy == principal.Username {
+		return models.ChangeRequest{}, ErrSelfApproval
+	}
+
+	now := time.Now().UTC()
+	decision.DecidedBy = principal.Username
+	decision.DecidedAt = &now
+	return service.changeRequestRepo.DecideChangeRequest(ctx, changeRequestId, decision, now)
+}
+
+func (service *companyService) publishChangeRequest(eventType string, changeRequest models.ChangeRequest) {
+	event := models.KafkaEvent{
+		Type: eventType,
+		Data: models.ChangeRequestEvent{
+			ChangeRequest: changeRequest,
+		},
+	}
+
+	service.publishEvent(event)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatchCompanySensitiveChanges(t *testing.T) {
+	companyId := uuid.New()
+	name := "new-name"
+	description := "new-description"
+
+	testCases := []struct {
+		name               string
+		updateCompanyInput models.UpdateCompanyInput
+		principal          models.Principal
+		stubMock           func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo)
+		validate           func(companyOutput models.CompanyOutput, changeRequest *models.ChangeRequest, err error)
+	}{
+		{
+			name:               "a non approver creates a change request",
+			updateCompanyInput: models.UpdateCompanyInput{Name: &name},
+			principal:          models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, Version: 3}, nil)
+				cr.On("CreateChangeRequest", mock.Anything, mock.MatchedBy(func(changeRequest models.ChangeRequest) bool {
+					return changeRequest.CompanyID == companyId &&
+						changeRequest.BaseVersion == 3 &&
+						changeRequest.RequestedBy == "alice" &&
+						changeRequest.Status == models.ChangeRequestStatusPending &&
+						changeRequest.ExpiresAt.Sub(changeRequest.RequestedAt) == time.Hour
+				})).
+					Return(nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, changeRequest *models.ChangeRequest, err error) {
+				assert.NoError(t, err)
+				assert.NotNil(t, changeRequest)
+				assert.Equal(t, uuid.Nil, companyOutput.ID)
+			},
+		},
+		{
+			name:               "non sensitive changes are applied",
+			updateCompanyInput: models.UpdateCompanyInput{Description: &description},
+			principal:          models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				r.On("PatchCompany", mock.Anything, companyId, mock.AnythingOfType("models.UpdateCompanyInput"), (*int)(nil), mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{ID: companyId, Description: description}, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, changeRequest *models.ChangeRequest, err error) {
+				assert.NoError(t, err)
+				assert.Nil(t, changeRequest)
+				assert.Equal(t, description, companyOutput.Description)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+			cr := mocks.NewChangeRequestRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, cr, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+			testCase.stubMock(r, cr)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			companyOutput, changeRequest, err := companyService.PatchCompany(ctx, companyId, testCase.updateCompanyInput, testCase.principal)
+			testCase.validate(companyOutput, changeRequest, err)
+		})
+	}
+}
+
+func TestApproveChangeRequest(t *testing.T) {
+	companyId := uuid.New()
+	changeRequestId := uuid.New()
+	name := "new-name"
+	changeRequest := models.ChangeRequest{
+		ID:          changeRequestId,
+		CompanyID:   companyId,
+		Changes:     models.UpdateCompanyInput{Name: &name},
+		BaseVersion: 3,
+		Status:      models.ChangeRequestStatusPending,
+		RequestedBy: "alice",
+	}
+	approver := models.Principal{Username: "bob", Scopes: []string{models.ScopeCompaniesApprove}}
+
+	testCases := []struct {
+		name      string
+		principal models.Principal
+		stubMock  func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo)
+		validate  func(companyOutput models.CompanyOutput, err error)
+	}{
+		{
+			name:      "success test case",
+			principal: approver,
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				cr.On("GetChangeRequest", mock.Anything, changeRequestId).
+					Return(changeRequest, nil)
+				cr.On("DecideChangeRequest", mock.Anything, changeRequestId, mock.MatchedBy(func(decision models.ChangeRequest) bool {
+					return decision.Status == models.ChangeRequestStatusApproved && decision.DecidedBy == "bob"
+				}), mock.AnythingOfType("time.Time")).
+					Return(changeRequest, nil)
+				version := 3
+				r.On("PatchCompany", mock.Anything, companyId, changeRequest.Changes, &version, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{ID: companyId, Name: name, Version: 4}, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, name, companyOutput.Name)
+			},
+		},
+		{
+			name:      "the approver scope is required",
+			principal: models.Principal{Username: "bob"},
+			stubMock:  func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrApproverScopeRequired)
+			},
+		},
+		{
+			name:      "self approval is rejected",
+			principal: models.Principal{Username: "alice", Scopes: []string{models.ScopeCompaniesApprove}},
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				cr.On("GetChangeRequest", mock.Anything, changeRequestId).
+					Return(changeRequest, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrSelfApproval)
+			},
+		},
+		{
+			name:      "already decided or expired",
+			principal: approver,
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				cr.On("GetChangeRequest", mock.Anything, changeRequestId).
+					Return(changeRequest, nil)
+				cr.On("DecideChangeRequest", mock.Anything, changeRequestId, mock.Anything, mock.Anything).
+					Return(models.ChangeRequest{}, repo.ErrChangeRequestNotPending)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, repo.ErrChangeRequestNotPending)
+			},
+		},
+		{
+			name:      "the company changed since the request, it is superseded",
+			principal: approver,
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				cr.On("GetChangeRequest", mock.Anything, changeRequestId).
+					Return(changeRequest, nil)
+				cr.On("DecideChangeRequest", mock.Anything, changeRequestId, mock.Anything, mock.Anything).
+					Return(changeRequest, nil)
+				r.On("PatchCompany", mock.Anything, companyId, changeRequest.Changes, mock.Anything, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{}, repo.ErrVersionConflict)
+				cr.On("SetChangeRequestStatus", mock.Anything, changeRequestId, models.ChangeRequestStatusSuperseded).
+					Return(nil).Once()
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, repo.ErrVersionConflict)
+			},
+		},
+		{
+			name:      "the claim is released on other errors",
+			principal: approver,
+			stubMock: func(r *mocks.CompanyRepo, cr *mocks.ChangeRequestRepo) {
+				cr.On("GetChangeRequest", mock.Anything, changeRequestId).
+					Return(changeRequest, nil)
+				cr.On("DecideChangeRequest", mock.Anything, changeRequestId, mock.Anything, mock.Anything).
+					Return(changeRequest, nil)
+				r.On("PatchCompany", mock.Anything, companyId, changeRequest.Changes, mock.Anything, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{}, assert.AnError)
+				cr.On("SetChangeRequestStatus", mock.Anything, changeRequestId, models.ChangeRequestStatusPending).
+					Return(nil).Once()
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+			cr := mocks.NewChangeRequestRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, cr, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+			testCase.stubMock(r, cr)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			companyOutput, err := companyService.ApproveChangeRequest(ctx, changeRequestId, testCase.principal)
+			testCase.validate(companyOutput, err)
+		})
+	}
+}
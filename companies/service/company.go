@@ -1,37 +1,141 @@
 package service
 
 import (
+	"companies/blobstore"
+	"companies/consts"
 	"companies/eventpublisher"
 	"companies/models"
 	"companies/repo"
+	"companies/search"
+	"companies/tenancy"
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CompanyService interface {
-	CreateCompany(ctx context.Context, companyInput models.CompanyInput) (models.CompanyOutput, error)
-	PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput) (models.CompanyOutput, error)
+	CreateCompany(ctx context.Context, companyInput models.CompanyInput, principal models.Principal) (models.CompanyOutput, error)
+	PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput, principal models.Principal) (models.CompanyOutput, *models.ChangeRequest, error)
 	GetCompany(ctx context.Context, companyId uuid.UUID) (models.CompanyOutput, error)
-	DeleteCompany(ctx context.Context, companyId uuid.UUID) error
+	GetCompanyByIdentifier(ctx context.Context, scheme string, value string) (models.CompanyOutput, error)
+	DeleteCompany(ctx context.Context, companyId uuid.UUID, principal models.Principal) error
+	GetAncestors(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error)
+	GetChildren(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error)
+	GetSubtree(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error)
+	RemoveParent(ctx context.Context, companyId uuid.UUID, principal models.Principal) (models.CompanyOutput, error)
+	AddTags(ctx context.Context, companyId uuid.UUID, tags []string, principal models.Principal) (models.CompanyOutput, error)
+	RemoveTag(ctx context.Context, companyId uuid.UUID, tag string, principal models.Principal) (models.CompanyOutput, error)
+	ListCompanies(ctx context.Context, query models.CompanyQuery) ([]models.CompanyOutput, error)
+	ExportCompanies(ctx context.Context, query models.CompanyQuery, fn func(models.CompanyOutput) error) error
+	CountTags(ctx context.Context) ([]models.TagCount, error)
+	TransitionCompany(ctx context.Context, companyId uuid.UUID, input models.TransitionInput, principal models.Principal) (models.CompanyOutput, error)
+	ListChangeRequests(ctx context.Context, companyId *uuid.UUID) ([]models.ChangeRequest, error)
+	GetChangeRequest(ctx context.Context, changeRequestId uuid.UUID) (models.ChangeRequest, error)
+	ApproveChangeRequest(ctx context.Context, changeRequestId uuid.UUID, principal models.Principal) (models.CompanyOutput, error)
+	RejectChangeRequest(ctx context.Context, changeRequestId uuid.UUID, input models.RejectChangeRequestInput, principal models.Principal) (models.ChangeRequest, error)
+	BatchCompanies(ctx context.Context, operations []models.BatchOperation, allOrNothing bool, principal models.Principal) ([]models.BatchOperationResult, error)
+	ImportCompany(ctx context.Context, companyInput models.CompanyInput, dryRun bool, principal models.Principal) (models.CompanyOutput, error)
+	// SearchCompanies only the fields the user can read are matched and highlighted
+	SearchCompanies(ctx context.Context, query models.SearchQuery, fields []string) ([]models.CompanySearchHit, error)
+	CompanyStats(ctx context.Context, query models.CompanyQuery) (models.CompanyStats, error)
+	EmployeeStats(ctx context.Context, query models.CompanyQuery, statsQuery models.EmployeeStatsQuery) (models.EmployeeStats, error)
+	FindDuplicates(ctx context.Context, name string, excludeId *uuid.UUID) ([]models.DuplicateCandidate, error)
+	ReportDuplicates(ctx context.Context, threshold float64, progress func()) (models.DuplicatesReport, error)
+	MergeCompanies(ctx context.Context, targetId uuid.UUID, input models.MergeInput, principal models.Principal) (models.CompanyOutput, error)
 }
 
+var (
+	ErrParentNotFound = errors.New("parent company not found")
+	ErrHierarchyCycle = errors.New("the parent company is the company itself or one of its descendants")
+	ErrReasonRequired = errors.New("the transition requires a reason")
+	// ErrTransitionForbidden the user does not have the scope of the transition
+	ErrTransitionForbidden = errors.New("the transition is not allowed for the user")
+	// ErrTransitionNotAllowed the company status is not one the transition can be applied from
+	ErrTransitionNotAllowed = errors.New("the transition is not allowed from the company status")
+)
+
 type companyService struct {
-	repo           repo.CompanyRepo
-	eventPublisher eventpublisher.EventPublisher
+	repo              repo.CompanyRepo
+	changeRequestRepo repo.ChangeRequestRepo
+	eventPublisher    eventpublisher.EventPublisher
+	blobStore         blobstore.BlobStore
+	// changeRequestTTL how long a change request waits for an approval
+	changeRequestTTL time.Duration
+	ownerRule        ownerRule
+	transactor       repo.Transactor
+	// searchIndex is kept in sync with the name and the description of the companies
+	searchIndex search.Index
+	// statsCache is cleared by the events of the company changes
+	statsCache *StatsCache
+	// pending holds the side effects of a batch transaction until it is committed, they run directly when nil
+	pending *[]func()
 }
 
-func NewCompanyService(repo repo.CompanyRepo, eventPublisher eventpublisher.EventPublisher) CompanyService {
+func NewCompanyService(
+	repo repo.CompanyRepo,
+	changeRequestRepo repo.ChangeRequestRepo,
+	eventPublisher eventpublisher.EventPublisher,
+	blobStore blobstore.BlobStore,
+	changeRequestTTL time.Duration,
+	ownerOnlyWrites bool,
+	transactor repo.Transactor,
+	searchIndex search.Index,
+	statsCache *StatsCache,
+) CompanyService {
 	return &companyService{
-		repo:           repo,
-		eventPublisher: eventPublisher,
+		repo:              repo,
+		changeRequestRepo: changeRequestRepo,
+		eventPublisher:    eventPublisher,
+		blobStore:         blobStore,
+		changeRequestTTL:  changeRequestTTL,
+		ownerRule:         ownerRule{enabled: ownerOnlyWrites},
+		transactor:        transactor,
+		searchIndex:       searchIndex,
+		statsCache:        statsCache,
+	}
+}
+
+// afterCommit runs fn directly, or once the batch transaction is committed
+func (service *companyService) afterCommit(fn func()) {
+	if service.pending != nil {
+		*service.pending = append(*service.pending, fn)
+		return
 	}
+	fn()
 }
 
-func (service *companyService) CreateCompany(ctx context.Context, companyInput models.CompanyInput) (models.CompanyOutput, error) {
+func (service *companyService) publishEvent(event models.KafkaEvent) {
+	service.afterCommit(func() {
+		if event.Type != models.KafkaEventTypeCompanyGet {
+			service.statsCache.Clear()
+		}
+		go service.eventPublisher.PublishEvent(event)
+	})
+}
+
+func (service *companyService) CreateCompany(ctx context.Context, companyInput models.CompanyInput, principal models.Principal) (models.CompanyOutput, error) {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
 	company := models.Company{}
 	company.ID = uuid.New()
+	company.Tenant = scope.Tenant
 	company.FromCompanyInput(companyInput)
+	stamp := models.NewAuditStamp(principal)
+	company.CreatedBy, company.CreatedAt = stamp.By, stamp.At
+	company.UpdatedBy, company.UpdatedAt = stamp.By, stamp.At
+	if company.ParentID != nil {
+		err = service.checkNewParent(ctx, *company.ParentID)
+		if err != nil {
+			return models.CompanyOutput{}, err
+		}
+	}
 	insertedId, err := service.repo.CreateCompany(ctx, company)
 	if err != nil {
 		return models.CompanyOutput{}, err
@@ -47,13 +151,56 @@ func (service *companyService) CreateCompany(ctx context.Context, companyInput m
 		Data: output,
 	}
 
-	go service.eventPublisher.PublishEvent(event)
+	service.publishEvent(event)
+	if company.ParentID != nil {
+		service.publishParentSet(company)
+	}
+	service.indexCompany(company)
 
 	return output, nil
 }
 
-func (service *companyService) PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput) (models.CompanyOutput, error) {
-	company, err := service.repo.PatchCompany(ctx, companyId, updateCompanyInput)
+// checkNewParent a new company has no descendants so it can not create a cycle, the parent only has to exist
+func (service *companyService) checkNewParent(ctx context.Context, parentId uuid.UUID) error {
+	_, err := service.repo.GetCompany(ctx, parentId)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.Join(ErrParentNotFound, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// PatchCompany applies the patch, unless it changes sensitive fields and the user is not an approver.
+// A pending change request is returned in that case and the patch is applied when another user approves it
+func (service *companyService) PatchCompany(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput, principal models.Principal) (models.CompanyOutput, *models.ChangeRequest, error) {
+	err := service.ownerRule.authorize(ctx, service.repo, companyId, principal)
+	if err != nil {
+		return models.CompanyOutput{}, nil, err
+	}
+	if updateCompanyInput.ParentID != nil {
+		err = service.checkParent(ctx, companyId, *updateCompanyInput.ParentID)
+		if err != nil {
+			return models.CompanyOutput{}, nil, err
+		}
+	}
+	if updateCompanyInput.HasSensitiveChanges() && !principal.HasScope(models.ScopeCompaniesApprove) {
+		changeRequest, err := service.createChangeRequest(ctx, companyId, updateCompanyInput, principal)
+		if err != nil {
+			return models.CompanyOutput{}, nil, err
+		}
+		return models.CompanyOutput{}, &changeRequest, nil
+	}
+	output, err := service.applyPatch(ctx, companyId, updateCompanyInput, nil, principal)
+	if err != nil {
+		return models.CompanyOutput{}, nil, err
+	}
+	return output, nil, nil
+}
+
+func (service *companyService) applyPatch(ctx context.Context, companyId uuid.UUID, updateCompanyInput models.UpdateCompanyInput, expectedVersion *int, principal models.Principal) (models.CompanyOutput, error) {
+	company, err := service.repo.PatchCompany(ctx, companyId, updateCompanyInput, expectedVersion, models.NewAuditStamp(principal))
 	if err != nil {
 		return models.CompanyOutput{}, err
 	}
@@ -65,13 +212,36 @@ func (service *companyService) PatchCompany(ctx context.Context, companyId uuid.
 		Data: output,
 	}
 
-	go service.eventPublisher.PublishEvent(event)
+	service.publishEvent(event)
+	if updateCompanyInput.ParentID != nil || updateCompanyInput.OwnershipPercentage != nil {
+		service.publishParentSet(company)
+	}
+	service.indexCompany(company)
 
 	return output, nil
 }
 
+// GetCompany returns a CompanyMergedError when the company was merged into another one
 func (service *companyService) GetCompany(ctx context.Context, companyId uuid.UUID) (models.CompanyOutput, error) {
 	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return models.CompanyOutput{}, service.getTombstone(ctx, companyId, err)
+	}
+	companyOutput := models.CompanyOutput{}
+	companyOutput.FromCompany(company)
+
+	event := models.KafkaEvent{
+		Type: models.KafkaEventTypeCompanyGet,
+		Data: companyOutput,
+	}
+
+	service.publishEvent(event)
+
+	return companyOutput, nil
+}
+
+func (service *companyService) GetCompanyByIdentifier(ctx context.Context, scheme string, value string) (models.CompanyOutput, error) {
+	company, err := service.repo.GetCompanyByIdentifier(ctx, scheme, value)
 	if err != nil {
 		return models.CompanyOutput{}, err
 	}
@@ -83,23 +253,282 @@ func (service *companyService) GetCompany(ctx context.Context, companyId uuid.UU
 		Data: companyOutput,
 	}
 
-	go service.eventPublisher.PublishEvent(event)
+	service.publishEvent(event)
 
 	return companyOutput, nil
 }
 
-func (service *companyService) DeleteCompany(ctx context.Context, companyId uuid.UUID) error {
-	err := service.repo.DeleteCompany(ctx, companyId)
+// DeleteCompany deletes a company and its attachments, its direct children become top level companies
+func (service *companyService) DeleteCompany(ctx context.Context, companyId uuid.UUID, principal models.Principal) error {
+	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.Join(repo.ErrDocumentNotFound, err)
+		}
+		return err
+	}
+	err = service.ownerRule.check(company, principal)
 	if err != nil {
 		return err
 	}
 
+	// detach the children first, a failed delete can be retried without leaving children pointing to a deleted parent
+	childIds, err := service.repo.DetachChildren(ctx, companyId, models.NewAuditStamp(principal))
+	if err != nil {
+		return err
+	}
+	for _, childId := range childIds {
+		service.publishParentRemove(childId)
+	}
+
+	err = service.repo.DeleteCompany(ctx, companyId)
+	if err != nil {
+		return err
+	}
+
+	// the company is gone so a blob that fails to be deleted is only logged
+	service.afterCommit(func() {
+		for _, attachment := range company.Attachments {
+			key := attachment.BlobKey(companyId)
+			err := service.blobStore.Delete(ctx, key)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+					Str(consts.LogKeyCompanyId, companyId.String()).
+					Str(consts.LogKeyBlobKey, key).
+					Msg("error while deleting the blob of a deleted company")
+			}
+		}
+	})
+
 	event := models.KafkaEvent{
 		Type: models.KafkaEventTypeCompanyDelete,
-		Data: nil,
+		Data: models.CompanyDeleteEvent{
+			CompanyID: companyId,
+		},
 	}
 
-	go service.eventPublisher.PublishEvent(event)
+	service.publishEvent(event)
+	service.unindexCompany(companyId)
+
+	return nil
+}
+
+func (service *companyService) GetAncestors(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error) {
+	nodes, err := service.repo.GetAncestors(ctx, companyId)
+	if err != nil {
+		return nil, err
+	}
+	return toCompanyNodeOutputs(nodes), nil
+}
+
+func (service *companyService) GetChildren(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error) {
+	maxDepth := 0
+	nodes, err := service.repo.GetDescendants(ctx, companyId, &maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	return toCompanyNodeOutputs(nodes), nil
+}
+
+func (service *companyService) GetSubtree(ctx context.Context, companyId uuid.UUID) ([]models.CompanyNodeOutput, error) {
+	nodes, err := service.repo.GetDescendants(ctx, companyId, nil)
+	if err != nil {
+		return nil, err
+	}
+	return toCompanyNodeOutputs(nodes), nil
+}
+
+func (service *companyService) RemoveParent(ctx context.Context, companyId uuid.UUID, principal models.Principal) (models.CompanyOutput, error) {
+	err := service.ownerRule.authorize(ctx, service.repo, companyId, principal)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	company, err := service.repo.UnsetParent(ctx, companyId, models.NewAuditStamp(principal))
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	output := models.CompanyOutput{}
+	output.FromCompany(company)
+
+	service.publishParentRemove(companyId)
+
+	return output, nil
+}
 
+// checkParent makes sure parentId exists and that it is not companyId or one of its descendants
+func (service *companyService) checkParent(ctx context.Context, companyId uuid.UUID, parentId uuid.UUID) error {
+	if parentId == companyId {
+		return ErrHierarchyCycle
+	}
+	ancestors, err := service.repo.GetAncestors(ctx, parentId)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.Join(ErrParentNotFound, err)
+		}
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == companyId {
+			return ErrHierarchyCycle
+		}
+	}
 	return nil
 }
+
+func (service *companyService) publishParentSet(company models.Company) {
+	event := models.KafkaEvent{
+		Type: models.KafkaEventTypeCompanyParentSet,
+		Data: models.ParentRelationEvent{
+			CompanyID:           company.ID,
+			ParentID:            company.ParentID,
+			OwnershipPercentage: company.OwnershipPercentage,
+		},
+	}
+
+	service.publishEvent(event)
+}
+
+func (service *companyService) publishParentRemove(companyId uuid.UUID) {
+	event := models.KafkaEvent{
+		Type: models.KafkaEventTypeCompanyParentRemove,
+		Data: models.ParentRelationEvent{
+			CompanyID: companyId,
+		},
+	}
+
+	service.publishEvent(event)
+}
+
+func (service *companyService) AddTags(ctx context.Context, companyId uuid.UUID, tags []string, principal models.Principal) (models.CompanyOutput, error) {
+	err := service.ownerRule.authorize(ctx, service.repo, companyId, principal)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	company, err := service.repo.AddTags(ctx, companyId, models.NormalizeTags(tags), models.NewAuditStamp(principal))
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	return service.publishPatch(company), nil
+}
+
+func (service *companyService) RemoveTag(ctx context.Context, companyId uuid.UUID, tag string, principal models.Principal) (models.CompanyOutput, error) {
+	err := service.ownerRule.authorize(ctx, service.repo, companyId, principal)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	company, err := service.repo.RemoveTag(ctx, companyId, models.NormalizeTag(tag), models.NewAuditStamp(principal))
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	return service.publishPatch(company), nil
+}
+
+func (service *companyService) ListCompanies(ctx context.Context, query models.CompanyQuery) ([]models.CompanyOutput, error) {
+	companies, err := service.repo.ListCompanies(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	outputs := make([]models.CompanyOutput, 0, len(companies))
+	for _, company := range companies {
+		output := models.CompanyOutput{}
+		output.FromCompany(company)
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// ExportCompanies calls fn with each company of the query filter, the error of fn stops the export
+func (service *companyService) ExportCompanies(ctx context.Context, query models.CompanyQuery, fn func(models.CompanyOutput) error) error {
+	return service.repo.ExportCompanies(ctx, query, func(company models.Company) error {
+		output := models.CompanyOutput{}
+		output.FromCompany(company)
+		return fn(output)
+	})
+}
+
+func (service *companyService) CountTags(ctx context.Context) ([]models.TagCount, error) {
+	return service.repo.CountTags(ctx)
+}
+
+// publishPatch publishes a company.patch event with the updated company and returns its output
+func (service *companyService) publishPatch(company models.Company) models.CompanyOutput {
+	output := models.CompanyOutput{}
+	output.FromCompany(company)
+
+	event := models.KafkaEvent{
+		Type: models.KafkaEventTypeCompanyPatch,
+		Data: output,
+	}
+
+	service.publishEvent(event)
+
+	return output
+}
+
+func toCompanyNodeOutputs(nodes []models.CompanyNode) []models.CompanyNodeOutput {
+	outputs := make([]models.CompanyNodeOutput, 0, len(nodes))
+	for _, node := range nodes {
+		output := models.CompanyNodeOutput{}
+		output.FromCompanyNode(node)
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+// TransitionCompany applies a lifecycle transition, the status is compared and set atomically
+// so concurrent transitions can not both succeed
+func (service *companyService) TransitionCompany(ctx context.Context, companyId uuid.UUID, input models.TransitionInput, principal models.Principal) (models.CompanyOutput, error) {
+	transition, ok := models.CompanyTransitions[input.Transition]
+	if !ok {
+		return models.CompanyOutput{}, ErrTransitionNotAllowed
+	}
+	if transition.Scope != "" && !principal.HasScope(transition.Scope) {
+		return models.CompanyOutput{}, ErrTransitionForbidden
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if transition.RequiresReason && reason == "" {
+		return models.CompanyOutput{}, ErrReasonRequired
+	}
+
+	company, err := service.repo.GetCompany(ctx, companyId)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	err = service.ownerRule.check(company, principal)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	if !transition.IsAllowedFrom(company.Status) {
+		return models.CompanyOutput{}, ErrTransitionNotAllowed
+	}
+
+	from := company.Status
+	change := models.StatusChange{
+		Status:    transition.To,
+		Reason:    reason,
+		ChangedBy: principal.Username,
+		ChangedAt: time.Now().UTC(),
+	}
+	company, err = service.repo.SetStatus(ctx, companyId, from, change)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+
+	event := models.KafkaEvent{
+		Type: transition.EventType,
+		Data: models.StatusChangeEvent{
+			CompanyID:    companyId,
+			Transition:   input.Transition,
+			From:         from,
+			StatusChange: change,
+		},
+	}
+
+	service.publishEvent(event)
+
+	companyOutput := models.CompanyOutput{}
+	companyOutput.FromCompany(company)
+	return companyOutput, nil
+}
@@ -4,13 +4,18 @@ import (
 	"companies/eventpublisher"
 	"companies/mocks"
 	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestCreateCompany(t *testing.T) {
@@ -21,6 +26,7 @@ func TestCreateCompany(t *testing.T) {
 		name         string
 		companyInput models.CompanyInput
 		company      models.Company
+		noTenant     bool
 		stubMock     func(r *mocks.CompanyRepo, company models.Company)
 		validate     func(company models.Company, companyOutput models.CompanyOutput, err error)
 	}{
@@ -40,9 +46,15 @@ func TestCreateCompany(t *testing.T) {
 				NumberOfEmployees: 10,
 				Registered:        true,
 				Type:              "Corporations",
+				Status:            models.CompanyStatusDraft,
+				Tenant:            "tenant-a",
+				CreatedBy:         "alice",
+				UpdatedBy:         "alice",
 			},
 			stubMock: func(r *mocks.CompanyRepo, company models.Company) {
-				r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+				r.On("CreateCompany", mock.Anything, mock.MatchedBy(func(created models.Company) bool {
+					return created.Tenant == "tenant-a"
+				})).
 					Return(company.ID, nil)
 			},
 			validate: func(company models.Company, companyOutput models.CompanyOutput, err error) {
@@ -51,6 +63,12 @@ func TestCreateCompany(t *testing.T) {
 
 				expectedCompanyOutput := models.CompanyOutput{}
 				expectedCompanyOutput.FromCompany(company)
+				// the audit stamps are set by the service when the company is created
+				if assert.NotNil(t, companyOutput.CreatedAt) {
+					assert.Equal(t, companyOutput.CreatedAt, companyOutput.UpdatedAt)
+				}
+				expectedCompanyOutput.CreatedAt = companyOutput.CreatedAt
+				expectedCompanyOutput.UpdatedAt = companyOutput.UpdatedAt
 
 				assert.Equal(t, expectedCompanyOutput, companyOutput)
 			},
@@ -81,22 +99,37 @@ func TestCreateCompany(t *testing.T) {
 				assert.Equal(t, uuid.Nil, companyOutput.ID)
 			},
 		},
+		{
+			name: "request without tenant",
+			companyInput: models.CompanyInput{
+				Name: "company-name",
+				Type: "Corporations",
+			},
+			noTenant: true,
+			stubMock: func(r *mocks.CompanyRepo, company models.Company) {},
+			validate: func(company models.Company, companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, tenancy.ErrNoTenant)
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			r := new(mocks.CompanyRepo)
+			r := mocks.NewCompanyRepo(t)
 
-			eventPublisher := eventpublisher.NewEventPublisher(nil)
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
 
-			companyService := NewCompanyService(r, eventPublisher)
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
 
 			testCase.stubMock(r, testCase.company)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
+			if !testCase.noTenant {
+				ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+			}
 
-			companyOutput, err := companyService.CreateCompany(ctx, testCase.companyInput)
+			companyOutput, err := companyService.CreateCompany(ctx, testCase.companyInput, models.Principal{Username: "alice"})
 			testCase.validate(testCase.company, companyOutput, err)
 		})
 	}
@@ -136,7 +169,7 @@ func TestPatchCompany(t *testing.T) {
 				Type:              companyType,
 			},
 			stubMock: func(r *mocks.CompanyRepo, company models.Company) {
-				r.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput")).
+				r.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), (*int)(nil), mock.AnythingOfType("models.AuditStamp")).
 					Return(company, nil)
 			},
 			validate: func(company models.Company, companyOutput models.CompanyOutput, err error) {
@@ -168,7 +201,7 @@ func TestPatchCompany(t *testing.T) {
 				Type:              companyType,
 			},
 			stubMock: func(r *mocks.CompanyRepo, company models.Company) {
-				r.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput")).
+				r.On("PatchCompany", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.UpdateCompanyInput"), (*int)(nil), mock.AnythingOfType("models.AuditStamp")).
 					Return(models.Company{}, assert.AnError)
 			},
 			validate: func(company models.Company, companyOutput models.CompanyOutput, err error) {
@@ -181,16 +214,18 @@ func TestPatchCompany(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			r := new(mocks.CompanyRepo)
 
-			eventPublisher := eventpublisher.NewEventPublisher(nil)
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
 
-			companyService := NewCompanyService(r, eventPublisher)
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
 
 			testCase.stubMock(r, testCase.company)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
-			companyOutput, err := companyService.PatchCompany(ctx, testCase.companyId, testCase.updateCompanyInput)
+			principal := models.Principal{Username: "alice", Scopes: []string{models.ScopeCompaniesApprove}}
+			companyOutput, changeRequest, err := companyService.PatchCompany(ctx, testCase.companyId, testCase.updateCompanyInput, principal)
+			assert.Nil(t, changeRequest)
 			testCase.validate(testCase.company, companyOutput, err)
 		})
 	}
@@ -202,6 +237,8 @@ func TestGetCompany(t *testing.T) {
 	numberOfEmployees := 10
 	registered := true
 	companyType := "Corporations"
+	mergedId := uuid.New()
+	survivorId := uuid.New()
 
 	testCases := []struct {
 		name      string
@@ -254,15 +291,44 @@ func TestGetCompany(t *testing.T) {
 				assert.Error(t, err)
 			},
 		},
+		{
+			name:      "the company was merged",
+			companyId: mergedId,
+			stubMock: func(r *mocks.CompanyRepo, company models.Company) {
+				r.On("GetCompany", mock.Anything, mergedId).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+				r.On("GetTombstone", mock.Anything, mergedId).
+					Return(models.CompanyTombstone{ID: mergedId, MergedInto: survivorId}, nil)
+			},
+			validate: func(company models.Company, companyOutput models.CompanyOutput, err error) {
+				var mergedErr *CompanyMergedError
+				if assert.ErrorAs(t, err, &mergedErr) {
+					assert.Equal(t, survivorId, mergedErr.Tombstone.MergedInto)
+				}
+			},
+		},
+		{
+			name:      "the company does not exist",
+			companyId: mergedId,
+			stubMock: func(r *mocks.CompanyRepo, company models.Company) {
+				r.On("GetCompany", mock.Anything, mergedId).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+				r.On("GetTombstone", mock.Anything, mergedId).
+					Return(models.CompanyTombstone{}, mongo.ErrNoDocuments)
+			},
+			validate: func(company models.Company, companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
 			r := new(mocks.CompanyRepo)
 
-			eventPublisher := eventpublisher.NewEventPublisher(nil)
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
 
-			companyService := NewCompanyService(r, eventPublisher)
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
 
 			testCase.stubMock(r, testCase.company)
 
@@ -279,24 +345,95 @@ func TestDeleteCompany(t *testing.T) {
 	testCases := []struct {
 		name      string
 		companyId uuid.UUID
-		stubMock  func(r *mocks.CompanyRepo)
+		stubMock  func(r *mocks.CompanyRepo, b *mocks.BlobStore)
 		validate  func(err error)
 	}{
 		{
 			name:      "success test case",
 			companyId: uuid.New(),
-			stubMock: func(r *mocks.CompanyRepo) {
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(models.Company{}, nil)
+				r.On("DetachChildren", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.AuditStamp")).
+					Return(nil, nil)
+				r.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(nil)
+			},
+			validate: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:      "company not found",
+			companyId: uuid.New(),
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+			},
+			validate: func(err error) {
+				assert.ErrorIs(t, err, repo.ErrDocumentNotFound)
+			},
+		},
+		{
+			name:      "children are detached before the delete",
+			companyId: uuid.New(),
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(models.Company{}, nil)
+				r.On("DetachChildren", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.AuditStamp")).
+					Return([]uuid.UUID{uuid.New(), uuid.New()}, nil)
+				r.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(nil)
+			},
+			validate: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:      "attachment blobs are deleted, failures are only logged",
+			companyId: uuid.New(),
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(models.Company{
+						Attachments: []models.Attachment{
+							{ID: uuid.New(), SHA256: "aa"},
+							{ID: uuid.New(), SHA256: "bb"},
+						},
+					}, nil)
+				r.On("DetachChildren", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.AuditStamp")).
+					Return(nil, nil)
 				r.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
 					Return(nil)
+				b.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, "/aa") })).
+					Return(assert.AnError).Once()
+				b.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, "/bb") })).
+					Return(nil).Once()
 			},
 			validate: func(err error) {
 				assert.NoError(t, err)
 			},
 		},
+		{
+			name:      "detach children returned an error",
+			companyId: uuid.New(),
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(models.Company{}, nil)
+				r.On("DetachChildren", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.AuditStamp")).
+					Return(nil, assert.AnError)
+			},
+			validate: func(err error) {
+				assert.Error(t, err)
+			},
+		},
 		{
 			name:      "repo returned an error",
 			companyId: uuid.New(),
-			stubMock: func(r *mocks.CompanyRepo) {
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore) {
+				r.On("GetCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
+					Return(models.Company{}, nil)
+				r.On("DetachChildren", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("models.AuditStamp")).
+					Return(nil, nil)
 				r.On("DeleteCompany", mock.Anything, mock.AnythingOfType("uuid.UUID")).
 					Return(assert.AnError)
 			},
@@ -309,18 +446,194 @@ func TestDeleteCompany(t *testing.T) {
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
 			r := new(mocks.CompanyRepo)
+			b := mocks.NewBlobStore(t)
 
-			eventPublisher := eventpublisher.NewEventPublisher(nil)
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
 
-			companyService := NewCompanyService(r, eventPublisher)
+			companyService := NewCompanyService(r, nil, eventPublisher, b, time.Hour, false, nil, newSearchIndex(t), nil)
 
-			testCase.stubMock(r)
+			testCase.stubMock(r, b)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
-			err := companyService.DeleteCompany(ctx, testCase.companyId)
+			err := companyService.DeleteCompany(ctx, testCase.companyId, models.Principal{Username: "alice"})
 			testCase.validate(err)
 		})
 	}
 }
+
+func TestPatchCompanyParent(t *testing.T) {
+	companyId := uuid.New()
+	parentId := uuid.New()
+
+	testCases := []struct {
+		name     string
+		parentId uuid.UUID
+		stubMock func(r *mocks.CompanyRepo)
+		validate func(companyOutput models.CompanyOutput, err error)
+	}{
+		{
+			name:     "success test case",
+			parentId: parentId,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetAncestors", mock.Anything, parentId).
+					Return([]models.CompanyNode{{Company: models.Company{ID: uuid.New()}}}, nil)
+				r.On("PatchCompany", mock.Anything, companyId, mock.AnythingOfType("models.UpdateCompanyInput"), (*int)(nil), mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{ID: companyId, ParentID: &parentId}, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, &parentId, companyOutput.ParentID)
+			},
+		},
+		{
+			name:     "company is its own parent",
+			parentId: companyId,
+			stubMock: func(r *mocks.CompanyRepo) {},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrHierarchyCycle)
+			},
+		},
+		{
+			name:     "parent is a descendant of the company",
+			parentId: parentId,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetAncestors", mock.Anything, parentId).
+					Return([]models.CompanyNode{
+						{Company: models.Company{ID: uuid.New()}, Depth: 0},
+						{Company: models.Company{ID: companyId}, Depth: 1},
+					}, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrHierarchyCycle)
+			},
+		},
+		{
+			name:     "parent does not exist",
+			parentId: parentId,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetAncestors", mock.Anything, parentId).
+					Return(nil, errors.Join(assert.AnError, mongo.ErrNoDocuments))
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrParentNotFound)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := new(mocks.CompanyRepo)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			parentId := testCase.parentId
+			updateCompanyInput := models.UpdateCompanyInput{
+				ParentID: &parentId,
+			}
+			companyOutput, _, err := companyService.PatchCompany(ctx, companyId, updateCompanyInput, models.Principal{})
+			testCase.validate(companyOutput, err)
+			r.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransitionCompany(t *testing.T) {
+	companyId := uuid.New()
+
+	testCases := []struct {
+		name      string
+		input     models.TransitionInput
+		principal models.Principal
+		stubMock  func(r *mocks.CompanyRepo)
+		validate  func(companyOutput models.CompanyOutput, err error)
+	}{
+		{
+			name:      "success test case",
+			input:     models.TransitionInput{Transition: "activate"},
+			principal: models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, Status: models.CompanyStatusDraft}, nil)
+				r.On("SetStatus", mock.Anything, companyId, models.CompanyStatusDraft, mock.MatchedBy(func(change models.StatusChange) bool {
+					return change.Status == models.CompanyStatusActive && change.ChangedBy == "alice"
+				})).
+					Return(models.Company{ID: companyId, Status: models.CompanyStatusActive}, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, models.CompanyStatusActive, companyOutput.Status)
+			},
+		},
+		{
+			name:      "reason is required",
+			input:     models.TransitionInput{Transition: "suspend", Reason: "  "},
+			principal: models.Principal{Username: "alice"},
+			stubMock:  func(r *mocks.CompanyRepo) {},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrReasonRequired)
+			},
+		},
+		{
+			name:      "scope is required",
+			input:     models.TransitionInput{Transition: "dissolve", Reason: "liquidated"},
+			principal: models.Principal{Username: "alice", Scopes: []string{"companies:read"}},
+			stubMock:  func(r *mocks.CompanyRepo) {},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrTransitionForbidden)
+			},
+		},
+		{
+			name:      "not allowed from the current status",
+			input:     models.TransitionInput{Transition: "reinstate"},
+			principal: models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, Status: models.CompanyStatusDissolved}, nil)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrTransitionNotAllowed)
+			},
+		},
+		{
+			name:      "status changed concurrently",
+			input:     models.TransitionInput{Transition: "dissolve", Reason: "liquidated"},
+			principal: models.Principal{Username: "alice", Scopes: []string{models.ScopeCompaniesDissolve}},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, Status: models.CompanyStatusSuspended}, nil)
+				r.On("SetStatus", mock.Anything, companyId, models.CompanyStatusSuspended, mock.AnythingOfType("models.StatusChange")).
+					Return(models.Company{}, repo.ErrStatusChanged)
+			},
+			validate: func(companyOutput models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, repo.ErrStatusChanged)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			companyOutput, err := companyService.TransitionCompany(ctx, companyId, testCase.input, testCase.principal)
+			testCase.validate(companyOutput, err)
+		})
+	}
+}
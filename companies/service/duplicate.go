@@ -0,0 +1,152 @@
+package service
+
+import (
+	"companies/consts"
+	"companies/dedupe"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// nameCandidatesLimit the number of companies sharing the most trigrams with the name that are scored
+const nameCandidatesLimit = 50
+
+// FindDuplicates returns the companies of the tenant whose name is similar to the name, the most similar first.
+// excludeId is the company being renamed, it is not a duplicate of itself
+func (service *companyService) FindDuplicates(ctx context.Context, name string, excludeId *uuid.UUID) ([]models.DuplicateCandidate, error) {
+	key := dedupe.Key(name)
+	if key == "" {
+		return []models.DuplicateCandidate{}, nil
+	}
+	companies, err := service.repo.FindNameCandidates(ctx, dedupe.Trigrams(key), nameCandidatesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []models.DuplicateCandidate{}
+	for _, company := range companies {
+		if excludeId != nil && company.ID == *excludeId {
+			continue
+		}
+		score := dedupe.Similarity(key, company.NameKey)
+		if score < dedupe.DefaultThreshold {
+			continue
+		}
+		candidates = append(candidates, models.DuplicateCandidate{
+			ID:    company.ID,
+			Name:  company.Name,
+			Score: score,
+			Exact: company.NameKey == key,
+		})
+	}
+	slices.SortStableFunc(candidates, func(a, b models.DuplicateCandidate) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(candidates) > models.MaxDuplicateCandidates {
+		candidates = candidates[:models.MaxDuplicateCandidates]
+	}
+	return candidates, nil
+}
+
+// ReportDuplicates reads every company of the tenant and groups the ones with similar names, progress is called
+// with each company read. The companies without a name key yet are compared on the key of their name
+func (service *companyService) ReportDuplicates(ctx context.Context, threshold float64, progress func()) (models.DuplicatesReport, error) {
+	if threshold == 0 {
+		threshold = dedupe.DefaultThreshold
+	}
+	companies := []models.Company{}
+	keys := []string{}
+	err := service.repo.ExportCompanies(ctx, models.CompanyQuery{}, func(company models.Company) error {
+		key := company.NameKey
+		if key == "" {
+			key = dedupe.Key(company.Name)
+		}
+		companies = append(companies, models.Company{ID: company.ID, Name: company.Name})
+		keys = append(keys, key)
+		progress()
+		return ctx.Err()
+	})
+	if err != nil {
+		return models.DuplicatesReport{}, err
+	}
+
+	report := models.DuplicatesReport{
+		Companies: len(companies),
+		Clusters:  []models.DuplicateCluster{},
+	}
+	for _, cluster := range dedupe.FindClusters(keys, threshold) {
+		output := models.DuplicateCluster{MinScore: cluster.MinScore}
+		keyCounts := map[string]int{}
+		for _, member := range cluster.Members {
+			keyCounts[keys[member]]++
+		}
+		for i, member := range cluster.Members {
+			output.Companies = append(output.Companies, models.DuplicateCandidate{
+				ID:    companies[member].ID,
+				Name:  companies[member].Name,
+				Score: cluster.Scores[i],
+				Exact: keyCounts[keys[member]] > 1,
+			})
+		}
+		report.Clusters = append(report.Clusters, output)
+	}
+	return report, nil
+}
+
+// BackfillNameKeys sets the canonical name, the name key and the trigrams of the companies of every tenant created
+// before them or normalized differently. It returns the number of updated companies and of conflicts: the companies
+// whose canonical name is taken by another company of the tenant, ex: "Acme" and "ACME", they have to be renamed or
+// merged before the next run. It can run while the service is running
+func BackfillNameKeys(ctx context.Context, companyRepo repo.CompanyRepo) (int, int, error) {
+	ctx = tenancy.WithScope(ctx, tenancy.Scope{AllTenants: true})
+	count, conflicts := 0, 0
+	err := companyRepo.ExportCompanies(ctx, models.CompanyQuery{}, func(company models.Company) error {
+		updated := false
+		key := dedupe.Key(company.Name)
+		if company.NameKey != key {
+			err := companyRepo.SetNameKeys(ctx, company.ID, key, dedupe.Trigrams(key))
+			if err != nil {
+				return err
+			}
+			updated = true
+		}
+
+		nameCanonical := dedupe.CanonicalName(company.Name)
+		if company.NameCanonical != nameCanonical {
+			err := companyRepo.SetNameCanonical(ctx, company.ID, nameCanonical)
+			switch {
+			case errors.Is(err, repo.ErrNameTaken):
+				conflicts++
+				log.Warn().
+					Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+					Str(consts.LogKeyCompanyId, company.ID.String()).
+					Str(consts.LogKeyCompanyName, company.Name).
+					Msg("the canonical name of the company is taken by another company of the tenant")
+			case err != nil:
+				return err
+			default:
+				updated = true
+			}
+		}
+
+		if updated {
+			count++
+		}
+		return nil
+	})
+	return count, conflicts, err
+}
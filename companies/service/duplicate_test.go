@@ -0,0 +1,114 @@
+package service
+
+import (
+	"companies/dedupe"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	companyId := uuid.New()
+	exactId := uuid.New()
+	typoId := uuid.New()
+	otherId := uuid.New()
+
+	r := mocks.NewCompanyRepo(t)
+	r.On("FindNameCandidates", mock.Anything, dedupe.Trigrams("acme"), nameCandidatesLimit).
+		Return([]models.Company{
+			{ID: otherId, Name: "Acme Widgets", NameKey: "acme widgets"},
+			{ID: companyId, Name: "Acme", NameKey: "acme"},
+			{ID: typoId, Name: "Acmee", NameKey: "acmee"},
+			{ID: exactId, Name: "ACME Limited", NameKey: "acme"},
+		}, nil)
+
+	companyService := NewCompanyService(r, nil, nil, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+	candidates, err := companyService.FindDuplicates(context.Background(), "Acme Ltd.", &companyId)
+
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 2) {
+		assert.Equal(t, models.DuplicateCandidate{ID: exactId, Name: "ACME Limited", Score: 1, Exact: true}, candidates[0])
+		assert.Equal(t, typoId, candidates[1].ID)
+		assert.False(t, candidates[1].Exact)
+		assert.GreaterOrEqual(t, candidates[1].Score, dedupe.DefaultThreshold)
+	}
+
+	// a name made only of a legal suffix or punctuation has no key and no duplicates
+	candidates, err = companyService.FindDuplicates(context.Background(), "...", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, candidates)
+}
+
+func TestReportDuplicates(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+
+	r := mocks.NewCompanyRepo(t)
+	r.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(models.Company) error)
+			fn(models.Company{ID: ids[0], Name: "Acme Ltd", NameKey: "acme"})
+			fn(models.Company{ID: ids[1], Name: "Initech"})
+			// the companies created before the name keys are compared on the key of their name
+			fn(models.Company{ID: ids[2], Name: "ACME Limited"})
+			fn(models.Company{ID: ids[3], Name: "Acmee", NameKey: "acmee"})
+		}).
+		Return(nil)
+
+	companyService := NewCompanyService(r, nil, nil, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+	read := 0
+	report, err := companyService.ReportDuplicates(context.Background(), 0, func() {
+		read++
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, read)
+	assert.Equal(t, 4, report.Companies)
+	if assert.Len(t, report.Clusters, 1) {
+		cluster := report.Clusters[0]
+		if assert.Len(t, cluster.Companies, 3) {
+			assert.Equal(t, models.DuplicateCandidate{ID: ids[0], Name: "Acme Ltd", Score: 1, Exact: true}, cluster.Companies[0])
+			assert.Equal(t, models.DuplicateCandidate{ID: ids[2], Name: "ACME Limited", Score: 1, Exact: true}, cluster.Companies[1])
+			assert.Equal(t, ids[3], cluster.Companies[2].ID)
+			assert.False(t, cluster.Companies[2].Exact)
+		}
+		assert.Less(t, cluster.MinScore, float64(1))
+	}
+}
+
+func TestBackfillNameKeys(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	r := mocks.NewCompanyRepo(t)
+	r.On("ExportCompanies", mock.Anything, models.CompanyQuery{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(models.Company) error)
+			fn(models.Company{ID: ids[0], Name: "Acme Ltd", NameCanonical: "acme ltd", NameKey: "acme", NameTrigrams: dedupe.Trigrams("acme")})
+			fn(models.Company{ID: ids[1], Name: "Initech Inc."})
+			fn(models.Company{ID: ids[2], Name: "ACME LTD", NameKey: "acme", NameTrigrams: dedupe.Trigrams("acme")})
+		}).
+		Return(nil)
+	r.On("SetNameKeys", mock.Anything, ids[1], "initech", dedupe.Trigrams("initech")).
+		Return(nil).
+		Once()
+	r.On("SetNameCanonical", mock.Anything, ids[1], "initech inc.").
+		Return(nil).
+		Once()
+	r.On("SetNameCanonical", mock.Anything, ids[2], "acme ltd").
+		Return(repo.ErrNameTaken).
+		Once()
+
+	count, conflicts, err := BackfillNameKeys(context.Background(), r)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, conflicts)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"companies/models"
+	"context"
+	"errors"
+)
+
+var ErrDuplicateName = errors.New("a company of the tenant already has the name")
+
+// ImportCompany creates an imported company like CreateCompany, a company with the name of an existing one
+// is reported instead of failing on the unique index. A dry run only runs the checks
+func (service *companyService) ImportCompany(ctx context.Context, companyInput models.CompanyInput, dryRun bool, principal models.Principal) (models.CompanyOutput, error) {
+	exists, err := service.repo.CompanyNameExists(ctx, companyInput.Name)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	if exists {
+		return models.CompanyOutput{}, ErrDuplicateName
+	}
+	if !dryRun {
+		return service.CreateCompany(ctx, companyInput, principal)
+	}
+
+	if companyInput.ParentID != nil {
+		err = service.checkNewParent(ctx, *companyInput.ParentID)
+		if err != nil {
+			return models.CompanyOutput{}, err
+		}
+	}
+	company := models.Company{}
+	company.FromCompanyInput(companyInput)
+	output := models.CompanyOutput{}
+	output.FromCompany(company)
+	return output, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestImportCompany(t *testing.T) {
+	numberOfEmployees := 10
+	registered := true
+	parentId := uuid.New()
+	companyInput := models.CompanyInput{
+		Name:              "company-name",
+		NumberOfEmployees: &numberOfEmployees,
+		Registered:        &registered,
+		Type:              "Corporations",
+	}
+	childInput := companyInput
+	childInput.ParentID = &parentId
+
+	testCases := []struct {
+		name         string
+		companyInput models.CompanyInput
+		dryRun       bool
+		stubMock     func(r *mocks.CompanyRepo)
+		validate     func(output models.CompanyOutput, err error)
+	}{
+		{
+			name:         "import",
+			companyInput: companyInput,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("CompanyNameExists", mock.Anything, "company-name").
+					Return(false, nil)
+				r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+					Return(uuid.New(), nil)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.NoError(t, err)
+				assert.NotEqual(t, uuid.Nil, output.ID)
+				assert.Equal(t, "company-name", output.Name)
+			},
+		},
+		{
+			name:         "dry run does not create the company",
+			companyInput: companyInput,
+			dryRun:       true,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("CompanyNameExists", mock.Anything, "company-name").
+					Return(false, nil)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, uuid.Nil, output.ID)
+				assert.Equal(t, "company-name", output.Name)
+			},
+		},
+		{
+			name:         "dry run checks the parent",
+			companyInput: childInput,
+			dryRun:       true,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("CompanyNameExists", mock.Anything, "company-name").
+					Return(false, nil)
+				r.On("GetCompany", mock.Anything, parentId).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrParentNotFound)
+			},
+		},
+		{
+			name:         "duplicate name",
+			companyInput: companyInput,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("CompanyNameExists", mock.Anything, "company-name").
+					Return(true, nil)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrDuplicateName)
+			},
+		},
+		{
+			name:         "repo returned an error",
+			companyInput: companyInput,
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("CompanyNameExists", mock.Anything, "company-name").
+					Return(false, assert.AnError)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+
+			output, err := companyService.ImportCompany(ctx, testCase.companyInput, testCase.dryRun, models.Principal{Username: "alice"})
+			testCase.validate(output, err)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"companies/blobstore"
+	"companies/consts"
+	"companies/eventpublisher"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNotJobOwner = errors.New("only the user that created the job or an admin can access it")
+	// ErrJobResultNotReady the job did not succeed, it has no result to download
+	ErrJobResultNotReady = errors.New("the job has no result")
+)
+
+type JobService interface {
+	// CreateJob queues a job, the input is stored with it when it is not nil
+	CreateJob(ctx context.Context, jobType string, params any, input *models.JobInput, principal models.Principal) (models.Job, error)
+	GetJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error)
+	CancelJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error)
+	RetryJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error)
+	GetJobResult(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, io.ReadCloser, error)
+}
+
+type jobService struct {
+	repo           repo.JobRepo
+	blobStore      blobstore.BlobStore
+	eventPublisher eventpublisher.EventPublisher
+}
+
+func NewJobService(repo repo.JobRepo, blobStore blobstore.BlobStore, eventPublisher eventpublisher.EventPublisher) JobService {
+	return &jobService{
+		repo:           repo,
+		blobStore:      blobStore,
+		eventPublisher: eventPublisher,
+	}
+}
+
+func (service *jobService) CreateJob(ctx context.Context, jobType string, params any, input *models.JobInput, principal models.Principal) (models.Job, error) {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return models.Job{}, err
+	}
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job := models.Job{
+		ID:        uuid.New(),
+		Tenant:    scope.Tenant,
+		Type:      jobType,
+		Status:    models.JobStatusQueued,
+		Params:    encodedParams,
+		CreatedBy: principal.Username,
+		Scopes:    principal.Scopes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if input != nil {
+		job.Input = models.JobInputKey(job.ID)
+		err = service.blobStore.Put(ctx, job.Input, input.Content, input.Size, input.ContentType)
+		if err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	err = service.repo.CreateJob(ctx, job)
+	if err != nil {
+		if job.Input != "" {
+			service.deleteBlob(ctx, job.Input)
+		}
+		return models.Job{}, err
+	}
+
+	service.publish(models.KafkaEventTypeJobCreate, job)
+
+	return job, nil
+}
+
+func (service *jobService) GetJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error) {
+	job, err := service.repo.GetJob(ctx, jobId)
+	if err != nil {
+		return models.Job{}, err
+	}
+	err = checkJobOwner(job, principal)
+	if err != nil {
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+// CancelJob cancels a queued job, a running job is only flagged and it is canceled by its worker
+func (service *jobService) CancelJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error) {
+	_, err := service.GetJob(ctx, jobId, principal)
+	if err != nil {
+		return models.Job{}, err
+	}
+	job, err := service.repo.CancelJob(ctx, jobId, time.Now().UTC())
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	eventType := models.KafkaEventTypeJobCancel
+	if job.Status == models.JobStatusRunning {
+		eventType = models.KafkaEventTypeJobCancelRequest
+	}
+	service.publish(eventType, job)
+
+	return job, nil
+}
+
+func (service *jobService) RetryJob(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, error) {
+	_, err := service.GetJob(ctx, jobId, principal)
+	if err != nil {
+		return models.Job{}, err
+	}
+	job, err := service.repo.RetryJob(ctx, jobId, time.Now().UTC())
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	service.publish(models.KafkaEventTypeJobRetry, job)
+
+	return job, nil
+}
+
+func (service *jobService) GetJobResult(ctx context.Context, jobId uuid.UUID, principal models.Principal) (models.Job, io.ReadCloser, error) {
+	job, err := service.GetJob(ctx, jobId, principal)
+	if err != nil {
+		return models.Job{}, nil, err
+	}
+	if job.Status != models.JobStatusSucceeded {
+		return models.Job{}, nil, ErrJobResultNotReady
+	}
+	content, err := service.blobStore.Get(ctx, models.JobResultKey(job.ID))
+	if err != nil {
+		return models.Job{}, nil, err
+	}
+	return job, content, nil
+}
+
+// checkJobOwner the parameters and the result of a job can hold fields only its creator is allowed to read
+func checkJobOwner(job models.Job, principal models.Principal) error {
+	if job.CreatedBy != principal.Username && !principal.HasScope(models.ScopeCompaniesAdmin) {
+		return ErrNotJobOwner
+	}
+	return nil
+}
+
+// deleteBlob only logs the errors, the input of a job that was not created is an orphan blob
+func (service *jobService) deleteBlob(ctx context.Context, key string) {
+	err := service.blobStore.Delete(ctx, key)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+			Str(consts.LogKeyBlobKey, key).
+			Msg("error while deleting blob")
+	}
+}
+
+func (service *jobService) publish(eventType string, job models.Job) {
+	service.eventPublisher.PublishEvent(models.KafkaEvent{
+		Type: eventType,
+		Data: models.JobEvent{
+			Job: job,
+		},
+	})
+}
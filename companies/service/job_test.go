@@ -0,0 +1,230 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateJob(t *testing.T) {
+	params := models.ImportJobParams{Format: "text/csv", DryRun: true}
+
+	testCases := []struct {
+		name     string
+		input    *models.JobInput
+		stubMock func(r *mocks.JobRepo, b *mocks.BlobStore)
+		validate func(job models.Job, err error)
+	}{
+		{
+			name: "job without input",
+			stubMock: func(r *mocks.JobRepo, b *mocks.BlobStore) {
+				r.On("CreateJob", mock.Anything, mock.MatchedBy(func(job models.Job) bool {
+					return job.Tenant == "tenant-a" && job.Status == models.JobStatusQueued && job.Input == ""
+				})).
+					Return(nil)
+			},
+			validate: func(job models.Job, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, models.JobTypeCompaniesImport, job.Type)
+				assert.Equal(t, "alice", job.CreatedBy)
+				assert.JSONEq(t, `{"format":"text/csv","dry_run":true}`, string(job.Params))
+			},
+		},
+		{
+			name:  "the input is stored with the job",
+			input: &models.JobInput{Content: strings.NewReader("name\nacme\n"), Size: -1, ContentType: "text/csv"},
+			stubMock: func(r *mocks.JobRepo, b *mocks.BlobStore) {
+				b.On("Put", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, "/input") }), mock.Anything, int64(-1), "text/csv").
+					Return(nil)
+				r.On("CreateJob", mock.Anything, mock.AnythingOfType("models.Job")).
+					Return(nil)
+			},
+			validate: func(job models.Job, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, models.JobInputKey(job.ID), job.Input)
+			},
+		},
+		{
+			name:  "the input is deleted when the job can not be created",
+			input: &models.JobInput{Content: strings.NewReader("name\nacme\n"), Size: -1, ContentType: "text/csv"},
+			stubMock: func(r *mocks.JobRepo, b *mocks.BlobStore) {
+				b.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil)
+				r.On("CreateJob", mock.Anything, mock.AnythingOfType("models.Job")).
+					Return(assert.AnError)
+				b.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasSuffix(key, "/input") })).
+					Return(nil).Once()
+			},
+			validate: func(job models.Job, err error) {
+				assert.ErrorIs(t, err, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewJobRepo(t)
+			b := mocks.NewBlobStore(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			jobService := NewJobService(r, b, eventPublisher)
+
+			testCase.stubMock(r, b)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+
+			job, err := jobService.CreateJob(ctx, models.JobTypeCompaniesImport, params, testCase.input, models.Principal{Username: "alice"})
+			testCase.validate(job, err)
+		})
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name      string
+		principal models.Principal
+		stubMock  func(r *mocks.JobRepo)
+		validate  func(job models.Job, err error)
+	}{
+		{
+			name:      "cancel",
+			principal: models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("GetJob", mock.Anything, jobId).
+					Return(models.Job{ID: jobId, CreatedBy: "alice"}, nil)
+				r.On("CancelJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusRunning, CancelRequested: true}, nil)
+			},
+			validate: func(job models.Job, err error) {
+				assert.NoError(t, err)
+				assert.True(t, job.CancelRequested)
+			},
+		},
+		{
+			name:      "an admin can cancel the job of another user",
+			principal: models.Principal{Username: "bob", Scopes: []string{models.ScopeCompaniesAdmin}},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("GetJob", mock.Anything, jobId).
+					Return(models.Job{ID: jobId, CreatedBy: "alice"}, nil)
+				r.On("CancelJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{ID: jobId, Status: models.JobStatusCanceled}, nil)
+			},
+			validate: func(job models.Job, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, models.JobStatusCanceled, job.Status)
+			},
+		},
+		{
+			name:      "not the owner",
+			principal: models.Principal{Username: "bob"},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("GetJob", mock.Anything, jobId).
+					Return(models.Job{ID: jobId, CreatedBy: "alice"}, nil)
+			},
+			validate: func(job models.Job, err error) {
+				assert.ErrorIs(t, err, ErrNotJobOwner)
+			},
+		},
+		{
+			name:      "the job already finished",
+			principal: models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.JobRepo) {
+				r.On("GetJob", mock.Anything, jobId).
+					Return(models.Job{ID: jobId, CreatedBy: "alice"}, nil)
+				r.On("CancelJob", mock.Anything, jobId, mock.Anything).
+					Return(models.Job{}, repo.ErrJobNotCancelable)
+			},
+			validate: func(job models.Job, err error) {
+				assert.ErrorIs(t, err, repo.ErrJobNotCancelable)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewJobRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			jobService := NewJobService(r, nil, eventPublisher)
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			job, err := jobService.CancelJob(ctx, jobId, testCase.principal)
+			testCase.validate(job, err)
+		})
+	}
+}
+
+func TestGetJobResult(t *testing.T) {
+	jobId := uuid.New()
+
+	testCases := []struct {
+		name     string
+		stubMock func(r *mocks.JobRepo, b *mocks.BlobStore)
+		validate func(job models.Job, content io.ReadCloser, err error)
+	}{
+		{
+			name: "succeeded job",
+			stubMock: func(r *mocks.JobRepo, b *mocks.BlobStore) {
+				r.On("GetJob", mock.Anything, jobId).
+					Return(models.Job{ID: jobId, CreatedBy: "alice", Status: models.JobStatusSucceeded}, nil)
+				b.On("Get", mock.Anything, models.JobResultKey(jobId)).
+					Return(io.NopCloser(strings.NewReader("name\n")), nil)
+			},
+			validate: func(job models.Job, content io.ReadCloser, err error) {
+				assert.NoError(t, err)
+				data, _ := io.ReadAll(content)
+				assert.Equal(t, "name\n", string(data))
+			},
+		},
+		{
+			name: "running job",
+			stubMock: func(r *mocks.JobRepo, b *mocks.BlobStore) {
+				r.On("GetJob", mock.Anything, jobId).
+					Return(models.Job{ID: jobId, CreatedBy: "alice", Status: models.JobStatusRunning}, nil)
+			},
+			validate: func(job models.Job, content io.ReadCloser, err error) {
+				assert.ErrorIs(t, err, ErrJobResultNotReady)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewJobRepo(t)
+			b := mocks.NewBlobStore(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			jobService := NewJobService(r, b, eventPublisher)
+
+			testCase.stubMock(r, b)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			job, content, err := jobService.GetJobResult(ctx, jobId, models.Principal{Username: "alice"})
+			testCase.validate(job, content, err)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"companies/consts"
+	"companies/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrMergeSameCompany    = errors.New("a company can not be merged into itself")
+	ErrMergeSourceNotFound = errors.New("the source company of the merge was not found")
+	// ErrMergeTooLarge the concatenated values are over the limits of the company fields
+	ErrMergeTooLarge = errors.New("the concatenated values are larger than allowed")
+)
+
+// CompanyMergedError the company was merged, the tombstone points to the survivor
+type CompanyMergedError struct {
+	Tombstone models.CompanyTombstone
+}
+
+func (err *CompanyMergedError) Error() string {
+	return "the company was merged into " + err.Tombstone.MergedInto.String()
+}
+
+// getTombstone returns a CompanyMergedError when the company that was not found was merged, err otherwise
+func (service *companyService) getTombstone(ctx context.Context, companyId uuid.UUID, err error) error {
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	tombstone, tombstoneErr := service.repo.GetTombstone(ctx, companyId)
+	if tombstoneErr != nil {
+		if errors.Is(tombstoneErr, mongo.ErrNoDocuments) {
+			return err
+		}
+		return tombstoneErr
+	}
+	return &CompanyMergedError{Tombstone: tombstone}
+}
+
+// MergeCompanies merges the source company into the target company in a transaction. The target takes the values
+// of the source resolved by the fields strategies and the children of the source, the source is deleted and
+// replaced by a tombstone that redirects to the target
+func (service *companyService) MergeCompanies(ctx context.Context, targetId uuid.UUID, input models.MergeInput, principal models.Principal) (models.CompanyOutput, error) {
+	if input.SourceID == targetId {
+		return models.CompanyOutput{}, ErrMergeSameCompany
+	}
+
+	var output models.CompanyOutput
+	var pending []func()
+	err := service.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		// the function runs again after a transient error, nothing of the previous attempt is kept
+		pending = nil
+		transactionService := *service
+		transactionService.pending = &pending
+		var err error
+		output, err = transactionService.mergeCompanies(ctx, targetId, input, principal)
+		return err
+	})
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+
+	for _, fn := range pending {
+		fn()
+	}
+	return output, nil
+}
+
+func (service *companyService) mergeCompanies(ctx context.Context, targetId uuid.UUID, input models.MergeInput, principal models.Principal) (models.CompanyOutput, error) {
+	target, err := service.repo.GetCompany(ctx, targetId)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	source, err := service.repo.GetCompany(ctx, input.SourceID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.CompanyOutput{}, errors.Join(ErrMergeSourceNotFound, err)
+		}
+		return models.CompanyOutput{}, err
+	}
+	for _, company := range []models.Company{target, source} {
+		err = service.ownerRule.check(company, principal)
+		if err != nil {
+			return models.CompanyOutput{}, err
+		}
+	}
+
+	// the children of the source move under the target, that can not be one of them
+	ancestors, err := service.repo.GetAncestors(ctx, targetId)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == source.ID {
+			return models.CompanyOutput{}, ErrHierarchyCycle
+		}
+	}
+
+	merged := models.MergeCompany(target, source, input.Fields)
+	if len(merged.Tags) > models.MaxTagsPerCompany ||
+		len(merged.Description) > models.MaxMergedTextLength ||
+		len(merged.InternalNotes) > models.MaxMergedTextLength {
+		return models.CompanyOutput{}, ErrMergeTooLarge
+	}
+
+	stamp := models.NewAuditStamp(principal)
+	children, err := service.repo.MoveChildren(ctx, source.ID, targetId, stamp)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	// the source is deleted before the target is replaced so the target can take its unique name and identifiers
+	err = service.repo.DeleteCompany(ctx, source.ID)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	err = service.repo.CreateTombstone(ctx, models.CompanyTombstone{
+		ID:         source.ID,
+		Tenant:     source.Tenant,
+		MergedInto: targetId,
+		MergedAt:   stamp.At,
+		MergedBy:   stamp.By,
+	})
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+	company, err := service.repo.ReplaceCompany(ctx, merged, target.Version, stamp)
+	if err != nil {
+		return models.CompanyOutput{}, err
+	}
+
+	// the attachments of the source are not moved, their blobs are keyed by the source id
+	service.afterCommit(func() {
+		for _, attachment := range source.Attachments {
+			key := attachment.BlobKey(source.ID)
+			err := service.blobStore.Delete(ctx, key)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+					Str(consts.LogKeyCompanyId, source.ID.String()).
+					Str(consts.LogKeyBlobKey, key).
+					Msg("error while deleting the blob of a merged company")
+			}
+		}
+	})
+
+	output := models.CompanyOutput{}
+	output.FromCompany(company)
+
+	event := models.KafkaEvent{
+		Type: models.KafkaEventTypeCompanyMerge,
+		Data: models.MergeEvent{
+			SourceID: source.ID,
+			TargetID: targetId,
+			Fields:   input.ChangedFields(),
+			Company:  output,
+		},
+	}
+
+	service.publishEvent(event)
+	for _, child := range children {
+		service.publishParentSet(child)
+	}
+	service.unindexCompany(source.ID)
+	service.indexCompany(company)
+
+	return output, nil
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/repo"
+	"companies/tenancy"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMergeCompanies(t *testing.T) {
+	targetId := uuid.New()
+	sourceId := uuid.New()
+	childId := uuid.New()
+	attachments := []models.Attachment{{ID: uuid.New(), Kind: models.AttachmentKindLogo, FileName: "logo.png"}}
+	target := models.Company{ID: targetId, Tenant: "tenant-a", Name: "Acme", Description: "Rockets", Version: 3}
+	source := models.Company{ID: sourceId, Tenant: "tenant-a", Name: "Acme Ltd", Description: "Anvils", Attachments: attachments}
+	input := models.MergeInput{
+		SourceID: sourceId,
+		Fields:   map[string]string{"name": models.MergeStrategySource, "description": models.MergeStrategyConcat},
+	}
+
+	testCases := []struct {
+		name     string
+		targetId uuid.UUID
+		input    models.MergeInput
+		stubMock func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool)
+		validate func(output models.CompanyOutput, err error)
+	}{
+		{
+			name:     "success test case",
+			targetId: targetId,
+			input:    input,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						err := fn(ctx)
+						*committed = err == nil
+						return err
+					})
+				r.On("GetCompany", mock.Anything, targetId).
+					Return(target, nil)
+				r.On("GetCompany", mock.Anything, sourceId).
+					Return(source, nil)
+				r.On("GetAncestors", mock.Anything, targetId).
+					Return([]models.CompanyNode{}, nil)
+				r.On("MoveChildren", mock.Anything, sourceId, targetId, mock.AnythingOfType("models.AuditStamp")).
+					Return([]models.Company{{ID: childId, ParentID: &targetId}}, nil)
+				r.On("DeleteCompany", mock.Anything, sourceId).
+					Return(nil)
+				r.On("CreateTombstone", mock.Anything, mock.MatchedBy(func(tombstone models.CompanyTombstone) bool {
+					return tombstone.ID == sourceId && tombstone.MergedInto == targetId &&
+						tombstone.Tenant == "tenant-a" && tombstone.MergedBy == "alice"
+				})).
+					Return(nil)
+				r.On("ReplaceCompany", mock.Anything, mock.MatchedBy(func(company models.Company) bool {
+					return company.ID == targetId && company.Name == "Acme Ltd" && company.Description == "Rockets\nAnvils"
+				}), 3, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{ID: targetId, Name: "Acme Ltd", Description: "Rockets\nAnvils", Version: 4}, nil)
+				b.On("Delete", mock.Anything, attachments[0].BlobKey(sourceId)).
+					Run(func(args mock.Arguments) {
+						assert.True(t, *committed)
+					}).
+					Return(nil).Once()
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, models.CompanyOutput{ID: targetId, Name: "Acme Ltd", Description: "Rockets\nAnvils"}, output)
+			},
+		},
+		{
+			name:     "a company can not be merged into itself",
+			targetId: sourceId,
+			input:    input,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrMergeSameCompany)
+			},
+		},
+		{
+			name:     "source not found",
+			targetId: targetId,
+			input:    input,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+				r.On("GetCompany", mock.Anything, targetId).
+					Return(target, nil)
+				r.On("GetCompany", mock.Anything, sourceId).
+					Return(models.Company{}, mongo.ErrNoDocuments)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrMergeSourceNotFound)
+			},
+		},
+		{
+			name:     "the source is an ancestor of the target",
+			targetId: targetId,
+			input:    input,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+				r.On("GetCompany", mock.Anything, targetId).
+					Return(target, nil)
+				r.On("GetCompany", mock.Anything, sourceId).
+					Return(source, nil)
+				r.On("GetAncestors", mock.Anything, targetId).
+					Return([]models.CompanyNode{{Company: source}}, nil)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrHierarchyCycle)
+			},
+		},
+		{
+			name:     "the concatenated description is too long",
+			targetId: targetId,
+			input:    models.MergeInput{SourceID: sourceId, Fields: map[string]string{"description": models.MergeStrategyConcat}},
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+				longTarget := target
+				longTarget.Description = strings.Repeat("a", models.MaxMergedTextLength)
+				r.On("GetCompany", mock.Anything, targetId).
+					Return(longTarget, nil)
+				r.On("GetCompany", mock.Anything, sourceId).
+					Return(source, nil)
+				r.On("GetAncestors", mock.Anything, targetId).
+					Return([]models.CompanyNode{}, nil)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, ErrMergeTooLarge)
+			},
+		},
+		{
+			name:     "the target was changed during the merge",
+			targetId: targetId,
+			input:    input,
+			stubMock: func(r *mocks.CompanyRepo, b *mocks.BlobStore, tx *mocks.Transactor, committed *bool) {
+				tx.On("WithTransaction", mock.Anything, mock.Anything).
+					Return(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					})
+				r.On("GetCompany", mock.Anything, targetId).
+					Return(target, nil)
+				r.On("GetCompany", mock.Anything, sourceId).
+					Return(source, nil)
+				r.On("GetAncestors", mock.Anything, targetId).
+					Return([]models.CompanyNode{}, nil)
+				r.On("MoveChildren", mock.Anything, sourceId, targetId, mock.AnythingOfType("models.AuditStamp")).
+					Return([]models.Company{}, nil)
+				r.On("DeleteCompany", mock.Anything, sourceId).
+					Return(nil)
+				r.On("CreateTombstone", mock.Anything, mock.AnythingOfType("models.CompanyTombstone")).
+					Return(nil)
+				r.On("ReplaceCompany", mock.Anything, mock.AnythingOfType("models.Company"), 3, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{}, repo.ErrVersionConflict)
+			},
+			validate: func(output models.CompanyOutput, err error) {
+				assert.ErrorIs(t, err, repo.ErrVersionConflict)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+			b := mocks.NewBlobStore(t)
+			tx := mocks.NewTransactor(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, b, time.Hour, false, tx, newSearchIndex(t), nil)
+
+			committed := false
+			testCase.stubMock(r, b, tx, &committed)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+
+			output, err := companyService.MergeCompanies(ctx, testCase.targetId, testCase.input, models.Principal{Username: "alice"})
+			testCase.validate(output, err)
+		})
+	}
+}
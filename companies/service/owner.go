@@ -0,0 +1,40 @@
+package service
+
+import (
+	"companies/models"
+	"companies/repo"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotOwner = errors.New("only the user that created the company or an admin can modify it")
+
+// ownerRule the optional rule that only the user that created a company, or a user with the admin scope,
+// can modify or delete it. The companies created before the audit stamps can only be modified by admins
+type ownerRule struct {
+	enabled bool
+}
+
+func (rule ownerRule) check(company models.Company, principal models.Principal) error {
+	if !rule.enabled || principal.HasScope(models.ScopeCompaniesAdmin) {
+		return nil
+	}
+	if company.CreatedBy == "" || company.CreatedBy != principal.Username {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// authorize loads the company to check the rule, nothing is loaded when the rule is disabled
+func (rule ownerRule) authorize(ctx context.Context, companyRepo repo.CompanyRepo, companyId uuid.UUID, principal models.Principal) error {
+	if !rule.enabled || principal.HasScope(models.ScopeCompaniesAdmin) {
+		return nil
+	}
+	company, err := companyRepo.GetCompany(ctx, companyId)
+	if err != nil {
+		return err
+	}
+	return rule.check(company, principal)
+}
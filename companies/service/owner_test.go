@@ -0,0 +1,110 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOwnerRule(t *testing.T) {
+	companyId := uuid.New()
+	tags := []string{"vip"}
+
+	testCases := []struct {
+		name            string
+		ownerOnlyWrites bool
+		principal       models.Principal
+		stubMock        func(r *mocks.CompanyRepo)
+		validate        func(err error)
+	}{
+		{
+			name:            "rule disabled",
+			ownerOnlyWrites: false,
+			principal:       models.Principal{Username: "bob"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("AddTags", mock.Anything, companyId, tags, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{ID: companyId, Tags: tags}, nil)
+			},
+			validate: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:            "owner",
+			ownerOnlyWrites: true,
+			principal:       models.Principal{Username: "alice"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, CreatedBy: "alice"}, nil)
+				r.On("AddTags", mock.Anything, companyId, tags, mock.MatchedBy(func(stamp models.AuditStamp) bool {
+					return stamp.By == "alice" && !stamp.At.IsZero()
+				})).
+					Return(models.Company{ID: companyId, Tags: tags}, nil)
+			},
+			validate: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:            "not the owner",
+			ownerOnlyWrites: true,
+			principal:       models.Principal{Username: "bob"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId, CreatedBy: "alice"}, nil)
+			},
+			validate: func(err error) {
+				assert.ErrorIs(t, err, ErrNotOwner)
+			},
+		},
+		{
+			name:            "company created before the audit stamps",
+			ownerOnlyWrites: true,
+			principal:       models.Principal{Username: "bob"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompany", mock.Anything, companyId).
+					Return(models.Company{ID: companyId}, nil)
+			},
+			validate: func(err error) {
+				assert.ErrorIs(t, err, ErrNotOwner)
+			},
+		},
+		{
+			name:            "admin",
+			ownerOnlyWrites: true,
+			principal:       models.Principal{Username: "bob", Scopes: []string{models.ScopeCompaniesAdmin}},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("AddTags", mock.Anything, companyId, tags, mock.AnythingOfType("models.AuditStamp")).
+					Return(models.Company{ID: companyId, Tags: tags}, nil)
+			},
+			validate: func(err error) {
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, testCase.ownerOnlyWrites, nil, newSearchIndex(t), nil)
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := companyService.AddTags(ctx, companyId, tags, testCase.principal)
+			testCase.validate(err)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"companies/consts"
+	"companies/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// SearchCompanies matches the text against the fields of the search index, the companies are read from the database
+// so the hits of companies deleted since they were indexed, ex: by another replica, are dropped and removed from the index
+func (service *companyService) SearchCompanies(ctx context.Context, query models.SearchQuery, fields []string) ([]models.CompanySearchHit, error) {
+	hits, err := service.searchIndex.Search(ctx, query.Q, fields, query.GetLimit(), query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return []models.CompanySearchHit{}, nil
+	}
+
+	companyIds := make([]uuid.UUID, 0, len(hits))
+	for _, hit := range hits {
+		companyIds = append(companyIds, hit.ID)
+	}
+	companies, err := service.repo.GetCompanies(ctx, companyIds)
+	if err != nil {
+		return nil, err
+	}
+	companiesById := make(map[uuid.UUID]models.Company, len(companies))
+	for _, company := range companies {
+		companiesById[company.ID] = company
+	}
+
+	outputs := make([]models.CompanySearchHit, 0, len(hits))
+	for _, hit := range hits {
+		company, ok := companiesById[hit.ID]
+		if !ok {
+			service.unindexCompany(hit.ID)
+			continue
+		}
+		output := models.CompanySearchHit{
+			Score:      hit.Score,
+			Highlights: hit.Highlights,
+		}
+		output.Company.FromCompany(company)
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// indexCompany a company that fails to be indexed is only logged, it is indexed again by its next change
+// or by a rebuild of the index
+func (service *companyService) indexCompany(company models.Company) {
+	service.afterCommit(func() {
+		err := service.searchIndex.IndexCompanies(company)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyCompanyId, company.ID.String()).
+				Msg("error while indexing company")
+		}
+	})
+}
+
+// unindexCompany a deleted company that is still in the index is dropped from the search results anyway
+func (service *companyService) unindexCompany(companyId uuid.UUID) {
+	service.afterCommit(func() {
+		err := service.searchIndex.DeleteCompany(companyId)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str(consts.LogKeyTimeUTC, time.Now().UTC().String()).
+				Str(consts.LogKeyCompanyId, companyId.String()).
+				Msg("error while removing company from the search index")
+		}
+	})
+}
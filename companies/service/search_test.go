@@ -0,0 +1,157 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/search"
+	"companies/tenancy"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newSearchIndex(t *testing.T) search.Index {
+	index, err := search.NewMemoryIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		index.Close()
+	})
+	return index
+}
+
+func TestSearchCompanies(t *testing.T) {
+	acme := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme", Description: "Rockets"}
+	deleted := models.Company{ID: uuid.New(), Tenant: "tenant-a", Name: "Acme Old", Description: "Rockets"}
+
+	testCases := []struct {
+		name     string
+		query    models.SearchQuery
+		stubMock func(r *mocks.CompanyRepo)
+		validate func(hits []models.CompanySearchHit, err error, index search.Index)
+	}{
+		{
+			name:  "the deleted companies are dropped from the results and the index",
+			query: models.SearchQuery{Q: "acme"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompanies", mock.Anything, mock.MatchedBy(func(companyIds []uuid.UUID) bool {
+					return assert.ElementsMatch(t, []uuid.UUID{acme.ID, deleted.ID}, companyIds)
+				})).
+					Return([]models.Company{acme}, nil)
+			},
+			validate: func(hits []models.CompanySearchHit, err error, index search.Index) {
+				assert.NoError(t, err)
+				if assert.Len(t, hits, 1) {
+					assert.Equal(t, acme.ID, hits[0].Company.ID)
+					assert.Equal(t, "Acme", hits[0].Company.Name)
+					assert.Equal(t, []string{"<mark>Acme</mark>"}, hits[0].Highlights["name"])
+				}
+
+				ctx := tenancy.WithScope(context.Background(), tenancy.Scope{Tenant: "tenant-a"})
+				indexHits, err := index.Search(ctx, "acme", search.Fields, 20, 0)
+				assert.NoError(t, err)
+				assert.Len(t, indexHits, 1)
+			},
+		},
+		{
+			name:     "no match",
+			query:    models.SearchQuery{Q: "globex"},
+			stubMock: func(r *mocks.CompanyRepo) {},
+			validate: func(hits []models.CompanySearchHit, err error, index search.Index) {
+				assert.NoError(t, err)
+				assert.Empty(t, hits)
+			},
+		},
+		{
+			name:  "repo error",
+			query: models.SearchQuery{Q: "acme"},
+			stubMock: func(r *mocks.CompanyRepo) {
+				r.On("GetCompanies", mock.Anything, mock.Anything).
+					Return(nil, assert.AnError)
+			},
+			validate: func(hits []models.CompanySearchHit, err error, index search.Index) {
+				assert.ErrorIs(t, err, assert.AnError)
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			index := newSearchIndex(t)
+			assert.NoError(t, index.IndexCompanies(acme, deleted))
+
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, index, nil)
+
+			testCase.stubMock(r)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+
+			hits, err := companyService.SearchCompanies(ctx, testCase.query, search.Fields)
+			testCase.validate(hits, err, index)
+		})
+	}
+}
+
+func TestSearchIndexSync(t *testing.T) {
+	r := mocks.NewCompanyRepo(t)
+
+	eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+	index := newSearchIndex(t)
+	companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, index, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+	// the name is a sensitive field, an approver patches it directly
+	principal := models.Principal{Username: "alice", Scopes: []string{models.ScopeCompaniesApprove}}
+
+	companyId := uuid.New()
+	r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+		Return(companyId, nil)
+	_, err := companyService.CreateCompany(ctx, models.CompanyInput{Name: "Acme", Type: "Corporations"}, principal)
+	assert.NoError(t, err)
+
+	hits, err := index.Search(ctx, "acme", search.Fields, 20, 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	// the patched name replaces the indexed one
+	name := "Globex"
+	r.On("PatchCompany", mock.Anything, companyId, mock.Anything, (*int)(nil), mock.Anything).
+		Return(models.Company{ID: companyId, Tenant: "tenant-a", Name: name}, nil)
+	_, _, err = companyService.PatchCompany(ctx, companyId, models.UpdateCompanyInput{Name: &name}, principal)
+	assert.NoError(t, err)
+
+	hits, err = index.Search(ctx, "acme", search.Fields, 20, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+	hits, err = index.Search(ctx, "globex", search.Fields, 20, 0)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	r.On("GetCompany", mock.Anything, companyId).
+		Return(models.Company{ID: companyId, Tenant: "tenant-a", Name: name}, nil)
+	r.On("DetachChildren", mock.Anything, companyId, mock.Anything).
+		Return([]uuid.UUID{}, nil)
+	r.On("DeleteCompany", mock.Anything, companyId).
+		Return(nil)
+	err = companyService.DeleteCompany(ctx, companyId, principal)
+	assert.NoError(t, err)
+
+	hits, err = index.Search(ctx, "globex", search.Fields, 20, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DefaultStatsCacheEntries bounds the memory of the stats cache, the filters of the queries are free so every
+// combination is a different entry
+const DefaultStatsCacheEntries = 1000
+
+// StatsCache keeps the company statistics of each tenant and query for a short time. It is cleared when
+// the companies are changed through this replica, the changes of the other replicas show up once the entries expire.
+// A nil StatsCache caches nothing
+type StatsCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+func NewStatsCache(ttl time.Duration, maxEntries int) *StatsCache {
+	return &StatsCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    map[string]statsCacheEntry{},
+	}
+}
+
+func (cache *StatsCache) get(key string) (any, bool) {
+	if cache == nil {
+		return nil, false
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entry, ok := cache.entries[key]
+	if !ok || !cache.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// set drops the expired entries when the cache is full, the value is not cached if it is still full
+func (cache *StatsCache) set(key string, value any) {
+	if cache == nil {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	now := cache.now()
+	if len(cache.entries) >= cache.maxEntries {
+		for entryKey, entry := range cache.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(cache.entries, entryKey)
+			}
+		}
+		if len(cache.entries) >= cache.maxEntries {
+			return
+		}
+	}
+	cache.entries[key] = statsCacheEntry{value: value, expiresAt: now.Add(cache.ttl)}
+}
+
+// Clear drops every entry, the events do not say which tenant a change belongs to
+func (cache *StatsCache) Clear() {
+	if cache == nil {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	clear(cache.entries)
+}
+
+// statsCacheKey the statistics of a query are cached per tenant, all the tenants share the * key
+func statsCacheKey(ctx context.Context, name string, query ...any) (string, error) {
+	scope, err := tenancy.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	tenant := scope.Tenant
+	if scope.AllTenants {
+		tenant = "*"
+	}
+	encodedQuery, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	return name + "|" + tenant + "|" + string(encodedQuery), nil
+}
+
+// cachedStats returns the cached statistics of the key or computes and caches them
+func cachedStats[T any](cache *StatsCache, key string, compute func() (T, error)) (T, error) {
+	value, ok := cache.get(key)
+	if ok {
+		return value.(T), nil
+	}
+	stats, err := compute()
+	if err != nil {
+		return stats, err
+	}
+	cache.set(key, stats)
+	return stats, nil
+}
+
+// CompanyStats the pagination of the query is ignored
+func (service *companyService) CompanyStats(ctx context.Context, query models.CompanyQuery) (models.CompanyStats, error) {
+	query.Limit, query.Offset = 0, 0
+	key, err := statsCacheKey(ctx, "companies", query)
+	if err != nil {
+		return models.CompanyStats{}, err
+	}
+	return cachedStats(service.statsCache, key, func() (models.CompanyStats, error) {
+		return service.repo.CompanyStats(ctx, query)
+	})
+}
+
+// EmployeeStats the pagination of the query is ignored
+func (service *companyService) EmployeeStats(ctx context.Context, query models.CompanyQuery, statsQuery models.EmployeeStatsQuery) (models.EmployeeStats, error) {
+	query.Limit, query.Offset = 0, 0
+	buckets, percentiles := statsQuery.GetBuckets(), statsQuery.GetPercentiles()
+	key, err := statsCacheKey(ctx, "employees", query, buckets, percentiles)
+	if err != nil {
+		return models.EmployeeStats{}, err
+	}
+	return cachedStats(service.statsCache, key, func() (models.EmployeeStats, error) {
+		return service.repo.EmployeeStats(ctx, query, buckets, percentiles)
+	})
+}
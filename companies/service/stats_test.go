@@ -0,0 +1,129 @@
+package service
+
+import (
+	"companies/eventpublisher"
+	"companies/mocks"
+	"companies/models"
+	"companies/tenancy"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCompanyStatsCache(t *testing.T) {
+	r := mocks.NewCompanyRepo(t)
+
+	eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	statsCache := NewStatsCache(time.Minute, DefaultStatsCacheEntries)
+	statsCache.now = func() time.Time { return now }
+
+	companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), statsCache)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tenantA := tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+	tenantB := tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-b"})
+
+	query := models.CompanyQuery{Type: "Cooperative"}
+	stats := models.CompanyStats{Total: 2, ByType: map[string]int{"Cooperative": 2}}
+	r.On("CompanyStats", mock.Anything, query).
+		Return(stats, nil).
+		Times(4)
+
+	// the pagination is not part of the cache key
+	for _, offset := range []int{0, 10} {
+		output, err := companyService.CompanyStats(tenantA, models.CompanyQuery{Type: "Cooperative", Offset: offset})
+		assert.NoError(t, err)
+		assert.Equal(t, stats, output)
+	}
+
+	// each tenant has its own entries
+	_, err := companyService.CompanyStats(tenantB, query)
+	assert.NoError(t, err)
+
+	// a change of a company clears the cache
+	r.On("CreateCompany", mock.Anything, mock.AnythingOfType("models.Company")).
+		Return(uuid.New(), nil)
+	_, err = companyService.CreateCompany(tenantA, models.CompanyInput{Name: "Acme", Type: "Cooperative"}, models.Principal{Username: "alice"})
+	assert.NoError(t, err)
+	_, err = companyService.CompanyStats(tenantA, query)
+	assert.NoError(t, err)
+	_, err = companyService.CompanyStats(tenantA, query)
+	assert.NoError(t, err)
+
+	// the entries expire
+	now = now.Add(time.Minute)
+	_, err = companyService.CompanyStats(tenantA, query)
+	assert.NoError(t, err)
+}
+
+func TestEmployeeStats(t *testing.T) {
+	testCases := []struct {
+		name                string
+		statsQuery          models.EmployeeStatsQuery
+		expectedBuckets     []int
+		expectedPercentiles []float64
+	}{
+		{
+			name:                "default buckets and percentiles",
+			statsQuery:          models.EmployeeStatsQuery{},
+			expectedBuckets:     []int{0, 10, 50, 250},
+			expectedPercentiles: []float64{50, 90, 99},
+		},
+		{
+			name:                "the buckets are sorted and start at 0",
+			statsQuery:          models.EmployeeStatsQuery{Buckets: []int{100, 20, 100}, Percentiles: []float64{99.9, 25}},
+			expectedBuckets:     []int{0, 20, 100},
+			expectedPercentiles: []float64{25, 99.9},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := mocks.NewCompanyRepo(t)
+
+			eventPublisher := eventpublisher.NewEventPublisher(nil, "")
+
+			companyService := NewCompanyService(r, nil, eventPublisher, nil, time.Hour, false, nil, newSearchIndex(t), nil)
+
+			stats := models.EmployeeStats{Count: 3, Percentiles: map[string]int{"p50": 12}}
+			r.On("EmployeeStats", mock.Anything, models.CompanyQuery{}, testCase.expectedBuckets, testCase.expectedPercentiles).
+				Return(stats, nil)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			ctx = tenancy.WithScope(ctx, tenancy.Scope{Tenant: "tenant-a"})
+
+			output, err := companyService.EmployeeStats(ctx, models.CompanyQuery{}, testCase.statsQuery)
+			assert.NoError(t, err)
+			assert.Equal(t, stats, output)
+		})
+	}
+}
+
+func TestStatsCacheMaxEntries(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	statsCache := NewStatsCache(time.Minute, 2)
+	statsCache.now = func() time.Time { return now }
+
+	statsCache.set("a", 1)
+	statsCache.set("b", 2)
+	statsCache.set("c", 3)
+	_, ok := statsCache.get("c")
+	assert.False(t, ok, "a full cache does not take new entries")
+
+	// the expired entries make room
+	now = now.Add(time.Minute)
+	statsCache.set("c", 3)
+	value, ok := statsCache.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	_, ok = statsCache.get("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,37 @@
+package tenancy
+
+import (
+	"context"
+	"errors"
+)
+
+// ScopeCompaniesSuperAdmin the jwt scope needed to read the companies of other tenants
+const ScopeCompaniesSuperAdmin = "companies:superadmin"
+
+// AllTenants the X-Tenant header value a super admin sends to read across every tenant
+const AllTenants = "*"
+
+var ErrNoTenant = errors.New("the request has no tenant")
+
+// Scope the tenants the queries of a request are restricted to
+type Scope struct {
+	Tenant string
+	// AllTenants is only set for the read requests of a super admin that asked for it explicitly
+	AllTenants bool
+}
+
+type scopeKey struct{}
+
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// FromContext returns the scope of the request, ErrNoTenant is returned when there is none
+// so a query can never run without a tenant by mistake
+func FromContext(ctx context.Context) (Scope, error) {
+	scope, ok := ctx.Value(scopeKey{}).(Scope)
+	if !ok || (scope.Tenant == "" && !scope.AllTenants) {
+		return Scope{}, ErrNoTenant
+	}
+	return scope, nil
+}
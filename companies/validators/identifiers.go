@@ -0,0 +1,91 @@
+package validators
+
+import (
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// 18 alphanumeric chars followed by 2 check digits
+var leiRegexp = regexp.MustCompile(`^[A-Z0-9]{18}[0-9]{2}$`)
+
+var dunsRegexp = regexp.MustCompile(`^[0-9]{9}$`)
+
+// euVATRegexps the VIES number formats keyed by the VAT country prefix, Greece uses EL
+var euVATRegexps = map[string]*regexp.Regexp{
+	"AT": regexp.MustCompile(`^U[0-9]{8}$`),
+	"BE": regexp.MustCompile(`^[01][0-9]{9}$`),
+	"BG": regexp.MustCompile(`^[0-9]{9,10}$`),
+	"CY": regexp.MustCompile(`^[0-9]{8}[A-Z]$`),
+	"CZ": regexp.MustCompile(`^[0-9]{8,10}$`),
+	"DE": regexp.MustCompile(`^[0-9]{9}$`),
+	"DK": regexp.MustCompile(`^[0-9]{8}$`),
+	"EE": regexp.MustCompile(`^[0-9]{9}$`),
+	"EL": regexp.MustCompile(`^[0-9]{9}$`),
+	"ES": regexp.MustCompile(`^[0-9A-Z][0-9]{7}[0-9A-Z]$`),
+	"FI": regexp.MustCompile(`^[0-9]{8}$`),
+	"FR": regexp.MustCompile(`^[0-9A-HJ-NP-Z]{2}[0-9]{9}$`),
+	"HR": regexp.MustCompile(`^[0-9]{11}$`),
+	"HU": regexp.MustCompile(`^[0-9]{8}$`),
+	"IE": regexp.MustCompile(`^([0-9]{7}[A-W][A-I]?|[0-9][A-Z+*][0-9]{5}[A-W])$`),
+	"IT": regexp.MustCompile(`^[0-9]{11}$`),
+	"LT": regexp.MustCompile(`^([0-9]{9}|[0-9]{12})$`),
+	"LU": regexp.MustCompile(`^[0-9]{8}$`),
+	"LV": regexp.MustCompile(`^[0-9]{11}$`),
+	"MT": regexp.MustCompile(`^[0-9]{8}$`),
+	"NL": regexp.MustCompile(`^[0-9]{9}B[0-9]{2}$`),
+	"PL": regexp.MustCompile(`^[0-9]{10}$`),
+	"PT": regexp.MustCompile(`^[0-9]{9}$`),
+	"RO": regexp.MustCompile(`^[1-9][0-9]{1,9}$`),
+	"SE": regexp.MustCompile(`^[0-9]{10}01$`),
+	"SI": regexp.MustCompile(`^[0-9]{8}$`),
+	"SK": regexp.MustCompile(`^[0-9]{10}$`),
+	"XI": regexp.MustCompile(`^([0-9]{9}|[0-9]{12}|GD[0-4][0-9]{2}|HA[5-9][0-9]{2})$`),
+}
+
+func isLEI(fl validator.FieldLevel) bool {
+	return IsValidLEI(fl.Field().String())
+}
+
+func isEUVAT(fl validator.FieldLevel) bool {
+	return IsValidEUVAT(fl.Field().String())
+}
+
+func isDUNS(fl validator.FieldLevel) bool {
+	return IsValidDUNS(fl.Field().String())
+}
+
+// IsValidLEI checks the format and the ISO 17442 (ISO 7064 MOD 97-10) check digits of a LEI
+func IsValidLEI(lei string) bool {
+	if !leiRegexp.MatchString(lei) {
+		return false
+	}
+	// letters are expanded to two digits, A=10 ... Z=35, and the resulting number mod 97 must be 1
+	remainder := 0
+	for _, char := range lei {
+		if char >= 'A' && char <= 'Z' {
+			value := int(char-'A') + 10
+			remainder = (remainder*100 + value) % 97
+			continue
+		}
+		remainder = (remainder*10 + int(char-'0')) % 97
+	}
+	return remainder == 1
+}
+
+// IsValidEUVAT checks an EU VAT number, including the country prefix, against the format of its member state
+func IsValidEUVAT(vat string) bool {
+	if len(vat) < 4 {
+		return false
+	}
+	countryRegexp, exists := euVATRegexps[vat[:2]]
+	if !exists {
+		return false
+	}
+	return countryRegexp.MatchString(vat[2:])
+}
+
+// IsValidDUNS checks that a DUNS number has 9 digits and is not all zeros
+func IsValidDUNS(duns string) bool {
+	return dunsRegexp.MatchString(duns) && duns != "000000000"
+}
@@ -0,0 +1,58 @@
+package validators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidLEI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		lei      string
+		expected bool
+	}{
+		{name: "valid LEI", lei: "5493001KJTIIGC8Y1R12", expected: true},
+		{name: "valid LEI with letters", lei: "HWUPKR0MPOU8FGXBT394", expected: true},
+		{name: "wrong check digits", lei: "5493001KJTIIGC8Y1R13", expected: false},
+		{name: "lower case", lei: "5493001kjtiigc8y1r12", expected: false},
+		{name: "too short", lei: "5493001KJTIIGC8Y1R1", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, IsValidLEI(testCase.lei))
+		})
+	}
+}
+
+func TestIsValidEUVAT(t *testing.T) {
+	testCases := []struct {
+		name     string
+		vat      string
+		expected bool
+	}{
+		{name: "valid DE", vat: "DE123456789", expected: true},
+		{name: "valid AT", vat: "ATU12345678", expected: true},
+		{name: "valid NL", vat: "NL123456789B01", expected: true},
+		{name: "valid IE", vat: "IE1234567WA", expected: true},
+		{name: "Greece uses the EL prefix", vat: "GR123456789", expected: false},
+		{name: "wrong DE length", vat: "DE12345678", expected: false},
+		{name: "AT without U", vat: "AT12345678", expected: false},
+		{name: "non EU country", vat: "US123456789", expected: false},
+		{name: "too short", vat: "DE", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, IsValidEUVAT(testCase.vat))
+		})
+	}
+}
+
+func TestIsValidDUNS(t *testing.T) {
+	assert.True(t, IsValidDUNS("150483782"))
+	assert.False(t, IsValidDUNS("15-048-3782"))
+	assert.False(t, IsValidDUNS("000000000"))
+	assert.False(t, IsValidDUNS("15048378"))
+}
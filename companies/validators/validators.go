@@ -0,0 +1,79 @@
+package validators
+
+import (
+	"companies/models"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NACE Rev. 2 division, group or class, ex: 62, 62.0, 62.01
+var naceRegexp = regexp.MustCompile(`^\d{2}(\.\d{1,2})?$`)
+
+// 4 digit SIC code, ex: 7372
+var sicRegexp = regexp.MustCompile(`^\d{4}$`)
+
+// normalized tag, ex: vip, under-review, region:emea
+var tagRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_:-]{0,31}$`)
+
+// RegisterValidators registers the custom binding tags used by the models package,
+// validation errors report the JSON or query string field names
+func RegisterValidators(v *validator.Validate) error {
+	v.RegisterTagNameFunc(fieldName)
+
+	validations := map[string]validator.Func{
+		"nace":     isNACE,
+		"sic":      isSIC,
+		"pastdate": isPastDate,
+		"lei":      isLEI,
+		"eu_vat":   isEUVAT,
+		"duns":     isDUNS,
+		"tag":      isTag,
+	}
+	for tag, fn := range validations {
+		err := v.RegisterValidation(tag, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldName returns the JSON or query string name of a field
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func isNACE(fl validator.FieldLevel) bool {
+	return naceRegexp.MatchString(fl.Field().String())
+}
+
+func isSIC(fl validator.FieldLevel) bool {
+	return sicRegexp.MatchString(fl.Field().String())
+}
+
+// isTag checks the normalized form of a tag, the input can use upper case letters and spaces
+func isTag(fl validator.FieldLevel) bool {
+	return tagRegexp.MatchString(models.NormalizeTag(fl.Field().String()))
+}
+
+// isPastDate checks that a date string is not in the future, the layout is checked by the datetime tag
+func isPastDate(fl validator.FieldLevel) bool {
+	date, err := time.Parse(models.DateLayout, fl.Field().String())
+	if err != nil {
+		return false
+	}
+	return !date.After(time.Now().UTC())
+}
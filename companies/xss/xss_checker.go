@@ -8,12 +8,14 @@ import (
 
 var ErrFoundXSS = errors.New("found XSS in input")
 
-func CheckForXSS(input string) error {
+func CheckForXSS(inputs ...string) error {
 	p := bluemonday.UGCPolicy()
 
-	sanitizedInput := p.Sanitize(input)
-	if sanitizedInput != input {
-		return ErrFoundXSS
+	for _, input := range inputs {
+		sanitizedInput := p.Sanitize(input)
+		if sanitizedInput != input {
+			return ErrFoundXSS
+		}
 	}
 	return nil
 }
@@ -1,6 +1,18 @@
 import { MongoClient } from "mongodb";
 import migration0001 from "./migrations/0001-add-unique-index-to-users.js";
 import migration0002 from "./migrations/0002-add-unique-index-to-companies.js";
+import migration0003 from "./migrations/0003-add-company-profile-indexes.js";
+import migration0004 from "./migrations/0004-add-unique-indexes-to-company-identifiers.js";
+import migration0005 from "./migrations/0005-add-parent-id-index-to-companies.js";
+import migration0006 from "./migrations/0006-add-tags-index-to-companies.js";
+import migration0007 from "./migrations/0007-add-status-to-companies.js";
+import migration0008 from "./migrations/0008-add-company-change-requests.js";
+import migration0009 from "./migrations/0009-add-tenant-to-companies.js";
+import migration0010 from "./migrations/0010-add-jobs.js";
+import migration0011 from "./migrations/0011-add-employees-index-to-companies.js";
+import migration0012 from "./migrations/0012-add-name-trigrams-index-to-companies.js";
+import migration0013 from "./migrations/0013-add-company-tombstones.js";
+import migration0014 from "./migrations/0014-add-canonical-name-indexes.js";
 import dotenv from "dotenv";
 
 dotenv.config();
@@ -13,6 +25,21 @@ const MIGRATIONS_COLLECTION = "migrations";
 const migrations = [
   { id: "0001-add-unique-index-to-users", func: migration0001 },
   { id: "0002-add-unique-index-to-companies", func: migration0002 },
+  { id: "0003-add-company-profile-indexes", func: migration0003 },
+  {
+    id: "0004-add-unique-indexes-to-company-identifiers",
+    func: migration0004,
+  },
+  { id: "0005-add-parent-id-index-to-companies", func: migration0005 },
+  { id: "0006-add-tags-index-to-companies", func: migration0006 },
+  { id: "0007-add-status-to-companies", func: migration0007 },
+  { id: "0008-add-company-change-requests", func: migration0008 },
+  { id: "0009-add-tenant-to-companies", func: migration0009 },
+  { id: "0010-add-jobs", func: migration0010 },
+  { id: "0011-add-employees-index-to-companies", func: migration0011 },
+  { id: "0012-add-name-trigrams-index-to-companies", func: migration0012 },
+  { id: "0013-add-company-tombstones", func: migration0013 },
+  { id: "0014-add-canonical-name-indexes", func: migration0014 },
 ];
 
 async function runMigrations() {
@@ -0,0 +1,11 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0003: Creating indexes on the companies profile fields"
+  );
+  const companies = db.collection("companies");
+  await companies.createIndex({ "registered_address.country": 1 });
+  await companies.createIndex({ "operating_address.country": 1 });
+  await companies.createIndex({ "industry_codes.nace": 1 });
+  await companies.createIndex({ "industry_codes.sic": 1 });
+  await companies.createIndex({ founded_on: 1 });
+}
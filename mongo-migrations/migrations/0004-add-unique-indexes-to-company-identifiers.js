@@ -0,0 +1,14 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0004: Creating unique indexes on companies.identifiers"
+  );
+  const companies = db.collection("companies");
+  // identifiers are optional, only index the companies that have them
+  for (const scheme of ["lei", "vat", "duns"]) {
+    const field = `identifiers.${scheme}`;
+    await companies.createIndex(
+      { [field]: 1 },
+      { unique: true, partialFilterExpression: { [field]: { $type: "string" } } }
+    );
+  }
+}
@@ -0,0 +1,7 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0005: Creating index on companies.parent_id"
+  );
+  const companies = db.collection("companies");
+  await companies.createIndex({ parent_id: 1 });
+}
@@ -0,0 +1,5 @@
+export default async function (db) {
+  console.log("Running migration 0006: Creating index on companies.tags");
+  const companies = db.collection("companies");
+  await companies.createIndex({ tags: 1 });
+}
@@ -0,0 +1,12 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0007: Setting the status of the existing companies and creating index on companies.status"
+  );
+  const companies = db.collection("companies");
+  // the companies created before the lifecycle statuses are in use already
+  await companies.updateMany(
+    { status: { $exists: false } },
+    { $set: { status: "active" } }
+  );
+  await companies.createIndex({ status: 1 });
+}
@@ -0,0 +1,12 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0008: Setting the version of the existing companies and creating indexes on company_change_requests"
+  );
+  const companies = db.collection("companies");
+  await companies.updateMany(
+    { version: { $exists: false } },
+    { $set: { version: 0 } }
+  );
+  const changeRequests = db.collection("company_change_requests");
+  await changeRequests.createIndex({ status: 1, company_id: 1, requested_at: 1 });
+}
@@ -0,0 +1,29 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0009: Setting the tenant of the existing users and companies, making the unique indexes per tenant and creating indexes on invites"
+  );
+  // the data created before tenants existed belongs to the default tenant
+  for (const name of ["users", "companies", "company_change_requests"]) {
+    await db
+      .collection(name)
+      .updateMany({ tenant: { $exists: false } }, { $set: { tenant: "default" } });
+  }
+
+  const companies = db.collection("companies");
+  await companies.dropIndex("name_1");
+  await companies.createIndex({ tenant: 1, name: 1 }, { unique: true });
+  for (const scheme of ["lei", "vat", "duns"]) {
+    const field = `identifiers.${scheme}`;
+    await companies.dropIndex(`${field}_1`);
+    await companies.createIndex(
+      { tenant: 1, [field]: 1 },
+      { unique: true, partialFilterExpression: { [field]: { $type: "string" } } }
+    );
+  }
+  await companies.createIndex({ tenant: 1, parent_id: 1 });
+
+  // the users register into the tenant of an invite, the expired invites are removed
+  const invites = db.collection("invites");
+  await invites.createIndex({ code_hash: 1 }, { unique: true });
+  await invites.createIndex({ expires_at: 1 }, { expireAfterSeconds: 0 });
+}
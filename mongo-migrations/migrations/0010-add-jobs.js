@@ -0,0 +1,8 @@
+export default async function (db) {
+  console.log("Running migration 0010: Creating indexes on jobs");
+  const jobs = db.collection("jobs");
+  // the workers claim the oldest queued job of their types, or a running job whose lease expired
+  await jobs.createIndex({ status: 1, type: 1, created_at: 1 });
+  await jobs.createIndex({ status: 1, lease_expires_at: 1 });
+  await jobs.createIndex({ tenant: 1, created_at: -1 });
+}
@@ -0,0 +1,6 @@
+export default async function (db) {
+  console.log("Running migration 0011: Creating index on companies.number_of_employees");
+  const companies = db.collection("companies");
+  // the percentiles of the employee stats skip to their rank in the companies of a tenant sorted by number_of_employees
+  await companies.createIndex({ tenant: 1, number_of_employees: 1 });
+}
@@ -0,0 +1,7 @@
+export default async function (db) {
+  console.log("Running migration 0012: Creating index on companies.name_trigrams");
+  const companies = db.collection("companies");
+  // the possible duplicates of a name are the companies of the tenant sharing its trigrams,
+  // the keys of the existing companies are set by the companies backfill-name-keys command
+  await companies.createIndex({ tenant: 1, name_trigrams: 1 });
+}
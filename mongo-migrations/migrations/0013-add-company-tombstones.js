@@ -0,0 +1,5 @@
+export default async function (db) {
+  console.log("Running migration 0013: Creating index on company_tombstones.merged_into");
+  // the redirects to a company that is merged in turn are moved to the new survivor
+  await db.collection("company_tombstones").createIndex({ tenant: 1, merged_into: 1 });
+}
@@ -0,0 +1,18 @@
+export default async function (db) {
+  console.log(
+    "Running migration 0014: Creating unique indexes on companies.name_canonical and users.username_canonical"
+  );
+  // the canonical forms are case folded and NFKC normalized, "Acme" and "ACME" are the same name. The canonical forms
+  // of the existing documents are set by the companies backfill-name-keys and auth backfill-canonical-usernames
+  // commands, the documents without one are only unique on their exact name until then
+  const companies = db.collection("companies");
+  await companies.createIndex(
+    { tenant: 1, name_canonical: 1 },
+    { unique: true, partialFilterExpression: { name_canonical: { $type: "string" } } }
+  );
+  const users = db.collection("users");
+  await users.createIndex(
+    { username_canonical: 1 },
+    { unique: true, partialFilterExpression: { username_canonical: { $type: "string" } } }
+  );
+}